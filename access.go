@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// accessTimeLayout Apache 访问日志的时间格式
+const accessTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// accessRequest 访问日志中的请求，客户端 IP 与查询参数按格式化对象的配置匿名化与过滤，
+// 与 http.request.v1 日志中记录的内容一致
+type accessRequest struct {
+	*http.Request
+	ip  string
+	uri string
+}
+
+// newAccessRequest af 为 nil 时记录原始的 IP 与请求地址
+func newAccessRequest(r *http.Request, af *LogsV1Formatter) *accessRequest {
+	ar := &accessRequest{Request: r, ip: parseIP(r.RemoteAddr), uri: r.RequestURI}
+	if af == nil {
+		return ar
+	}
+	if af.IPAnonymizer != nil {
+		ar.ip = af.IPAnonymizer(ar.ip)
+	}
+	if i := strings.IndexByte(ar.uri, '?'); i >= 0 && af.ParamFilter != nil {
+		ar.uri = ar.uri[:i+1] + af.ParamFilter.filterQuery(ar.uri[i+1:])
+		ar.uri = strings.TrimSuffix(ar.uri, "?")
+	}
+	return ar
+}
+
+// combinedLine 生成 Apache/NCSA combined 格式的访问日志
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/4.08"
+func combinedLine(r *accessRequest, status int, size int64, start time.Time) string {
+	return fmt.Sprintf("%s \"%s\" \"%s\"\n",
+		commonFields(r, status, size, start),
+		accessEscape(r.Referer()),
+		accessEscape(r.UserAgent()),
+	)
+}
+
+// commonLine 生成 Apache/NCSA common 格式的访问日志
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326
+func commonLine(r *accessRequest, status int, size int64, start time.Time) string {
+	return commonFields(r, status, size, start) + "\n"
+}
+
+func commonFields(r *accessRequest, status int, size int64, start time.Time) string {
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		accessField(r.ip),
+		accessField(accessUser(r.Request)),
+		start.Format(accessTimeLayout),
+		r.Method,
+		accessEscape(r.uri),
+		r.Proto,
+		status,
+		accessSize(size),
+	)
+}
+
+func accessUser(r *http.Request) string {
+	if r.URL != nil && r.URL.User != nil {
+		return r.URL.User.Username()
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return ""
+}
+
+func accessSize(size int64) string {
+	if size == 0 {
+		return "-"
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+func accessField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(accessEscape(s), " ", "%20")
+}
+
+// accessEscape 转义引号与控制字符，避免请求内容破坏访问日志的格式
+func accessEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
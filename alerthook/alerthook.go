@@ -0,0 +1,276 @@
+// Package alerthook 将 fatal / panic 级别的日志推送到 Slack、Teams 或通用 webhook，
+// 使严重故障第一时间通知到人
+package alerthook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Format webhook 请求体的格式
+type Format int
+
+const (
+	// Slack {"text": "..."}，同样适用于 Mattermost、Rocket.Chat 等兼容接口
+	Slack Format = iota
+	// Teams Office 365 connector 的 MessageCard
+	Teams
+	// Generic Alert 的 JSON，附带渲染后的 text 字段
+	Generic
+)
+
+// DefaultTemplate 缺省的消息模板
+const DefaultTemplate = `[{{.Level}}] {{.Service}} ({{.Environment}}): {{.Message}}` +
+	`{{if .Error}}
+error: {{.Error}}{{end}}` +
+	`{{if .Host}}
+host: {{.Host}}{{end}}` +
+	`{{if .Suppressed}}
+{{.Suppressed}} alerts suppressed by rate limit{{end}}`
+
+// Alert 模板使用的告警信息
+type Alert struct {
+	Time        time.Time              `json:"time"`
+	Level       string                 `json:"level"`
+	Service     string                 `json:"service"`
+	Environment string                 `json:"environment"`
+	Channel     string                 `json:"channel,omitempty"`
+	Message     string                 `json:"message"`
+	Error       string                 `json:"error,omitempty"`
+	Host        string                 `json:"host,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	// 上一条告警之后因限流而未发送的告警数
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// Option Hook 的可选配置
+type Option func(*Hook)
+
+// WithLevels 设置告警的日志级别，默认 fatal / panic
+func WithLevels(levels ...logrus.Level) Option {
+	return func(h *Hook) {
+		h.levels = levels
+	}
+}
+
+// WithFormat 设置请求体格式，默认 Slack
+func WithFormat(f Format) Option {
+	return func(h *Hook) {
+		h.format = f
+	}
+}
+
+// WithTemplate 设置消息模板，模板数据为 *Alert；模板解析失败时 New 返回错误
+func WithTemplate(text string) Option {
+	return func(h *Hook) {
+		h.templateText = text
+	}
+}
+
+// WithRateLimit 设置每个时间窗口内最多发送的告警数，默认每分钟 10 条
+func WithRateLimit(n int, interval time.Duration) Option {
+	return func(h *Hook) {
+		h.limit = n
+		h.interval = interval
+	}
+}
+
+// WithHTTPClient 设置发送使用的 HTTP 客户端，默认超时 5 秒
+func WithHTTPClient(c *http.Client) Option {
+	return func(h *Hook) {
+		h.client = c
+	}
+}
+
+// Hook 实现 logrus.Hook，同步发送告警，发送失败时由 logrus 输出到 stderr
+//
+//	hook, err := alerthook.New(os.Getenv("SLACK_WEBHOOK"))
+//	l.AddHook(hook)
+type Hook struct {
+	url          string
+	levels       []logrus.Level
+	format       Format
+	templateText string
+	template     *template.Template
+	limit        int
+	interval     time.Duration
+	client       *http.Client
+
+	mu          sync.Mutex
+	windowStart time.Time
+	sent        int
+	suppressed  int
+}
+
+// New 创建 webhook 告警 hook
+func New(url string, opts ...Option) (*Hook, error) {
+	h := &Hook{
+		url:          url,
+		levels:       []logrus.Level{logrus.PanicLevel, logrus.FatalLevel},
+		templateText: DefaultTemplate,
+		limit:        10,
+		interval:     time.Minute,
+		client:       &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	tmpl, err := template.New("alert").Parse(h.templateText)
+	if err != nil {
+		return nil, err
+	}
+	h.template = tmpl
+	return h, nil
+}
+
+// Levels implements logrus.Hook interface
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook interface
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	suppressed, ok := h.allow(entry.Time)
+	if !ok {
+		return nil
+	}
+
+	alert := newAlert(entry)
+	alert.Suppressed = suppressed
+
+	body, err := h.payload(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alerthook: webhook status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// allow 判断是否允许发送，返回之前被限流的告警数
+func (h *Hook) allow(now time.Time) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if now.Sub(h.windowStart) >= h.interval {
+		h.windowStart = now
+		h.sent = 0
+	}
+	if h.sent >= h.limit {
+		h.suppressed++
+		return 0, false
+	}
+	h.sent++
+
+	suppressed := h.suppressed
+	h.suppressed = 0
+	return suppressed, true
+}
+
+func (h *Hook) payload(alert *Alert) ([]byte, error) {
+	text := &bytes.Buffer{}
+	if err := h.template.Execute(text, alert); err != nil {
+		return nil, err
+	}
+
+	switch h.format {
+	case Teams:
+		return json.Marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    alert.Message,
+			"themeColor": "D00000",
+			"title":      fmt.Sprintf("[%s] %s", alert.Level, alert.Service),
+			"text":       text.String(),
+		})
+	case Generic:
+		return json.Marshal(struct {
+			*Alert
+			Text string `json:"text"`
+		}{Alert: alert, Text: text.String()})
+	default:
+		return json.Marshal(map[string]string{"text": text.String()})
+	}
+}
+
+// record LogsV1 日志中用于生成告警的字段
+type record struct {
+	Schema  string                 `json:"schema"`
+	Service string                 `json:"s"`
+	Channel string                 `json:"c"`
+	Env     string                 `json:"e"`
+	Message string                 `json:"m"`
+	Err     string                 `json:"err"`
+	Context map[string]interface{} `json:"ctx"`
+	Host    *struct {
+		Hostname string `json:"hostname"`
+	} `json:"host"`
+}
+
+// newAlert 使用日志对象的 Formatter 生成 LogsV1 日志并读取告警字段，内容经过其脱敏规则处理；
+// Formatter 不是 logger 包创建的时无法脱敏，只记录日志内容，不记录字段与错误
+func newAlert(entry *logrus.Entry) *Alert {
+	rec := &record{Message: entry.Message}
+	var fw *logger.Forwarder
+	if entry.Logger != nil {
+		fw = logger.NewForwarder(entry.Logger.Formatter)
+	}
+	if fw != nil {
+		decoded := &record{}
+		if err := fw.Decode(entry, decoded); err == nil {
+			rec = decoded
+		} else {
+			fw = nil
+		}
+	}
+
+	alert := &Alert{
+		Time:        entry.Time,
+		Level:       entry.Level.String(),
+		Service:     rec.Service,
+		Environment: rec.Env,
+		Channel:     rec.Channel,
+		Message:     rec.Message,
+		Error:       rec.Err,
+		Fields:      rec.Context,
+	}
+	if rec.Host != nil {
+		alert.Host = rec.Host.Hostname
+	}
+	if alert.Error == "" && fw != nil {
+		// 没有 error 字段时使用按字段名排序的第一个错误
+		fields := logger.Fields(entry)
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err, ok := fields[k].(error); ok {
+				alert.Error = fw.RedactString(err.Error())
+				break
+			}
+		}
+	}
+	return alert
+}
@@ -0,0 +1,190 @@
+package alerthook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.bodies = append(r.bodies, body)
+}
+
+func newLogger(t *testing.T, url string, opts ...Option) *logrus.Logger {
+	hook, err := New(url, opts...)
+	if err != nil {
+		t.Fatalf("New() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(io.Discard)
+	l.AddHook(hook)
+	return l
+}
+
+func TestHookFormats(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	entry := func(l *logrus.Logger) {
+		func() {
+			defer func() { recover() }()
+			l.WithFields(logrus.Fields{"error": "disk full", "channel": "journal"}).Panic("cannot write journal")
+		}()
+	}
+
+	entry(newLogger(t, srv.URL))
+	entry(newLogger(t, srv.URL, WithFormat(Teams)))
+	entry(newLogger(t, srv.URL, WithFormat(Generic), WithTemplate("{{.Service}}/{{.Channel}}: {{.Message}}")))
+	entry(newLogger(t, srv.URL, WithLevels(logrus.ErrorLevel)))
+
+	if len(rec.bodies) != 3 {
+		t.Fatalf("expect 3 webhook calls, got %d", len(rec.bodies))
+	}
+
+	cases := []struct {
+		Actual interface{}
+		Expect interface{}
+	}{
+		{Actual: rec.bodies[0]["text"], Expect: "[panic] test (prod): cannot write journal\nerror: disk full"},
+		{Actual: rec.bodies[1]["@type"], Expect: "MessageCard"},
+		{Actual: rec.bodies[1]["title"], Expect: "[panic] test"},
+		{Actual: rec.bodies[2]["text"], Expect: "test/journal: cannot write journal"},
+		{Actual: rec.bodies[2]["level"], Expect: "panic"},
+		{Actual: rec.bodies[2]["error"], Expect: "disk full"},
+	}
+	for idx, each := range cases {
+		if each.Actual != each.Expect {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, each.Actual)
+		}
+	}
+}
+
+func TestHookRateLimit(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, _ := New(srv.URL, WithLevels(logrus.ErrorLevel), WithRateLimit(2, time.Hour))
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(io.Discard)
+	l.AddHook(hook)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		l.WithTime(now).Error("flood")
+	}
+	if len(rec.bodies) != 2 {
+		t.Fatalf("expect 2 webhook calls within window, got %d", len(rec.bodies))
+	}
+
+	l.WithTime(now.Add(time.Hour)).Error("flood")
+	if len(rec.bodies) != 3 {
+		t.Fatalf("expect webhook call after window, got %d", len(rec.bodies))
+	}
+	if text := rec.bodies[2]["text"].(string); !strings.HasSuffix(text, "3 alerts suppressed by rate limit") {
+		t.Fatalf("expect suppressed count in text, got %q", text)
+	}
+}
+
+func TestHookBoundError(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	l := newLogger(t, srv.URL, WithFormat(Generic), WithLevels(logrus.ErrorLevel))
+	logger.With(l, logrus.Fields{"cause": errors.New("disk full")}).Error("cannot write journal")
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("expect 1 webhook call, got %d", len(rec.bodies))
+	}
+	if v := rec.bodies[0]["error"]; v != "disk full" {
+		t.Fatalf("expect bound error, got %#v", v)
+	}
+}
+
+func TestHookRedaction(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, _ := New(srv.URL, WithFormat(Generic), WithLevels(logrus.ErrorLevel))
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(logger.NewLogfmtFormatter("test", "prod", logger.WithRedaction(
+		logger.RedactRule{ID: "password", Keys: []string{"password"}},
+		logger.RedactRule{ID: "token", ValuePattern: regexp.MustCompile(`tok_\w+`)},
+	)))
+	l.AddHook(hook)
+
+	l.WithFields(logrus.Fields{
+		"password": "hunter2",
+		"cause":    errors.New("login with tok_abc123 failed"),
+	}).Error("login failed")
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("expect 1 webhook call, got %d", len(rec.bodies))
+	}
+	fields, _ := rec.bodies[0]["fields"].(map[string]interface{})
+	if v := fields["password"]; v != logger.DefaultRedactReplacement {
+		t.Fatalf("expect password redacted, got %#v", v)
+	}
+	if v := rec.bodies[0]["error"]; v != "login with "+logger.DefaultRedactReplacement+" failed" {
+		t.Fatalf("expect error redacted, got %#v", v)
+	}
+}
+
+func TestHookForeignFormatter(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, _ := New(srv.URL, WithFormat(Generic), WithLevels(logrus.ErrorLevel))
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.AddHook(hook)
+
+	l.WithFields(logrus.Fields{
+		"password": "hunter2",
+		"cause":    errors.New("password hunter2 rejected"),
+	}).Error("login failed")
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("expect 1 webhook call, got %d", len(rec.bodies))
+	}
+	body := rec.bodies[0]
+	if body["message"] != "login failed" || body["fields"] != nil || body["error"] != nil {
+		t.Fatalf("expect message without fields and error, got %#v", body)
+	}
+}
+
+func TestNewInvalidTemplate(t *testing.T) {
+	if _, err := New("http://localhost", WithTemplate("{{.Message")); err == nil {
+		t.Fatal("expect template parse error")
+	}
+}
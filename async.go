@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrWriterClosed 向已关闭的 AsyncWriter 写入日志
+var ErrWriterClosed = errors.New("logger: async writer closed")
+
+// AsyncWriter 在后台 goroutine 中写入日志，写入方不会被慢速的输出阻塞
+//
+// 排队中的日志占用 MemoryBudget，预算不足时丢弃并计入预算的统计；
+// 进程退出前需要调用 Close 或通过 RegisterCloser 登记，否则排队中的日志会丢失
+type AsyncWriter struct {
+	w      io.Writer
+	budget *Budget
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte
+	writing bool
+	closed  bool
+	done    chan struct{}
+}
+
+// NewAsyncWriter 创建异步写入对象
+func NewAsyncWriter(w io.Writer) *AsyncWriter {
+	aw := &AsyncWriter{
+		w:      w,
+		budget: MemoryBudget(),
+		done:   make(chan struct{}),
+	}
+	aw.cond = sync.NewCond(&aw.mu)
+	go aw.run()
+	return aw
+}
+
+// Write implements io.Writer interface
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	n := int64(len(p))
+
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	if aw.closed {
+		return 0, ErrWriterClosed
+	}
+	if !aw.budget.Acquire(n) {
+		return len(p), nil
+	}
+
+	aw.queue = append(aw.queue, append([]byte(nil), p...))
+	aw.cond.Signal()
+	return len(p), nil
+}
+
+// Flush 等待排队中的日志全部写入，ctx 结束时返回 ctx.Err()
+func (aw *AsyncWriter) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	go func() {
+		aw.mu.Lock()
+		for len(aw.queue) > 0 || aw.writing {
+			aw.cond.Wait()
+		}
+		aw.mu.Unlock()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 停止接收日志，写入排队中的日志后返回，不会关闭下层的输出
+func (aw *AsyncWriter) Close() error {
+	aw.mu.Lock()
+	if !aw.closed {
+		aw.closed = true
+		aw.cond.Broadcast()
+	}
+	aw.mu.Unlock()
+
+	<-aw.done
+	return nil
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+
+	for {
+		aw.mu.Lock()
+		for len(aw.queue) == 0 && !aw.closed {
+			aw.cond.Wait()
+		}
+		if len(aw.queue) == 0 {
+			aw.mu.Unlock()
+			return
+		}
+		batch := aw.queue
+		aw.queue = nil
+		aw.writing = true
+		aw.mu.Unlock()
+
+		for _, p := range batch {
+			_, _ = aw.w.Write(p)
+			aw.budget.Release(int64(len(p)))
+		}
+
+		aw.mu.Lock()
+		aw.writing = false
+		aw.cond.Broadcast()
+		aw.mu.Unlock()
+	}
+}
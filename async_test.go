@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter 每次写入前等待 gate
+type slowWriter struct {
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	gate chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	out := &slowWriter{gate: make(chan struct{})}
+	aw := NewAsyncWriter(out)
+
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := aw.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error, Expected=nil, Actual=%q", err.Error())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := aw.Flush(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Flush() error, Expected=%v, Actual=%v", context.DeadlineExceeded, err)
+	}
+
+	close(out.gate)
+	if err := aw.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if out.String() != "a\nb\nc\n" {
+		t.Fatalf("expect all lines in order, got %q", out.String())
+	}
+
+	aw.Write([]byte("d\n"))
+	aw.Close()
+	if out.String() != "a\nb\nc\nd\n" {
+		t.Fatalf("expect Close to drain queue, got %q", out.String())
+	}
+	if _, err := aw.Write([]byte("e\n")); err != ErrWriterClosed {
+		t.Fatalf("Write() error, Expected=%v, Actual=%v", ErrWriterClosed, err)
+	}
+	if inUse := aw.budget.Stats().InUse; inUse != 0 {
+		t.Fatalf("expect budget released, got %d in use", inUse)
+	}
+}
+
+func TestAsyncWriterBudget(t *testing.T) {
+	out := &slowWriter{gate: make(chan struct{})}
+	aw := NewAsyncWriter(out)
+	aw.budget = NewBudget(4)
+
+	aw.Write([]byte("abc\n"))
+	aw.Write([]byte("def\n"))
+	close(out.gate)
+	aw.Close()
+
+	if out.String() != "abc\n" {
+		t.Fatalf("expect second line dropped, got %q", out.String())
+	}
+	if dropped := aw.budget.Stats().Dropped; dropped != 1 {
+		t.Fatalf("expect 1 dropped line, got %d", dropped)
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HeaderBaggage W3C Baggage 的 header
+const HeaderBaggage = "baggage"
+
+// maxBaggageLength W3C Baggage 规定的 header 最大长度
+const maxBaggageLength = 8192
+
+type baggageKey struct{}
+
+// ContextWithBaggage 将 baggage 保存到 ctx，通过 WithContext(ctx) 输出的日志在 ctx.baggage 中记录这些字段
+func ContextWithBaggage(ctx context.Context, baggage map[string]string) context.Context {
+	return context.WithValue(ctx, baggageKey{}, baggage)
+}
+
+// BaggageFromContext 获得 ctx 中保存的 baggage
+func BaggageFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	baggage, _ := ctx.Value(baggageKey{}).(map[string]string)
+	return baggage
+}
+
+// ParseBaggage 解析 W3C Baggage header，keys 为空时返回全部成员，否则只返回 keys 中的成员，
+// 忽略成员的属性与格式错误的成员
+func ParseBaggage(header string, keys ...string) map[string]string {
+	if header == "" || len(header) > maxBaggageLength {
+		return nil
+	}
+
+	var baggage map[string]string
+	for _, member := range strings.Split(header, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		i := strings.IndexByte(member, '=')
+		if i <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(member[:i])
+		if key == "" || !baggageSelected(keys, key) {
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(member[i+1:]))
+		if err != nil {
+			continue
+		}
+		if baggage == nil {
+			baggage = map[string]string{}
+		}
+		baggage[key] = value
+	}
+	return baggage
+}
+
+func baggageSelected(keys []string, key string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// BaggageMiddleware 解析请求的 baggage header，将 keys 指定的成员保存到请求的 ctx，
+// 之后通过 WithContext(r.Context()) 输出的日志都带有这些字段
+//
+//	h = logger.BaggageMiddleware("tenant.id", "session.id")(h)
+func BaggageMiddleware(keys ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if baggage := ParseBaggage(strings.Join(r.Header.Values(HeaderBaggage), ","), keys...); len(baggage) > 0 {
+				r = r.WithContext(ContextWithBaggage(r.Context(), baggage))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
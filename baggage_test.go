@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestParseBaggage(t *testing.T) {
+	cases := []struct {
+		Header string
+		Keys   []string
+		Expect map[string]string
+	}{
+		{Header: "tenant=acme, user.id=u%2042;prop=1", Expect: map[string]string{"tenant": "acme", "user.id": "u 42"}},
+		{Header: "tenant=acme,user.id=42", Keys: []string{"user.id"}, Expect: map[string]string{"user.id": "42"}},
+		{Header: "=bad,novalue,bad=%zz", Expect: nil},
+		{Header: "", Expect: nil},
+	}
+	for _, each := range cases {
+		if v := ParseBaggage(each.Header, each.Keys...); !reflect.DeepEqual(v, each.Expect) {
+			t.Fatalf("ParseBaggage(%q), Expected=%v, Actual=%v", each.Header, each.Expect, v)
+		}
+	}
+}
+
+func TestBaggageMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := BaggageMiddleware("tenant.id")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.WithContext(r.Context()).Info("handled")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderBaggage, "tenant.id=acme,secret=x")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	data := out.Bytes()
+	if v := jsoniter.Get(data, "ctx", "baggage", "tenant.id").ToString(); v != "acme" {
+		t.Fatalf("output ctx.baggage.tenant.id, Expected=%q, Actual=%q", "acme", v)
+	}
+	if jsoniter.Get(data, "ctx", "baggage", "secret").LastError() == nil {
+		t.Fatalf("output ctx.baggage.secret should be omitted")
+	}
+}
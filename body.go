@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxDecompressedSize 解压请求 body 时默认的最大字节数
+const DefaultMaxDecompressedSize = 1 << 20
+
+// BodyParser 解析请求 body，将参数记录到 request.Param，或者在 request.Body 中记录摘要
+type BodyParser func(enc Encoder, body []byte, request *RequestData) error
+
+// BodySummary 不解析内容的请求 body 的摘要
+type BodySummary struct {
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+var defaultBodyParsers = map[string]BodyParser{
+	"application/json":         ParseJSONBody,
+	"application/xml":          ParseXMLBody,
+	"text/xml":                 ParseXMLBody,
+	"application/protobuf":     SummarizeBody,
+	"application/x-protobuf":   SummarizeBody,
+	"application/grpc":         SummarizeBody,
+	"application/octet-stream": SummarizeBody,
+}
+
+// WithBodyParser 按 content type 设置请求 body 的解析函数，覆盖默认的解析函数，
+// p 为 nil 时不解析该类型的 body
+func WithBodyParser(contentType string, p BodyParser) Option {
+	return func(af *LogsV1Formatter) {
+		if af.BodyParsers == nil {
+			af.BodyParsers = map[string]BodyParser{}
+		}
+		af.BodyParsers[strings.ToLower(contentType)] = p
+	}
+}
+
+// WithMaxDecompressedSize 设置解压 gzip、deflate 请求 body 时的最大字节数，
+// 超出时不解析 body，0 表示不解压
+func WithMaxDecompressedSize(n int64) Option {
+	return func(af *LogsV1Formatter) {
+		af.MaxDecompressedSize = n
+	}
+}
+
+// bodyParser 查找 content type 对应的解析函数，未设置时按 +json、+xml 后缀使用 JSON 与 XML 的解析函数
+func (af *LogsV1Formatter) bodyParser(contentType string) (string, BodyParser) {
+	if contentType == "" {
+		return "", nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", nil
+	}
+
+	lookup := func(t string) (BodyParser, bool) {
+		if p, ok := af.BodyParsers[t]; ok {
+			return p, true
+		}
+		p, ok := defaultBodyParsers[t]
+		return p, ok
+	}
+	if p, ok := lookup(mediaType); ok {
+		return mediaType, p
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		p, _ := lookup("application/json")
+		return mediaType, p
+	case strings.HasSuffix(mediaType, "+xml"):
+		p, _ := lookup("application/xml")
+		return mediaType, p
+	}
+	return mediaType, nil
+}
+
+// ParseJSONBody 将 JSON 对象的字段记录为请求参数
+func ParseJSONBody(enc Encoder, body []byte, request *RequestData) error {
+	if len(body) == 0 {
+		return nil
+	}
+	params := make(map[string]interface{})
+	if err := enc.Unmarshal(body, &params); err != nil {
+		return err
+	}
+	for k, v := range params {
+		request.Param[k] = v
+	}
+	return nil
+}
+
+// ParseXMLBody 将 XML 展开为请求参数，参数名为根元素以下的元素路径，以 . 分隔，
+// 属性以 @ 开头，例如 <order id="1"><item><sku>a</sku></item></order>
+// 记录为 @id=1 与 item.sku=a，同名的元素有多个时记录为数组
+func ParseXMLBody(_ Encoder, body []byte, request *RequestData) error {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+
+	var (
+		path []string
+		text []string
+	)
+	key := func(name string) string {
+		if len(path) <= 1 {
+			return name
+		}
+		if name == "" {
+			return strings.Join(path[1:], ".")
+		}
+		return strings.Join(path[1:], ".") + "." + name
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			text = append(text, "")
+			for _, attr := range t.Attr {
+				setParam(request, key("@"+attr.Name.Local), []string{attr.Value})
+			}
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1] += string(t)
+			}
+		case xml.EndElement:
+			if v := strings.TrimSpace(text[len(text)-1]); v != "" && len(path) > 1 {
+				setParam(request, key(""), []string{v})
+			}
+			path = path[:len(path)-1]
+			text = text[:len(text)-1]
+		}
+	}
+	return nil
+}
+
+// SummarizeBody 不解析 body，只记录类型与大小，用于 protobuf 等二进制内容；
+// 格式化时不读取这类 body，传入的 body 为 nil，大小使用 request.BytesIn，未知时为 -1
+func SummarizeBody(_ Encoder, body []byte, request *RequestData) error {
+	size := len(body)
+	if body == nil {
+		size = int(request.BytesIn)
+	}
+	request.Body = &BodySummary{
+		ContentType: request.Headers["content-type"],
+		Size:        size,
+	}
+	return nil
+}
+
+// summaryOnly 判断解析函数是否只记录摘要，这类 body 不需要读取
+func summaryOnly(p BodyParser) bool {
+	return reflect.ValueOf(p).Pointer() == reflect.ValueOf(SummarizeBody).Pointer()
+}
+
+// decompressBody 按 Content-Encoding 解压 body，解压后超过 limit 字节时返回错误
+func decompressBody(encoding string, body []byte, limit int64) ([]byte, error) {
+	var (
+		r   io.Reader
+		err error
+	)
+	if len(body) == 0 {
+		return body, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// 按规范 deflate 是 zlib 格式，但也有客户端直接发送未封装的 deflate 数据
+		if r, err = zlib.NewReader(bytes.NewReader(body)); err != nil {
+			r, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %s", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("decompression disabled")
+	}
+
+	decompressed, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decompressed)) > limit {
+		return nil, fmt.Errorf("decompressed body exceeds %d bytes", limit)
+	}
+	return decompressed, nil
+}
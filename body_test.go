@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseXMLBody(t *testing.T) {
+	request := &RequestData{Param: logrus.Fields{}}
+	body := `<?xml version="1.0"?>
+<order id="42">
+	<customer>gopher</customer>
+	<item sku="a"><qty>1</qty></item>
+	<item sku="b"><qty>2</qty></item>
+</order>`
+	if err := ParseXMLBody(nil, []byte(body), request); err != nil {
+		t.Fatalf("ParseXMLBody() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	expected := logrus.Fields{
+		"@id":       "42",
+		"customer":  "gopher",
+		"item.@sku": []string{"a", "b"},
+		"item.qty":  []string{"1", "2"},
+	}
+	if !reflect.DeepEqual(request.Param, expected) {
+		t.Fatalf("ParseXMLBody() param, Expected=%v, Actual=%v", expected, request.Param)
+	}
+
+	if err := ParseXMLBody(nil, []byte(`<order><id>1</order>`), &RequestData{Param: logrus.Fields{}}); err == nil {
+		t.Fatalf("ParseXMLBody() error for malformed XML, Expected=error, Actual=nil")
+	}
+}
+
+func TestFormatRequestBody(t *testing.T) {
+	newRequest := func(contentType, body string) *http.Request {
+		return &http.Request{
+			Method: http.MethodPost,
+			URL:    &url.URL{Path: "/api"},
+			Header: http.Header{"Content-Type": {contentType}},
+			Body:   ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+	format := func(f logrus.Formatter, req *http.Request) []byte {
+		data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+		if err != nil {
+			t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+		}
+		return data
+	}
+
+	f := NewFormatter("test", "test")
+
+	data := format(f, newRequest("application/soap+xml; charset=utf-8", `<env><body><op>ping</op></body></env>`))
+	if v := jsoniter.Get(data, "request", "param", "body.op").ToString(); v != "ping" {
+		t.Fatalf("output request.param.body.op, Expected=%q, Actual=%q", "ping", v)
+	}
+
+	req := newRequest("application/x-protobuf", "\x0a\x03abc")
+	req.ContentLength = 5
+	data = format(f, req)
+	if v := jsoniter.Get(data, "request", "body").ToString(); v != `{"content_type":"application/x-protobuf","size":5}` {
+		t.Fatalf("output request.body, Actual=%s", v)
+	}
+	if v := jsoniter.Get(data, "request", "param").ToString(); v != "{}" {
+		t.Fatalf("output request.param, Expected={}, Actual=%s", v)
+	}
+	if b, _ := ioutil.ReadAll(req.Body); !bytes.Equal(b, []byte("\x0a\x03abc")) {
+		t.Fatalf("request body is not restored")
+	}
+
+	// 长度未知时不读取 body，大小使用中间件统计的 bytes_in
+	upload := strings.NewReader("upload")
+	req = newRequest("application/octet-stream", "")
+	req.Body = ioutil.NopCloser(upload)
+	req.ContentLength = -1
+	data, _ = f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req, "bytes_in": 6}})
+	if v := jsoniter.Get(data, "request", "body", "size").ToInt(); v != 6 {
+		t.Fatalf("output request.body.size, Expected=6, Actual=%d", v)
+	}
+	if upload.Len() != 6 {
+		t.Fatalf("summarized request body is read, Expected=6 bytes unread, Actual=%d", upload.Len())
+	}
+
+	custom := NewFormatter("test", "test",
+		WithBodyParser("application/x-protobuf", func(_ Encoder, body []byte, request *RequestData) error {
+			return errors.New("unsupported")
+		}),
+		WithBodyParser("application/json", nil),
+	)
+	data = format(custom, newRequest("application/x-protobuf", "\x0a\x03abc"))
+	if v := jsoniter.Get(data, "request_parse_error").ToString(); v != "decode application/x-protobuf body: unsupported" {
+		t.Fatalf("output request_parse_error, Actual=%q", v)
+	}
+	data = format(custom, newRequest("application/json", `{"a":1}`))
+	if v := jsoniter.Get(data, "request", "param").ToString(); v != "{}" {
+		t.Fatalf("output request.param with JSON parser disabled, Expected={}, Actual=%s", v)
+	}
+}
+
+func TestFormatCompressedBody(t *testing.T) {
+	compress := func(encoding string, p []byte) []byte {
+		b := &bytes.Buffer{}
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(b)
+		case "deflate":
+			w = zlib.NewWriter(b)
+		default:
+			w, _ = flate.NewWriter(b, flate.DefaultCompression)
+		}
+		_, _ = w.Write(p)
+		_ = w.Close()
+		return b.Bytes()
+	}
+
+	cases := []struct {
+		Encoding string
+		Wire     string
+		Body     []byte
+		Param    string
+		Error    string
+	}{
+		{Encoding: "gzip", Wire: "gzip", Body: []byte(`{"a":"1"}`), Param: "1"},
+		{Encoding: "deflate", Wire: "deflate", Body: []byte(`{"a":"2"}`), Param: "2"},
+		{Encoding: "flate", Wire: "deflate", Body: []byte(`{"a":"3"}`), Param: "3"},
+		{Encoding: "gzip", Wire: "br", Body: []byte(`{"a":"4"}`), Error: "decompress body: unsupported content encoding br"},
+		{
+			Encoding: "gzip",
+			Wire:     "gzip",
+			Body:     []byte(`{"a":"` + strings.Repeat("x", 2048) + `"}`),
+			Error:    "decompress body: decompressed body exceeds 1024 bytes",
+		},
+	}
+
+	f := NewFormatter("test", "test", WithMaxDecompressedSize(1024))
+	for _, c := range cases {
+		wire := compress(c.Encoding, c.Body)
+		req := &http.Request{
+			Method: http.MethodPost,
+			URL:    &url.URL{Path: "/api"},
+			Header: http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {c.Wire}},
+			Body:   ioutil.NopCloser(bytes.NewReader(wire)),
+		}
+		data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+		if err != nil {
+			t.Fatalf("%s: Format() error, Expected=nil, Actual=%q", c.Encoding, err.Error())
+		}
+		if v := jsoniter.Get(data, "request", "param", "a").ToString(); c.Param != "" && v != c.Param {
+			t.Fatalf("%s: output request.param.a, Expected=%q, Actual=%q", c.Encoding, c.Param, v)
+		}
+		if v := jsoniter.Get(data, "request_parse_error").ToString(); v != c.Error {
+			t.Fatalf("%s: output request_parse_error, Expected=%q, Actual=%q", c.Encoding, c.Error, v)
+		}
+		// body 保持压缩前的内容
+		if b, _ := ioutil.ReadAll(req.Body); !bytes.Equal(b, wire) {
+			t.Fatalf("%s: request body is not restored", c.Encoding)
+		}
+	}
+}
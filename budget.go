@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"sync/atomic"
+)
+
+// DefaultMemoryLimit 缓冲模式默认共享的内存上限
+const DefaultMemoryLimit = 64 << 20
+
+var memoryBudget = NewBudget(DefaultMemoryLimit)
+
+// Budget 内存预算，异步队列、环形缓冲、磁盘暂存等缓冲模式在保存日志前
+// 需要先申请预算，预算不足时丢弃日志并计数，保证日志组件不会耗尽宿主程序的内存
+type Budget struct {
+	limit        int64
+	inUse        int64
+	dropped      int64
+	droppedBytes int64
+}
+
+// BudgetStats 内存预算的使用情况
+type BudgetStats struct {
+	Limit        int64 `json:"limit"`
+	InUse        int64 `json:"in_use"`
+	Dropped      int64 `json:"dropped"`
+	DroppedBytes int64 `json:"dropped_bytes"`
+}
+
+// NewBudget 创建内存预算，limit <= 0 时不限制
+func NewBudget(limit int64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// MemoryBudget 获得所有缓冲模式共享的全局内存预算，
+// AsyncWriter 以及 lokisink、fluentsink、sentryhook 的发送队列都从中申请
+func MemoryBudget() *Budget {
+	return memoryBudget
+}
+
+// SetLimit 调整预算上限，已占用的部分不受影响
+func (b *Budget) SetLimit(limit int64) {
+	atomic.StoreInt64(&b.limit, limit)
+}
+
+// Acquire 申请 n 字节，预算不足时返回 false 并记录为丢弃
+func (b *Budget) Acquire(n int64) bool {
+	for {
+		limit := atomic.LoadInt64(&b.limit)
+		used := atomic.LoadInt64(&b.inUse)
+		if limit > 0 && used+n > limit {
+			atomic.AddInt64(&b.dropped, 1)
+			atomic.AddInt64(&b.droppedBytes, n)
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&b.inUse, used, used+n) {
+			return true
+		}
+	}
+}
+
+// Release 归还之前申请的 n 字节
+func (b *Budget) Release(n int64) {
+	atomic.AddInt64(&b.inUse, -n)
+}
+
+// Stats 获得预算的使用情况
+func (b *Budget) Stats() BudgetStats {
+	return BudgetStats{
+		Limit:        atomic.LoadInt64(&b.limit),
+		InUse:        atomic.LoadInt64(&b.inUse),
+		Dropped:      atomic.LoadInt64(&b.dropped),
+		DroppedBytes: atomic.LoadInt64(&b.droppedBytes),
+	}
+}
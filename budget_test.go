@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(100)
+
+	if !b.Acquire(60) {
+		t.Fatalf("Acquire(60) Expected=true, Actual=false")
+	}
+	if b.Acquire(50) {
+		t.Fatalf("Acquire(50) Expected=false, Actual=true")
+	}
+	b.Release(60)
+	if !b.Acquire(50) {
+		t.Fatalf("Acquire(50) after Release Expected=true, Actual=false")
+	}
+
+	stats := b.Stats()
+	expected := BudgetStats{Limit: 100, InUse: 50, Dropped: 1, DroppedBytes: 50}
+	if stats != expected {
+		t.Fatalf("Stats() Expected=%+v, Actual=%+v", expected, stats)
+	}
+
+	unlimited := NewBudget(0)
+	if !unlimited.Acquire(1 << 40) {
+		t.Fatalf("unlimited Acquire() Expected=true, Actual=false")
+	}
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildData 构建信息，用于追溯输出日志的二进制文件
+type BuildData struct {
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	GoVersion string `json:"go"`
+}
+
+// WithBuildInfo 在每条日志中记录模块版本、VCS 提交与 Go 版本
+func WithBuildInfo() Option {
+	build := BuildInfo()
+	return func(f *LogsV1Formatter) {
+		f.Build = build
+	}
+}
+
+// BuildInfo 读取当前二进制的构建信息
+func BuildInfo() *BuildData {
+	build := &BuildData{
+		GoVersion: runtime.Version(),
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	build.Version = info.Main.Version
+	build.Revision = vcsRevision(info)
+	return build
+}
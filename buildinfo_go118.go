@@ -0,0 +1,26 @@
+//go:build go1.18
+// +build go1.18
+
+package logger
+
+import (
+	"runtime/debug"
+)
+
+// vcsRevision 读取构建时记录的 VCS 提交，工作区有未提交的修改时追加 -dirty
+func vcsRevision(info *debug.BuildInfo) string {
+	revision := ""
+	modified := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+	return revision
+}
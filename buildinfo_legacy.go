@@ -0,0 +1,13 @@
+//go:build !go1.18
+// +build !go1.18
+
+package logger
+
+import (
+	"runtime/debug"
+)
+
+// vcsRevision go1.18 之前的版本不记录 VCS 信息
+func vcsRevision(info *debug.BuildInfo) string {
+	return ""
+}
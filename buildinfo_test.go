@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestBuildInfo(t *testing.T) {
+	f := NewFormatter("test", "test", WithBuildInfo())
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if v := jsoniter.Get(data, "build", "go").ToString(); v != runtime.Version() {
+		t.Fatalf("output build.go, Expected=%q, Actual=%q", runtime.Version(), v)
+	}
+}
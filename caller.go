@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxCallerDepth 重新查找调用位置时读取的最大调用栈深度
+const maxCallerDepth = 64
+
+// WithCallerSkip 记录 logrus 确定的调用位置之上第 n 层的调用，用于封装了日志方法的函数，
+// 需要开启 logrus 的 ReportCaller
+func WithCallerSkip(n int) Option {
+	return func(af *LogsV1Formatter) {
+		af.CallerSkip = n
+	}
+}
+
+// WithCallerSkipPackages 调用位置的函数名以 prefixes 之一开头时继续向上查找，
+// 用于跳过项目内封装日志的包，例如 github.com/acme/app/internal/log.
+func WithCallerSkipPackages(prefixes ...string) Option {
+	return func(af *LogsV1Formatter) {
+		af.CallerSkipPackages = append(af.CallerSkipPackages, prefixes...)
+	}
+}
+
+// WithCallerTrimPrefix 去掉调用位置的文件路径与函数名中的前缀，例如模块路径或构建目录，
+// 按顺序使用第一个匹配的前缀
+func WithCallerTrimPrefix(prefixes ...string) Option {
+	return func(af *LogsV1Formatter) {
+		af.CallerTrimPrefixes = append(af.CallerTrimPrefixes, prefixes...)
+	}
+}
+
+// WithShortCallerFunc 函数名只保留包名及之后的部分，例如 handler.(*Server).Create
+func WithShortCallerFunc() Option {
+	return func(af *LogsV1Formatter) {
+		af.ShortCallerFunc = true
+	}
+}
+
+// callerFields 返回记录到 ctx 的文件位置与函数名
+func (af *LogsV1Formatter) callerFields(c *runtime.Frame) (string, string) {
+	frame := af.resolveCaller(c)
+	file := trimPrefixes(frame.File, af.CallerTrimPrefixes)
+	fn := trimPrefixes(frame.Function, af.CallerTrimPrefixes)
+	if af.ShortCallerFunc {
+		fn = shortFuncName(fn)
+	}
+	return file + ":" + strconv.Itoa(frame.Line), fn
+}
+
+// resolveCaller 按 CallerSkip 与 CallerSkipPackages 向上查找调用位置，
+// 只能在输出日志的调用栈内查找，当前调用栈中找不到原调用位置时（例如在其他 goroutine 中格式化）使用原调用位置
+func (af *LogsV1Formatter) resolveCaller(c *runtime.Frame) runtime.Frame {
+	if af.CallerSkip <= 0 && !hasAnyPrefix(c.Function, af.CallerSkipPackages) {
+		return *c
+	}
+
+	pcs := make([]uintptr, maxCallerDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	found := false
+	skip := af.CallerSkip
+	for {
+		f, more := frames.Next()
+		if !found {
+			found = f.Function == c.Function && f.File == c.File && f.Line == c.Line
+		}
+		if found {
+			if skip > 0 {
+				skip--
+			} else if !hasAnyPrefix(f.Function, af.CallerSkipPackages) {
+				return f
+			}
+		}
+		if !more {
+			return *c
+		}
+	}
+}
+
+func trimPrefixes(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return strings.TrimPrefix(s[len(p):], "/")
+		}
+	}
+	return s
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// shortFuncName 去掉函数名中包名之前的导入路径，泛型参数中的 / 不影响结果
+func shortFuncName(fn string) string {
+	path := fn
+	if i := strings.IndexByte(path, '['); i >= 0 {
+		path = path[:i]
+	}
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return fn[i+1:]
+	}
+	return fn
+}
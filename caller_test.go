@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+// logWrapper 模拟封装了日志方法的函数
+func logWrapper(l *logrus.Logger, msg string) {
+	l.Info(msg)
+}
+
+func TestCallerOptions(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(file) + "/"
+
+	cases := []struct {
+		name string
+		opts []Option
+		file string
+		fn   string
+	}{
+		{name: "default", file: file, fn: "github.com/lancer05/logger.logWrapper"},
+		{name: "skip", opts: []Option{WithCallerSkip(1)}, file: file, fn: "github.com/lancer05/logger.TestCallerOptions"},
+		{
+			name: "skip packages",
+			opts: []Option{WithCallerSkipPackages("github.com/lancer05/logger.logWrapper")},
+			file: file,
+			fn:   "github.com/lancer05/logger.TestCallerOptions",
+		},
+		{
+			name: "trim",
+			opts: []Option{WithCallerTrimPrefix(dir, "github.com/lancer05/")},
+			file: "caller_test.go",
+			fn:   "logger.logWrapper",
+		},
+		{name: "short", opts: []Option{WithShortCallerFunc()}, file: file, fn: "logger.logWrapper"},
+	}
+
+	for _, c := range cases {
+		out := &bytes.Buffer{}
+		l, _ := NewLogger("test", "test", c.opts...)
+		l.SetOutput(out)
+		l.SetReportCaller(true)
+
+		logWrapper(l, c.name)
+
+		data := out.Bytes()
+		if fn := jsoniter.Get(data, "ctx", "func").ToString(); fn != c.fn {
+			t.Fatalf("%s: output ctx.func, Expected=%q, Actual=%q", c.name, c.fn, fn)
+		}
+		f := jsoniter.Get(data, "ctx", "file").ToString()
+		if !strings.HasPrefix(f, c.file+":") {
+			t.Fatalf("%s: output ctx.file, Expected=%q, Actual=%q", c.name, c.file, f)
+		}
+	}
+}
+
+func TestShortFuncName(t *testing.T) {
+	cases := map[string]string{
+		"github.com/acme/app/handler.(*Server).Create": "handler.(*Server).Create",
+		"main.main":                                      "main.main",
+		"github.com/acme/app/pkg.Map[...].func1":         "pkg.Map[...].func1",
+		"github.com/acme/app/pkg.F[github.com/acme/x.T]": "pkg.F[github.com/acme/x.T]",
+	}
+	for fn, expected := range cases {
+		if actual := shortFuncName(fn); actual != expected {
+			t.Fatalf("shortFuncName(%q) Expected=%q, Actual=%q", fn, expected, actual)
+		}
+	}
+}
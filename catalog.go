@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"sync"
+)
+
+// Catalog 日志消息目录，按 消息码 -> 语言 -> 文本 保存本地化的日志内容
+//
+// 消息码是稳定的、面向机器的标识，文本可以随语言与版本调整，
+// 下游告警与统计应当只依赖消息码
+type Catalog struct {
+	mu sync.RWMutex
+	// 找不到指定语言的文本时使用的语言
+	fallback string
+	messages map[string]map[string]string
+}
+
+// NewCatalog 创建消息目录，fallback 为缺省语言
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: fallback,
+		messages: map[string]map[string]string{},
+	}
+}
+
+// Register 登记消息码在指定语言下的文本
+func (c *Catalog) Register(code, lang, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	texts, ok := c.messages[code]
+	if !ok {
+		texts = map[string]string{}
+		c.messages[code] = texts
+	}
+	texts[lang] = text
+}
+
+// RegisterAll 批量登记同一语言下的消息文本
+func (c *Catalog) RegisterAll(lang string, texts map[string]string) {
+	for code, text := range texts {
+		c.Register(code, lang, text)
+	}
+}
+
+// Lookup 查找消息码对应的文本，指定语言不存在时回退到缺省语言
+func (c *Catalog) Lookup(code, lang string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	texts, ok := c.messages[code]
+	if !ok {
+		return "", false
+	}
+	if text, ok := texts[lang]; ok {
+		return text, true
+	}
+	if text, ok := texts[c.fallback]; ok {
+		return text, true
+	}
+	return "", false
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCatalogLookup(t *testing.T) {
+	c := NewCatalog("en")
+	c.Register("DB_DOWN", "en", "database unreachable")
+	c.RegisterAll("zh", map[string]string{"DB_DOWN": "数据库无法连接"})
+
+	cases := []struct {
+		Code   string
+		Lang   string
+		Expect string
+		Found  bool
+	}{
+		{Code: "DB_DOWN", Lang: "zh", Expect: "数据库无法连接", Found: true},
+		{Code: "DB_DOWN", Lang: "fr", Expect: "database unreachable", Found: true},
+		{Code: "UNKNOWN", Lang: "zh", Expect: "", Found: false},
+	}
+
+	for idx, each := range cases {
+		actual, found := c.Lookup(each.Code, each.Lang)
+		if actual != each.Expect || found != each.Found {
+			t.Fatalf("%d: expect: %s(%v), got: %s(%v)", idx, each.Expect, each.Found, actual, found)
+		}
+	}
+}
+
+func TestFormatterCatalog(t *testing.T) {
+	c := NewCatalog("en")
+	c.Register("DB_DOWN", "zh", "数据库无法连接")
+
+	f := NewFormatter("test", "test", WithCatalog(c, "zh"))
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Message: "db down",
+		Data:    logrus.Fields{"code": "DB_DOWN"},
+	}
+
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if v := jsoniter.Get(data, "code").ToString(); v != "DB_DOWN" {
+		t.Fatalf("Format() code, Expected=%q, Actual=%q", "DB_DOWN", v)
+	}
+	if v := jsoniter.Get(data, "m").ToString(); v != "数据库无法连接" {
+		t.Fatalf("Format() message, Expected=%q, Actual=%q", "数据库无法连接", v)
+	}
+}
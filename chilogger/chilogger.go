@@ -0,0 +1,51 @@
+// Package chilogger 提供 chi 路由的访问日志中间件，在 logger.Middleware 的基础上
+// 记录 chi 匹配的路由模板与 URL 参数
+//
+//	r := chi.NewRouter()
+//	r.Use(chilogger.Middleware(l))
+//	r.Get("/users/{id}", getUser)
+//
+// 输出的 request.route 为 /users/{id}，request.route_params 为 {"id": "42"}
+package chilogger
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Middleware 访问日志中间件，可选配置与 logger.Middleware 相同，
+// 必须通过 chi.Router.Use 注册，路由在中间件之后匹配，请求处理完成后读取匹配结果
+func Middleware(l *logrus.Logger, opts ...logger.MiddlewareOption) func(http.Handler) http.Handler {
+	access := logger.Middleware(l, opts...)
+	return func(next http.Handler) http.Handler {
+		return access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 中间件返回前记录路由，panic 时同样记录
+			defer recordRoute(r)
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// recordRoute 将 chi 的路由匹配结果记录到请求的 ctx
+func recordRoute(r *http.Request) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return
+	}
+	logger.SetRoute(r, rctx.RoutePattern(), "")
+
+	keys, values := rctx.URLParams.Keys, rctx.URLParams.Values
+	if len(keys) == 0 {
+		return
+	}
+	params := make(map[string]string, len(keys))
+	for i, k := range keys {
+		if i < len(values) {
+			params[k] = values[i]
+		}
+	}
+	logger.SetRouteParams(r, params)
+}
@@ -0,0 +1,48 @@
+package chilogger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+)
+
+func TestMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	r := chi.NewRouter()
+	r.Use(Middleware(l))
+	r.Route("/orgs/{org}", func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orgs/acme/users/42", nil))
+
+	data := out.Bytes()
+	if err := logger.Validate(data); err != nil {
+		t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+	}
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(logger.SchemaHTTPRequestV1)},
+		{path: []interface{}{"request", "status"}, expected: "204"},
+		{path: []interface{}{"request", "route"}, expected: "/orgs/{org}/users/{id}"},
+		{path: []interface{}{"request", "route_params", "org"}, expected: "acme"},
+		{path: []interface{}{"request", "route_params", "id"}, expected: "42"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf("output %q, Expected=%q, Actual=%q", c.path, c.expected, v)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExitFlushTimeout Fatal 退出进程前等待登记的对象关闭的最长时间
+var ExitFlushTimeout = 5 * time.Second
+
+var exitHandlerOnce sync.Once
+
+var closers struct {
+	mu   sync.Mutex
+	list []namedCloser
+}
+
+type namedCloser struct {
+	name string
+	c    io.Closer
+}
+
+// RegisterCloser 登记进程退出前需要关闭的输出、sink 或 hook，
+// 由 Close 统一刷新缓冲并关闭，避免退出时丢失最后的日志
+//
+//	sink := lokisink.New(addr)
+//	logger.RegisterCloser("loki", sink)
+//	defer logger.Close(ctx)
+func RegisterCloser(name string, c io.Closer) {
+	closers.mu.Lock()
+	closers.list = append(closers.list, namedCloser{name: name, c: c})
+	closers.mu.Unlock()
+}
+
+// CloseError 关闭时发生的错误，按关闭顺序排列
+type CloseError []error
+
+func (e CloseError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Close 按登记的相反顺序关闭所有对象，ctx 结束后不再等待尚未完成的关闭
+//
+// 关闭后登记列表被清空，可以再次登记
+func Close(ctx context.Context) error {
+	closers.mu.Lock()
+	list := closers.list
+	closers.list = nil
+	closers.mu.Unlock()
+
+	var errs CloseError
+	for i := len(list) - 1; i >= 0; i-- {
+		nc := list[i]
+
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", nc.name, ctx.Err()))
+			continue
+		}
+
+		result := make(chan error, 1)
+		go func() {
+			result <- nc.c.Close()
+		}()
+
+		select {
+		case err := <-result:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("close %s: %w", nc.name, err))
+			}
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("close %s: %w", nc.name, ctx.Err()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// registerExitHandler 登记 logrus 的退出处理函数，Fatal 级别的日志退出进程前调用 Close，
+// 确保异步输出与日志文件中的最后一条日志写入完成；只登记一次
+//
+// 在此之后登记的退出处理函数如果还要输出日志，应使用 logrus.DeferExitHandler 登记到前面
+func registerExitHandler() {
+	exitHandlerOnce.Do(func() {
+		logrus.RegisterExitHandler(flushOnExit)
+	})
+}
+
+func flushOnExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), ExitFlushTimeout)
+	defer cancel()
+	if err := Close(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "logger: flush on exit:", err)
+	}
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type closeFunc func() error
+
+func (fn closeFunc) Close() error {
+	return fn()
+}
+
+func TestClose(t *testing.T) {
+	var order []string
+	record := func(name string, err error) closeFunc {
+		return func() error {
+			order = append(order, name)
+			return err
+		}
+	}
+
+	RegisterCloser("first", record("first", nil))
+	RegisterCloser("second", record("second", errors.New("boom")))
+	RegisterCloser("third", record("third", nil))
+
+	err := Close(context.Background())
+	if err == nil || err.Error() != "close second: boom" {
+		t.Fatalf("Close() error, Expected=%q, Actual=%v", "close second: boom", err)
+	}
+	if strings.Join(order, ",") != "third,second,first" {
+		t.Fatalf("expect reverse order, got %v", order)
+	}
+
+	if err := Close(context.Background()); err != nil {
+		t.Fatalf("Close() twice error, Expected=nil, Actual=%q", err.Error())
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	RegisterCloser("quick", closeFunc(func() error { return nil }))
+	RegisterCloser("stuck", closeFunc(func() error {
+		<-block
+		return nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := Close(ctx)
+	errs, ok := err.(CloseError)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expect 2 close errors, got %v", err)
+	}
+	for _, e := range errs {
+		if !errors.Is(e, context.DeadlineExceeded) {
+			t.Fatalf("expect deadline exceeded, got %v", e)
+		}
+	}
+}
+
+func TestCloseAsyncWriter(t *testing.T) {
+	out := &slowWriter{gate: make(chan struct{})}
+	close(out.gate)
+
+	l, _ := NewLogger("test", "prod")
+	aw := NewAsyncWriter(out)
+	l.SetOutput(aw)
+	RegisterCloser("async", aw)
+
+	l.Info("last words")
+	if err := Close(context.Background()); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if !strings.Contains(out.String(), `"m":"last words"`) {
+		t.Fatalf("expect last line flushed, got %q", out.String())
+	}
+}
+
+func TestFatalFlushesClosers(t *testing.T) {
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(&strings.Builder{})
+	exited := -1
+	l.ExitFunc = func(code int) { exited = code }
+
+	closed := false
+	RegisterCloser("sink", closeFunc(func() error {
+		closed = true
+		return nil
+	}))
+	defer Close(context.Background())
+
+	l.Fatal("boom")
+	if exited != 1 || !closed {
+		t.Fatalf("Fatal() Expected exit 1 after closing, Actual exit=%d closed=%v", exited, closed)
+	}
+}
@@ -0,0 +1,124 @@
+// Package cobralogger 记录 cobra 命令行工具的每次命令执行，使内部工具与服务输出相同结构的日志
+//
+//	root.AddCommand(migrateCmd, serveCmd)
+//	cobralogger.Instrument(root, l)
+//	if err := root.Execute(); err != nil {
+//		os.Exit(1)
+//	}
+package cobralogger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// RedactFlagAnnotation 标记需要脱敏的命令行参数，名称在 logger.DefaultSensitiveParams 中的参数默认脱敏
+//
+//	cmd.Flags().String("dsn", "", "database dsn")
+//	_ = cmd.Flags().SetAnnotation("dsn", cobralogger.RedactFlagAnnotation, []string{"true"})
+const RedactFlagAnnotation = "logger_redact"
+
+// instrumentedAnnotation 标记已经记录日志的命令，重复调用 Instrument 时跳过
+const instrumentedAnnotation = "cobralogger_instrumented"
+
+// 记录在 ctx.cli.exit_code 中的退出码，与返回错误时调用 os.Exit(1)、
+// 未恢复的 panic 使 Go 程序以 2 退出的约定一致
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitPanic = 2
+)
+
+// Instrument 记录 root 及其子命令的每次执行，ctx.cli 中记录命令、位置参数、
+// 显式设置的参数、退出码与耗时，需要在添加完子命令之后调用，重复调用不会重复记录
+func Instrument(root *cobra.Command, l *logrus.Logger) {
+	instrument(root, l)
+	for _, c := range root.Commands() {
+		Instrument(c, l)
+	}
+}
+
+func instrument(cmd *cobra.Command, l *logrus.Logger) {
+	run, runE := cmd.Run, cmd.RunE
+	if run == nil && runE == nil {
+		return
+	}
+	if _, ok := cmd.Annotations[instrumentedAnnotation]; ok {
+		return
+	}
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[instrumentedAnnotation] = "true"
+
+	cmd.Run = nil
+	cmd.RunE = func(c *cobra.Command, args []string) (err error) {
+		start := time.Now()
+		defer func() {
+			cli := logrus.Fields{
+				"command":   c.CommandPath(),
+				"args":      args,
+				"flags":     flagValues(c),
+				"exit_code": ExitOK,
+				"duration":  time.Since(start).String(),
+			}
+			entry := l.WithContext(c.Context())
+
+			if r := recover(); r != nil {
+				cli["exit_code"] = ExitPanic
+				entry.WithFields(logrus.Fields{
+					"cli":   cli,
+					"error": fmt.Sprintf("%v", r),
+				}).Error("command panicked")
+				panic(r)
+			}
+			if err != nil {
+				cli["exit_code"] = ExitError
+				entry.WithFields(logrus.Fields{
+					"cli":   cli,
+					"error": err,
+				}).Error("command failed")
+				return
+			}
+			entry.WithField("cli", cli).Info("command finished")
+		}()
+
+		if runE != nil {
+			return runE(c, args)
+		}
+		run(c, args)
+		return nil
+	}
+}
+
+// flagValues 返回显式设置的参数，需要脱敏的参数值替换为 logger.DefaultRedactReplacement
+func flagValues(c *cobra.Command) map[string]string {
+	flags := map[string]string{}
+	c.Flags().Visit(func(f *pflag.Flag) {
+		if redactFlag(f) {
+			flags[f.Name] = logger.DefaultRedactReplacement
+			return
+		}
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
+
+func redactFlag(f *pflag.Flag) bool {
+	if _, ok := f.Annotations[RedactFlagAnnotation]; ok {
+		return true
+	}
+	name := strings.ReplaceAll(strings.ToLower(f.Name), "-", "_")
+	for _, s := range logger.DefaultSensitiveParams {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package cobralogger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"github.com/spf13/cobra"
+)
+
+func TestInstrument(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	root := &cobra.Command{Use: "tool"}
+	migrate := &cobra.Command{
+		Use: "migrate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.New("dirty database")
+		},
+	}
+	migrate.Flags().String("dsn", "", "")
+	migrate.Flags().String("password", "", "")
+	migrate.Flags().Int("steps", 0, "")
+	_ = migrate.Flags().SetAnnotation("dsn", RedactFlagAnnotation, []string{"true"})
+	serve := &cobra.Command{Use: "serve", Run: func(cmd *cobra.Command, args []string) {}}
+	root.AddCommand(migrate, serve)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	Instrument(root, l)
+
+	root.SetArgs([]string{"migrate", "up", "--dsn", "postgres://u:p@db/app", "--password", "secret", "--steps", "3"})
+	if err := root.Execute(); err == nil {
+		t.Fatalf("Execute() error, Expected=%q, Actual=nil", "dirty database")
+	}
+	root.SetArgs([]string{"serve"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() error, Expected=nil, Actual=%q", err)
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if len(lines) != 2 {
+		t.Fatalf("log lines, Expected=2, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"l"}, expected: "error"},
+		{line: 0, path: []interface{}{"err"}, expected: "dirty database"},
+		{line: 0, path: []interface{}{"ctx", "cli", "command"}, expected: "tool migrate"},
+		{line: 0, path: []interface{}{"ctx", "cli", "args", 0}, expected: "up"},
+		{line: 0, path: []interface{}{"ctx", "cli", "exit_code"}, expected: "1"},
+		{line: 0, path: []interface{}{"ctx", "cli", "flags", "dsn"}, expected: logger.DefaultRedactReplacement},
+		{line: 0, path: []interface{}{"ctx", "cli", "flags", "password"}, expected: logger.DefaultRedactReplacement},
+		{line: 0, path: []interface{}{"ctx", "cli", "flags", "steps"}, expected: "3"},
+		{line: 1, path: []interface{}{"l"}, expected: "info"},
+		{line: 1, path: []interface{}{"ctx", "cli", "command"}, expected: "tool serve"},
+		{line: 1, path: []interface{}{"ctx", "cli", "exit_code"}, expected: "0"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
+
+func TestInstrumentTwice(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	root := &cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}}
+	Instrument(root, l)
+	Instrument(root, l)
+	root.SetArgs([]string{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() error, Expected=nil, Actual=%q", err)
+	}
+
+	if n := bytes.Count(out.Bytes(), []byte("\n")); n != 1 {
+		t.Fatalf("log lines, Expected=1, Actual=%d", n)
+	}
+}
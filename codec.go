@@ -0,0 +1,62 @@
+//go:build !lite
+// +build !lite
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+var (
+	// DefaultEncoder 默认的编码实现
+	DefaultEncoder Encoder = jsoniter.ConfigDefault
+
+	// sortedDefaultEncoder 按键排序 map 的默认编码实现
+	sortedDefaultEncoder Encoder = jsoniter.Config{
+		EscapeHTML:             true,
+		SortMapKeys:            true,
+		ValidateJsonRawMessage: true,
+	}.Froze()
+
+	emptyStack = make([]string, 0)
+)
+
+func jsonEncode(w io.Writer, v interface{}) error {
+	return jsoniter.NewEncoder(w).Encode(v)
+}
+
+func jsonDecode(r io.Reader, v interface{}) error {
+	return jsoniter.NewDecoder(r).Decode(v)
+}
+
+func wrapf(err error, format string, args ...interface{}) error {
+	return errors.Wrapf(err, format, args...)
+}
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// stackTrace 从错误信息中获取调用栈信息
+func stackTrace(err error) []string {
+	if err, ok := err.(stackTracer); ok {
+		return strings.Split(
+			strings.ReplaceAll(
+				strings.TrimLeft(
+					fmt.Sprintf("%+v", err.StackTrace()),
+					"\n",
+				),
+				"\n\t",
+				" ",
+			),
+			"\n",
+		)
+	}
+
+	return emptyStack
+}
@@ -0,0 +1,57 @@
+//go:build lite
+// +build lite
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lite 模式只依赖标准库，不引入 jsoniter 与 pkg/errors
+
+var (
+	// DefaultEncoder 默认的编码实现
+	DefaultEncoder Encoder = StdEncoder{}
+
+	// sortedDefaultEncoder 按键排序 map 的默认编码实现，encoding/json 总是按键排序
+	sortedDefaultEncoder Encoder = StdEncoder{}
+
+	emptyStack = make([]string, 0)
+)
+
+func jsonEncode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func jsonDecode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func wrapf(err error, format string, args ...interface{}) error {
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
+
+// stackTrace 从错误信息中获取调用栈信息
+//
+// 无法引用 pkg/errors 的类型，改为解析 %+v 输出的调用栈，
+// 兼容 pkg/errors 等在 %+v 时输出 "函数\n\t文件:行号" 的错误类型
+func stackTrace(err error) []string {
+	verbose := fmt.Sprintf("%+v", err)
+	msg := err.Error()
+	if verbose == msg || !strings.HasPrefix(verbose, msg) {
+		return emptyStack
+	}
+
+	lines := strings.Split(strings.TrimLeft(verbose[len(msg):], "\n"), "\n")
+	trace := make([]string, 0, len(lines)/2)
+	for i := 0; i+1 < len(lines); i += 2 {
+		if !strings.HasPrefix(lines[i+1], "\t") {
+			break
+		}
+		trace = append(trace, lines[i]+" "+strings.TrimPrefix(lines[i+1], "\t"))
+	}
+	return trace
+}
@@ -0,0 +1,326 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EnvPrefix 日志配置环境变量的前缀
+const EnvPrefix = "LOG_"
+
+// Config 日志配置，可以从 YAML/JSON 文件或环境变量加载
+type Config struct {
+	Service string `json:"service" yaml:"service"`
+	Env     string `json:"env" yaml:"env"`
+	// 日志级别，默认 info
+	Level string `json:"level" yaml:"level"`
+	// 输出目标，stdout、stderr 或文件路径，默认 stderr
+	Output string `json:"output" yaml:"output"`
+	// 时间格式，为空时使用默认格式
+	TimeLayout   string         `json:"time_layout" yaml:"time_layout"`
+	Retention    string         `json:"retention" yaml:"retention"`
+	HostMetadata bool           `json:"host_metadata" yaml:"host_metadata"`
+	Kubernetes   bool           `json:"kubernetes" yaml:"kubernetes"`
+	BuildInfo    bool           `json:"build_info" yaml:"build_info"`
+	Redact       []RedactConfig `json:"redact" yaml:"redact"`
+	// 以审计模式运行脱敏规则
+	RedactAudit bool `json:"redact_audit" yaml:"redact_audit"`
+	// 检测并替换密钥等敏感信息，规则见 SecretRules
+	SecretScanning bool `json:"secret_scanning" yaml:"secret_scanning"`
+	// 单个字段与单条日志的最大字节数，0 使用默认值，负数表示不限制
+	MaxFieldSize int `json:"max_field_size" yaml:"max_field_size"`
+	MaxEntrySize int `json:"max_entry_size" yaml:"max_entry_size"`
+	// 请求 IP 的匿名化方式，truncate 或 hash，hash 需要设置 ip_salt
+	IPAnonymization string `json:"ip_anonymization" yaml:"ip_anonymization"`
+	IPSalt          string `json:"ip_salt" yaml:"ip_salt"`
+	// 访问日志的采样规则，通过 WithReloadableSampling 用于访问日志中间件
+	Sampling []SamplingConfig `json:"sampling" yaml:"sampling"`
+	// 各输出组件的配置，由 lokisink.FromConfig、fluentsink.FromConfig 等组件通过 SinkOptions 解析
+	Sinks map[string]map[string]interface{} `json:"sinks" yaml:"sinks"`
+}
+
+// RedactConfig 脱敏规则的配置
+type RedactConfig struct {
+	ID           string   `json:"id" yaml:"id"`
+	Keys         []string `json:"keys" yaml:"keys"`
+	KeyPattern   string   `json:"key_pattern" yaml:"key_pattern"`
+	ValuePattern string   `json:"value_pattern" yaml:"value_pattern"`
+	Replacement  string   `json:"replacement" yaml:"replacement"`
+}
+
+// SamplingConfig 访问日志采样规则的配置，含义与 SamplingRule 相同
+type SamplingConfig struct {
+	MinStatus int     `json:"min_status" yaml:"min_status"`
+	MaxStatus int     `json:"max_status" yaml:"max_status"`
+	Rate      float64 `json:"rate" yaml:"rate"`
+}
+
+// LoadConfig 从文件加载配置，扩展名为 .json 时按 JSON 解析，否则按 YAML 解析
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, wrapf(err, "read log config %s", path)
+	}
+
+	c := &Config{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = jsonDecode(bytes.NewReader(b), c)
+	} else {
+		err = yamlDecode(b, c)
+	}
+	if err != nil {
+		return nil, wrapf(err, "parse log config %s", path)
+	}
+	return c, nil
+}
+
+// ConfigFromEnv 从 LOG_ 开头的环境变量加载配置
+//
+//	LOG_SERVICE、LOG_ENV、LOG_LEVEL、LOG_OUTPUT、LOG_TIME_LAYOUT、LOG_RETENTION
+//	LOG_IP_ANONYMIZATION、LOG_IP_SALT
+//	LOG_HOST_METADATA、LOG_KUBERNETES、LOG_BUILD_INFO、LOG_REDACT_AUDIT、LOG_SECRET_SCANNING 布尔值
+//	LOG_REDACT_KEYS 逗号分隔的脱敏字段名
+//	LOG_MAX_FIELD_SIZE、LOG_MAX_ENTRY_SIZE 字节数
+func ConfigFromEnv() (*Config, error) {
+	c := &Config{
+		Service:    os.Getenv(EnvPrefix + "SERVICE"),
+		Env:        os.Getenv(EnvPrefix + "ENV"),
+		Level:      os.Getenv(EnvPrefix + "LEVEL"),
+		Output:     os.Getenv(EnvPrefix + "OUTPUT"),
+		TimeLayout: os.Getenv(EnvPrefix + "TIME_LAYOUT"),
+		Retention:  os.Getenv(EnvPrefix + "RETENTION"),
+
+		IPAnonymization: os.Getenv(EnvPrefix + "IP_ANONYMIZATION"),
+		IPSalt:          os.Getenv(EnvPrefix + "IP_SALT"),
+	}
+
+	flags := map[string]*bool{
+		"HOST_METADATA": &c.HostMetadata,
+		"KUBERNETES":    &c.Kubernetes,
+		"BUILD_INFO":    &c.BuildInfo,
+		"REDACT_AUDIT":  &c.RedactAudit,
+
+		"SECRET_SCANNING": &c.SecretScanning,
+	}
+	for name, ptr := range flags {
+		v := os.Getenv(EnvPrefix + name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, wrapf(err, "parse %s%s", EnvPrefix, name)
+		}
+		*ptr = b
+	}
+
+	sizes := map[string]*int{
+		"MAX_FIELD_SIZE": &c.MaxFieldSize,
+		"MAX_ENTRY_SIZE": &c.MaxEntrySize,
+	}
+	for name, ptr := range sizes {
+		v := os.Getenv(EnvPrefix + name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, wrapf(err, "parse %s%s", EnvPrefix, name)
+		}
+		*ptr = n
+	}
+
+	if keys := os.Getenv(EnvPrefix + "REDACT_KEYS"); keys != "" {
+		c.Redact = append(c.Redact, RedactConfig{
+			ID:   "env",
+			Keys: strings.Split(keys, ","),
+		})
+	}
+	return c, nil
+}
+
+// NewFromConfig 根据配置文件创建日志对象
+func NewFromConfig(path string) (*logrus.Logger, error) {
+	c, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConfig(c)
+}
+
+// NewFromEnv 根据环境变量创建日志对象
+func NewFromEnv() (*logrus.Logger, error) {
+	c, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConfig(c)
+}
+
+// NewWithConfig 根据配置创建日志对象
+func NewWithConfig(c *Config, opts ...Option) (*logrus.Logger, error) {
+	configOpts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := NewLogger(c.Service, c.Env, append(configOpts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Level != "" {
+		level, err := logrus.ParseLevel(c.Level)
+		if err != nil {
+			return nil, wrapf(err, "parse log level")
+		}
+		l.SetLevel(level)
+	}
+
+	switch c.Output {
+	case "", "stderr":
+		l.SetOutput(os.Stderr)
+	case "stdout":
+		l.SetOutput(os.Stdout)
+	default:
+		f, err := os.OpenFile(c.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, wrapf(err, "open log output %s", c.Output)
+		}
+		l.SetOutput(f)
+		RegisterCloser("output "+c.Output, f)
+	}
+	return l, nil
+}
+
+// Options 将配置转换为格式化对象的可选配置
+func (c *Config) Options() ([]Option, error) {
+	var opts []Option
+	if c.TimeLayout != "" {
+		layout := c.TimeLayout
+		opts = append(opts, func(f *LogsV1Formatter) {
+			f.TimeLayout = layout
+		})
+	}
+	if c.Retention != "" {
+		opts = append(opts, WithRetention(c.Retention))
+	}
+	if c.HostMetadata {
+		opts = append(opts, WithHostMetadata())
+	}
+	if c.Kubernetes {
+		opts = append(opts, WithKubernetesMetadata())
+	}
+	if c.BuildInfo {
+		opts = append(opts, WithBuildInfo())
+	}
+	if c.MaxFieldSize != 0 || c.MaxEntrySize != 0 {
+		field, entry := sizeLimit(c.MaxFieldSize, DefaultMaxFieldSize), sizeLimit(c.MaxEntrySize, DefaultMaxEntrySize)
+		opts = append(opts, WithSizeLimits(field, entry))
+	}
+	switch c.IPAnonymization {
+	case "":
+	case IPAnonymizeTruncate:
+		opts = append(opts, WithIPTruncation())
+	case IPAnonymizeHash:
+		if c.IPSalt == "" {
+			return nil, errors.New("ip_anonymization hash requires ip_salt")
+		}
+		opts = append(opts, WithIPHashing(c.IPSalt))
+	default:
+		return nil, fmt.Errorf("unknown ip_anonymization %q", c.IPAnonymization)
+	}
+
+	rules, err := c.RedactRules()
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) > 0 {
+		opts = append(opts, WithRedaction(rules...))
+		if c.RedactAudit {
+			opts = append(opts, WithRedactionAudit(nil))
+		}
+	}
+	return opts, nil
+}
+
+// sizeLimit 将配置的字节数转换为格式化对象的限制，0 使用默认值，负数表示不限制
+func sizeLimit(n, def int) int {
+	switch {
+	case n == 0:
+		return def
+	case n < 0:
+		return 0
+	}
+	return n
+}
+
+// RedactRules 编译配置中的脱敏规则，开启 secret_scanning 时包含 SecretRules
+func (c *Config) RedactRules() ([]RedactRule, error) {
+	rules := make([]RedactRule, 0, len(c.Redact))
+	for _, rc := range c.Redact {
+		rule := RedactRule{
+			ID:          rc.ID,
+			Keys:        rc.Keys,
+			Replacement: rc.Replacement,
+		}
+
+		var err error
+		if rc.KeyPattern != "" {
+			if rule.KeyPattern, err = regexp.Compile(rc.KeyPattern); err != nil {
+				return nil, wrapf(err, "compile redact rule %s key pattern", rc.ID)
+			}
+		}
+		if rc.ValuePattern != "" {
+			if rule.ValuePattern, err = regexp.Compile(rc.ValuePattern); err != nil {
+				return nil, wrapf(err, "compile redact rule %s value pattern", rc.ID)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	if c.SecretScanning {
+		rules = append(rules, SecretRules()...)
+	}
+	return rules, nil
+}
+
+// samplingRules 检查并转换配置中的访问日志采样规则
+func (c *Config) samplingRules() ([]*samplingRule, error) {
+	rules := make([]*samplingRule, 0, len(c.Sampling))
+	for _, sc := range c.Sampling {
+		if sc.Rate < 0 || sc.Rate > 1 {
+			return nil, fmt.Errorf("sampling rate %v out of range [0, 1]", sc.Rate)
+		}
+		if sc.MinStatus > sc.MaxStatus {
+			return nil, fmt.Errorf("sampling min_status %d greater than max_status %d", sc.MinStatus, sc.MaxStatus)
+		}
+		rules = append(rules, &samplingRule{SamplingRule: SamplingRule(sc)})
+	}
+	return rules, nil
+}
+
+// SinkOptions 将 sinks 中名为 name 的配置解析到 v，配置不存在时返回 false
+func (c *Config) SinkOptions(name string, v interface{}) (bool, error) {
+	raw, ok := c.Sinks[name]
+	if !ok {
+		return false, nil
+	}
+
+	b := &bytes.Buffer{}
+	if err := jsonEncode(b, raw); err != nil {
+		return true, wrapf(err, "encode sink %s options", name)
+	}
+	if err := jsonDecode(b, v); err != nil {
+		return true, wrapf(err, "decode sink %s options", name)
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logconfig")
+	if err != nil {
+		t.Fatalf("TempDir() error, Expected=nil, Actual=%q", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "app.log")
+	path := filepath.Join(dir, "log.json")
+	_ = ioutil.WriteFile(path, []byte(`{
+  "service": "api",
+  "env": "prod",
+  "level": "warn",
+  "output": "`+output+`",
+  "retention": "30d",
+  "redact": [{"id": "password", "keys": ["password"]}],
+  "sinks": {"loki": {"url": "http://loki:3100", "batch_size": 100}}
+}`), 0o644)
+
+	l, err := NewFromConfig(path)
+	if err != nil {
+		t.Fatalf("NewFromConfig() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if l.GetLevel() != logrus.WarnLevel {
+		t.Fatalf("level, Expected=%s, Actual=%s", logrus.WarnLevel, l.GetLevel())
+	}
+
+	l.Info("ignored")
+	l.WithField("password", "secret").Warn("logged")
+	l.Out.(*os.File).Close()
+
+	data, _ := ioutil.ReadFile(output)
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"s"}, expected: "api"},
+		{path: []interface{}{"e"}, expected: "prod"},
+		{path: []interface{}{"m"}, expected: "logged"},
+		{path: []interface{}{"retention"}, expected: "30d"},
+		{path: []interface{}{"ctx", "password"}, expected: DefaultRedactReplacement},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+
+	c, _ := LoadConfig(path)
+	sink := struct {
+		URL       string `json:"url"`
+		BatchSize int    `json:"batch_size"`
+	}{}
+	if ok, err := c.SinkOptions("loki", &sink); !ok || err != nil {
+		t.Fatalf("SinkOptions() Expected=true,nil Actual=%v,%v", ok, err)
+	}
+	if sink.URL != "http://loki:3100" || sink.BatchSize != 100 {
+		t.Fatalf("SinkOptions() Actual=%+v", sink)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	envs := map[string]string{
+		"LOG_SERVICE":          "worker",
+		"LOG_LEVEL":            "debug",
+		"LOG_BUILD_INFO":       "true",
+		"LOG_REDACT_KEYS":      "token,secret",
+		"LOG_HOST_METADATA":    "not-bool",
+		"LOG_MAX_FIELD_SIZE":   "1024",
+		"LOG_MAX_ENTRY_SIZE":   "-1",
+		"LOG_IP_ANONYMIZATION": "truncate",
+	}
+	for k, v := range envs {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Fatalf("ConfigFromEnv() error, Expected=error, Actual=nil")
+	}
+
+	os.Setenv("LOG_HOST_METADATA", "1")
+	c, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if c.Service != "worker" || c.Level != "debug" || !c.BuildInfo || !c.HostMetadata {
+		t.Fatalf("ConfigFromEnv() Actual=%+v", c)
+	}
+	if len(c.Redact) != 1 || len(c.Redact[0].Keys) != 2 {
+		t.Fatalf("ConfigFromEnv() redact, Actual=%+v", c.Redact)
+	}
+
+	opts, err := c.Options()
+	if err != nil {
+		t.Fatalf("Options() error, Expected=nil, Actual=%q", err.Error())
+	}
+	f := NewFormatter("worker", "prod", opts...).(*LogsV1Formatter)
+	if f.MaxFieldSize != 1024 || f.MaxEntrySize != 0 {
+		t.Fatalf("size limits Expected=1024/0, Actual=%d/%d", f.MaxFieldSize, f.MaxEntrySize)
+	}
+	if f.IPAnonymizer == nil || f.IPAnonymizer("10.1.2.3") != "10.1.2.0" {
+		t.Fatalf("ip anonymization, Expected=truncate")
+	}
+
+	c.IPAnonymization = IPAnonymizeHash
+	if _, err := c.Options(); err == nil {
+		t.Fatalf("Options() error for hash without salt, Expected=error, Actual=nil")
+	}
+}
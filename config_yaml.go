@@ -0,0 +1,12 @@
+//go:build !lite
+// +build !lite
+
+package logger
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+func yamlDecode(b []byte, v interface{}) error {
+	return yaml.Unmarshal(b, v)
+}
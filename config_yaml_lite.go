@@ -0,0 +1,13 @@
+//go:build lite
+// +build lite
+
+package logger
+
+import (
+	"errors"
+)
+
+// yamlDecode lite 模式不引入 YAML 解析，只支持 JSON 格式的配置文件
+func yamlDecode(b []byte, v interface{}) error {
+	return errors.New("yaml config is not supported in lite build, use json instead")
+}
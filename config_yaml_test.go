@@ -0,0 +1,52 @@
+//go:build !lite
+// +build !lite
+
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logconfig")
+	if err != nil {
+		t.Fatalf("TempDir() error, Expected=nil, Actual=%q", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log.yaml")
+	_ = ioutil.WriteFile(path, []byte(`
+service: api
+level: warn
+redact:
+  - id: password
+    keys: [password]
+sinks:
+  loki:
+    url: http://loki:3100
+    labels:
+      cluster: c1
+`), 0o644)
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if c.Service != "api" || c.Level != "warn" || len(c.Redact) != 1 || c.Redact[0].Keys[0] != "password" {
+		t.Fatalf("LoadConfig() Actual=%+v", c)
+	}
+
+	sink := struct {
+		URL    string            `json:"url"`
+		Labels map[string]string `json:"labels"`
+	}{}
+	if ok, err := c.SinkOptions("loki", &sink); !ok || err != nil {
+		t.Fatalf("SinkOptions() Expected=true,nil Actual=%v,%v", ok, err)
+	}
+	if sink.URL != "http://loki:3100" || sink.Labels["cluster"] != "c1" {
+		t.Fatalf("SinkOptions() Actual=%+v", sink)
+	}
+}
@@ -0,0 +1,405 @@
+// Package conformance 日志格式化的一致性测试套件
+//
+// 自定义的格式化对象或新登记的日志规范在上线前应当通过该套件，
+// 保证下游的日志消费方能够正确解析
+//
+//	func TestMyFormatter(t *testing.T) {
+//		conformance.Run(t, NewMyFormatter())
+//	}
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"github.com/lancer05/logger/loggertest"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Assertion 对格式化结果中某个字段的断言
+type Assertion struct {
+	Path []interface{}
+	// 期望的值，为空时只要求字段存在
+	Expected string
+}
+
+// Case 一条测试日志及其断言
+type Case struct {
+	Name string
+	// 每次调用返回新的 entry，避免格式化过程修改数据影响后续断言
+	Entry      func() *logrus.Entry
+	Assertions []Assertion
+	// 与运行环境相关的字段路径，以 . 分隔，golden 比较时忽略
+	Ignore []string
+}
+
+// Cases 获得内置的测试用例，覆盖所有内置的日志规范
+func Cases() []Case {
+	return []Case{
+		{
+			Name: "general",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.InfoLevel, "hello", logrus.Fields{
+					"channel": "conformance",
+					"user":    42,
+					"id":      "entry-id",
+					"foo":     "bar",
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaGeneralLogsV1)},
+				{Path: path("t")},
+				{Path: path("l"), Expected: "info"},
+				{Path: path("s")},
+				{Path: path("e")},
+				{Path: path("c"), Expected: "conformance"},
+				{Path: path("u"), Expected: "42"},
+				{Path: path("i"), Expected: "entry-id"},
+				{Path: path("m"), Expected: "hello"},
+				{Path: path("ctx", "foo"), Expected: "bar"},
+			},
+		},
+		{
+			Name: "error",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.ErrorLevel, "failed", logrus.Fields{
+					"error": errors.New("top level"),
+					"cause": pkgerrors.New("nested"),
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("l"), Expected: "error"},
+				{Path: path("err"), Expected: "top level"},
+				{Path: path("ctx", "cause", "msg"), Expected: "nested"},
+				{Path: path("ctx", "cause", "trace", 0)},
+			},
+			Ignore: []string{"ctx.cause.trace", "ctx.cause.fingerprint"},
+		},
+		{
+			Name: "http.request",
+			Entry: func() *logrus.Entry {
+				req := &http.Request{
+					RemoteAddr: "1.2.3.4:1234",
+					Method:     http.MethodGet,
+					Header:     http.Header{"X-Test": []string{"1"}},
+					URL: &url.URL{
+						Path:     "/api",
+						RawQuery: "q=1",
+					},
+				}
+				return newEntry(logrus.InfoLevel, "", logrus.Fields{
+					"request":  req,
+					"status":   http.StatusOK,
+					"duration": time.Second,
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaHTTPRequestV1)},
+				{Path: path("request", "ip"), Expected: "1.2.3.4"},
+				{Path: path("request", "method"), Expected: http.MethodGet},
+				{Path: path("request", "path"), Expected: "/api"},
+				{Path: path("request", "header", "x-test"), Expected: "1"},
+				{Path: path("request", "param", "q"), Expected: "1"},
+				{Path: path("request", "status"), Expected: "200"},
+				{Path: path("request", "duration"), Expected: "1s"},
+			},
+		},
+		{
+			Name: "sql.query",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.DebugLevel, "", logrus.Fields{
+					"sql": &logger.SQLData{
+						Statement:   "select 1",
+						Fingerprint: "fp",
+						Rows:        1,
+						Duration:    "1ms",
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaSQLQueryV1)},
+				{Path: path("sql", "statement"), Expected: "select 1"},
+				{Path: path("sql", "fingerprint"), Expected: "fp"},
+				{Path: path("sql", "rows"), Expected: "1"},
+			},
+		},
+		{
+			Name: "http.client",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.InfoLevel, "", logrus.Fields{
+					"client": &logger.ClientRequestData{
+						Host:   "example.com",
+						Method: http.MethodPost,
+						Path:   "/v1",
+						Status: "201",
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaHTTPClientV1)},
+				{Path: path("client", "host"), Expected: "example.com"},
+				{Path: path("client", "status"), Expected: "201"},
+			},
+		},
+		{
+			Name: "mq.message",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.InfoLevel, "", logrus.Fields{
+					"mq": &logger.MessageData{
+						System:  "kafka",
+						Topic:   "orders",
+						Offset:  7,
+						Outcome: logger.OutcomeSuccess,
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaMQMessageV1)},
+				{Path: path("mq", "topic"), Expected: "orders"},
+				{Path: path("mq", "offset"), Expected: "7"},
+			},
+		},
+		{
+			Name: "job.run",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.InfoLevel, "", logrus.Fields{
+					"job": &logger.JobData{
+						Name:  "sync",
+						RunID: "run-1",
+						Event: logger.JobStart,
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaJobRunV1)},
+				{Path: path("job", "run_id"), Expected: "run-1"},
+			},
+		},
+		{
+			Name: "runtime.stats",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.InfoLevel, "runtime stats", logrus.Fields{
+					"runtime": &logger.RuntimeStatsData{
+						HeapAlloc:    1 << 20,
+						HeapObjects:  1000,
+						Sys:          8 << 20,
+						Goroutines:   12,
+						GCCount:      2,
+						GCPauseTotal: "150µs",
+						GCPauseMax:   "100µs",
+						CPUPercent:   12.5,
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaRuntimeStatsV1)},
+				{Path: path("runtime", "heap_alloc"), Expected: "1048576"},
+				{Path: path("runtime", "goroutines"), Expected: "12"},
+				{Path: path("runtime", "gc_pause_max"), Expected: "100µs"},
+				{Path: path("runtime", "cpu_percent"), Expected: "12.5"},
+			},
+		},
+		{
+			Name: "grpc.client",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.WarnLevel, "grpc client call", logrus.Fields{
+					"grpc": &logger.GRPCCallData{
+						Method:   "/helloworld.Greeter/SayHello",
+						Target:   "greeter:50051",
+						Code:     "NotFound",
+						Duration: "3ms",
+						Metadata: map[string]string{"x-request-id": "req-1"},
+						Request:  map[string]interface{}{"name": "world"},
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaGRPCClientV1)},
+				{Path: path("grpc", "method"), Expected: "/helloworld.Greeter/SayHello"},
+				{Path: path("grpc", "code"), Expected: "NotFound"},
+				{Path: path("grpc", "metadata", "x-request-id"), Expected: "req-1"},
+				{Path: path("grpc", "request", "name"), Expected: "world"},
+			},
+		},
+		{
+			Name: "ws.connection",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.WarnLevel, "websocket closed", logrus.Fields{
+					"ws": &logger.WSConnectionData{
+						ID:          "conn-1",
+						Path:        "/ws",
+						Event:       logger.WSClose,
+						Duration:    "1m0s",
+						MessagesIn:  3,
+						MessagesOut: 5,
+						CloseCode:   1006,
+						CloseReason: "abnormal closure",
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaWSConnectionV1)},
+				{Path: path("ws", "id"), Expected: "conn-1"},
+				{Path: path("ws", "event"), Expected: logger.WSClose},
+				{Path: path("ws", "messages_out"), Expected: "5"},
+				{Path: path("ws", "close_code"), Expected: "1006"},
+			},
+		},
+		{
+			Name: "graphql.request",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.InfoLevel, "", logrus.Fields{
+					"graphql": &logger.GraphQLData{
+						Operation: "CreateOrder",
+						Type:      "mutation",
+						QueryHash: "8f14e45f",
+						Variables: map[string]interface{}{"sku": "A1", "qty": 2},
+						Duration:  "12ms",
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaGraphQLRequestV1)},
+				{Path: path("graphql", "operation"), Expected: "CreateOrder"},
+				{Path: path("graphql", "type"), Expected: "mutation"},
+				{Path: path("graphql", "variables", "sku"), Expected: "A1"},
+				{Path: path("graphql", "errors"), Expected: "0"},
+			},
+		},
+		{
+			Name: "redis.command",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.DebugLevel, "", logrus.Fields{
+					"redis": &logger.RedisCommandData{
+						Command:    "get",
+						KeyPattern: "session:*",
+						Args:       []interface{}{"get", "session:42"},
+						Duration:   "1ms",
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaRedisCommandV1)},
+				{Path: path("redis", "command"), Expected: "get"},
+				{Path: path("redis", "key_pattern"), Expected: "session:*"},
+				{Path: path("redis", "args", 1), Expected: "session:42"},
+			},
+		},
+		{
+			Name: "mongo.command",
+			Entry: func() *logrus.Entry {
+				return newEntry(logrus.DebugLevel, "", logrus.Fields{
+					"mongo": &logger.MongoCommandData{
+						Command:    "find",
+						Database:   "shop",
+						Collection: "orders",
+						Document:   map[string]interface{}{"filter": map[string]interface{}{"user_id": "?"}},
+						Duration:   "3ms",
+						Outcome:    logger.OutcomeSuccess,
+					},
+				})
+			},
+			Assertions: []Assertion{
+				{Path: path("schema"), Expected: string(logger.SchemaMongoCommandV1)},
+				{Path: path("mongo", "command"), Expected: "find"},
+				{Path: path("mongo", "collection"), Expected: "orders"},
+				{Path: path("mongo", "document", "filter", "user_id"), Expected: "?"},
+				{Path: path("mongo", "outcome"), Expected: logger.OutcomeSuccess},
+			},
+		},
+	}
+}
+
+// Run 使用内置用例与 extra 中的附加用例校验格式化对象
+func Run(t *testing.T, f logrus.Formatter, extra ...Case) {
+	t.Helper()
+
+	for _, c := range append(Cases(), extra...) {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			// 两次Format是为了校验Format的幂等性
+			first := format(t, f, c.Entry())
+			second := format(t, f, c.Entry())
+
+			for _, data := range [][]byte{first, second} {
+				for _, a := range c.Assertions {
+					if err := check(data, a); err != nil {
+						t.Fatalf("%s: %s", c.Name, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Golden 使用内置用例与 extra 中的附加用例格式化日志，与 dir 目录下以用例名称命名的 golden 文件比较，
+// 在 CI 中发现日志规范的变化，设置 UPDATE_GOLDEN 环境变量时更新 golden 文件
+//
+//	func TestGolden(t *testing.T) {
+//		conformance.Golden(t, NewMyFormatter(), "testdata")
+//	}
+func Golden(t *testing.T, f logrus.Formatter, dir string, extra ...Case) {
+	t.Helper()
+
+	for _, c := range append(Cases(), extra...) {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			data := format(t, f, c.Entry())
+			loggertest.AssertGolden(t, filepath.Join(dir, c.Name+".json"), data, c.Ignore...)
+		})
+	}
+}
+
+func format(t *testing.T, f logrus.Formatter, entry *logrus.Entry) []byte {
+	t.Helper()
+
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	data = append([]byte{}, data...)
+
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		t.Fatalf("Format() output should end with a newline")
+	}
+	if bytes.Count(data, []byte("\n")) != 1 {
+		t.Fatalf("Format() output should be a single line")
+	}
+	if !jsoniter.Valid(data) {
+		t.Fatalf("Format() output is not valid JSON: %s", data)
+	}
+	return data
+}
+
+func check(data []byte, a Assertion) error {
+	v := jsoniter.Get(data, a.Path...)
+	if v.LastError() != nil {
+		return fmt.Errorf("field %q missing", a.Path)
+	}
+	if a.Expected != "" && v.ToString() != a.Expected {
+		return fmt.Errorf("field %q, Expected=%q, Actual=%q", a.Path, a.Expected, v.ToString())
+	}
+	return nil
+}
+
+func newEntry(level logrus.Level, msg string, data logrus.Fields) *logrus.Entry {
+	return &logrus.Entry{
+		Time:    time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   level,
+		Message: msg,
+		Data:    data,
+	}
+}
+
+func path(p ...interface{}) []interface{} {
+	return p
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+type entryKey struct{}
+
+// NewContext 将日志对象保存到 ctx，之后通过 FromContext 获得的日志对象都带有相同的字段
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext 获得 ctx 中保存的日志对象，不存在时使用 logrus 的标准日志对象
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	return logrus.StandardLogger().WithContext(ctx)
+}
+
+// ContextExtractor 从 ctx 中提取日志字段，例如认证用户、租户与链路信息
+type ContextExtractor func(ctx context.Context) logrus.Fields
+
+var (
+	contextExtractorsMu sync.Mutex
+	// contextExtractorList 保存 []ContextExtractor，写入时复制，格式化时无需加锁
+	contextExtractorList atomic.Value
+)
+
+// RegisterContextExtractor 登记 ctx 字段的提取函数，所有格式化对象在 entry 带有 ctx 时
+// (WithContext、FromContext 等) 调用提取函数，提取的字段与 entry 的字段一样处理，
+// 可以被 With 绑定的字段与 entry.Data 内的同名字段覆盖
+func RegisterContextExtractor(fn ContextExtractor) {
+	contextExtractorsMu.Lock()
+	defer contextExtractorsMu.Unlock()
+
+	fns := contextExtractors()
+	contextExtractorList.Store(append(append([]ContextExtractor(nil), fns...), fn))
+}
+
+func contextExtractors() []ContextExtractor {
+	fns, _ := contextExtractorList.Load().([]ContextExtractor)
+	return fns
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+type extractorKey struct{}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	defer contextExtractorList.Store([]ContextExtractor(nil))
+	RegisterContextExtractor(func(ctx context.Context) logrus.Fields {
+		tenant, _ := ctx.Value(extractorKey{}).(string)
+		if tenant == "" {
+			return nil
+		}
+		return logrus.Fields{"tenant": tenant, "user": "u-" + tenant, "plan": "free"}
+	})
+
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	ctx := context.WithValue(context.Background(), extractorKey{}, "acme")
+	l.WithContext(ctx).WithField("plan", "pro").Info("hello")
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"tenant"}, expected: "acme"},
+		{path: []interface{}{"u"}, expected: "u-acme"},
+		{path: []interface{}{"ctx", "plan"}, expected: "pro"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+
+	out.Reset()
+	l.Info("no context")
+	if jsoniter.Get(out.Bytes(), "tenant").LastError() == nil {
+		t.Fatalf("output tenant should be omitted without context")
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"net/http"
+)
+
+// WithCookieAllowlist 完整记录名称在 names 中的 cookie，区分大小写，
+// 例如语言、主题等不含凭证的 cookie，其他 cookie 的值替换为 DefaultRedactReplacement
+func WithCookieAllowlist(names ...string) Option {
+	return func(f *LogsV1Formatter) {
+		f.CookieAllowlist = append(f.CookieAllowlist, names...)
+	}
+}
+
+// parseCookies 解析 Cookie header，同名的 cookie 只记录第一个
+func (af *LogsV1Formatter) parseCookies(req *http.Request) map[string]string {
+	parsed := req.Cookies()
+	if len(parsed) == 0 {
+		return nil
+	}
+
+	cookies := make(map[string]string, len(parsed))
+	for _, c := range parsed {
+		if _, ok := cookies[c.Name]; ok {
+			continue
+		}
+		cookies[c.Name] = DefaultRedactReplacement
+		if af.cookieAllowed(c.Name) {
+			cookies[c.Name] = c.Value
+		}
+	}
+	return cookies
+}
+
+func (af *LogsV1Formatter) cookieAllowed(name string) bool {
+	for _, n := range af.CookieAllowlist {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFormatCookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", "session=abc123; lang=en; session=other")
+
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Message: "request",
+		Data:    logrus.Fields{"request": req},
+	}
+	data, err := NewFormatter("test", "test", WithCookieAllowlist("lang")).Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"request", "cookie", "session"}, expected: DefaultRedactReplacement},
+		{path: []interface{}{"request", "cookie", "lang"}, expected: "en"},
+		{path: []interface{}{"request", "header", "cookie"}, expected: ""},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+}
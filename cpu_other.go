@@ -0,0 +1,13 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package logger
+
+import (
+	"time"
+)
+
+// processCPUTime 当前平台不支持读取进程的 CPU 时间
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,241 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Formatter = (*DatadogFormatter)(nil)
+
+// DatadogFormatter 按 Datadog 标准属性输出 JSON 日志，日志级别记录为 status，
+// ctx 中的 trace_id 与 span_id 转换为 dd.trace_id 与 dd.span_id，使日志无需管道重写即可关联 APM 链路；
+// 请求日志额外输出 http.*、network.client.ip 与纳秒数 duration，ctx 与各规范的内容与 v2 相同
+type DatadogFormatter struct {
+	*LogsV1Formatter
+}
+
+// NewDatadogFormatter 创建 Datadog 格式化对象，可选配置与 NewFormatter 相同
+func NewDatadogFormatter(service, env string, opts ...Option) *DatadogFormatter {
+	return &DatadogFormatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (df *DatadogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	df.collect(entry, data)
+	enc := df.encoder()
+	err := encodeSafely(func() error { return writeDatadog(b, enc, entry.Level, data, df.logsV2(data)) })
+	schema := data.Schema
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "datadog encode %s log", schema)
+	}
+	return b.Bytes(), nil
+}
+
+func writeDatadog(b *bytes.Buffer, enc Encoder, level logrus.Level, data *LogsV1, v *LogsV2) error {
+	b.WriteString(`{"timestamp":`)
+	writeString(b, v.Time)
+	pairs := []struct {
+		key, value string
+		omit       bool
+	}{
+		{key: "status", value: datadogStatus(level)},
+		{key: "service", value: v.Service},
+		{key: "message", value: v.Message},
+		{key: "host", value: hostnameOf(data.Host), omit: data.Host == nil},
+		{key: "kind", value: v.Kind, omit: v.Kind == ""},
+		{key: "id", value: v.ID},
+		{key: "tenant", value: v.Tenant, omit: v.Tenant == ""},
+		{key: "session_id", value: v.SessionID, omit: v.SessionID == ""},
+		{key: "code", value: v.Code, omit: v.Code == ""},
+		{key: "retention", value: v.Retention, omit: v.Retention == ""},
+		{key: "request_parse_error", value: v.RequestParseError, omit: v.RequestParseError == ""},
+	}
+	for _, p := range pairs {
+		if p.omit {
+			continue
+		}
+		b.WriteByte(',')
+		writeString(b, p.key)
+		b.WriteByte(':')
+		writeString(b, p.value)
+	}
+
+	dd := map[string]interface{}{"service": v.Service, "env": v.Environment}
+	if data.Build != nil && data.Build.Version != "" {
+		dd["version"] = data.Build.Version
+	}
+	// 无法转换的 ID 原样记录在顶层的 trace_id 与 span_id
+	ids := []struct {
+		key, value string
+		hexLen     int
+	}{
+		{key: "trace_id", value: v.TraceID, hexLen: 32},
+		{key: "span_id", value: v.SpanID, hexLen: 16},
+	}
+	for _, id := range ids {
+		if converted, ok := datadogID(id.value, id.hexLen); ok {
+			dd[id.key] = converted
+		} else if id.value != "" {
+			b.WriteByte(',')
+			writeString(b, id.key)
+			b.WriteByte(':')
+			writeString(b, id.value)
+		}
+	}
+	type object struct {
+		key   string
+		value map[string]interface{}
+	}
+	objects := []object{
+		{key: "dd", value: dd},
+		{key: "logger", value: map[string]interface{}{"name": v.Channel}},
+	}
+	if v.User != "" {
+		objects = append(objects, object{key: "usr", value: map[string]interface{}{"id": v.User}})
+	}
+	if data.Request != nil {
+		httpAttrs, network, duration := datadogHTTP(data, v.RequestID)
+		objects = append(objects, object{key: "http", value: httpAttrs}, object{key: "network", value: network})
+		if duration > 0 {
+			b.WriteString(`,"duration":`)
+			b.WriteString(strconv.FormatInt(int64(duration), 10))
+		}
+	} else if v.RequestID != "" {
+		b.WriteString(`,"request_id":`)
+		writeString(b, v.RequestID)
+	}
+	if v.Err != nil {
+		e := map[string]interface{}{"message": v.Err.Message}
+		if v.Err.Type != "" {
+			e["kind"] = v.Err.Type
+		}
+		if len(v.Err.Trace) > 0 {
+			e["stack"] = strings.Join(v.Err.Trace, "\n")
+		}
+		if v.Err.Fingerprint != "" {
+			e["fingerprint"] = v.Err.Fingerprint
+		}
+		objects = append(objects, object{key: "error", value: e})
+	}
+	for _, o := range objects {
+		b.WriteByte(',')
+		writeString(b, o.key)
+		b.WriteByte(':')
+		if err := writeFields(b, enc, o.value); err != nil {
+			return err
+		}
+	}
+
+	b.WriteString(`,"ctx":`)
+	if err := writeFields(b, enc, v.Context); err != nil {
+		return err
+	}
+	if err := writeSections(b, enc, v.Sections); err != nil {
+		return err
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// datadogHTTP 请求日志对应的 http 与 network 标准属性以及请求耗时
+func datadogHTTP(data *LogsV1, requestID string) (attrs, network map[string]interface{}, duration time.Duration) {
+	r := data.Request
+	attrs = map[string]interface{}{
+		"method":      r.Method,
+		"url":         r.Path,
+		"url_details": map[string]interface{}{"path": r.Path},
+	}
+	if status, err := strconv.Atoi(r.Status); err == nil {
+		attrs["status_code"] = status
+	}
+	if r.Route != "" {
+		attrs["route"] = r.Route
+	}
+	if strings.HasPrefix(r.Proto, "HTTP/") {
+		attrs["version"] = strings.TrimPrefix(r.Proto, "HTTP/")
+	}
+	if ua := r.Headers["user-agent"]; ua != "" {
+		attrs["useragent"] = ua
+	} else if r.UserAgent != "" {
+		attrs["useragent"] = r.UserAgent
+	}
+	if referer := r.Headers["referer"]; referer != "" {
+		attrs["referer"] = referer
+	}
+	if requestID != "" {
+		attrs["request_id"] = requestID
+	}
+
+	network = map[string]interface{}{
+		"client":     map[string]interface{}{"ip": r.IP},
+		"bytes_read": r.BytesIn,
+	}
+	if data.Response != nil {
+		network["bytes_written"] = data.Response.BytesOut
+	}
+	duration, _ = time.ParseDuration(r.Duration)
+	return attrs, network, duration
+}
+
+// datadogStatus 日志级别对应的 Datadog status
+func datadogStatus(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "debug"
+	case logrus.InfoLevel:
+		return "info"
+	case logrus.WarnLevel:
+		return "warning"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.FatalLevel:
+		return "critical"
+	default:
+		return "emergency"
+	}
+}
+
+// datadogID 将 W3C 十六进制的 trace/span ID 转换为 Datadog 使用的十进制 64 位 ID，
+// 128 位的 trace ID 取低 64 位，已经是十进制的 ID 原样返回
+func datadogID(id string, hexLen int) (string, bool) {
+	if id == "" {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(id, 10, 64); err == nil && len(id) != hexLen {
+		return id, true
+	}
+	if !isHexID(id, hexLen) {
+		return "", false
+	}
+	n, err := strconv.ParseUint(id[hexLen-16:], 16, 64)
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatUint(n, 10), true
+}
+
+// hostnameOf 未记录主机信息时返回空字符串
+func hostnameOf(host *HostData) string {
+	if host == nil {
+		return ""
+	}
+	return host.Hostname
+}
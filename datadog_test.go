@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDatadogFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetFormatter(NewDatadogFormatter("order", "prod"))
+
+	l.WithFields(logrus.Fields{
+		"channel":  "billing",
+		"user":     "u1",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"order_id": 42,
+		"error":    errors.New("payment declined"),
+	}).Warn("charge failed")
+
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.RemoteAddr = "1.2.3.4:1234"
+	req.Header.Set("User-Agent", "curl/7.79")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	l.WithField("trace_id", "not-a-trace").Info("hello")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("log lines, Expected=3, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"status"}, expected: "warning"},
+		{line: 0, path: []interface{}{"service"}, expected: "order"},
+		{line: 0, path: []interface{}{"message"}, expected: "charge failed"},
+		{line: 0, path: []interface{}{"dd", "env"}, expected: "prod"},
+		{line: 0, path: []interface{}{"dd", "trace_id"}, expected: "11803532876627986230"},
+		{line: 0, path: []interface{}{"dd", "span_id"}, expected: "67667974448284343"},
+		{line: 0, path: []interface{}{"logger", "name"}, expected: "billing"},
+		{line: 0, path: []interface{}{"usr", "id"}, expected: "u1"},
+		{line: 0, path: []interface{}{"error", "message"}, expected: "payment declined"},
+		{line: 0, path: []interface{}{"error", "kind"}, expected: "*errors.errorString"},
+		{line: 0, path: []interface{}{"ctx", "order_id"}, expected: "42"},
+		{line: 0, path: []interface{}{"ctx", "trace_id"}, expected: ""},
+		{line: 1, path: []interface{}{"status"}, expected: "info"},
+		{line: 1, path: []interface{}{"http", "method"}, expected: "GET"},
+		{line: 1, path: []interface{}{"http", "url"}, expected: "/orders"},
+		{line: 1, path: []interface{}{"http", "status_code"}, expected: "200"},
+		{line: 1, path: []interface{}{"http", "useragent"}, expected: "curl/7.79"},
+		{line: 1, path: []interface{}{"network", "client", "ip"}, expected: "1.2.3.4"},
+		{line: 1, path: []interface{}{"network", "bytes_written"}, expected: "5"},
+		{line: 2, path: []interface{}{"trace_id"}, expected: "not-a-trace"},
+		{line: 2, path: []interface{}{"dd", "trace_id"}, expected: ""},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+	if v := jsoniter.Get(lines[1], "duration").ToInt64(); v <= 0 {
+		t.Fatalf("duration, Expected>0, Actual=%d", v)
+	}
+}
+
+func TestDatadogID(t *testing.T) {
+	cases := []struct {
+		id       string
+		hexLen   int
+		expected string
+		ok       bool
+	}{
+		{id: "4bf92f3577b34da6a3ce929d0e0e4736", hexLen: 32, expected: "11803532876627986230", ok: true},
+		{id: "00f067aa0ba902b7", hexLen: 16, expected: "67667974448284343", ok: true},
+		{id: "1234567890", hexLen: 32, expected: "1234567890", ok: true},
+		{id: "00000000000000000000000000000000", hexLen: 32},
+		{id: "xyz", hexLen: 16},
+		{id: "", hexLen: 16},
+	}
+	for _, c := range cases {
+		if v, ok := datadogID(c.id, c.hexLen); v != c.expected || ok != c.ok {
+			t.Fatalf("datadogID(%q), Expected=%q %v, Actual=%q %v", c.id, c.expected, c.ok, v, ok)
+		}
+	}
+}
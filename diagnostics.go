@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"net/http"
+	"sync"
+)
+
+var (
+	diagnosticsMu sync.Mutex
+	enrichSkipped = map[string]int64{}
+)
+
+// DiagnosticsData 日志组件自身的运行状态
+type DiagnosticsData struct {
+	Memory BudgetStats `json:"memory"`
+	// 各补充函数因超时被跳过的次数
+	EnrichSkipped map[string]int64 `json:"enrich_skipped"`
+}
+
+// Diagnostics 获得日志组件当前的运行状态
+func Diagnostics() DiagnosticsData {
+	diagnosticsMu.Lock()
+	skipped := make(map[string]int64, len(enrichSkipped))
+	for k, v := range enrichSkipped {
+		skipped[k] = v
+	}
+	diagnosticsMu.Unlock()
+
+	return DiagnosticsData{
+		Memory:        memoryBudget.Stats(),
+		EnrichSkipped: skipped,
+	}
+}
+
+func recordEnrichSkipped(name string) {
+	diagnosticsMu.Lock()
+	enrichSkipped[name]++
+	diagnosticsMu.Unlock()
+}
+
+// DiagnosticsHandler 以 JSON 格式输出日志组件的运行状态，可挂载到内部管理端口
+func DiagnosticsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		_ = jsonEncode(w, Diagnostics())
+	})
+}
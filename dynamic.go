@@ -0,0 +1,27 @@
+package logger
+
+// DynamicField 每条日志格式化时计算的字段，例如当前的部署颜色、特性开关快照的哈希
+type DynamicField struct {
+	Key string
+	// 返回字段的当前值，返回 nil 时不输出该字段，必须快速返回且可以并发调用
+	Value func() interface{}
+}
+
+// WithDynamicField 添加格式化时计算的字段，字段与 entry 的字段一样处理，
+// 优先级最低，可以被 ctx 提取的字段、With 绑定的字段与 entry.Data 内的同名字段覆盖
+//
+//	logger.WithDynamicField("deploy_color", func() interface{} { return color.Load() })
+func WithDynamicField(key string, fn func() interface{}) Option {
+	return func(f *LogsV1Formatter) {
+		f.DynamicFields = append(f.DynamicFields, DynamicField{Key: key, Value: fn})
+	}
+}
+
+// dynamicFields 计算动态字段，field 为 collect 中处理单个字段的函数
+func (af *LogsV1Formatter) dynamicFields(field func(k string, v interface{})) {
+	for _, d := range af.DynamicFields {
+		if v := d.Value(); v != nil {
+			field(d.Key, v)
+		}
+	}
+}
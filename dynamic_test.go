@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestWithDynamicField(t *testing.T) {
+	color := "blue"
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test",
+		WithDynamicField("deploy_color", func() interface{} { return color }),
+		WithDynamicField("flags", func() interface{} { return nil }),
+	)
+	l.SetOutput(out)
+
+	l.Info("before")
+	color = "green"
+	l.Info("after")
+	l.WithField("deploy_color", "canary").Info("override")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	expected := []string{"blue", "green", "canary"}
+	if len(lines) != len(expected) {
+		t.Fatalf("output lines, Expected=%d, Actual=%d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		if v := jsoniter.Get(line, "ctx", "deploy_color").ToString(); v != expected[i] {
+			t.Fatalf("output ctx.deploy_color, Expected=%q, Actual=%q", expected[i], v)
+		}
+		if jsoniter.Get(line, "ctx", "flags").LastError() == nil {
+			t.Fatalf("output ctx.flags should be omitted when the value is nil")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package echologger 提供 Echo 框架的访问日志中间件，以 http.request.v1 规范记录请求，
+// 记录 Echo 匹配的路由模板与处理函数名，处理函数返回的错误记录在 err
+//
+//	e := echo.New()
+//	e.Use(echologger.Middleware(l))
+//	e.GET("/users/:id", getUser)
+package echologger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Option 中间件的可选配置
+type Option func(*config)
+
+type config struct {
+	skipper     func(c echo.Context) bool
+	statusLevel func(status int) logrus.Level
+}
+
+// WithSkipper 返回 true 的请求不记录日志，例如健康检查
+func WithSkipper(fn func(c echo.Context) bool) Option {
+	return func(c *config) {
+		c.skipper = fn
+	}
+}
+
+// WithStatusLevel 设置响应状态码对应的日志级别，默认使用 logger.DefaultStatusLevel
+func WithStatusLevel(fn func(status int) logrus.Level) Option {
+	return func(c *config) {
+		c.statusLevel = fn
+	}
+}
+
+// Middleware 访问日志中间件，每个请求结束后记录一条日志
+//
+// 处理函数返回错误时先交给 Echo 的 HTTPErrorHandler 写入响应，再按实际的响应状态码记录，
+// echo.HTTPError 带有 Internal 时 err 记录内部错误，否则记录返回给客户端的消息
+func Middleware(l *logrus.Logger, opts ...Option) echo.MiddlewareFunc {
+	cfg := &config{statusLevel: logger.DefaultStatusLevel}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.skipper != nil && cfg.skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			req := c.Request().WithContext(logger.ContextWithRoute(c.Request().Context()))
+			c.SetRequest(req)
+
+			err := next(c)
+			if err != nil {
+				// 由 HTTPErrorHandler 写入响应后才能得到实际的状态码
+				c.Error(err)
+			}
+			logger.SetRoute(req, c.Path(), logger.HandlerName(c.Handler()))
+
+			res := c.Response()
+			fields := logrus.Fields{
+				"request":   req,
+				"status":    res.Status,
+				"duration":  time.Since(start),
+				"bytes_out": res.Size,
+			}
+			if err != nil {
+				fields["error"] = errorValue(err)
+			}
+			l.WithContext(req.Context()).WithFields(fields).Log(cfg.statusLevel(res.Status), "http request")
+			return err
+		}
+	}
+}
+
+// errorValue 返回记录在 err 的错误
+func errorValue(err error) interface{} {
+	he, ok := err.(*echo.HTTPError)
+	if !ok {
+		return err
+	}
+	if he.Internal != nil {
+		return he.Internal
+	}
+	return fmt.Sprintf("%v", he.Message)
+}
@@ -0,0 +1,79 @@
+package echologger
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/labstack/echo/v4"
+	"github.com/lancer05/logger"
+)
+
+func getUser(c echo.Context) error {
+	if c.Param("id") == "0" {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found").SetInternal(errors.New("no rows"))
+	}
+	return c.String(http.StatusOK, "ok")
+}
+
+func TestMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	e := echo.New()
+	e.Use(Middleware(l, WithSkipper(func(c echo.Context) bool { return c.Path() == "/healthz" })))
+	e.GET("/users/:id", getUser)
+	e.GET("/healthz", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	cases := []struct {
+		path     string
+		expected map[string]string
+	}{
+		{path: "/users/1", expected: map[string]string{
+			"schema": string(logger.SchemaHTTPRequestV1), "l": "info", "err": "",
+			"request.route": "/users/:id", "request.status": "200", "request.path": "/users/1",
+		}},
+		{path: "/users/0", expected: map[string]string{
+			"l": "warning", "err": "no rows", "request.status": "404",
+		}},
+		{path: "/healthz"},
+	}
+
+	for _, c := range cases {
+		out.Reset()
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.path, nil))
+
+		data := out.Bytes()
+		if c.expected == nil {
+			if len(data) != 0 {
+				t.Fatalf("%s: skipped request should not be logged, Actual=%s", c.path, data)
+			}
+			continue
+		}
+		if err := logger.Validate(data); err != nil {
+			t.Fatalf("%s: Validate() error, Expected=nil, Actual=%q", c.path, err)
+		}
+		for key, expected := range c.expected {
+			if v := jsoniter.Get(data, splitPath(key)...).ToString(); v != expected {
+				t.Fatalf("%s: output %s, Expected=%q, Actual=%q", c.path, key, expected, v)
+			}
+		}
+		if handler := jsoniter.Get(data, "request", "handler").ToString(); handler == "" {
+			t.Fatalf("%s: output request.handler should not be empty", c.path)
+		}
+	}
+}
+
+func splitPath(key string) []interface{} {
+	var path []interface{}
+	for _, part := range strings.Split(key, ".") {
+		path = append(path, part)
+	}
+	return path
+}
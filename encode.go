@@ -0,0 +1,342 @@
+package logger
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const hex = "0123456789abcdef"
+
+// writeLogsV1 按字段顺序直接写入 JSON，不经过反射，
+// 常见类型的 ctx 字段无需额外的内存分配，其他类型回退到 enc
+func writeLogsV1(b *bytes.Buffer, enc Encoder, data *LogsV1) error {
+	b.WriteString(`{"schema":`)
+	writeString(b, data.Schema)
+	b.WriteString(`,"t":"`)
+	b.Write(data.timeBuf)
+	b.WriteString(`","l":`)
+	writeString(b, data.Level)
+	b.WriteString(`,"s":`)
+	writeString(b, data.Service)
+	b.WriteString(`,"c":`)
+	writeString(b, data.Channel)
+	b.WriteString(`,"i":`)
+	writeString(b, data.ID)
+	if data.RequestID != "" {
+		b.WriteString(`,"request_id":`)
+		writeString(b, data.RequestID)
+	}
+	if data.Tenant != "" {
+		b.WriteString(`,"tenant":`)
+		writeString(b, data.Tenant)
+	}
+	if data.SessionID != "" {
+		b.WriteString(`,"session_id":`)
+		writeString(b, data.SessionID)
+	}
+	b.WriteString(`,"e":`)
+	writeString(b, data.Environment)
+	b.WriteString(`,"u":`)
+	writeString(b, data.User)
+	b.WriteString(`,"m":`)
+	writeString(b, data.Message)
+	if data.Code != "" {
+		b.WriteString(`,"code":`)
+		writeString(b, data.Code)
+	}
+
+	if data.Host != nil {
+		if err := writeKeyValue(b, enc, "host", data.Host); err != nil {
+			return err
+		}
+	}
+	if data.Retention != "" {
+		b.WriteString(`,"retention":`)
+		writeString(b, data.Retention)
+	}
+	if data.Build != nil {
+		if err := writeKeyValue(b, enc, "build", data.Build); err != nil {
+			return err
+		}
+	}
+
+	b.WriteString(`,"ctx":`)
+	if err := writeFields(b, enc, data.Context); err != nil {
+		return err
+	}
+	b.WriteString(`,"err":`)
+	writeString(b, data.Err)
+	if data.ErrFingerprint != "" {
+		b.WriteString(`,"err_fingerprint":`)
+		writeString(b, data.ErrFingerprint)
+	}
+	if data.Errors != nil {
+		b.WriteString(`,"errors":`)
+		if err := writeValue(b, enc, data.Errors); err != nil {
+			return err
+		}
+	}
+
+	if data.Request != nil {
+		if err := writeKeyValue(b, enc, "request", data.Request); err != nil {
+			return err
+		}
+	}
+	if data.RequestParseError != "" {
+		b.WriteString(`,"request_parse_error":`)
+		writeString(b, data.RequestParseError)
+	}
+	if data.Response != nil {
+		if err := writeKeyValue(b, enc, "response", data.Response); err != nil {
+			return err
+		}
+	}
+	if data.SQL != nil {
+		if err := writeKeyValue(b, enc, "sql", data.SQL); err != nil {
+			return err
+		}
+	}
+	if data.Client != nil {
+		if err := writeKeyValue(b, enc, "client", data.Client); err != nil {
+			return err
+		}
+	}
+	if data.MQ != nil {
+		if err := writeKeyValue(b, enc, "mq", data.MQ); err != nil {
+			return err
+		}
+	}
+	if data.Job != nil {
+		if err := writeKeyValue(b, enc, "job", data.Job); err != nil {
+			return err
+		}
+	}
+	if data.Runtime != nil {
+		if err := writeKeyValue(b, enc, "runtime", data.Runtime); err != nil {
+			return err
+		}
+	}
+	if data.sectionKey != "" {
+		if err := writeKeyValue(b, enc, data.sectionKey, data.section); err != nil {
+			return err
+		}
+	}
+
+	b.WriteString("}\n")
+	return nil
+}
+
+func writeKeyValue(b *bytes.Buffer, enc Encoder, key string, v interface{}) error {
+	b.WriteByte(',')
+	writeString(b, key)
+	b.WriteByte(':')
+	return writeValue(b, enc, v)
+}
+
+func writeFields(b *bytes.Buffer, enc Encoder, m map[string]interface{}) error {
+	if _, ok := enc.(sortedEncoder); ok {
+		return writeSortedFields(b, enc, m)
+	}
+
+	b.WriteByte('{')
+	first := true
+	for k, v := range m {
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		writeString(b, k)
+		b.WriteByte(':')
+		if err := writeValue(b, enc, v); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func writeSortedFields(b *bytes.Buffer, enc Encoder, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeString(b, k)
+		b.WriteByte(':')
+		if err := writeValue(b, enc, m[k]); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+// writeValue 写入字段值，常见类型直接写入，其他类型使用 enc 编码
+func writeValue(b *bytes.Buffer, enc Encoder, v interface{}) error {
+	var scratch [64]byte
+
+	switch val := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		writeString(b, val)
+	case bool:
+		b.Write(strconv.AppendBool(scratch[:0], val))
+	case int:
+		b.Write(strconv.AppendInt(scratch[:0], int64(val), 10))
+	case int8:
+		b.Write(strconv.AppendInt(scratch[:0], int64(val), 10))
+	case int16:
+		b.Write(strconv.AppendInt(scratch[:0], int64(val), 10))
+	case int32:
+		b.Write(strconv.AppendInt(scratch[:0], int64(val), 10))
+	case int64:
+		b.Write(strconv.AppendInt(scratch[:0], val, 10))
+	case uint:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(val), 10))
+	case uint8:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(val), 10))
+	case uint16:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(val), 10))
+	case uint32:
+		b.Write(strconv.AppendUint(scratch[:0], uint64(val), 10))
+	case uint64:
+		b.Write(strconv.AppendUint(scratch[:0], val, 10))
+	case float32:
+		return writeFloat(b, enc, float64(val), 32)
+	case float64:
+		return writeFloat(b, enc, val, 64)
+	case time.Duration:
+		b.Write(strconv.AppendInt(scratch[:0], int64(val), 10))
+	case logrus.Fields:
+		return writeFields(b, enc, val)
+	case map[string]interface{}:
+		return writeFields(b, enc, val)
+	case []string:
+		b.WriteByte('[')
+		for i, s := range val {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeString(b, s)
+		}
+		b.WriteByte(']')
+	case []interface{}:
+		b.WriteByte('[')
+		for i, item := range val {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeValue(b, enc, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		encoded, err := enc.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(encoded)
+	}
+	return nil
+}
+
+// writeFloat 与 encoding/json 的浮点数格式保持一致
+func writeFloat(b *bytes.Buffer, enc Encoder, f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// 由编码实现决定如何处理，默认的实现返回错误，由格式化对象降级输出
+		encoded, err := enc.Marshal(f)
+		if err != nil {
+			return err
+		}
+		b.Write(encoded)
+		return nil
+	}
+
+	var scratch [64]byte
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	out := strconv.AppendFloat(scratch[:0], f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(out)
+		if n >= 4 && out[n-4] == 'e' && out[n-3] == '-' && out[n-2] == '0' {
+			out[n-2] = out[n-1]
+			out = out[:n-1]
+		}
+	}
+	b.Write(out)
+	return nil
+}
+
+// writeString 写入 JSON 字符串，与 encoding/json 一样转义 HTML 字符，
+// 非法的 UTF-8 替换为 \ufffd
+func writeString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b.WriteString(s[start:i])
+			switch c {
+			case '\\', '"':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[c>>4])
+				b.WriteByte(hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWriteLogsV1(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api?q=1", nil)
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Level:   logrus.WarnLevel,
+		Message: "<tag> & \"quoted\"\n  \xff",
+		Data: logrus.Fields{
+			"channel":  "test",
+			"request":  req,
+			"status":   200,
+			"int":      -42,
+			"uint":     uint16(7),
+			"float":    1.5e-7,
+			"float32":  float32(3.25),
+			"bool":     true,
+			"nil":      nil,
+			"duration": time.Second,
+			"elapsed":  time.Millisecond,
+			"strings":  []string{"a", "b"},
+			"mixed":    []interface{}{1, "x", map[string]interface{}{"k": "v"}},
+			"nested":   logrus.Fields{"deep": map[string]string{"a": "b"}},
+			"struct":   struct{ A int }{A: 1},
+			"cause":    errors.New("boom"),
+		},
+	}
+
+	f := NewFormatter("test", "test", WithHostMetadata(), WithRetention("7d")).(*LogsV1Formatter)
+	data := &LogsV1{}
+	f.collect(entry, data)
+	data.Time = string(data.timeBuf)
+
+	expected := &bytes.Buffer{}
+	if err := jsonEncode(expected, data); err != nil {
+		t.Fatalf("jsonEncode() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	actual := &bytes.Buffer{}
+	if err := writeLogsV1(actual, DefaultEncoder, data); err != nil {
+		t.Fatalf("writeLogsV1() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	var e, a interface{}
+	if err := json.Unmarshal(expected.Bytes(), &e); err != nil {
+		t.Fatalf("Unmarshal() expected error: %s", err)
+	}
+	if err := json.Unmarshal(actual.Bytes(), &a); err != nil {
+		t.Fatalf("Unmarshal() actual error: %s, output: %s", err, actual.String())
+	}
+	if !reflect.DeepEqual(e, a) {
+		t.Fatalf("writeLogsV1() output\nExpected=%s\nActual=%s", expected.String(), actual.String())
+	}
+}
+
+func TestWriteValueNaN(t *testing.T) {
+	if err := writeValue(&bytes.Buffer{}, DefaultEncoder, math.NaN()); err == nil {
+		t.Fatalf("writeValue(NaN) error, Expected=error, Actual=nil")
+	}
+}
+
+// nullEncoder 将 NaN 与 Inf 编码为 null
+type nullEncoder struct {
+	StdEncoder
+}
+
+func (e nullEncoder) Marshal(v interface{}) ([]byte, error) {
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return []byte("null"), nil
+	}
+	return e.StdEncoder.Marshal(v)
+}
+
+func TestWriteValueNaNEncoder(t *testing.T) {
+	b := &bytes.Buffer{}
+	v := map[string]interface{}{"nan": math.NaN(), "inf": float32(math.Inf(1))}
+	if err := writeValue(b, nullEncoder{}, v); err != nil {
+		t.Fatalf("writeValue() error, Expected=nil, Actual=%q", err.Error())
+	}
+	actual := map[string]interface{}{}
+	if err := json.Unmarshal(b.Bytes(), &actual); err != nil {
+		t.Fatalf("writeValue() output is not JSON, Actual=%q", b.String())
+	}
+	for _, k := range []string{"nan", "inf"} {
+		if v, ok := actual[k]; !ok || v != nil {
+			t.Fatalf("output %s, Expected=null, Actual=%v", k, v)
+		}
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	f := NewFormatter("bench", "bench")
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Level:   logrus.InfoLevel,
+		Message: "user logged in",
+		Data: logrus.Fields{
+			"channel": "auth",
+			"user":    "u-1",
+			"tenant":  "acme",
+			"attempt": 3,
+			"elapsed": 1.25,
+		},
+		Buffer: &bytes.Buffer{},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Buffer.Reset()
+		if _, err := f.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormatRequest(b *testing.B) {
+	f := NewFormatter("bench", "bench")
+	req := httptest.NewRequest(http.MethodGet, "/api?q=1", nil)
+	req.Header.Set("User-Agent", "bench")
+	entry := &logrus.Entry{
+		Time:  time.Now(),
+		Level: logrus.InfoLevel,
+		Data: logrus.Fields{
+			"request":  req,
+			"status":   200,
+			"duration": time.Millisecond,
+		},
+		Buffer: &bytes.Buffer{},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Buffer.Reset()
+		if _, err := f.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
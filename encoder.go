@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"encoding/json"
+)
+
+// Encoder JSON 编码实现，jsoniter.ConfigDefault、sonic.ConfigDefault 等均满足该接口
+//
+//	logger.NewLogger("api", "prod", logger.WithEncoder(sonic.ConfigDefault))
+type Encoder interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StdEncoder 使用标准库 encoding/json 的编码实现，输出稳定可复现
+type StdEncoder struct{}
+
+// Marshal implements Encoder interface
+func (StdEncoder) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Encoder interface
+func (StdEncoder) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// WithEncoder 设置编码实现，用于 ctx 中复杂类型的字段与请求体的解析，
+// 默认使用 jsoniter，lite 模式下默认使用 encoding/json
+func WithEncoder(enc Encoder) Option {
+	return func(f *LogsV1Formatter) {
+		f.Encoder = enc
+	}
+}
+
+// WithSortedKeys 按键排序输出 ctx、request.header、request.param 等对象，
+// 浮点数与 encoding/json 的格式一致，相同的日志输出完全相同的内容，用于快照测试与下游去重
+//
+// 自定义的 Encoder 需要自行保证 map 按键排序
+func WithSortedKeys() Option {
+	return func(f *LogsV1Formatter) {
+		f.SortKeys = true
+	}
+}
+
+// sortedEncoder 标记需要按键排序输出，writeFields 据此排序
+type sortedEncoder struct {
+	Encoder
+}
+
+func (af *LogsV1Formatter) encoder() Encoder {
+	enc := af.Encoder
+	if enc == nil {
+		enc = DefaultEncoder
+		if af.SortKeys {
+			enc = sortedDefaultEncoder
+		}
+	}
+	if af.SortKeys {
+		return sortedEncoder{enc}
+	}
+	return enc
+}
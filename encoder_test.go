@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+type countingEncoder struct {
+	StdEncoder
+	marshal int
+}
+
+func (e *countingEncoder) Marshal(v interface{}) ([]byte, error) {
+	e.marshal++
+	return e.StdEncoder.Marshal(v)
+}
+
+func TestWithEncoder(t *testing.T) {
+	enc := &countingEncoder{}
+	f := NewFormatter("test", "test", WithEncoder(enc))
+
+	data, err := f.Format(&logrus.Entry{
+		Time: time.Now(),
+		Data: logrus.Fields{
+			"plain":  "fast path",
+			"struct": struct{ Name string }{Name: "custom"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if enc.marshal != 1 {
+		t.Fatalf("Marshal() calls, Expected=1, Actual=%d", enc.marshal)
+	}
+	if v := jsoniter.Get(data, "ctx", "struct", "Name").ToString(); v != "custom" {
+		t.Fatalf("output ctx.struct.Name, Expected=%q, Actual=%q", "custom", v)
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	entry := func() *logrus.Entry {
+		fields := logrus.Fields{
+			"nested": map[string]interface{}{"z": 1, "a": 2.5, "m": []interface{}{map[string]interface{}{"y": 1, "b": 2}}},
+			"ratio":  1e-7,
+			"request": &http.Request{
+				Method: http.MethodGet,
+				URL:    &url.URL{Path: "/", RawQuery: "z=1&a=2&m=3&b=4"},
+				Header: http.Header{"X-Z": {"1"}, "X-A": {"2"}, "X-M": {"3"}},
+			},
+		}
+		for i := 0; i < 20; i++ {
+			fields[fmt.Sprintf("k%02d", i)] = i
+		}
+		return &logrus.Entry{Time: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC), Data: fields}
+	}
+
+	for _, enc := range []Encoder{nil, StdEncoder{}} {
+		f := NewFormatter("test", "test", WithSortedKeys(), WithEncoder(enc))
+		first, err := f.Format(entry())
+		if err != nil {
+			t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+		}
+		for i := 0; i < 20; i++ {
+			data, _ := f.Format(entry())
+			if !bytes.Equal(first, data) {
+				t.Fatalf("Format() output is not stable:\n%s\n%s", first, data)
+			}
+		}
+
+		for _, expected := range []string{
+			`"ctx":{"k00":0,"k01":1,`,
+			`"nested":{"a":2.5,"m":[{"b":2,"y":1}],"z":1},"ratio":1e-7}`,
+			`"header":{"x-a":"2","x-m":"3","x-z":"1"}`,
+			`"param":{"a":"2","b":"4","m":"3","z":"1"}`,
+		} {
+			if !bytes.Contains(first, []byte(expected)) {
+				t.Fatalf("Format() output should contain %s, Actual=%s", expected, first)
+			}
+		}
+	}
+}
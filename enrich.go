@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EnricherFunc 在格式化时为日志补充上下文字段，例如 GeoIP、UA 解析，
+// 传入的是 entry 的副本，panic 的补充函数视为跳过
+type EnricherFunc func(entry *logrus.Entry) logrus.Fields
+
+// Enricher 具名的补充函数，名称用于记录被跳过的情况
+type Enricher struct {
+	Name   string
+	Enrich EnricherFunc
+}
+
+// WithEnricher 添加补充字段的函数，补充的字段可以被 entry.Data 内的同名字段覆盖
+func WithEnricher(name string, fn EnricherFunc) Option {
+	return func(f *LogsV1Formatter) {
+		f.Enrichers = append(f.Enrichers, Enricher{Name: name, Enrich: fn})
+	}
+}
+
+// WithEnrichDeadline 限制每条日志执行补充函数的总耗时，
+// 超出时间的补充函数被跳过并记录在 enrich_skipped 字段
+func WithEnrichDeadline(d time.Duration) Option {
+	return func(f *LogsV1Formatter) {
+		f.EnrichDeadline = d
+	}
+}
+
+func (af *LogsV1Formatter) enrich(entry *logrus.Entry, context logrus.Fields) {
+	if len(af.Enrichers) == 0 {
+		return
+	}
+
+	// 超时的补充函数会在 Format 返回后继续运行，而 entry 会被 logrus 回收复用，只传入副本
+	snapshot := snapshotEntry(entry)
+	var deadline time.Time
+	if af.EnrichDeadline > 0 {
+		deadline = time.Now().Add(af.EnrichDeadline)
+	}
+
+	var skipped []string
+	for _, e := range af.Enrichers {
+		var fields logrus.Fields
+		ok := false
+		if deadline.IsZero() {
+			fields, ok = callEnricher(e.Enrich, snapshot)
+		} else if remaining := time.Until(deadline); remaining > 0 {
+			fields, ok = runEnricher(e.Enrich, snapshot, remaining)
+		}
+		if !ok {
+			skipped = append(skipped, e.Name)
+			continue
+		}
+		for k, v := range fields {
+			context[k] = v
+		}
+	}
+
+	if len(skipped) > 0 {
+		context["enrich_skipped"] = skipped
+		for _, name := range skipped {
+			recordEnrichSkipped(name)
+		}
+	}
+}
+
+// snapshotEntry 复制 entry 中补充函数可能读取的内容，Data 为浅拷贝
+func snapshotEntry(entry *logrus.Entry) *logrus.Entry {
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	return &logrus.Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Caller:  entry.Caller,
+		Message: entry.Message,
+		Context: entry.Context,
+	}
+}
+
+// callEnricher 执行补充函数，panic 时视为跳过
+func callEnricher(fn EnricherFunc, entry *logrus.Entry) (fields logrus.Fields, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			fields, ok = nil, false
+		}
+	}()
+	return fn(entry), true
+}
+
+// runEnricher 在超时时间内执行补充函数，超时后放弃等待其结果
+func runEnricher(fn EnricherFunc, entry *logrus.Entry, timeout time.Duration) (logrus.Fields, bool) {
+	type result struct {
+		fields logrus.Fields
+		ok     bool
+	}
+	ch := make(chan result, 1)
+	go func() {
+		fields, ok := callEnricher(fn, entry)
+		ch <- result{fields: fields, ok: ok}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-ch:
+		return r.fields, r.ok
+	case <-timer.C:
+		return nil, false
+	}
+}
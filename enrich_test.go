@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEnrichDeadline(t *testing.T) {
+	f := NewFormatter("test", "test",
+		WithEnrichDeadline(20*time.Millisecond),
+		WithEnricher("fast", func(entry *logrus.Entry) logrus.Fields {
+			return logrus.Fields{"country": "CN"}
+		}),
+		WithEnricher("slow", func(entry *logrus.Entry) logrus.Fields {
+			time.Sleep(200 * time.Millisecond)
+			return logrus.Fields{"browser": "firefox"}
+		}),
+	)
+
+	entry := &logrus.Entry{
+		Time: time.Now(),
+		Data: logrus.Fields{},
+	}
+
+	start := time.Now()
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("Format() took %s, Expected less than deadline", elapsed)
+	}
+
+	if v := jsoniter.Get(data, "ctx", "country").ToString(); v != "CN" {
+		t.Fatalf("Format() ctx.country, Expected=%q, Actual=%q", "CN", v)
+	}
+	if v := jsoniter.Get(data, "ctx", "browser").ToString(); v != "" {
+		t.Fatalf("Format() ctx.browser, Expected=%q, Actual=%q", "", v)
+	}
+	if v := jsoniter.Get(data, "ctx", "enrich_skipped", 0).ToString(); v != "slow" {
+		t.Fatalf("Format() ctx.enrich_skipped, Expected=%q, Actual=%q", "slow", v)
+	}
+	if n := Diagnostics().EnrichSkipped["slow"]; n < 1 {
+		t.Fatalf("Diagnostics() EnrichSkipped, Expected>=1, Actual=%d", n)
+	}
+}
+
+func TestEnrichSnapshot(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan string, 1)
+	f := NewFormatter("test", "test",
+		WithEnrichDeadline(20*time.Millisecond),
+		WithEnricher("panic", func(entry *logrus.Entry) logrus.Fields {
+			panic("boom")
+		}),
+		WithEnricher("slow", func(entry *logrus.Entry) logrus.Fields {
+			<-release
+			done <- entry.Data["order_id"].(string)
+			return logrus.Fields{"late": true}
+		}),
+	)
+
+	entry := &logrus.Entry{
+		Time: time.Now(),
+		Data: logrus.Fields{"order_id": "o1"},
+	}
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	// logrus 复用 entry 时会修改 Data，超时的补充函数读取的是副本
+	entry.Data["order_id"] = "o2"
+	close(release)
+	if v := <-done; v != "o1" {
+		t.Fatalf("enricher entry.Data, Expected=%q, Actual=%q", "o1", v)
+	}
+
+	skipped := []string{
+		jsoniter.Get(data, "ctx", "enrich_skipped", 0).ToString(),
+		jsoniter.Get(data, "ctx", "enrich_skipped", 1).ToString(),
+	}
+	if skipped[0] != "panic" || skipped[1] != "slow" {
+		t.Fatalf("Format() ctx.enrich_skipped, Expected=[panic slow], Actual=%q", skipped)
+	}
+}
+
+func TestEnrichPanicWithoutDeadline(t *testing.T) {
+	f := NewFormatter("test", "test",
+		WithEnricher("panic", func(entry *logrus.Entry) logrus.Fields {
+			panic("boom")
+		}),
+		WithEnricher("fast", func(entry *logrus.Entry) logrus.Fields {
+			return logrus.Fields{"country": "CN"}
+		}),
+	)
+
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if v := jsoniter.Get(data, "ctx", "country").ToString(); v != "CN" {
+		t.Fatalf("Format() ctx.country, Expected=%q, Actual=%q", "CN", v)
+	}
+	if v := jsoniter.Get(data, "ctx", "enrich_skipped", 0).ToString(); v != "panic" {
+		t.Fatalf("Format() ctx.enrich_skipped, Expected=%q, Actual=%q", "panic", v)
+	}
+}
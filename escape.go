@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithRawStrings 不转义消息与字段中的控制字符和无效 UTF-8，
+// 仅在日志内容完全可信且需要保留多行文本时使用
+func WithRawStrings() Option {
+	return func(af *LogsV1Formatter) {
+		af.RawStrings = true
+	}
+}
+
+// escapeString 将控制字符 (含 ANSI 转义序列的 ESC)、Unicode 行分隔符与无效 UTF-8
+// 转义为可见文本，防止通过请求头等外部输入伪造日志行或污染终端
+//
+// 换行与回车转义为 \n、\r，制表符保留，其他字符转义为 \xNN 或 \uNNNN
+func escapeString(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 && c != '\t' || c >= 0x7f {
+			break
+		}
+	}
+	if i == len(s) {
+		return s
+	}
+
+	b := &strings.Builder{}
+	b.Grow(len(s) + 8)
+	b.WriteString(s[:i])
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteString(`\x`)
+			writeHexByte(b, s[i])
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteByte('\t')
+		case r < 0x20 || r == 0x7f:
+			b.WriteString(`\x`)
+			writeHexByte(b, byte(r))
+		case r >= 0x80 && r < 0xa0, r == '\u2028', r == '\u2029':
+			fmt.Fprintf(b, `\u%04x`, r)
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func writeHexByte(b *strings.Builder, c byte) {
+	b.WriteByte(hex[c>>4])
+	b.WriteByte(hex[c&0xf])
+}
+
+// escapeLogsV1 转义顶层字段与请求信息，ctx 字段在收集时已经处理
+func escapeLogsV1(data *LogsV1) {
+	data.Channel = escapeString(data.Channel)
+	data.ID = escapeString(data.ID)
+	data.RequestID = escapeString(data.RequestID)
+	data.Tenant = escapeString(data.Tenant)
+	data.SessionID = escapeString(data.SessionID)
+	data.User = escapeString(data.User)
+	data.Message = escapeString(data.Message)
+	data.Code = escapeString(data.Code)
+	data.Err = escapeString(data.Err)
+	data.RequestParseError = escapeString(data.RequestParseError)
+
+	if req := data.Request; req != nil {
+		req.Method = escapeString(req.Method)
+		req.Path = escapeString(req.Path)
+		req.Status = escapeString(req.Status)
+		req.Duration = escapeString(req.Duration)
+		req.Route = escapeString(req.Route)
+		req.Handler = escapeString(req.Handler)
+		req.Proto = escapeString(req.Proto)
+		if req.TLS != nil {
+			req.TLS.ServerName = escapeString(req.TLS.ServerName)
+			req.TLS.ClientSubject = escapeString(req.TLS.ClientSubject)
+		}
+		req.UserAgent = escapeString(req.UserAgent)
+		req.UABrowser = escapeString(req.UABrowser)
+		req.UAOS = escapeString(req.UAOS)
+		req.UADevice = escapeString(req.UADevice)
+		for k, v := range req.Headers {
+			if escaped := escapeString(v); escaped != v {
+				req.Headers[k] = escaped
+			}
+		}
+		for k, v := range req.Cookies {
+			if escaped := escapeString(v); escaped != v {
+				req.Cookies[k] = escaped
+			}
+		}
+		for k, v := range req.RouteParams {
+			if escaped := escapeString(v); escaped != v {
+				req.RouteParams[k] = escaped
+			}
+		}
+		for i := range req.Files {
+			file := &req.Files[i]
+			file.Field = escapeString(file.Field)
+			file.Filename = escapeString(file.Filename)
+			file.ContentType = escapeString(file.ContentType)
+		}
+		if req.Body != nil {
+			req.Body.ContentType = escapeString(req.Body.ContentType)
+		}
+		if param, ok := (sanitizer{escape: true}).sanitize(req.Param).(map[string]interface{}); ok {
+			req.Param = param
+		}
+	}
+}
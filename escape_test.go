@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestEscapeString(t *testing.T) {
+	cases := []struct {
+		Value  string
+		Expect string
+	}{
+		{Value: "plain text", Expect: "plain text"},
+		{Value: "中文\ttab", Expect: "中文\ttab"},
+		{Value: "line1\nline2\r\n", Expect: `line1\nline2\r\n`},
+		{Value: "\x1b[31mred\x1b[0m", Expect: `\x1b[31mred\x1b[0m`},
+		{Value: "nul\x00del\x7f", Expect: `nul\x00del\x7f`},
+		{Value: "bad\xff\xfeutf8", Expect: `bad\xff\xfeutf8`},
+		{Value: "c1\u0085sep\u2028", Expect: `c1\u0085sep\u2028`},
+	}
+
+	for idx, each := range cases {
+		if actual := escapeString(each.Value); actual != each.Expect {
+			t.Fatalf("%d: expect: %q, got: %q", idx, each.Expect, actual)
+		}
+	}
+}
+
+func TestFormatEscape(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/login", nil)
+	req.Header.Set("User-Agent", "curl\n{\"l\":\"info\",\"m\":\"forged\"}")
+
+	entry := &logrus.Entry{
+		Message: "login\nfailed",
+		Data: logrus.Fields{
+			"request": req,
+			"user":    "u1\x1b[2J",
+			"note":    "a\rb",
+			"tags":    []string{"ok", "x\ny"},
+			"nested":  map[string]interface{}{"k\n": "v\x00"},
+			"cause":   errors.New("bad\ninput"),
+		},
+	}
+
+	var out struct {
+		Message string                 `json:"m"`
+		User    string                 `json:"u"`
+		Context map[string]interface{} `json:"ctx"`
+		Request struct {
+			Headers map[string]string `json:"header"`
+		} `json:"request"`
+	}
+
+	p, err := NewFormatter("test", "prod").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if err := json.Unmarshal(p, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, p)
+	}
+
+	cases := []struct {
+		Actual interface{}
+		Expect interface{}
+	}{
+		{Actual: out.Message, Expect: `login\nfailed`},
+		{Actual: out.User, Expect: `u1\x1b[2J`},
+		{Actual: out.Context["note"], Expect: `a\rb`},
+		{Actual: out.Context["tags"].([]interface{})[1], Expect: `x\ny`},
+		{Actual: out.Context["nested"].(map[string]interface{})[`k\n`], Expect: `v\x00`},
+		{Actual: out.Context["cause"].(map[string]interface{})["msg"], Expect: `bad\ninput`},
+		{Actual: out.Request.Headers["user-agent"], Expect: `curl\n{"l":"info","m":"forged"}`},
+	}
+	for idx, each := range cases {
+		if each.Actual != each.Expect {
+			t.Fatalf("%d: expect: %q, got: %q", idx, each.Expect, each.Actual)
+		}
+	}
+
+	raw, _ := NewFormatter("test", "prod", WithRawStrings()).Format(&logrus.Entry{Message: "multi\nline"})
+	if err := json.Unmarshal(raw, &out); err != nil || out.Message != "multi\nline" {
+		t.Fatalf("expect raw message with WithRawStrings, got %s", raw)
+	}
+}
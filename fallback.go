@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// encodeErrorKey 记录编码失败时被替换的字段与原因
+const encodeErrorKey = "encode_error"
+
+// encodeSafely 执行编码，编码过程中的 panic (如 MarshalJSON 实现有误) 转换为错误
+func encodeSafely(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during encode: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// encodeFallback 编码失败后，将无法单独编码的 ctx 字段替换为 %v 文本并记录到 ctx.encode_error，
+// 再次调用 write 编码；没有可替换的字段时返回原来的错误
+func encodeFallback(data *LogsV1, cause error, check func(v interface{}) error, write func() error) error {
+	var keys []string
+	for k, v := range data.Context {
+		if err := encodeSafely(func() error { return check(v) }); err != nil {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return cause
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		data.Context[k] = sprintValue(data.Context[k])
+	}
+	data.Context[encodeErrorKey] = logrus.Fields{
+		"fields": keys,
+		"msg":    cause.Error(),
+	}
+	return encodeSafely(write)
+}
+
+// sprintValue 使用 %v 转换为文本，String / Error 方法 panic 时使用类型名
+func sprintValue(v interface{}) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("%T", v)
+		}
+	}()
+	return fmt.Sprintf("%v", v)
+}
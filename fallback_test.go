@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("cannot marshal")
+}
+
+func (failingMarshaler) String() string {
+	return "failing"
+}
+
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalJSON() ([]byte, error) {
+	panic("marshal bug")
+}
+
+func TestFormatFallback(t *testing.T) {
+	entry := &logrus.Entry{
+		Message: "fallback",
+		Data: logrus.Fields{
+			"ok":    1,
+			"bad":   failingMarshaler{},
+			"crash": panickingMarshaler{},
+		},
+	}
+
+	p, err := NewFormatter("test", "prod").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	var out struct {
+		Message string                 `json:"m"`
+		Context map[string]interface{} `json:"ctx"`
+	}
+	if err := json.Unmarshal(p, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, p)
+	}
+
+	if out.Message != "fallback" || out.Context["ok"] != 1.0 {
+		t.Fatalf("expect other fields to be kept, got %s", p)
+	}
+	if out.Context["bad"] != "failing" || out.Context["crash"] != "{}" {
+		t.Fatalf("expect unencodable fields replaced with %%v text, got %s", p)
+	}
+
+	marker, _ := out.Context[encodeErrorKey].(map[string]interface{})
+	if !reflect.DeepEqual(marker["fields"], []interface{}{"bad", "crash"}) {
+		t.Fatalf("expect encode_error to list replaced fields, got %s", p)
+	}
+	if msg, _ := marker["msg"].(string); msg == "" {
+		t.Fatalf("expect encode_error to record the cause, got %s", p)
+	}
+}
+
+func TestFormatFallbackOtherFormats(t *testing.T) {
+	entry := &logrus.Entry{
+		Message: "fallback",
+		Data:    logrus.Fields{"bad": failingMarshaler{}},
+	}
+
+	p, err := NewMsgpackFormatter("test", "prod").Format(entry)
+	if err != nil {
+		t.Fatalf("msgpack Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	record, err := DecodeMsgpack(p)
+	if err != nil {
+		t.Fatalf("DecodeMsgpack() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if bad := record["ctx"].(map[string]interface{})["bad"]; bad != "failing" {
+		t.Fatalf("expect msgpack fallback, got %#v", bad)
+	}
+
+	entry.Buffer = &bytes.Buffer{}
+	p, err = NewLogfmtFormatter("test", "prod").Format(entry)
+	if err != nil {
+		t.Fatalf("logfmt Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if !strings.Contains(string(p), " ctx.bad=failing ctx.encode_error.fields=") {
+		t.Fatalf("expect logfmt fallback, got %q", p)
+	}
+}
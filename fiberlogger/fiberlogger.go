@@ -0,0 +1,101 @@
+// Package fiberlogger 提供 Fiber 框架的访问日志中间件，
+// 将 fasthttp 的请求转换为 *http.Request 后以 http.request.v1 规范记录
+//
+//	app := fiber.New()
+//	app.Use(fiberlogger.Middleware(l))
+//	app.Get("/users/:id", getUser)
+package fiberlogger
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// Option 中间件的可选配置
+type Option func(*config)
+
+type config struct {
+	skipper     func(c *fiber.Ctx) bool
+	statusLevel func(status int) logrus.Level
+}
+
+// WithSkipper 返回 true 的请求不记录日志，例如健康检查
+func WithSkipper(fn func(c *fiber.Ctx) bool) Option {
+	return func(c *config) {
+		c.skipper = fn
+	}
+}
+
+// WithStatusLevel 设置响应状态码对应的日志级别，默认使用 logger.DefaultStatusLevel
+func WithStatusLevel(fn func(status int) logrus.Level) Option {
+	return func(c *config) {
+		c.statusLevel = fn
+	}
+}
+
+// Middleware 访问日志中间件，每个请求结束后记录一条日志，ctx 使用 c.UserContext()
+//
+// 处理函数返回错误时先交给 Fiber 的 ErrorHandler 写入响应，再按实际的响应状态码记录，
+// 错误记录在 err，与 Fiber 自带的 logger 中间件一样不再向上返回错误
+func Middleware(l *logrus.Logger, opts ...Option) fiber.Handler {
+	cfg := &config{statusLevel: logger.DefaultStatusLevel}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.skipper != nil && cfg.skipper(c) {
+			return c.Next()
+		}
+
+		start := time.Now()
+		err := c.Next()
+		if err != nil {
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		duration := time.Since(start)
+
+		fields := logrus.Fields{
+			"status":    c.Response().StatusCode(),
+			"duration":  duration,
+			"bytes_out": len(c.Response().Body()),
+		}
+		if err != nil {
+			fields["error"] = err
+		}
+		req, cerr := Request(c)
+		if cerr != nil {
+			fields["request_convert_error"] = cerr.Error()
+		} else {
+			fields["request"] = req
+		}
+		l.WithContext(c.UserContext()).WithFields(fields).Log(cfg.statusLevel(c.Response().StatusCode()), "http request")
+		return nil
+	}
+}
+
+// Request 将 Fiber 的请求转换为 *http.Request，并记录匹配的路由模板与处理函数名，
+// 用于在处理函数中记录带有请求信息的日志
+func Request(c *fiber.Ctx) (*http.Request, error) {
+	req := &http.Request{}
+	if err := fasthttpadaptor.ConvertRequest(c.Context(), req, true); err != nil {
+		return nil, err
+	}
+	req = req.WithContext(logger.ContextWithRoute(c.UserContext()))
+
+	if route := c.Route(); route != nil {
+		handler := ""
+		if n := len(route.Handlers); n > 0 {
+			handler = logger.HandlerName(route.Handlers[n-1])
+		}
+		logger.SetRoute(req, route.Path, handler)
+	}
+	return req, nil
+}
@@ -0,0 +1,74 @@
+package fiberlogger
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+)
+
+func getUser(c *fiber.Ctx) error {
+	if c.Params("id") == "0" {
+		return fiber.NewError(fiber.StatusNotFound, "user not found")
+	}
+	return c.SendString("ok")
+}
+
+func TestMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	app := fiber.New()
+	app.Use(Middleware(l, WithSkipper(func(c *fiber.Ctx) bool { return c.Path() == "/healthz" })))
+	app.Get("/users/:id", getUser)
+	app.Get("/healthz", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	cases := []struct {
+		path     string
+		expected map[string]string
+	}{
+		{path: "/users/1?lang=en", expected: map[string]string{
+			"schema": string(logger.SchemaHTTPRequestV1), "l": "info", "err": "",
+			"request.route": "/users/:id", "request.status": "200", "request.path": "/users/1",
+			"request.param.lang": "en", "request.header.user-agent": "fiber-test",
+		}},
+		{path: "/users/0", expected: map[string]string{
+			"l": "warning", "err": "user not found", "request.status": "404",
+		}},
+		{path: "/healthz"},
+	}
+
+	for _, c := range cases {
+		out.Reset()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req.Header.Set("User-Agent", "fiber-test")
+		if _, err := app.Test(req); err != nil {
+			t.Fatalf("%s: Test() error, Expected=nil, Actual=%q", c.path, err)
+		}
+
+		data := out.Bytes()
+		if c.expected == nil {
+			if len(data) != 0 {
+				t.Fatalf("%s: skipped request should not be logged, Actual=%s", c.path, data)
+			}
+			continue
+		}
+		if err := logger.Validate(data); err != nil {
+			t.Fatalf("%s: Validate() error, Expected=nil, Actual=%q", c.path, err)
+		}
+		for key, expected := range c.expected {
+			var path []interface{}
+			for _, part := range strings.Split(key, ".") {
+				path = append(path, part)
+			}
+			if v := jsoniter.Get(data, path...).ToString(); v != expected {
+				t.Fatalf("%s: output %s, Expected=%q, Actual=%q", c.path, key, expected, v)
+			}
+		}
+	}
+}
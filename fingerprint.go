@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// DefaultFingerprintFrames 计算错误指纹默认使用的调用栈帧数
+const DefaultFingerprintFrames = 5
+
+// causer pkg/errors 的根因接口
+type causer interface {
+	Cause() error
+}
+
+// WithErrorFingerprint 设置计算错误指纹使用的调用栈帧数，小于等于 0 时不计算指纹
+func WithErrorFingerprint(frames int) Option {
+	return func(af *LogsV1Formatter) {
+		af.FingerprintFrames = frames
+	}
+}
+
+// ErrorFingerprint 计算错误的稳定指纹，用于在日志平台中聚合相同的错误：
+// 使用根因的类型与调用栈最内层 frames 帧的函数名，不含行号，代码行变动后指纹不变；
+// 没有调用栈时使用根因的类型与错误信息。结果为 16 位十六进制字符
+func ErrorFingerprint(err error, frames int) string {
+	return fingerprint(err, filterStack(stackTrace(err), frames, nil))
+}
+
+func (af *LogsV1Formatter) fingerprint(err error) string {
+	if af.FingerprintFrames <= 0 {
+		return ""
+	}
+	return fingerprint(err, filterStack(stackTrace(err), af.FingerprintFrames, af.StackFrameFilters))
+}
+
+func fingerprint(err error, trace []string) string {
+	cause := rootCause(err)
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\n", cause)
+	if len(trace) == 0 {
+		h.Write([]byte(cause.Error()))
+	}
+	for _, frame := range trace {
+		// 帧的格式为 "函数名 文件:行号"
+		if i := strings.IndexByte(frame, ' '); i >= 0 {
+			frame = frame[:i]
+		}
+		h.Write([]byte(frame))
+		h.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
+
+// rootCause 沿 Unwrap 与 Cause 找到最内层的错误
+func rootCause(err error) error {
+	for {
+		var next error
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		case causer:
+			next = e.Cause()
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
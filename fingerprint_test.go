@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func newStackError(msg string) error {
+	return pkgerrors.New(msg)
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	// 调用栈相同时与错误信息无关
+	a := ErrorFingerprint(newStackError("order 1 not found"), 3)
+	b := ErrorFingerprint(newStackError("order 2 not found"), 3)
+	if len(a) != 16 || a != b {
+		t.Fatalf("ErrorFingerprint() Expected same fingerprint, Actual=%q %q", a, b)
+	}
+
+	// 没有调用栈时使用根因的类型与错误信息
+	wrapped := fmt.Errorf("load: %w", errors.New("timeout"))
+	if ErrorFingerprint(wrapped, 3) != ErrorFingerprint(errors.New("timeout"), 3) {
+		t.Fatalf("ErrorFingerprint() wrapped error should use root cause")
+	}
+	if ErrorFingerprint(errors.New("timeout"), 3) == ErrorFingerprint(errors.New("refused"), 3) {
+		t.Fatalf("ErrorFingerprint() Expected different fingerprint for different messages")
+	}
+}
+
+func TestFormatErrorFingerprint(t *testing.T) {
+	entry := logrus.WithFields(logrus.Fields{
+		"error": errors.New("timeout"),
+		"cause": newStackError("nested"),
+	})
+	entry.Level = logrus.ErrorLevel
+
+	data, err := NewFormatter("test", "test").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+	if v := jsoniter.Get(data, "err_fingerprint").ToString(); v != ErrorFingerprint(errors.New("timeout"), DefaultFingerprintFrames) {
+		t.Fatalf("Format() err_fingerprint, Actual=%q", v)
+	}
+	if v := jsoniter.Get(data, "ctx", "cause", "fingerprint").ToString(); len(v) != 16 {
+		t.Fatalf("Format() ctx.cause.fingerprint, Actual=%q", v)
+	}
+
+	data, _ = NewFormatter("test", "test", WithErrorFingerprint(0)).Format(entry)
+	if jsoniter.Get(data, "err_fingerprint").LastError() == nil {
+		t.Fatalf("Format() err_fingerprint should be omitted when disabled")
+	}
+}
@@ -0,0 +1,470 @@
+// Package fluentsink 通过 Fluentd forward 协议将日志直接发送到 fluentd / fluent-bit
+//
+// 日志以 Forward 模式批量发送，每批带有 chunk 标识并等待服务端 ack 确认，
+// 未确认的批次会重连后重试
+package fluentsink
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/lancer05/logger/internal/msgpack"
+)
+
+// ErrClosed 向已关闭的 Sink 写入日志
+var ErrClosed = errors.New("fluentsink: sink closed")
+
+// Option Sink 的可选配置
+type Option func(*config)
+
+type config struct {
+	batchSize    int
+	batchWait    time.Duration
+	bufferSize   int
+	maxRetries   int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	timeout      time.Duration
+	ack          bool
+	blocking     bool
+	errorHandler func(error)
+	budget       *logger.Budget
+}
+
+// WithBatch 设置每批最多的日志条数与最长等待时间，默认 500 条 / 1 秒
+func WithBatch(size int, wait time.Duration) Option {
+	return func(cfg *config) {
+		cfg.batchSize = size
+		cfg.batchWait = wait
+	}
+}
+
+// WithBufferSize 设置等待发送的日志队列长度，默认 10000
+func WithBufferSize(n int) Option {
+	return func(cfg *config) {
+		cfg.bufferSize = n
+	}
+}
+
+// WithRetry 设置发送失败时的最大重试次数与指数退避的范围，默认 5 次 / 500ms ~ 30s
+func WithRetry(max int, minBackoff, maxBackoff time.Duration) Option {
+	return func(cfg *config) {
+		cfg.maxRetries = max
+		cfg.minBackoff = minBackoff
+		cfg.maxBackoff = maxBackoff
+	}
+}
+
+// WithTimeout 设置连接、写入与等待 ack 的超时时间，默认 3 秒
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = d
+	}
+}
+
+// WithoutAck 不等待服务端确认，写入连接即视为发送成功
+func WithoutAck() Option {
+	return func(cfg *config) {
+		cfg.ack = false
+	}
+}
+
+// WithBlocking 队列已满时阻塞写入，默认丢弃日志并计数，
+// 内存预算不足时仍然丢弃
+func WithBlocking() Option {
+	return func(cfg *config) {
+		cfg.blocking = true
+	}
+}
+
+// WithErrorHandler 设置发送失败的处理函数，默认输出到 stderr
+func WithErrorHandler(fn func(error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// Sink 实现 io.Writer，可以直接作为 logrus 的输出，
+// 等待发送的日志占用 logger.MemoryBudget，预算不足时丢弃并计数
+//
+// 写入的日志可以是 LogsV1 的 JSON 或 MsgpackFormatter 的输出，
+// 使用 MsgpackFormatter 时日志无需再次转换
+//
+//	sink := fluentsink.New("127.0.0.1:24224", "app.order")
+//	defer sink.Close()
+//	l.SetOutput(sink)
+type Sink struct {
+	network string
+	address string
+	tag     string
+	config  *config
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan event
+	done   chan struct{}
+
+	conn    net.Conn
+	dropped uint64
+}
+
+type event struct {
+	ts     time.Time
+	record []byte
+}
+
+// New 创建 forward 协议的发送对象，addr 为 host:port 或 unix:///path/to/socket
+func New(addr, tag string, opts ...Option) *Sink {
+	cfg := &config{
+		batchSize:  500,
+		batchWait:  time.Second,
+		bufferSize: 10000,
+		maxRetries: 5,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		timeout:    3 * time.Second,
+		ack:        true,
+		errorHandler: func(err error) {
+			fmt.Fprintf(os.Stderr, "fluentsink: %v\n", err)
+		},
+		budget: logger.MemoryBudget(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	network := "tcp"
+	if strings.HasPrefix(addr, "unix://") {
+		network = "unix"
+		addr = strings.TrimPrefix(addr, "unix://")
+	}
+
+	s := &Sink{
+		network: network,
+		address: addr,
+		tag:     tag,
+		config:  cfg,
+		queue:   make(chan event, cfg.bufferSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// ConfigName 日志配置中 sinks 下的配置名
+const ConfigName = "fluent"
+
+// Config 日志配置中 sinks.fluent 的内容，未设置的项使用默认值，batch_wait 与 timeout 为 time.ParseDuration 的格式
+//
+//	sinks:
+//	  fluent:
+//	    addr: fluent-bit:24224
+//	    tag: app.order
+//	    batch_size: 200
+//	    timeout: 5s
+type Config struct {
+	Addr       string `json:"addr"`
+	Tag        string `json:"tag"`
+	BatchSize  int    `json:"batch_size"`
+	BatchWait  string `json:"batch_wait"`
+	BufferSize int    `json:"buffer_size"`
+	MaxRetries int    `json:"max_retries"`
+	Timeout    string `json:"timeout"`
+	NoAck      bool   `json:"no_ack"`
+	Blocking   bool   `json:"blocking"`
+}
+
+// FromConfig 根据日志配置中的 sinks.fluent 创建发送对象，未配置时返回 false，opts 优先于配置
+//
+//	c, _ := logger.LoadConfig("log.yaml")
+//	l, _ := logger.NewWithConfig(c)
+//	sink, ok, err := fluentsink.FromConfig(c)
+//	if err != nil {
+//		return err
+//	}
+//	if ok {
+//		defer sink.Close()
+//		l.SetOutput(sink)
+//	}
+func FromConfig(c *logger.Config, opts ...Option) (*Sink, bool, error) {
+	sc := &Config{}
+	ok, err := c.SinkOptions(ConfigName, sc)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if sc.Addr == "" || sc.Tag == "" {
+		return nil, true, errors.New("fluentsink: sinks.fluent.addr and sinks.fluent.tag are required")
+	}
+	wait, err := parseDuration(sc.BatchWait)
+	if err != nil {
+		return nil, true, fmt.Errorf("fluentsink: parse sinks.fluent.batch_wait: %w", err)
+	}
+	timeout, err := parseDuration(sc.Timeout)
+	if err != nil {
+		return nil, true, fmt.Errorf("fluentsink: parse sinks.fluent.timeout: %w", err)
+	}
+
+	var configOpts []Option
+	if sc.BatchSize > 0 {
+		configOpts = append(configOpts, func(cfg *config) {
+			cfg.batchSize = sc.BatchSize
+		})
+	}
+	if wait > 0 {
+		configOpts = append(configOpts, func(cfg *config) {
+			cfg.batchWait = wait
+		})
+	}
+	if sc.BufferSize > 0 {
+		configOpts = append(configOpts, WithBufferSize(sc.BufferSize))
+	}
+	if sc.MaxRetries > 0 {
+		configOpts = append(configOpts, func(cfg *config) {
+			cfg.maxRetries = sc.MaxRetries
+		})
+	}
+	if timeout > 0 {
+		configOpts = append(configOpts, WithTimeout(timeout))
+	}
+	if sc.NoAck {
+		configOpts = append(configOpts, WithoutAck())
+	}
+	if sc.Blocking {
+		configOpts = append(configOpts, WithBlocking())
+	}
+	return New(sc.Addr, sc.Tag, append(configOpts, opts...)...), true, nil
+}
+
+// parseDuration 解析配置中的时间，为空时返回 0
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Write implements io.Writer interface，p 为一条格式化后的日志
+func (s *Sink) Write(p []byte) (int, error) {
+	e := event{ts: time.Now(), record: toRecord(p)}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	if !s.config.budget.Acquire(int64(len(e.record))) {
+		atomic.AddUint64(&s.dropped, 1)
+		return len(p), nil
+	}
+
+	if s.config.blocking {
+		s.queue <- e
+		return len(p), nil
+	}
+
+	select {
+	case s.queue <- e:
+	default:
+		s.config.budget.Release(int64(len(e.record)))
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped 返回因队列已满、内存预算不足或发送失败而丢弃的日志条数
+func (s *Sink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close 停止接收日志，发送队列中剩余的日志后关闭连接
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	<-s.done
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// toRecord 将日志转换为 MessagePack 编码的 map
+func toRecord(p []byte) []byte {
+	if len(p) > 0 && isMsgpackMap(p[0]) {
+		return append([]byte(nil), p...)
+	}
+
+	b := &bytes.Buffer{}
+	w := msgpack.NewWriter(b, logger.StdEncoder{})
+
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var record map[string]interface{}
+	if err := dec.Decode(&record); err != nil {
+		// 非 JSON 格式的日志作为 message 字段发送
+		record = map[string]interface{}{"message": strings.TrimRight(string(p), "\n")}
+	}
+	if err := w.WriteFields(record); err != nil {
+		b.Reset()
+		_ = w.WriteFields(map[string]interface{}{"message": strings.TrimRight(string(p), "\n")})
+	}
+	return b.Bytes()
+}
+
+func isMsgpackMap(c byte) bool {
+	return c&0xf0 == 0x80 || c == 0xde || c == 0xdf
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+
+	batch := make([]event, 0, s.config.batchSize)
+	timer := time.NewTimer(s.config.batchWait)
+	defer timer.Stop()
+
+	flush := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			for _, e := range batch {
+				s.config.budget.Release(int64(len(e.record)))
+			}
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= s.config.batchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(s.config.batchWait)
+		}
+	}
+}
+
+// send 发送一批日志，失败时断开连接并按指数退避重试
+func (s *Sink) send(batch []event) {
+	chunk := newChunkID()
+	msg := encodeForward(s.tag, batch, chunk, s.config.ack)
+
+	backoff := s.config.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.write(msg, chunk)
+		if err == nil {
+			return
+		}
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		if attempt >= s.config.maxRetries {
+			atomic.AddUint64(&s.dropped, uint64(len(batch)))
+			if s.config.errorHandler != nil {
+				s.config.errorHandler(fmt.Errorf("drop %d lines: %w", len(batch), err))
+			}
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > s.config.maxBackoff {
+			backoff = s.config.maxBackoff
+		}
+	}
+}
+
+func (s *Sink) write(msg []byte, chunk string) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.config.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if err := s.conn.SetDeadline(time.Now().Add(s.config.timeout)); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if !s.config.ack {
+		return nil
+	}
+
+	resp, err := msgpack.NewDecoder(s.conn).Decode()
+	if err != nil {
+		return fmt.Errorf("read ack: %w", err)
+	}
+	m, _ := resp.(map[string]interface{})
+	if ack, _ := m["ack"].(string); ack != chunk {
+		return fmt.Errorf("unexpected ack %v, expect %s", resp, chunk)
+	}
+	return nil
+}
+
+// encodeForward 按 Forward 模式编码: [tag, [[time, record], ...], option]
+func encodeForward(tag string, batch []event, chunk string, ack bool) []byte {
+	b := &bytes.Buffer{}
+	w := msgpack.NewWriter(b, logger.StdEncoder{})
+
+	w.WriteArrayHeader(3)
+	w.WriteString(tag)
+	w.WriteArrayHeader(len(batch))
+	for _, e := range batch {
+		w.WriteArrayHeader(2)
+		w.WriteExt(0, eventTime(e.ts))
+		w.WriteRaw(e.record)
+	}
+
+	if ack {
+		w.WriteMapHeader(2)
+		w.WriteString("chunk")
+		w.WriteString(chunk)
+	} else {
+		w.WriteMapHeader(1)
+	}
+	w.WriteString("size")
+	w.WriteInt(int64(len(batch)))
+	return b.Bytes()
+}
+
+// eventTime 编码 EventTime 扩展类型：秒与纳秒各 4 字节
+func eventTime(t time.Time) []byte {
+	p := make([]byte, 8)
+	binary.BigEndian.PutUint32(p[:4], uint32(t.Unix()))
+	binary.BigEndian.PutUint32(p[4:], uint32(t.Nanosecond()))
+	return p
+}
+
+func newChunkID() string {
+	p := make([]byte, 16)
+	_, _ = rand.Read(p)
+	return base64.StdEncoding.EncodeToString(p)
+}
@@ -0,0 +1,234 @@
+package fluentsink
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/lancer05/logger/internal/msgpack"
+	"github.com/sirupsen/logrus"
+)
+
+// server 模拟 fluentd 的 forward 输入，第一个连接不回复 ack 直接断开
+type server struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages [][]interface{}
+	conns    int
+}
+
+func newServer(t *testing.T) *server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %s", err)
+	}
+	srv := &server{ln: ln}
+	go srv.serve()
+	return srv
+}
+
+func (srv *server) serve() {
+	for {
+		conn, err := srv.ln.Accept()
+		if err != nil {
+			return
+		}
+		srv.mu.Lock()
+		srv.conns++
+		first := srv.conns == 1
+		srv.mu.Unlock()
+
+		go srv.handle(conn, first)
+	}
+}
+
+func (srv *server) handle(conn net.Conn, drop bool) {
+	defer conn.Close()
+
+	dec := msgpack.NewDecoder(conn)
+	for {
+		v, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		if drop {
+			return
+		}
+
+		msg := v.([]interface{})
+		srv.mu.Lock()
+		srv.messages = append(srv.messages, msg)
+		srv.mu.Unlock()
+
+		option := msg[2].(map[string]interface{})
+		b := &bytes.Buffer{}
+		w := msgpack.NewWriter(b, logger.StdEncoder{})
+		_ = w.WriteFields(map[string]interface{}{"ack": option["chunk"]})
+		conn.Write(b.Bytes())
+	}
+}
+
+func TestSink(t *testing.T) {
+	for _, format := range []string{"json", "msgpack"} {
+		t.Run(format, func(t *testing.T) {
+			srv := newServer(t)
+			defer srv.ln.Close()
+
+			sink := New(srv.ln.Addr().String(), "app.test",
+				WithBatch(10, 10*time.Millisecond),
+				WithRetry(3, time.Millisecond, time.Millisecond),
+			)
+
+			l := logrus.New()
+			l.SetOutput(sink)
+			if format == "msgpack" {
+				l.SetFormatter(logger.NewMsgpackFormatter("test", "prod"))
+			} else {
+				l.SetFormatter(logger.NewFormatter("test", "prod"))
+			}
+			l.WithFields(logrus.Fields{"channel": "order", "attempt": 2}).Info("first")
+			l.Warn("second")
+
+			if err := sink.Close(); err != nil {
+				t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+			}
+			if sink.Dropped() != 0 {
+				t.Fatalf("expect no dropped lines, got %d", sink.Dropped())
+			}
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			if srv.conns != 2 {
+				t.Fatalf("expect reconnect after missing ack, got %d connections", srv.conns)
+			}
+			if len(srv.messages) != 1 {
+				t.Fatalf("expect 1 forward message, got %d", len(srv.messages))
+			}
+
+			msg := srv.messages[0]
+			if msg[0] != "app.test" {
+				t.Fatalf("expect tag app.test, got %v", msg[0])
+			}
+			entries := msg[1].([]interface{})
+			if len(entries) != 2 {
+				t.Fatalf("expect 2 entries, got %d", len(entries))
+			}
+
+			first := entries[0].([]interface{})
+			if ts := first[0].(msgpack.Ext); ts.Type != 0 || len(ts.Data) != 8 {
+				t.Fatalf("expect EventTime, got %#v", first[0])
+			}
+			record := first[1].(map[string]interface{})
+			if record["m"] != "first" || record["c"] != "order" {
+				t.Fatalf("unexpected record %v", record)
+			}
+			if attempt := record["ctx"].(map[string]interface{})["attempt"]; attempt != int64(2) {
+				t.Fatalf("expect attempt=2, got %#v", attempt)
+			}
+		})
+	}
+}
+
+func TestSinkUnreachable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var handled error
+	sink := New(addr, "app.test",
+		WithBatch(1, time.Hour),
+		WithRetry(1, time.Millisecond, time.Millisecond),
+		WithErrorHandler(func(err error) { handled = err }),
+	)
+	sink.Write([]byte("plain text line\n"))
+	sink.Close()
+
+	if sink.Dropped() != 1 {
+		t.Fatalf("expect 1 dropped line, got %d", sink.Dropped())
+	}
+	if handled == nil {
+		t.Fatal("expect error handler to be called")
+	}
+	if _, err := sink.Write([]byte("{}")); err != ErrClosed {
+		t.Fatalf("Write() error, Expected=%v, Actual=%v", ErrClosed, err)
+	}
+}
+
+func TestSinkBudget(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	sink := New(addr, "app.test",
+		WithBatch(10, time.Hour),
+		WithRetry(0, time.Millisecond, time.Millisecond),
+		WithErrorHandler(func(err error) {}),
+	)
+	budget := logger.NewBudget(1 << 10)
+	sink.config.budget = budget
+
+	sink.Write([]byte("first\n"))
+	if inUse := budget.Stats().InUse; inUse == 0 {
+		t.Fatal("expect queued line to hold budget")
+	}
+	budget.SetLimit(1)
+	sink.Write([]byte("second\n"))
+	if dropped := budget.Stats().Dropped; dropped != 1 {
+		t.Fatalf("expect 1 line dropped by budget, got %d", dropped)
+	}
+
+	sink.Close()
+	if inUse := budget.Stats().InUse; inUse != 0 {
+		t.Fatalf("expect budget released after send, got %d in use", inUse)
+	}
+	if sink.Dropped() != 2 {
+		t.Fatalf("expect 2 dropped lines, got %d", sink.Dropped())
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	if _, ok, err := FromConfig(&logger.Config{}); ok || err != nil {
+		t.Fatalf("FromConfig() without sinks.fluent, Expected=false,nil Actual=%v,%v", ok, err)
+	}
+	invalid := &logger.Config{Sinks: map[string]map[string]interface{}{
+		ConfigName: {"addr": "127.0.0.1:24224"},
+	}}
+	if _, ok, err := FromConfig(invalid); !ok || err == nil {
+		t.Fatalf("FromConfig() without tag, Expected=true,error Actual=%v,%v", ok, err)
+	}
+
+	srv := newServer(t)
+	defer srv.ln.Close()
+
+	c := &logger.Config{Sinks: map[string]map[string]interface{}{
+		ConfigName: {
+			"addr":       srv.ln.Addr().String(),
+			"tag":        "app.config",
+			"batch_size": 10,
+			"batch_wait": "10ms",
+			"timeout":    "1s",
+		},
+	}}
+	sink, ok, err := FromConfig(c, WithRetry(3, time.Millisecond, time.Millisecond))
+	if !ok || err != nil {
+		t.Fatalf("FromConfig() Expected=true,nil Actual=%v,%v", ok, err)
+	}
+	if sink.config.batchSize != 10 || sink.config.batchWait != 10*time.Millisecond || sink.config.timeout != time.Second {
+		t.Fatalf("expect batch 10 / 10ms and timeout 1s, got %+v", sink.config)
+	}
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(sink)
+	l.Info("first")
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.messages) != 1 || srv.messages[0][0] != "app.config" {
+		t.Fatalf("expect 1 forward message tagged app.config, got %v", srv.messages)
+	}
+}
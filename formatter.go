@@ -2,14 +2,15 @@ package logger
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	jsoniter "github.com/json-iterator/go"
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,15 +22,37 @@ const (
 	SchemaGeneralLogsV1 Schema = "general.logs.v1"
 	// HTTPRequestV1 请求日志
 	SchemaHTTPRequestV1 Schema = "http.request.v1"
+	// SchemaSQLQueryV1 SQL 查询日志
+	SchemaSQLQueryV1 Schema = "sql.query.v1"
+	// SchemaHTTPClientV1 对外请求日志
+	SchemaHTTPClientV1 Schema = "http.client.v1"
+	// SchemaMQMessageV1 消息队列日志
+	SchemaMQMessageV1 Schema = "mq.message.v1"
+	// SchemaJobRunV1 后台任务日志
+	SchemaJobRunV1 Schema = "job.run.v1"
+	// SchemaRuntimeStatsV1 运行时统计日志
+	SchemaRuntimeStatsV1 Schema = "runtime.stats.v1"
+	// SchemaWSConnectionV1 WebSocket 连接日志
+	SchemaWSConnectionV1 Schema = "ws.connection.v1"
+	// SchemaGRPCClientV1 对外 gRPC 调用日志
+	SchemaGRPCClientV1 Schema = "grpc.client.v1"
+	// SchemaGraphQLRequestV1 GraphQL 操作日志
+	SchemaGraphQLRequestV1 Schema = "graphql.request.v1"
+	// SchemaRedisCommandV1 Redis 命令日志
+	SchemaRedisCommandV1 Schema = "redis.command.v1"
+	// SchemaMongoCommandV1 MongoDB 命令日志
+	SchemaMongoCommandV1 Schema = "mongo.command.v1"
 )
 
 var (
-	// MaxStackTrace 记录的错误信息的调用栈最大深度
+	// MaxStackTrace 记录的错误信息的调用栈默认最大深度，
+	// 格式化对象可以通过 WithMaxStackTrace 单独设置
 	MaxStackTrace = 10
 
-	_ logrus.Formatter = (*LogsV1Formatter)(nil)
+	// maxReusedFields 对象池中可复用的 ctx 的最大字段数
+	maxReusedFields = 64
 
-	emptyStack = make([]string, 0)
+	_ logrus.Formatter = (*LogsV1Formatter)(nil)
 
 	logsV1Pool = sync.Pool{
 		New: func() interface{} {
@@ -38,13 +61,33 @@ var (
 	}
 )
 
+// Option 格式化对象的可选配置
+type Option func(*LogsV1Formatter)
+
+// WithCatalog 根据消息码从目录中查找 lang 语言的文本作为日志内容
+func WithCatalog(c *Catalog, lang string) Option {
+	return func(f *LogsV1Formatter) {
+		f.Catalog = c
+		f.Language = lang
+	}
+}
+
 // NewFormatter 获得日志规范对应的格式化对象
-func NewFormatter(service, env string) logrus.Formatter {
-	return &LogsV1Formatter{
-		TimeLayout:  "2006-01-02T15:04:05.999Z07:00",
-		Service:     service,
-		Environment: env,
+func NewFormatter(service, env string, opts ...Option) logrus.Formatter {
+	f := &LogsV1Formatter{
+		TimeLayout:          "2006-01-02T15:04:05.999Z07:00",
+		Service:             service,
+		Environment:         env,
+		MaxFieldSize:        DefaultMaxFieldSize,
+		MaxEntrySize:        DefaultMaxEntrySize,
+		MaxMultipartSize:    DefaultMaxMultipartSize,
+		MaxDecompressedSize: DefaultMaxDecompressedSize,
+		FingerprintFrames:   DefaultFingerprintFrames,
+	}
+	for _, opt := range opts {
+		opt(f)
 	}
+	return f
 }
 
 // LogsV1 logs.v1 日志输出内容
@@ -55,12 +98,39 @@ type LogsV1 struct {
 	Service     string                 `json:"s"`
 	Channel     string                 `json:"c"`
 	ID          string                 `json:"i"`
+	RequestID   string                 `json:"request_id,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	SessionID   string                 `json:"session_id,omitempty"`
 	Environment string                 `json:"e"`
 	User        string                 `json:"u"`
 	Message     string                 `json:"m"`
+	Code        string                 `json:"code,omitempty"`
+	Host        *HostData              `json:"host,omitempty"`
+	Retention   string                 `json:"retention,omitempty"`
+	Build       *BuildData             `json:"build,omitempty"`
 	Context     map[string]interface{} `json:"ctx"`
 	Err         string                 `json:"err"`
-	Request     *RequestData           `json:"request,omitempty"`
+	// 错误的指纹，error 字段为 error 类型时记录
+	ErrFingerprint string `json:"err_fingerprint,omitempty"`
+	// error 字段为组合错误时，逐个记录其中的错误
+	Errors  []interface{} `json:"errors,omitempty"`
+	Request *RequestData  `json:"request,omitempty"`
+	// 解析请求信息时遇到的问题，请求不完整时仍然输出日志
+	RequestParseError string             `json:"request_parse_error,omitempty"`
+	Response          *ResponseData      `json:"response,omitempty"`
+	SQL               *SQLData           `json:"sql,omitempty"`
+	Client            *ClientRequestData `json:"client,omitempty"`
+	MQ                *MessageData       `json:"mq,omitempty"`
+	Job               *JobData           `json:"job,omitempty"`
+	Runtime           *RuntimeStatsData  `json:"runtime,omitempty"`
+
+	// 按 TimeLayout 格式化后的时间，复用以避免每条日志分配字符串
+	timeBuf []byte
+	// 自定义规范的字段名与内容
+	sectionKey string
+	section    interface{}
+	// error 字段的原始错误，用于输出 v2 的错误类型与调用栈
+	errValue error
 }
 
 // LogsV1Formatter 日志格式化
@@ -69,119 +139,522 @@ type LogsV1Formatter struct {
 	TimeLayout  string
 	Service     string
 	Environment string
+	// 消息目录，entry 带有 code 字段时用于查找本地化文本
+	Catalog *Catalog
+	// 消息目录查找使用的语言
+	Language string
+	// 格式化时补充字段的函数
+	Enrichers []Enricher
+	// 每条日志执行补充函数的总耗时上限，0 表示不限制
+	EnrichDeadline time.Duration
+	// 格式化时计算的字段
+	DynamicFields []DynamicField
+	// 脱敏规则
+	Redactor *Redactor
+	// 运行实例的信息，为空时不输出
+	Host *HostData
+	// 默认的保留期限标记
+	Retention string
+	// 按 channel 设置的保留期限标记
+	ChannelRetention map[string]string
+	// Kubernetes 相关的信息，记录在 ctx.k8s
+	Kubernetes *KubernetesData
+	// 构建信息，为空时不输出
+	Build *BuildData
+	// 编码实现，为空时使用 DefaultEncoder
+	Encoder Encoder
+	// ctx 字段的最大嵌套深度，0 表示使用 DefaultMaxDepth
+	MaxDepth int
+	// 不转义消息与字段中的控制字符
+	RawStrings bool
+	// 单个字段与单条日志的最大字节数，0 表示不限制
+	MaxFieldSize int
+	MaxEntrySize int
+	// ctx 中 []byte 字段的输出格式，默认 base64
+	BytesEncoding BytesEncoding
+	// 解析 multipart 请求时最多读取的 body 字节数，0 表示不读取
+	MaxMultipartSize int64
+	// 解压请求 body 时的最大字节数，0 表示不解压
+	MaxDecompressedSize int64
+	// 请求参数的过滤规则
+	ParamFilter *ParamFilter
+	// 完整记录值的 cookie 名，其他 cookie 只记录名称
+	CookieAllowlist []string
+	// 从 Authorization bearer token 提取用户标识的配置，为空时不提取
+	JWT *JWTOptions
+	// 从请求中读取会话ID的配置，为空时只使用 entry 中的 session_id 字段
+	Session *SessionOptions
+	// 按键排序输出对象，输出内容稳定
+	SortKeys bool
+	// 请求 IP 的匿名化函数，为空时记录原始 IP
+	IPAnonymizer func(ip string) string
+	// 用户标识的 HMAC key，设置后 u 输出用户标识的 HMAC
+	UserKey []byte
+	// User-Agent 解析函数，为空时不解析
+	UserAgentParser UserAgentParser
+	// 按 content type 设置的请求 body 解析函数，未设置的类型使用默认的解析函数
+	BodyParsers map[string]BodyParser
+	// 迁移期间在 v1 之后再输出一行 v2
+	DualEmit bool
+	// 记录调用位置时额外跳过的层数与函数名前缀
+	CallerSkip         int
+	CallerSkipPackages []string
+	// 调用位置的文件路径与函数名中去掉的前缀
+	CallerTrimPrefixes []string
+	// 函数名只保留包名及之后的部分
+	ShortCallerFunc bool
+	// 错误调用栈的最大深度，0 表示使用 MaxStackTrace
+	MaxStackTrace int
+	// 错误调用栈的过滤函数，过滤后再按最大深度截断
+	StackFrameFilters []StackFrameFilter
+	// 计算错误指纹使用的调用栈帧数，0 表示不计算
+	FingerprintFrames int
+	// 在 ctx 中记录 goroutine ID 与 goroutine 数量
+	GoroutineID    bool
+	GoroutineCount bool
+
+	// 运行期间替换的脱敏规则，设置后优先于 Redactor
+	redactor atomic.Value
+}
+
+// SetRedactor 原子地替换脱敏规则，可以在日志输出期间调用
+func (af *LogsV1Formatter) SetRedactor(rd *Redactor) {
+	af.redactor.Store(rd)
+}
+
+func (af *LogsV1Formatter) currentRedactor() *Redactor {
+	if rd, ok := af.redactor.Load().(*Redactor); ok {
+		return rd
+	}
+	return af.Redactor
 }
 
 // RequestData 请求相关的参数
 type RequestData struct {
-	IP       string            `json:"ip"`
-	Method   string            `json:"method"`
-	Path     string            `json:"path"`
+	IP     string `json:"ip"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// 匹配的路由模板与处理函数名，通过 SetRoute 记录
+	Route    string            `json:"route,omitempty"`
+	Handler  string            `json:"handler,omitempty"`
+	Proto    string            `json:"proto,omitempty"`
+	TLS      *TLSData          `json:"tls,omitempty"`
 	Headers  map[string]string `json:"header"`
 	Status   string            `json:"status"`
 	Duration string            `json:"duration"`
-	Param    logrus.Fields     `json:"param"`
+	// 请求 body 的字节数，优先使用 Content-Length，未知时为读取的字节数
+	BytesIn int64         `json:"bytes_in"`
+	Param   logrus.Fields `json:"param"`
+	Files   []FileData    `json:"files,omitempty"`
+	// 启用 User-Agent 解析时记录
+	UserAgent string       `json:"ua,omitempty"`
+	UABrowser string       `json:"ua_browser,omitempty"`
+	UAOS      string       `json:"ua_os,omitempty"`
+	UADevice  string       `json:"ua_device,omitempty"`
+	Body      *BodySummary `json:"body,omitempty"`
+	// Cookie header 解析后的 cookie，不在允许列表中的 cookie 值被替换
+	Cookies map[string]string `json:"cookie,omitempty"`
+	// 通过 SetRouteParams 记录的路由参数
+	RouteParams map[string]string `json:"route_params,omitempty"`
+}
+
+// ResponseData 响应相关的参数
+type ResponseData struct {
+	BytesOut int64 `json:"bytes_out"`
+	// 流式响应开始输出的耗时，request.duration 为整个流的持续时间
+	FirstByte string `json:"first_byte,omitempty"`
+	Streaming bool   `json:"streaming,omitempty"`
+}
+
+// SQLData SQL 查询相关的参数
+type SQLData struct {
+	Statement   string        `json:"statement"`
+	Fingerprint string        `json:"fingerprint"`
+	Args        []interface{} `json:"args,omitempty"`
+	Rows        int64         `json:"rows"`
+	Duration    string        `json:"duration"`
 }
 
 // Format implements logrus.Formatter interface
 func (af *LogsV1Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// logrus 会在输出后回收 entry.Buffer，返回值与 entry.Buffer 共享内存，
+	// 直接调用 Format 且未设置 Buffer 时返回独立的内存
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	af.collect(entry, data)
+	enc := af.encoder()
+	err := encodeSafely(func() error { return writeLogsV1(b, enc, data) })
+	if err != nil {
+		b.Truncate(start)
+		err = encodeFallback(data, err,
+			func(v interface{}) error { return writeValue(&bytes.Buffer{}, enc, v) },
+			func() error { return writeLogsV1(b, enc, data) },
+		)
+	}
+	if size := b.Len() - start; err == nil && af.MaxEntrySize > 0 && size > af.MaxEntrySize {
+		b.Truncate(start)
+		err = limitEntry(data, af.MaxEntrySize, size,
+			func(v interface{}) int {
+				scratch := &bytes.Buffer{}
+				_ = writeValue(scratch, enc, v)
+				return scratch.Len()
+			},
+			func() error { return writeLogsV1(b, enc, data) },
+		)
+	}
+	if err == nil && af.DualEmit {
+		mark := b.Len()
+		if encodeSafely(func() error { return writeLogsV2(b, enc, af.logsV2(data)) }) != nil {
+			b.Truncate(mark)
+		}
+	}
+	schema := data.Schema
+	// 写入完成后才放回对象池，放回前清除对调用方数据的引用
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "json encode %s log", schema)
+	}
+
+	return b.Bytes(), nil
+}
+
+// acquireLogsV1 从对象池获取 LogsV1，获取方在调用 releaseLogsV1 之前独占该对象
+func acquireLogsV1() *LogsV1 {
+	return logsV1Pool.Get().(*LogsV1)
+}
+
+// releaseLogsV1 清空 LogsV1 后放回对象池，只保留可复用的 ctx 与时间缓冲
+func releaseLogsV1(data *LogsV1) {
+	context := reuseFields(data.Context)
+	timeBuf := data.timeBuf[:0]
+	*data = LogsV1{
+		Context: context,
+		timeBuf: timeBuf,
+	}
+	logsV1Pool.Put(data)
+}
+
+// collect 从 entry 中提取日志内容填充到 data，data 必须是 acquireLogsV1 获得的空对象
+func (af *LogsV1Formatter) collect(entry *logrus.Entry, data *LogsV1) {
 	channel := ""
 	uid := ""
 	status := ""
 	duration := ""
+	var bytesIn, bytesOut interface{}
+	firstByte := ""
+	streaming := false
 	id := ""
 	errMsg := ""
-	context := logrus.Fields{}
+	code := ""
+	retention := ""
+	requestID := RequestIDFromContext(entry.Context)
+	tenant := TenantFromContext(entry.Context)
+	sessionID := ""
+	context := data.Context
+	if context == nil {
+		context = logrus.Fields{}
+	}
 	schema := SchemaGeneralLogsV1
 
 	// 先处理caller记录，允许entry.Data内的数据覆盖caller
 	// 可以实现自行记录caller的目的
 	if entry.HasCaller() {
-		caller := entry.Caller
-		context[logrus.FieldKeyFile] = fmt.Sprintf("%s:%d", caller.File, caller.Line)
-		context[logrus.FieldKeyFunc] = caller.Function
+		context[logrus.FieldKeyFile], context[logrus.FieldKeyFunc] = af.callerFields(entry.Caller)
 	}
 
-	for k, v := range entry.Data {
+	if af.Kubernetes != nil {
+		context["k8s"] = af.Kubernetes
+	}
+
+	af.goroutineFields(context)
+	af.enrich(entry, context)
+
+	field := func(k string, v interface{}) {
+		if lazy, ok := v.(*LazyValue); ok {
+			v = lazy.Value()
+		}
+
 		switch k {
 		case "channel":
 			channel, _ = v.(string)
-		case "request":
-			continue
+		case "request", "sql", "client", "mq", "job", "runtime":
+			return
 		case "user":
-			uid = fmt.Sprintf("%v", v)
+			uid = toString(v)
 		case "status":
-			status = fmt.Sprintf("%v", v)
+			status = toString(v)
 		case "id":
 			id, _ = v.(string)
 		case "duration":
-			duration = fmt.Sprintf("%v", v)
+			duration = toString(v)
+		case "bytes_in":
+			bytesIn = v
+		case "bytes_out":
+			bytesOut = v
+		case "first_byte":
+			firstByte = toString(v)
+		case "streaming":
+			streaming, _ = v.(bool)
 		case "error":
-			errMsg = fmt.Sprintf("%v", v)
+			errMsg = toString(v)
+			data.errValue, _ = v.(error)
+		case "code":
+			code = toString(v)
+		case "request_id":
+			requestID = toString(v)
+		case "tenant":
+			tenant = toString(v)
+		case "session_id":
+			sessionID = toString(v)
+		case "retention":
+			retention = toString(v)
 		default:
 			if err, ok := v.(error); !ok {
-				context[k] = v
+				context[k] = af.sanitizer().sanitize(v)
 			} else {
-				msg, trace := extractError(err)
-				errData := logrus.Fields{
-					"msg": msg,
-				}
-				if len(trace) > 0 {
-					errData["trace"] = trace
-				}
-				context[k] = errData
+				context[k] = af.errorFields(err, 0)
 			}
 		}
 	}
 
-	data := logsV1Pool.Get().(*LogsV1)
-	data.Time = entry.Time.Format(af.TimeLayout)
-	data.Level = entry.Level.String()
+	// 优先级从低到高依次为动态字段、ctx 提取的字段、绑定的字段、entry.Data 内的字段
+	af.dynamicFields(field)
+	if entry.Context != nil {
+		if baggage := BaggageFromContext(entry.Context); baggage != nil {
+			field("baggage", baggage)
+		}
+		for _, extract := range contextExtractors() {
+			for k, v := range extract(entry.Context) {
+				field(k, v)
+			}
+		}
+	}
+	eachField(entry, field)
+
+	data.timeBuf = entry.Time.AppendFormat(data.timeBuf[:0], af.TimeLayout)
+	data.Level = levelString(entry.Level)
 	data.Service = af.Service
 	data.Channel = channel
 	data.Environment = af.Environment
+	data.Host = af.Host
+	data.Build = af.Build
+	data.Retention = af.retention(channel, retention)
 	data.ID = id
+	data.RequestID = requestID
+	data.Tenant = tenant
+	if sessionID == "" && af.Session != nil {
+		if req, ok := entry.Data["request"].(*http.Request); ok {
+			sessionID = af.Session.extract(req)
+		}
+	}
+	data.SessionID = sessionID
 	data.Message = entry.Message
+	data.Code = code
 	data.Context = context
+	if af.JWT != nil {
+		if req, ok := entry.Data["request"].(*http.Request); ok {
+			var claims map[string]interface{}
+			if uid, claims = af.JWT.extract(req, uid); claims != nil {
+				context["jwt"] = af.sanitizer().sanitize(claims)
+			}
+		}
+	}
 	data.User = uid
+	if uid != "" && len(af.UserKey) > 0 {
+		data.User = hmacHex(af.UserKey, uid)
+	}
 	data.Err = errMsg
-	defer logsV1Pool.Put(data)
+	if data.errValue != nil {
+		data.ErrFingerprint = af.fingerprint(data.errValue)
+		data.Errors = af.errorList(data.errValue, 0)
+	}
+
+	if code != "" && af.Catalog != nil {
+		if text, ok := af.Catalog.Lookup(code, af.Language); ok {
+			data.Message = text
+		}
+	}
 
 	if rv, ok := entry.Data["request"]; ok {
 		if req, ok := rv.(*http.Request); ok {
 			schema = SchemaHTTPRequestV1
-			data.Request = richRequest(req, status, duration)
+			data.Request, data.RequestParseError = af.richRequest(req, status, duration)
+			if n, ok := toInt64(bytesIn); ok {
+				data.Request.BytesIn = n
+				if body := data.Request.Body; body != nil && body.Size < 0 {
+					body.Size = int(n)
+				}
+			}
+			if n, ok := toInt64(bytesOut); ok || streaming {
+				data.Response = &ResponseData{BytesOut: n, FirstByte: firstByte, Streaming: streaming}
+			}
+		}
+	}
+
+	if sv, ok := entry.Data["sql"]; ok {
+		if q, ok := sv.(*SQLData); ok {
+			schema = SchemaSQLQueryV1
+			data.SQL = q
+		}
+	}
+
+	if cv, ok := entry.Data["client"]; ok {
+		if c, ok := cv.(*ClientRequestData); ok {
+			schema = SchemaHTTPClientV1
+			data.Client = c
+		}
+	}
+
+	if mv, ok := entry.Data["mq"]; ok {
+		if m, ok := mv.(*MessageData); ok {
+			schema = SchemaMQMessageV1
+			data.MQ = m
+		}
+	}
+
+	if jv, ok := entry.Data["job"]; ok {
+		if j, ok := jv.(*JobData); ok {
+			schema = SchemaJobRunV1
+			data.Job = j
+		}
+	}
+
+	if rv, ok := entry.Data["runtime"]; ok {
+		if r, ok := rv.(*RuntimeStatsData); ok {
+			schema = SchemaRuntimeStatsV1
+			data.Runtime = r
+		}
+	}
+
+	if !af.RawStrings {
+		escapeLogsV1(data)
+	}
+	af.currentRedactor().redact(entry, data, af.encoder())
+	af.limitFields(data)
+
+	// 自定义规范的内容取自 ctx，已经过转义、脱敏与截断
+	if schema == SchemaGeneralLogsV1 {
+		if custom, ok := af.customSchema(entry, data); ok {
+			schema = custom
 		}
 	}
 
 	data.Schema = string(schema)
+}
 
-	var b *bytes.Buffer
-	if entry.Buffer != nil {
-		b = entry.Buffer
-	} else {
-		b = &bytes.Buffer{}
+// toString 字段值转换为字符串，字符串类型直接返回，避免内存分配
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
 	}
+	return fmt.Sprintf("%v", v)
+}
 
-	if err := jsoniter.NewEncoder(b).Encode(data); err != nil {
-		return nil, errors.Wrapf(err, "json encode %s log", schema)
+// toInt64 将整数类型的字段值转换为 int64
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
 	}
+	return 0, false
+}
 
-	return b.Bytes(), nil
+// levelString 与 logrus.Level.String 一致，但不分配内存
+func levelString(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel:
+		return "trace"
+	case logrus.DebugLevel:
+		return "debug"
+	case logrus.InfoLevel:
+		return "info"
+	case logrus.WarnLevel:
+		return "warning"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.FatalLevel:
+		return "fatal"
+	case logrus.PanicLevel:
+		return "panic"
+	}
+	return level.String()
+}
+
+// reuseFields 清空 ctx 以便复用，过大的 ctx 直接丢弃，避免长期占用内存
+func reuseFields(m logrus.Fields) logrus.Fields {
+	if m == nil || len(m) > maxReusedFields {
+		return logrus.Fields{}
+	}
+	for k := range m {
+		delete(m, k)
+	}
+	return m
 }
 
-func richRequest(req *http.Request, status, duration string) *RequestData {
-	request := &RequestData{
+// richRequest 提取请求信息，请求缺少 URL、Header、Body 或参数无法解析时尽量保留已提取的内容，
+// 并返回遇到的问题
+func (af *LogsV1Formatter) richRequest(req *http.Request, status, duration string) (request *RequestData, parseErr string) {
+	request = &RequestData{
 		IP:       parseIP(req.RemoteAddr),
 		Method:   req.Method,
-		Path:     req.URL.Path,
+		Proto:    req.Proto,
+		BytesIn:  req.ContentLength,
+		TLS:      richTLS(req.TLS),
 		Status:   status,
 		Duration: duration,
 		Headers:  map[string]string{},
 		Param:    logrus.Fields{},
 	}
 
+	var problems []string
+	defer func() {
+		if r := recover(); r != nil {
+			problems = append(problems, fmt.Sprintf("panic: %v", r))
+		}
+		// 解析中断时也要过滤已经记录的参数与 IP
+		af.ParamFilter.filter(request.Param)
+		af.anonymizeIP(request)
+		parseErr = strings.Join(problems, "; ")
+	}()
+
+	var params map[string]string
+	request.Route, request.Handler, params = routeFromContext(req.Context())
+	if len(params) > 0 {
+		// 复制一份，转义与脱敏时会修改
+		request.RouteParams = make(map[string]string, len(params))
+		for k, v := range params {
+			request.RouteParams[k] = v
+		}
+	}
+
+	if req.URL != nil {
+		request.Path = req.URL.Path
+	} else {
+		problems = append(problems, "missing url")
+	}
+
 	// 获取 header信息
 	for k, v := range req.Header {
+		if len(v) == 0 {
+			continue
+		}
 		k = strings.ToLower(k)
 		if len(v) > 1 {
 			request.Headers[k] = strings.Join(v, ", ")
@@ -190,66 +663,158 @@ func richRequest(req *http.Request, status, duration string) *RequestData {
 		}
 	}
 
-	// From 方式参数
-	if err := req.ParseForm(); err == nil {
-		if form := req.Form; len(form) > 0 {
-			for k, v := range form {
-				if len(v) > 1 {
-					request.Param[k] = v
-				} else {
-					request.Param[k] = v[0]
-				}
-			}
-		}
+	if _, ok := request.Headers["cookie"]; ok {
+		delete(request.Headers, "cookie")
+		request.Cookies = af.parseCookies(req)
 	}
 
-	// json 方式参数
-	if strings.Contains(request.Headers["content-type"], "application/json") {
-		if tmpBody, err := ioutil.ReadAll(req.Body); err == nil {
-			req.Body = ioutil.NopCloser(bytes.NewReader(tmpBody))
+	if ua := request.Headers["user-agent"]; ua != "" && af.UserAgentParser != nil {
+		agent := af.UserAgentParser(ua)
+		request.UserAgent = ua
+		request.UABrowser = agent.Browser
+		request.UAOS = agent.OS
+		request.UADevice = agent.Device
+	}
 
-			body := make(map[string]interface{})
-			if err := jsoniter.NewDecoder(bytes.NewReader(tmpBody)).Decode(&body); err == nil {
-				for k, v := range body {
-					request.Param[k] = v
-				}
-			}
+	// From 方式参数，没有 body 的请求只有 query 参数，忽略缺少 body 的错误
+	if err := req.ParseForm(); err != nil && req.Body != nil {
+		problems = append(problems, "parse form: "+err.Error())
+	}
+	for k, v := range req.Form {
+		setParam(request, k, v)
+	}
+
+	// multipart 方式参数与上传文件
+	if strings.Contains(request.Headers["content-type"], "multipart/form-data") {
+		if err := multipartParams(req, request, af.MaxMultipartSize); err != nil {
+			problems = append(problems, "parse multipart: "+err.Error())
 		}
 	}
 
-	return request
+	// 按 content type 解析 body
+	if mediaType, parse := af.bodyParser(request.Headers["content-type"]); req.Body != nil && parse != nil && summaryOnly(parse) {
+		// 只记录摘要时不读取 body，避免缓冲大文件上传
+		_ = parse(af.encoder(), nil, request)
+	} else if req.Body != nil && parse != nil {
+		tmpBody, err := ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(tmpBody))
+		if req.ContentLength < 0 {
+			request.BytesIn = int64(len(tmpBody))
+		}
+		if err != nil {
+			problems = append(problems, "read body: "+err.Error())
+		}
+		if tmpBody, err = decompressBody(request.Headers["content-encoding"], tmpBody, af.MaxDecompressedSize); err != nil {
+			problems = append(problems, "decompress body: "+err.Error())
+		} else if err := parse(af.encoder(), tmpBody, request); err != nil {
+			problems = append(problems, "decode "+mediaType+" body: "+err.Error())
+		}
+	}
+
+	return request, parseErr
 }
 
-type stackTracer interface {
-	StackTrace() errors.StackTrace
+// extractError 提取错误信息与按格式化对象的配置过滤后的调用栈
+func (af *LogsV1Formatter) extractError(err error) (string, []string) {
+	max := af.MaxStackTrace
+	if max <= 0 {
+		max = MaxStackTrace
+	}
+	return err.Error(), filterStack(stackTrace(err), max, af.StackFrameFilters)
 }
 
-// stackTrace 从错误信息中获取调用栈信息
-func stackTrace(err error) []string {
-	if err, ok := err.(stackTracer); ok {
-		return strings.Split(
-			strings.ReplaceAll(
-				strings.TrimLeft(
-					fmt.Sprintf("%+v", err.StackTrace()),
-					"\n",
-				),
-				"\n\t",
-				" ",
-			),
-			"\n",
-		)
+// ErrorCoder 带有错误码的错误，日志中记录为 ctx 内错误的 code 字段
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// ErrorCategorizer 带有分类的错误，日志中记录为 ctx 内错误的 category 字段，用于按错误类别统计
+type ErrorCategorizer interface {
+	Category() string
+}
+
+// errorClass 沿错误链查找错误码与分类，外层的错误优先
+func errorClass(err error) (code, category string) {
+	var coder ErrorCoder
+	if errors.As(err, &coder) {
+		code = coder.ErrorCode()
 	}
+	var categorizer ErrorCategorizer
+	if errors.As(err, &categorizer) {
+		category = categorizer.Category()
+	}
+	return code, category
+}
+
+// maxErrorDepth 组合错误嵌套展开的最大层数
+const maxErrorDepth = 3
 
-	return emptyStack
+// errorFields ctx 内错误对象的内容，组合错误中的错误在 errors 中逐个展开
+func (af *LogsV1Formatter) errorFields(err error, depth int) logrus.Fields {
+	msg, trace := af.extractError(err)
+	if !af.RawStrings {
+		msg = escapeString(msg)
+	}
+	errData := logrus.Fields{
+		"msg": msg,
+	}
+	if len(trace) > 0 {
+		errData["trace"] = trace
+	}
+	if fp := af.fingerprint(err); fp != "" {
+		errData["fingerprint"] = fp
+	}
+	errCode, category := errorClass(err)
+	if !af.RawStrings {
+		errCode, category = escapeString(errCode), escapeString(category)
+	}
+	if errCode != "" {
+		errData["code"] = errCode
+	}
+	if category != "" {
+		errData["category"] = category
+	}
+	if errs := af.errorList(err, depth); errs != nil {
+		errData["errors"] = errs
+	}
+	return errData
 }
 
-func extractError(err error) (string, []string) {
-	var trace []string
-	if st := stackTrace(err); len(st) > 0 {
-		if len(st) >= MaxStackTrace {
-			st = st[:MaxStackTrace]
+// errorList 组合错误中各个错误的对象，不是组合错误或超过 maxErrorDepth 时返回 nil
+func (af *LogsV1Formatter) errorList(err error, depth int) []interface{} {
+	if depth >= maxErrorDepth {
+		return nil
+	}
+	errs := multiErrors(err)
+	if len(errs) == 0 {
+		return nil
+	}
+	list := make([]interface{}, 0, len(errs))
+	for _, e := range errs {
+		if e != nil {
+			list = append(list, af.errorFields(e, depth+1))
 		}
-		trace = st
 	}
-	return err.Error(), trace
+	return list
+}
+
+// multiErrors 返回组合错误中的各个错误，沿错误链查找第一个组合错误，
+// 支持 errors.Join 与多个 %w 的 fmt.Errorf、hashicorp/go-multierror 以及 go.uber.org/multierr
+func multiErrors(err error) []error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch m := e.(type) {
+		case interface{ Unwrap() []error }:
+			return m.Unwrap()
+		case interface{ WrappedErrors() []error }:
+			return m.WrappedErrors()
+		case interface{ Errors() []error }:
+			return m.Errors()
+		}
+	}
+	return nil
+}
+
+// StackTrace 返回错误的调用栈，格式与日志 ctx 内错误的 trace 字段一致，深度为 MaxStackTrace
+func StackTrace(err error) []string {
+	return filterStack(stackTrace(err), MaxStackTrace, nil)
 }
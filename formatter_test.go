@@ -1,16 +1,19 @@
 package logger
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -119,3 +122,235 @@ func TestFormatterOutput(t *testing.T) {
 		}
 	}
 }
+
+// TestFormatConcurrent 并发输出日志，校验对象池复用不会串用其他日志的数据，
+// 配合 go test -race 检查数据竞争
+func TestFormatConcurrent(t *testing.T) {
+	out := &lockedBuffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	f := NewFormatter("test", "test")
+	const workers, perWorker = 16, 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				fields := logrus.Fields{"worker": w, "seq": i}
+				if i%2 == 0 {
+					fields["request"] = &http.Request{
+						RemoteAddr: "1.2.3.4:1234",
+						Method:     http.MethodGet,
+						Header:     http.Header{},
+						URL:        &url.URL{Path: fmt.Sprintf("/w%d", w)},
+					}
+				}
+				l.WithFields(fields).Info(fmt.Sprintf("w%d-%d", w, i))
+
+				// 直接调用 Format，返回值必须独立于对象池
+				data, err := f.Format(&logrus.Entry{Time: time.Now(), Message: "direct", Data: logrus.Fields{"worker": w}})
+				if err != nil {
+					t.Errorf("Format() error, Expected=nil, Actual=%q", err.Error())
+					return
+				}
+				if v := jsoniter.Get(data, "ctx", "worker").ToInt(); v != w {
+					t.Errorf("Format() ctx.worker, Expected=%d, Actual=%d", w, v)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != workers*perWorker {
+		t.Fatalf("log lines, Expected=%d, Actual=%d", workers*perWorker, len(lines))
+	}
+	for _, line := range lines {
+		w := jsoniter.Get(line, "ctx", "worker").ToInt()
+		seq := jsoniter.Get(line, "ctx", "seq").ToInt()
+		if m := jsoniter.Get(line, "m").ToString(); m != fmt.Sprintf("w%d-%d", w, seq) {
+			t.Fatalf("output m, Expected=%q, Actual=%q", fmt.Sprintf("w%d-%d", w, seq), m)
+		}
+
+		hasRequest := jsoniter.Get(line, "request").LastError() == nil
+		if hasRequest != (seq%2 == 0) {
+			t.Fatalf("output request for seq %d, Expected=%v, Actual=%v", seq, seq%2 == 0, hasRequest)
+		}
+		if hasRequest {
+			if p := jsoniter.Get(line, "request", "path").ToString(); p != fmt.Sprintf("/w%d", w) {
+				t.Fatalf("output request.path, Expected=%q, Actual=%q", fmt.Sprintf("/w%d", w), p)
+			}
+		}
+	}
+}
+
+type lockedBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func TestStackTrace(t *testing.T) {
+	if trace := StackTrace(errors.New("plain")); len(trace) != 0 {
+		t.Fatalf("expect empty trace for plain error, got %v", trace)
+	}
+
+	trace := StackTrace(pkgerrors.New("with stack"))
+	if len(trace) == 0 || len(trace) > MaxStackTrace {
+		t.Fatalf("expect 1..%d frames, got %d", MaxStackTrace, len(trace))
+	}
+	if !strings.HasPrefix(trace[0], "github.com/lancer05/logger.TestStackTrace ") {
+		t.Fatalf("expect innermost frame to be TestStackTrace, got %q", trace[0])
+	}
+}
+
+func TestFormatMalformedRequest(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Request *http.Request
+		Path    string
+		Error   string
+	}{
+		{
+			Name:    "empty",
+			Request: &http.Request{Method: http.MethodGet},
+			Error:   "missing url",
+		},
+		{
+			Name:    "without header and body",
+			Request: &http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/api", RawQuery: "q=1"}},
+			Path:    "/api",
+		},
+		{
+			Name: "invalid query",
+			Request: &http.Request{
+				Method: http.MethodGet,
+				URL:    &url.URL{Path: "/api", RawQuery: "q=%zz"},
+				Body:   http.NoBody,
+			},
+			Path:  "/api",
+			Error: "parse form: ",
+		},
+		{
+			Name: "invalid json body",
+			Request: &http.Request{
+				Method: http.MethodPost,
+				URL:    &url.URL{Path: "/api"},
+				Header: http.Header{"Content-Type": {"application/json"}},
+				Body:   io.NopCloser(strings.NewReader(`{"a":`)),
+			},
+			Path:  "/api",
+			Error: "decode application/json body: ",
+		},
+		{
+			Name: "json without body",
+			Request: &http.Request{
+				Method: http.MethodPost,
+				URL:    &url.URL{Path: "/api"},
+				Header: http.Header{"Content-Type": {"application/json"}},
+			},
+			Path: "/api",
+		},
+	}
+
+	f := NewFormatter("test", "test")
+	for _, c := range cases {
+		data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": c.Request}})
+		if err != nil {
+			t.Fatalf("%s: Format() error, Expected=nil, Actual=%q", c.Name, err.Error())
+		}
+		if v := jsoniter.Get(data, "schema").ToString(); v != string(SchemaHTTPRequestV1) {
+			t.Fatalf("%s: output schema, Expected=%q, Actual=%q", c.Name, SchemaHTTPRequestV1, v)
+		}
+		if v := jsoniter.Get(data, "request", "path").ToString(); v != c.Path {
+			t.Fatalf("%s: output request.path, Expected=%q, Actual=%q", c.Name, c.Path, v)
+		}
+		v := jsoniter.Get(data, "request_parse_error").ToString()
+		if c.Error == "" && v != "" || !strings.HasPrefix(v, c.Error) {
+			t.Fatalf("%s: output request_parse_error, Expected=%q, Actual=%q", c.Name, c.Error, v)
+		}
+	}
+}
+
+type classifiedError struct{}
+
+func (classifiedError) Error() string     { return "quota exceeded" }
+func (classifiedError) ErrorCode() string { return "QUOTA_EXCEEDED" }
+func (classifiedError) Category() string  { return "client" }
+
+func TestFormatErrorClass(t *testing.T) {
+	entry := logrus.WithFields(logrus.Fields{
+		"cause": fmt.Errorf("charge: %w", classifiedError{}),
+		"plain": errors.New("plain"),
+	})
+	entry.Level = logrus.ErrorLevel
+
+	data, err := NewFormatter("test", "test").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+	if v := jsoniter.Get(data, "ctx", "cause", "code").ToString(); v != "QUOTA_EXCEEDED" {
+		t.Fatalf("Format() ctx.cause.code, Expected=QUOTA_EXCEEDED, Actual=%q", v)
+	}
+	if v := jsoniter.Get(data, "ctx", "cause", "category").ToString(); v != "client" {
+		t.Fatalf("Format() ctx.cause.category, Expected=client, Actual=%q", v)
+	}
+	if jsoniter.Get(data, "ctx", "plain", "code").LastError() == nil {
+		t.Fatalf("Format() ctx.plain.code should be omitted")
+	}
+}
+
+type joinedError []error
+
+func (e joinedError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e joinedError) Unwrap() []error { return e }
+
+func TestFormatMultiError(t *testing.T) {
+	joined := joinedError{errors.New("disk full"), fmt.Errorf("flush: %w", classifiedError{})}
+	entry := logrus.WithFields(logrus.Fields{
+		"cause": fmt.Errorf("close: %w", joined),
+		"error": joined,
+	})
+	entry.Level = logrus.ErrorLevel
+
+	data, err := NewFormatter("test", "test").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+	if n := jsoniter.Get(data, "ctx", "cause", "errors").Size(); n != 2 {
+		t.Fatalf("Format() ctx.cause.errors size, Expected=2, Actual=%d", n)
+	}
+	if v := jsoniter.Get(data, "ctx", "cause", "errors", 0, "msg").ToString(); v != "disk full" {
+		t.Fatalf("Format() ctx.cause.errors[0].msg, Expected=disk full, Actual=%q", v)
+	}
+	if v := jsoniter.Get(data, "ctx", "cause", "errors", 1, "code").ToString(); v != "QUOTA_EXCEEDED" {
+		t.Fatalf("Format() ctx.cause.errors[1].code, Expected=QUOTA_EXCEEDED, Actual=%q", v)
+	}
+	if v := jsoniter.Get(data, "errors", 1, "msg").ToString(); v != "flush: quota exceeded" {
+		t.Fatalf("Format() errors[1].msg, Expected=flush: quota exceeded, Actual=%q", v)
+	}
+
+	data, err = NewV2Formatter("test", "test").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+	if v := jsoniter.Get(data, "err", "errors", 0, "msg").ToString(); v != "disk full" {
+		t.Fatalf("Format() v2 err.errors[0].msg, Expected=disk full, Actual=%q", v)
+	}
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Forwarder 将日志转发到 Sentry、webhook 等外部服务的 hook 使用，按格式化对象的配置生成 LogsV1 内容，
+// 转发的内容与写入日志的内容一样经过脱敏
+type Forwarder struct {
+	af *LogsV1Formatter
+}
+
+// NewForwarder 使用 formatters 中第一个本包创建的格式化对象，包括 logfmt、msgpack 等嵌入 LogsV1Formatter 的格式化对象；
+// 都不是时返回 nil，此时无法脱敏，调用方不应转发 ctx 与错误内容
+func NewForwarder(formatters ...logrus.Formatter) *Forwarder {
+	for _, f := range formatters {
+		if fb, ok := f.(formatterBase); ok {
+			return &Forwarder{af: fb.base()}
+		}
+	}
+	return nil
+}
+
+// Decode 生成 entry 的 LogsV1 JSON 并解码到 v，不受格式化对象的输出格式与 DualEmit 影响
+func (fw *Forwarder) Decode(entry *logrus.Entry, v interface{}) error {
+	dup := entry.Dup()
+	dup.Level = entry.Level
+	dup.Message = entry.Message
+	dup.Caller = entry.Caller
+
+	b := &bytes.Buffer{}
+	data := acquireLogsV1()
+	fw.af.collect(dup, data)
+	enc := fw.af.encoder()
+	err := encodeSafely(func() error { return writeLogsV1(b, enc, data) })
+	schema := data.Schema
+	releaseLogsV1(data)
+	if err != nil {
+		return wrapf(err, "json encode %s log", schema)
+	}
+	return json.Unmarshal(b.Bytes(), v)
+}
+
+// RedactString 使用格式化对象的脱敏规则处理 s，用于转发 Error() 等未经过格式化的文本，审计模式下返回原文
+func (fw *Forwarder) RedactString(s string) string {
+	rd := fw.af.currentRedactor()
+	if rd == nil {
+		return s
+	}
+	return rd.redactString("", s, func(path, rule string) {})
+}
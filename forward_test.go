@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestForwarder(t *testing.T) {
+	f := NewLogfmtFormatter("test", "test", WithRedaction(testRedactRules...))
+	f.DualEmit = true
+	fw := NewForwarder(nil, &logrus.TextFormatter{}, f)
+	if fw == nil {
+		t.Fatalf("NewForwarder() error, Expected=forwarder, Actual=nil")
+	}
+
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Level:   logrus.ErrorLevel,
+		Message: "paid with 4111111111111111",
+		Data:    logrus.Fields{"password": "hunter2", "order": 1},
+	}
+	v := map[string]interface{}{}
+	if err := fw.Decode(entry, &v); err != nil {
+		t.Fatalf("Decode() error, Expected=nil, Actual=%q", err.Error())
+	}
+	p, _ := json.Marshal(v)
+
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaGeneralLogsV1)},
+		{path: []interface{}{"m"}, expected: "paid with [CARD]"},
+		{path: []interface{}{"ctx", "password"}, expected: DefaultRedactReplacement},
+		{path: []interface{}{"ctx", "order"}, expected: "1"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(p, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+
+	if s := fw.RedactString("card 4111111111111111"); s != "card [CARD]" {
+		t.Fatalf("RedactString() error, Expected=%q, Actual=%q", "card [CARD]", s)
+	}
+	if fw := NewForwarder(&logrus.JSONFormatter{}); fw != nil {
+		t.Fatalf("NewForwarder() error, Expected=nil, Actual=%v", fw)
+	}
+}
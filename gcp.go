@@ -0,0 +1,245 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Formatter = (*GCPFormatter)(nil)
+
+// Cloud Logging 从结构化日志中识别的特殊字段
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpLabelsKey         = "logging.googleapis.com/labels"
+)
+
+// GCPFormatter 按 Google Cloud Logging 结构化日志的格式输出 JSON，用于 GKE、Cloud Run 等写入标准输出的环境，
+// 日志级别记录为 severity，请求日志输出 httpRequest，ctx 中的 trace_id、span_id 与调用位置
+// 分别记录为 logging.googleapis.com/trace、spanId 与 sourceLocation，其他内容与 v2 相同
+type GCPFormatter struct {
+	*LogsV1Formatter
+	// trace 字段使用的项目ID，为空时只记录 trace ID
+	ProjectID string
+}
+
+// NewGCPFormatter 创建 Cloud Logging 格式化对象，可选配置与 NewFormatter 相同，
+// 需要调用位置时开启 logrus 的 ReportCaller
+func NewGCPFormatter(service, env, projectID string, opts ...Option) *GCPFormatter {
+	return &GCPFormatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+		ProjectID:       projectID,
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (gf *GCPFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	gf.collect(entry, data)
+	enc := gf.encoder()
+	err := encodeSafely(func() error { return gf.write(b, enc, entry, data, gf.logsV2(data)) })
+	schema := data.Schema
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "gcp encode %s log", schema)
+	}
+	return b.Bytes(), nil
+}
+
+func (gf *GCPFormatter) write(b *bytes.Buffer, enc Encoder, entry *logrus.Entry, data *LogsV1, v *LogsV2) error {
+	traceID, spanID := v.TraceID, v.SpanID
+	if traceID == "" && data.Request != nil {
+		traceID, spanID = parseCloudTraceContext(data.Request.Headers["x-cloud-trace-context"])
+	}
+	if traceID != "" && gf.ProjectID != "" {
+		traceID = "projects/" + gf.ProjectID + "/traces/" + traceID
+	}
+
+	b.WriteString(`{"severity":`)
+	writeString(b, gcpSeverity(entry.Level))
+	pairs := []struct {
+		key, value string
+		omit       bool
+	}{
+		{key: "message", value: v.Message},
+		{key: "time", value: entry.Time.UTC().Format(time.RFC3339Nano)},
+		{key: gcpTraceKey, value: traceID, omit: traceID == ""},
+		{key: gcpSpanIDKey, value: spanID, omit: spanID == ""},
+		{key: "schema", value: v.Schema},
+		{key: "kind", value: v.Kind, omit: v.Kind == ""},
+		{key: "s", value: v.Service},
+		{key: "c", value: v.Channel},
+		{key: "i", value: v.ID},
+		{key: "request_id", value: v.RequestID, omit: v.RequestID == ""},
+		{key: "tenant", value: v.Tenant, omit: v.Tenant == ""},
+		{key: "session_id", value: v.SessionID, omit: v.SessionID == ""},
+		{key: "e", value: v.Environment},
+		{key: "u", value: v.User},
+		{key: "code", value: v.Code, omit: v.Code == ""},
+		{key: "retention", value: v.Retention, omit: v.Retention == ""},
+		{key: "request_parse_error", value: v.RequestParseError, omit: v.RequestParseError == ""},
+	}
+	for _, p := range pairs {
+		if p.omit {
+			continue
+		}
+		b.WriteByte(',')
+		writeString(b, p.key)
+		b.WriteByte(':')
+		writeString(b, p.value)
+	}
+
+	service := map[string]interface{}{"service": v.Service}
+	if data.Build != nil && data.Build.Version != "" {
+		service["version"] = data.Build.Version
+	}
+	if err := writeKeyValue(b, enc, "serviceContext", service); err != nil {
+		return err
+	}
+	if err := writeKeyValue(b, enc, gcpLabelsKey, map[string]interface{}{"env": v.Environment, "channel": v.Channel}); err != nil {
+		return err
+	}
+
+	ctx := v.Context
+	if location, ok := gcpSourceLocation(ctx); ok {
+		ctx = copyFields(ctx)
+		delete(ctx, logrus.FieldKeyFile)
+		delete(ctx, logrus.FieldKeyFunc)
+		if err := writeKeyValue(b, enc, gcpSourceLocationKey, location); err != nil {
+			return err
+		}
+	}
+	if data.Request != nil {
+		if err := writeKeyValue(b, enc, "httpRequest", gcpHTTPRequest(data)); err != nil {
+			return err
+		}
+	}
+	if v.Host != nil {
+		if err := writeKeyValue(b, enc, "host", v.Host); err != nil {
+			return err
+		}
+	}
+	if v.Build != nil {
+		if err := writeKeyValue(b, enc, "build", v.Build); err != nil {
+			return err
+		}
+	}
+	b.WriteString(`,"ctx":`)
+	if err := writeFields(b, enc, ctx); err != nil {
+		return err
+	}
+	if v.Err != nil {
+		if err := writeKeyValue(b, enc, "err", v.Err); err != nil {
+			return err
+		}
+	}
+	if err := writeSections(b, enc, v.Sections); err != nil {
+		return err
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// gcpSeverity 日志级别对应的 Cloud Logging severity
+func gcpSeverity(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	default:
+		return "ALERT"
+	}
+}
+
+// gcpSourceLocation 将 ctx 中记录的调用位置转换为 sourceLocation，line 按 LogEntrySourceLocation 的格式为字符串
+func gcpSourceLocation(ctx map[string]interface{}) (map[string]interface{}, bool) {
+	file, ok := ctx[logrus.FieldKeyFile].(string)
+	if !ok {
+		return nil, false
+	}
+	location := map[string]interface{}{"file": file}
+	if i := strings.LastIndexByte(file, ':'); i > 0 {
+		if _, err := strconv.Atoi(file[i+1:]); err == nil {
+			location["file"] = file[:i]
+			location["line"] = file[i+1:]
+		}
+	}
+	if fn, ok := ctx[logrus.FieldKeyFunc].(string); ok && fn != "" {
+		location["function"] = fn
+	}
+	return location, true
+}
+
+// gcpHTTPRequest 请求日志对应的 HttpRequest，int64 的字段按 JSON 映射的规则为字符串，latency 为秒数加 s
+func gcpHTTPRequest(data *LogsV1) map[string]interface{} {
+	r := data.Request
+	req := map[string]interface{}{
+		"requestMethod": r.Method,
+		"requestUrl":    r.Path,
+		"requestSize":   strconv.FormatInt(r.BytesIn, 10),
+		"remoteIp":      r.IP,
+	}
+	if status, err := strconv.Atoi(r.Status); err == nil {
+		req["status"] = status
+	}
+	if data.Response != nil {
+		req["responseSize"] = strconv.FormatInt(data.Response.BytesOut, 10)
+	}
+	if r.Proto != "" {
+		req["protocol"] = r.Proto
+	}
+	if ua := r.Headers["user-agent"]; ua != "" {
+		req["userAgent"] = ua
+	} else if r.UserAgent != "" {
+		req["userAgent"] = r.UserAgent
+	}
+	if referer := r.Headers["referer"]; referer != "" {
+		req["referer"] = referer
+	}
+	if d, err := time.ParseDuration(r.Duration); err == nil {
+		req["latency"] = strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+	}
+	return req
+}
+
+// parseCloudTraceContext 解析 X-Cloud-Trace-Context 请求头，格式为 TRACE_ID/SPAN_ID;o=OPTIONS，
+// 其中的 SPAN_ID 为十进制，转换为 Cloud Logging 使用的 16 位十六进制
+func parseCloudTraceContext(h string) (traceID, spanID string) {
+	if i := strings.IndexByte(h, ';'); i >= 0 {
+		h = h[:i]
+	}
+	traceID = h
+	if i := strings.IndexByte(h, '/'); i >= 0 {
+		traceID = h[:i]
+		if n, err := strconv.ParseUint(h[i+1:], 10, 64); err == nil && n != 0 {
+			spanID = strconv.FormatUint(n, 16)
+			spanID = strings.Repeat("0", 16-len(spanID)) + spanID
+		}
+	}
+	if !isHexID(traceID, 32) {
+		return "", ""
+	}
+	return traceID, spanID
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestGCPFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetFormatter(NewGCPFormatter("order", "prod", "my-project"))
+
+	l.ReportCaller = true
+	l.WithFields(logrus.Fields{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"order_id": 42,
+	}).Warn("charge failed")
+	l.ReportCaller = false
+
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.RemoteAddr = "1.2.3.4:1234"
+	req.Header.Set("User-Agent", "curl/7.79")
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("log lines, Expected=2, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"severity"}, expected: "WARNING"},
+		{line: 0, path: []interface{}{"message"}, expected: "charge failed"},
+		{line: 0, path: []interface{}{gcpTraceKey}, expected: "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736"},
+		{line: 0, path: []interface{}{gcpSpanIDKey}, expected: "00f067aa0ba902b7"},
+		{line: 0, path: []interface{}{gcpSourceLocationKey, "function"}, expected: "github.com/lancer05/logger.TestGCPFormatter"},
+		{line: 0, path: []interface{}{"serviceContext", "service"}, expected: "order"},
+		{line: 0, path: []interface{}{gcpLabelsKey, "env"}, expected: "prod"},
+		{line: 0, path: []interface{}{"ctx", "order_id"}, expected: "42"},
+		{line: 0, path: []interface{}{"ctx", "file"}, expected: ""},
+		{line: 1, path: []interface{}{"severity"}, expected: "INFO"},
+		{line: 1, path: []interface{}{gcpTraceKey}, expected: "projects/my-project/traces/105445aa7843bc8bf206b12000100000"},
+		{line: 1, path: []interface{}{gcpSpanIDKey}, expected: "0000000000000001"},
+		{line: 1, path: []interface{}{"httpRequest", "requestMethod"}, expected: "GET"},
+		{line: 1, path: []interface{}{"httpRequest", "requestUrl"}, expected: "/orders"},
+		{line: 1, path: []interface{}{"httpRequest", "status"}, expected: "200"},
+		{line: 1, path: []interface{}{"httpRequest", "responseSize"}, expected: "5"},
+		{line: 1, path: []interface{}{"httpRequest", "userAgent"}, expected: "curl/7.79"},
+		{line: 1, path: []interface{}{"httpRequest", "remoteIp"}, expected: "1.2.3.4"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+
+	if v := jsoniter.Get(lines[0], gcpSourceLocationKey, "file").ToString(); !strings.HasSuffix(v, "gcp_test.go") {
+		t.Fatalf("sourceLocation file, Expected=*gcp_test.go, Actual=%q", v)
+	}
+	if v := jsoniter.Get(lines[0], gcpSourceLocationKey, "line").GetInterface(); v == nil || v == "" {
+		t.Fatalf("sourceLocation line, Expected=non-empty, Actual=%v", v)
+	}
+	if v := jsoniter.Get(lines[1], "httpRequest", "latency").ToString(); !strings.HasSuffix(v, "s") {
+		t.Fatalf("httpRequest latency, Expected=*s, Actual=%q", v)
+	}
+}
+
+func TestGCPSeverity(t *testing.T) {
+	cases := []struct {
+		level    logrus.Level
+		expected string
+	}{
+		{level: logrus.TraceLevel, expected: "DEBUG"},
+		{level: logrus.InfoLevel, expected: "INFO"},
+		{level: logrus.ErrorLevel, expected: "ERROR"},
+		{level: logrus.FatalLevel, expected: "CRITICAL"},
+		{level: logrus.PanicLevel, expected: "ALERT"},
+	}
+	for _, c := range cases {
+		if v := gcpSeverity(c.level); v != c.expected {
+			t.Fatalf("gcpSeverity(%s), Expected=%q, Actual=%q", c.level, c.expected, v)
+		}
+	}
+}
@@ -3,7 +3,20 @@ module github.com/lancer05/logger
 go 1.16
 
 require (
-	github.com/json-iterator/go v1.1.10
+	github.com/99designs/gqlgen v0.17.2
+	github.com/go-chi/chi/v5 v5.0.7
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/gofiber/fiber/v2 v2.40.1
+	github.com/json-iterator/go v1.1.12
+	github.com/labstack/echo/v4 v4.9.0
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
+	github.com/spf13/cobra v1.5.0
+	github.com/spf13/pflag v1.0.5
+	github.com/valyala/fasthttp v1.41.0
+	go.mongodb.org/mongo-driver v1.10.3
+	go.uber.org/zap v1.21.0
+	google.golang.org/grpc v1.47.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithGoroutineID 在 ctx.goroutine_id 记录输出日志的 goroutine ID，count 为 true 时同时在
+// ctx.goroutines 记录当前的 goroutine 数量，用于排查并发问题
+//
+// goroutine ID 在格式化时读取，只有在输出日志的 goroutine 中格式化时才准确，
+// 因此不作为 Enricher 执行，不受 WithEnrichDeadline 影响
+func WithGoroutineID(count bool) Option {
+	return func(af *LogsV1Formatter) {
+		af.GoroutineID = true
+		af.GoroutineCount = count
+	}
+}
+
+func (af *LogsV1Formatter) goroutineFields(context logrus.Fields) {
+	if af.GoroutineID {
+		context["goroutine_id"] = goroutineID()
+	}
+	if af.GoroutineCount {
+		context["goroutines"] = runtime.NumGoroutine()
+	}
+}
+
+// goroutineID 从 runtime.Stack 的第一行 "goroutine 123 [running]:" 解析当前 goroutine 的 ID
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestWithGoroutineID(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test", WithGoroutineID(true))
+	l.SetOutput(out)
+
+	l.Info("here")
+	first := jsoniter.Get(out.Bytes(), "ctx", "goroutine_id").ToUint64()
+	if first == 0 || first != goroutineID() {
+		t.Fatalf("output ctx.goroutine_id, Expected=%d, Actual=%d", goroutineID(), first)
+	}
+	if n := jsoniter.Get(out.Bytes(), "ctx", "goroutines").ToInt(); n < 1 {
+		t.Fatalf("output ctx.goroutines, Expected>=1, Actual=%d", n)
+	}
+
+	out.Reset()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("there")
+	}()
+	<-done
+	if other := jsoniter.Get(out.Bytes(), "ctx", "goroutine_id").ToUint64(); other == first {
+		t.Fatalf("output ctx.goroutine_id should differ between goroutines, Actual=%d", other)
+	}
+}
@@ -0,0 +1,97 @@
+// Package gqllogger 提供 gqlgen 的扩展，以 graphql.request.v1 规范记录每个 GraphQL 操作
+//
+// 所有操作都通过同一个 /graphql 路径处理，访问日志无法区分，扩展按操作记录操作名、类型、
+// 查询语句的哈希、变量、错误数与耗时
+//
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
+//	srv.Use(gqllogger.Extension(l))
+package gqllogger
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Option 扩展的可选配置
+type Option func(*config)
+
+type config struct {
+	withoutVariables bool
+}
+
+// WithoutVariables 不记录操作的变量，变量中的敏感字段也可以通过格式化对象的脱敏规则处理，
+// 路径为 graphql.variables.<name>
+func WithoutVariables() Option {
+	return func(c *config) {
+		c.withoutVariables = true
+	}
+}
+
+// Extension 创建记录 GraphQL 操作的 gqlgen 扩展，有错误的操作记录为 warning
+func Extension(l *logrus.Logger, opts ...Option) graphql.HandlerExtension {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return &extension{logger: l, config: c}
+}
+
+type extension struct {
+	logger *logrus.Logger
+	config *config
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &extension{}
+
+// ExtensionName implements graphql.HandlerExtension interface
+func (e *extension) ExtensionName() string {
+	return "Logger"
+}
+
+// Validate implements graphql.HandlerExtension interface
+func (e *extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor interface
+func (e *extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	// 请求 body 无法解析时没有操作上下文，订阅结束时响应为空
+	if resp == nil || !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	data := &logger.GraphQLData{
+		Operation: oc.OperationName,
+		QueryHash: QueryHash(oc.RawQuery),
+		Errors:    len(resp.Errors),
+		Duration:  time.Since(oc.Stats.OperationStart).String(),
+	}
+	if oc.Operation != nil {
+		data.Type = string(oc.Operation.Operation)
+		if data.Operation == "" {
+			data.Operation = oc.Operation.Name
+		}
+	}
+	if !e.config.withoutVariables && len(oc.Variables) > 0 {
+		data.Variables = oc.Variables
+	}
+
+	level := logrus.InfoLevel
+	entry := e.logger.WithContext(ctx).WithField("graphql", data)
+	if len(resp.Errors) > 0 {
+		level = logrus.WarnLevel
+		// 错误数记录在 graphql.errors，err 记录第一个错误
+		entry = entry.WithField("error", resp.Errors[0])
+	}
+	entry.Log(level, "graphql request")
+	return resp
+}
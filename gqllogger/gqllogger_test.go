@@ -0,0 +1,89 @@
+package gqllogger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/testserver"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Expect string
+	}{
+		{
+			Input:  "query Find {\n  find(id: 42) { name }\n}",
+			Expect: "query Find{find(id:?){name}}",
+		},
+		{
+			Input:  "# comment\nquery { user(name: \"frank\", tags: [\"a\", \"b\"]) { id, name } }",
+			Expect: "query{user(name:? tags:[? ?]){id name}}",
+		},
+		{
+			Input:  "mutation($id: ID! = 1) { delete(id: $id, note: \"\"\"multi\nline\"\"\") }",
+			Expect: "mutation($id:ID!=?){delete(id:$id note:?)}",
+		},
+	}
+
+	for idx, each := range cases {
+		if actual := Normalize(each.Input); actual != each.Expect {
+			t.Fatalf("%d: expect: %s, got: %s", idx, each.Expect, actual)
+		}
+	}
+
+	if QueryHash("{ find(id: 1) }") != QueryHash("{find(id:2)}") {
+		t.Fatalf("QueryHash() should ignore literals")
+	}
+}
+
+func TestExtension(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test", logger.WithRedaction(logger.RedactRule{ID: "id", Keys: []string{"id"}}))
+	l.SetOutput(out)
+
+	srv := testserver.New()
+	srv.AddTransport(transport.POST{})
+	srv.Use(Extension(l))
+
+	cases := []struct {
+		Body     string
+		Logged   bool
+		Path     []interface{}
+		Expected string
+	}{
+		{Body: `{"query":"query Named { name }"}`, Logged: true, Path: []interface{}{"schema"}, Expected: string(logger.SchemaGraphQLRequestV1)},
+		{Body: `{"query":"query Named { name }"}`, Logged: true, Path: []interface{}{"graphql", "operation"}, Expected: "Named"},
+		{Body: `{"query":"query Named { name }"}`, Logged: true, Path: []interface{}{"graphql", "type"}, Expected: "query"},
+		{Body: `{"query":"query Named { name }"}`, Logged: true, Path: []interface{}{"graphql", "query_hash"}, Expected: QueryHash("query Named{name}")},
+		{Body: `{"query":"query Named { name }"}`, Logged: true, Path: []interface{}{"graphql", "errors"}, Expected: "0"},
+		{Body: `{"query":"query($id: Int!) { find(id: $id) }","variables":{"id":1}}`, Logged: true, Path: []interface{}{"graphql", "variables", "id"}, Expected: logger.DefaultRedactReplacement},
+		{Body: `{"query":"mutation { name }"}`, Logged: true, Path: []interface{}{"l"}, Expected: "warning"},
+		{Body: `{"query":"mutation { name }"}`, Logged: true, Path: []interface{}{"graphql", "errors"}, Expected: "1"},
+		{Body: `{"query":"mutation { name }"}`, Logged: true, Path: []interface{}{"err"}, Expected: "input: mutations are not supported"},
+		{Body: `{"query":"query {"}`, Logged: true, Path: []interface{}{"graphql", "errors"}, Expected: "1"},
+		{Body: `not json`, Logged: false},
+	}
+	for _, c := range cases {
+		out.Reset()
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(c.Body))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+
+		if logged := out.Len() > 0; logged != c.Logged {
+			t.Fatalf("%s logged, Expected=%v, Actual=%v", c.Body, c.Logged, logged)
+		}
+		if !c.Logged {
+			continue
+		}
+		if v := jsoniter.Get(out.Bytes(), c.Path...).ToString(); v != c.Expected {
+			t.Fatalf(`%s output %q, Expected=%q, Actual=%q`, c.Body, c.Path, c.Expected, v)
+		}
+	}
+}
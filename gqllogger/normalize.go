@@ -0,0 +1,35 @@
+package gqllogger
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var (
+	comment       = regexp.MustCompile(`#[^\n\r]*`)
+	blockString   = regexp.MustCompile(`"""(?:[^"\\]|\\.|"[^"]|""[^"])*"""`)
+	stringLiteral = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"`)
+	numberLiteral = regexp.MustCompile(`(^|[^\w$])-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b`)
+	whitespace    = regexp.MustCompile(`[\s,]+`)
+	punctuation   = regexp.MustCompile(` ?([{}()\[\]:=!|@]) ?`)
+)
+
+// Normalize 去掉 GraphQL 查询语句中的注释，将字面量统一替换为 ?，并压缩空白，
+// 使参数不同但结构相同的操作得到一致的结果
+func Normalize(query string) string {
+	q := blockString.ReplaceAllString(query, "?")
+	q = stringLiteral.ReplaceAllString(q, "?")
+	q = comment.ReplaceAllString(q, "")
+	q = numberLiteral.ReplaceAllString(q, "${1}?")
+	q = whitespace.ReplaceAllString(q, " ")
+	q = punctuation.ReplaceAllString(q, "$1")
+	return strings.TrimSpace(q)
+}
+
+// QueryHash 获得 GraphQL 查询语句的哈希，用于聚合统计同一类操作
+func QueryHash(query string) string {
+	sum := sha1.Sum([]byte(Normalize(query)))
+	return hex.EncodeToString(sum[:8])
+}
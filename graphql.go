@@ -0,0 +1,24 @@
+package logger
+
+// GraphQLData GraphQL 操作相关的参数
+type GraphQLData struct {
+	// 操作名，匿名操作为空
+	Operation string `json:"operation"`
+	// 操作类型，query、mutation 或 subscription
+	Type string `json:"type"`
+	// 去掉字面量与空白后的查询语句的哈希，用于聚合统计同一类操作
+	QueryHash string                 `json:"query_hash"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	// 响应中的错误数
+	Errors   int    `json:"errors"`
+	Duration string `json:"duration"`
+}
+
+func init() {
+	registerBuiltinSchema(SchemaDefinition{
+		Name:      SchemaGraphQLRequestV1,
+		Field:     "graphql",
+		Type:      GraphQLData{},
+		Sensitive: []string{"variables"},
+	})
+}
@@ -0,0 +1,27 @@
+package logger
+
+// GRPCCallData gRPC 调用相关的参数
+type GRPCCallData struct {
+	// 完整的方法名，如 /helloworld.Greeter/SayHello
+	Method   string `json:"method"`
+	Target   string `json:"target,omitempty"`
+	Peer     string `json:"peer,omitempty"`
+	Code     string `json:"code"`
+	Duration string `json:"duration"`
+	Stream   bool   `json:"stream,omitempty"`
+	// 发出的 metadata，按配置脱敏
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// 按配置记录的请求与响应内容，超出大小上限时截断字符串并丢弃超出的字段
+	Request          interface{} `json:"request,omitempty"`
+	Response         interface{} `json:"response,omitempty"`
+	PayloadTruncated bool        `json:"payload_truncated,omitempty"`
+}
+
+func init() {
+	registerBuiltinSchema(SchemaDefinition{
+		Name:      SchemaGRPCClientV1,
+		Field:     "grpc",
+		Type:      GRPCCallData{},
+		Sensitive: []string{"metadata", "request", "response"},
+	})
+}
@@ -0,0 +1,360 @@
+// Package grpclogger 提供 gRPC 客户端拦截器，以 grpc.client.v1 规范记录对外的 gRPC 调用
+//
+//	conn, err := grpc.Dial(target,
+//		grpc.WithChainUnaryInterceptor(grpclogger.UnaryClientInterceptor(l)),
+//		grpc.WithChainStreamInterceptor(grpclogger.StreamClientInterceptor(l)),
+//	)
+package grpclogger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultRedactedMetadata 默认脱敏的 metadata 键
+var DefaultRedactedMetadata = []string{"authorization", "cookie", "x-api-key"}
+
+// DefaultMaxPayloadBytes 记录请求与响应内容时单个消息默认的最大字节数
+const DefaultMaxPayloadBytes = 4096
+
+// Option 拦截器的可选配置
+type Option func(*config)
+
+type config struct {
+	codeLevel    func(code codes.Code) logrus.Level
+	redacted     map[string]bool
+	withMetadata bool
+	payloads     []string
+	maxPayload   int
+}
+
+// WithCodeLevel 设置状态码对应的日志级别，默认为 DefaultCodeLevel
+func WithCodeLevel(fn func(code codes.Code) logrus.Level) Option {
+	return func(c *config) {
+		c.codeLevel = fn
+	}
+}
+
+// WithRedactedMetadata 在 DefaultRedactedMetadata 之外追加需要脱敏的 metadata 键，不区分大小写
+func WithRedactedMetadata(keys ...string) Option {
+	return func(c *config) {
+		for _, k := range keys {
+			c.redacted[strings.ToLower(k)] = true
+		}
+	}
+}
+
+// WithoutMetadata 不记录发出的 metadata
+func WithoutMetadata() Option {
+	return func(c *config) {
+		c.withMetadata = false
+	}
+}
+
+// WithPayloads 记录 methods 的请求与响应内容，用于在测试环境排查内部接口，
+// 方法名为完整的方法名，以 / 结尾时匹配整个服务，如 /helloworld.Greeter/
+//
+// 内容以 protojson 格式输出到 grpc.request 与 grpc.response，流式调用只记录第一条发送与接收的消息，
+// 可以通过格式化对象的脱敏规则按字段名脱敏，如 logger.WithRedaction(logger.RedactRule{Keys: []string{"password"}})
+func WithPayloads(methods ...string) Option {
+	return func(c *config) {
+		c.payloads = append(c.payloads, methods...)
+	}
+}
+
+// WithMaxPayloadBytes 设置单个消息的最大字节数，超出时截断字符串并丢弃超出上限的字段，
+// 内容仍然是对象，脱敏规则照常生效，同时记录 grpc.payload_truncated，默认 DefaultMaxPayloadBytes
+func WithMaxPayloadBytes(n int) Option {
+	return func(c *config) {
+		c.maxPayload = n
+	}
+}
+
+// DefaultCodeLevel 默认的状态码日志级别：成功为 info，调用方引起的错误为 warning，其他为 error
+func DefaultCodeLevel(code codes.Code) logrus.Level {
+	switch code {
+	case codes.OK:
+		return logrus.InfoLevel
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.ResourceExhausted, codes.Aborted:
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		codeLevel:    DefaultCodeLevel,
+		redacted:     map[string]bool{},
+		withMetadata: true,
+		maxPayload:   DefaultMaxPayloadBytes,
+	}
+	for _, k := range DefaultRedactedMetadata {
+		c.redacted[k] = true
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// UnaryClientInterceptor 记录每次一元调用的方法、对端、状态码与耗时
+func UnaryClientInterceptor(l *logrus.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+		p := &peer.Peer{}
+		err := invoker(ctx, method, req, reply, cc, append(callOpts, grpc.Peer(p))...)
+		data := c.callData(ctx, method, cc, false)
+		if c.capture(method) {
+			data.Request = c.payload(data, req)
+			if err == nil {
+				data.Response = c.payload(data, reply)
+			}
+		}
+		c.log(l, ctx, data, p, start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor 在流结束时记录方法、对端、状态码与整个流的耗时，
+// 没有读完就放弃的流在 ctx 取消或超时时记录
+func StreamClientInterceptor(l *logrus.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		p := &peer.Peer{}
+		data := c.callData(ctx, method, cc, true)
+		stream, err := streamer(ctx, desc, cc, method, append(callOpts, grpc.Peer(p))...)
+		if err != nil {
+			c.log(l, ctx, data, p, start, err)
+			return nil, err
+		}
+		s := &loggedStream{
+			ClientStream:  stream,
+			serverStreams: desc.ServerStreams,
+			peer:          p,
+			done:          make(chan struct{}),
+		}
+		s.finish = func(p *peer.Peer, err error) {
+			close(s.done)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			c.log(l, ctx, data, p, start, err)
+		}
+		if c.capture(method) {
+			s.config = c
+			s.data = data
+		}
+		go s.watch(ctx)
+		return s, nil
+	}
+}
+
+func (c *config) callData(ctx context.Context, method string, cc *grpc.ClientConn, stream bool) *logger.GRPCCallData {
+	data := &logger.GRPCCallData{
+		Method: method,
+		Stream: stream,
+	}
+	if cc != nil {
+		data.Target = cc.Target()
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && c.withMetadata && len(md) > 0 {
+		data.Metadata = make(map[string]string, len(md))
+		for k, v := range md {
+			if c.redacted[k] {
+				data.Metadata[k] = logger.DefaultRedactReplacement
+			} else {
+				data.Metadata[k] = strings.Join(v, ", ")
+			}
+		}
+	}
+	return data
+}
+
+// capture 判断是否记录方法的请求与响应内容
+func (c *config) capture(method string) bool {
+	for _, m := range c.payloads {
+		if m == method || (strings.HasSuffix(m, "/") && strings.HasPrefix(method, m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// payload 将消息转换为 protojson 格式的内容，超出大小上限时截断并标记 data.PayloadTruncated
+func (c *config) payload(data *logger.GRPCCallData, msg interface{}) interface{} {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil
+	}
+	if c.maxPayload > 0 && len(b) > c.maxPayload {
+		data.PayloadTruncated = true
+		budget := c.maxPayload
+		return truncate(v, &budget)
+	}
+	return v
+}
+
+// truncate 按 JSON 编码的字节数截断内容，budget 为剩余的字节数：
+// 对象按字段名顺序、数组按顺序保留，超出上限之后的字段与元素丢弃，字符串截断到剩余的字节数
+func truncate(v interface{}, budget *int) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		m := make(map[string]interface{}, len(val))
+		for _, k := range keys {
+			// 引号、冒号与逗号
+			cost := len(k) + 4
+			if *budget < cost {
+				break
+			}
+			*budget -= cost
+			m[k] = truncate(val[k], budget)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			if *budget <= 0 {
+				break
+			}
+			*budget--
+			items = append(items, truncate(item, budget))
+		}
+		return items
+	case string:
+		n := len(val)
+		if n > *budget {
+			n = *budget
+			if n < 0 {
+				n = 0
+			}
+			for n > 0 && !utf8.RuneStart(val[n]) {
+				n--
+			}
+		}
+		*budget -= n + 2
+		return val[:n]
+	default:
+		b, _ := json.Marshal(val)
+		*budget -= len(b)
+		return val
+	}
+}
+
+func (c *config) log(l *logrus.Logger, ctx context.Context, data *logger.GRPCCallData, p *peer.Peer, start time.Time, err error) {
+	code := status.Code(err)
+	level := c.codeLevel(code)
+	if !l.IsLevelEnabled(level) {
+		return
+	}
+
+	data.Code = code.String()
+	data.Duration = time.Since(start).String()
+	if p.Addr != nil {
+		data.Peer = p.Addr.String()
+	}
+
+	entry := l.WithContext(ctx).WithField("grpc", data)
+	if err != nil {
+		entry = entry.WithField("error", err)
+	}
+	entry.Log(level, "grpc client call")
+}
+
+// loggedStream 在流结束时记录一次日志
+type loggedStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	once          sync.Once
+	finish        func(p *peer.Peer, err error)
+	// grpc.Peer 在流结束时写入的对端，只在 RecvMsg 返回后读取
+	peer *peer.Peer
+	// 流结束后关闭，用于结束 watch
+	done chan struct{}
+
+	// 记录内容时不为空，只记录第一条发送与接收的消息，
+	// 发送与接收可能在不同的 goroutine 中进行，修改 data 时需要加锁
+	config      *config
+	mu          sync.Mutex
+	data        *logger.GRPCCallData
+	sent, recvd sync.Once
+}
+
+// SendMsg implements grpc.ClientStream interface
+func (s *loggedStream) SendMsg(m interface{}) error {
+	if s.config != nil {
+		s.sent.Do(func() {
+			s.mu.Lock()
+			s.data.Request = s.config.payload(s.data, m)
+			s.mu.Unlock()
+		})
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+// watch 在 ctx 结束时记录没有读到结束的流，流已经结束时直接退出
+func (s *loggedStream) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		// grpc 同时在其他 goroutine 中写入 s.peer，从流的 context 中读取对端
+		p, ok := peer.FromContext(s.ClientStream.Context())
+		if !ok {
+			p = &peer.Peer{}
+		}
+		s.once.Do(func() { s.finish(p, status.FromContextError(ctx.Err()).Err()) })
+	case <-s.done:
+	}
+}
+
+// RecvMsg implements grpc.ClientStream interface
+func (s *loggedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil && s.config != nil {
+		s.recvd.Do(func() {
+			s.mu.Lock()
+			s.data.Response = s.config.payload(s.data, m)
+			s.mu.Unlock()
+		})
+	}
+	switch {
+	case err == io.EOF:
+		s.once.Do(func() { s.finish(s.peer, nil) })
+	case err != nil:
+		s.once.Do(func() { s.finish(s.peer, err) })
+	case !s.serverStreams:
+		// 客户端流只有一条响应，收到后调用结束
+		s.once.Do(func() { s.finish(s.peer, nil) })
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package grpclogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthClient 启动进程内的健康检查服务，返回经过拦截器的客户端
+func healthClient(t *testing.T, l *logrus.Logger, opts ...Option) (healthpb.HealthClient, func()) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("ok", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(l, opts...)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(l, opts...)),
+	)
+	if err != nil {
+		t.Fatalf("Dial() error, Expected=nil, Actual=%q", err)
+	}
+	return healthpb.NewHealthClient(conn), func() {
+		_ = conn.Close()
+		srv.Stop()
+	}
+}
+
+func TestClientInterceptors(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	client, stop := healthClient(t, l, WithRedactedMetadata("X-Token"))
+	defer stop()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Bearer secret", "x-token", "secret", "x-tenant", "acme")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "ok"}); err != nil {
+		t.Fatalf("Check() error, Expected=nil, Actual=%q", err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); err == nil {
+		t.Fatalf("Check() error, Expected=%q, Actual=nil", "NotFound")
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(streamCtx, &healthpb.HealthCheckRequest{Service: "ok"})
+	if err != nil {
+		t.Fatalf("Watch() error, Expected=nil, Actual=%q", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error, Expected=nil, Actual=%q", err)
+	}
+	cancel()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if len(lines) != 3 {
+		t.Fatalf("log lines, Expected=3, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"schema"}, expected: string(logger.SchemaGRPCClientV1)},
+		{line: 0, path: []interface{}{"l"}, expected: "info"},
+		{line: 0, path: []interface{}{"grpc", "method"}, expected: "/grpc.health.v1.Health/Check"},
+		{line: 0, path: []interface{}{"grpc", "target"}, expected: "bufnet"},
+		{line: 0, path: []interface{}{"grpc", "peer"}, expected: "bufconn"},
+		{line: 0, path: []interface{}{"grpc", "code"}, expected: "OK"},
+		{line: 0, path: []interface{}{"grpc", "metadata", "authorization"}, expected: logger.DefaultRedactReplacement},
+		{line: 0, path: []interface{}{"grpc", "metadata", "x-token"}, expected: logger.DefaultRedactReplacement},
+		{line: 0, path: []interface{}{"grpc", "metadata", "x-tenant"}, expected: "acme"},
+		{line: 1, path: []interface{}{"l"}, expected: "warning"},
+		{line: 1, path: []interface{}{"grpc", "code"}, expected: "NotFound"},
+		{line: 2, path: []interface{}{"grpc", "method"}, expected: "/grpc.health.v1.Health/Watch"},
+		{line: 2, path: []interface{}{"grpc", "stream"}, expected: "true"},
+		{line: 2, path: []interface{}{"grpc", "code"}, expected: "Canceled"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
+
+// lineWriter 将每行日志发送到 channel，用于等待其他 goroutine 写入的日志
+type lineWriter chan []byte
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- append([]byte{}, p...)
+	return len(p), nil
+}
+
+func TestClientStreamAbandoned(t *testing.T) {
+	out := make(lineWriter, 1)
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+
+	client, stop := healthClient(t, l)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "ok"})
+	if err != nil {
+		t.Fatalf("Watch() error, Expected=nil, Actual=%q", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv() error, Expected=nil, Actual=%q", err)
+	}
+	cancel()
+
+	select {
+	case line := <-out:
+		if v := jsoniter.Get(line, "grpc", "code").ToString(); v != "Canceled" {
+			t.Fatalf("output code, Expected=%q, Actual=%q", "Canceled", v)
+		}
+		if v := jsoniter.Get(line, "grpc", "peer").ToString(); v != "bufconn" {
+			t.Fatalf("output peer, Expected=%q, Actual=%q", "bufconn", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("output error, Expected=abandoned stream log, Actual=timeout")
+	}
+}
+
+func TestClientPayloads(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test", logger.WithRedaction(logger.RedactRule{ID: "status", Keys: []string{"status"}}))
+	l.SetOutput(out)
+
+	cases := []struct {
+		opts      []Option
+		request   string
+		response  string
+		truncated bool
+	}{
+		{opts: nil, request: "", response: ""},
+		{opts: []Option{WithPayloads("/grpc.health.v1.Health/Check")}, request: `{"service":"ok"}`, response: `{"status":"[REDACTED]"}`},
+		{opts: []Option{WithPayloads("/grpc.health.v1.Health/")}, request: `{"service":"ok"}`, response: `{"status":"[REDACTED]"}`},
+		{opts: []Option{WithPayloads("/grpc.health.v1.Health/"), WithMaxPayloadBytes(5)}, request: `{}`, response: `{}`, truncated: true},
+	}
+	for _, c := range cases {
+		out.Reset()
+		client, stop := healthClient(t, l, c.opts...)
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "ok"})
+		stop()
+		if err != nil {
+			t.Fatalf("Check() error, Expected=nil, Actual=%q", err)
+		}
+
+		data := out.Bytes()
+		if v := jsoniter.Get(data, "grpc", "payload_truncated").ToBool(); v != c.truncated {
+			t.Fatalf("grpc.payload_truncated, Expected=%v, Actual=%v", c.truncated, v)
+		}
+		if v := jsoniter.Get(data, "grpc", "request").ToString(); v != c.request {
+			t.Fatalf("grpc.request, Expected=%q, Actual=%q", c.request, v)
+		}
+		if v := jsoniter.Get(data, "grpc", "response").ToString(); v != c.response {
+			t.Fatalf("grpc.response, Expected=%q, Actual=%q", c.response, v)
+		}
+	}
+}
+
+func TestClientPayloadTruncated(t *testing.T) {
+	out := &bytes.Buffer{}
+	service := strings.Repeat("x", 100)
+
+	cases := []struct {
+		rules    []logger.RedactRule
+		expected string
+	}{
+		{rules: nil, expected: `{"service":"` + service[:29] + `"}`},
+		{rules: []logger.RedactRule{{ID: "service", Keys: []string{"service"}}}, expected: `{"service":"[REDACTED]"}`},
+	}
+	for _, c := range cases {
+		out.Reset()
+		l, _ := logger.NewLogger("test", "test", logger.WithRedaction(c.rules...))
+		l.SetOutput(out)
+		client, stop := healthClient(t, l, WithPayloads("/grpc.health.v1.Health/"), WithMaxPayloadBytes(40))
+		_, _ = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		stop()
+
+		data := out.Bytes()
+		if v := jsoniter.Get(data, "grpc", "payload_truncated").ToBool(); !v {
+			t.Fatalf("grpc.payload_truncated, Expected=true, Actual=%v", v)
+		}
+		if v := jsoniter.Get(data, "grpc", "request").ToString(); v != c.expected {
+			t.Fatalf("grpc.request, Expected=%q, Actual=%q", c.expected, v)
+		}
+	}
+}
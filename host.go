@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// EnvInstanceID 实例ID所在的环境变量
+const EnvInstanceID = "INSTANCE_ID"
+
+var (
+	// cgroupIDPattern cgroup 路径的最后一段为容器ID，例如 /docker/<id>、/kubepods/.../<id>、
+	// /system.slice/docker-<id>.scope、cri-containerd-<id>.scope
+	cgroupIDPattern = regexp.MustCompile(`/(?:[a-z]+(?:-[a-z]+)*-)?([0-9a-f]{64})(?:\.scope)?$`)
+	// mountIDPattern cgroup v2 下从 docker 挂载的 /etc/hostname 等文件的路径中解析容器ID
+	mountIDPattern = regexp.MustCompile(`/docker/containers/([0-9a-f]{64})/`)
+)
+
+// HostData 运行实例相关的参数，启动时获取一次
+type HostData struct {
+	Hostname    string `json:"hostname"`
+	PID         int    `json:"pid"`
+	ContainerID string `json:"container_id,omitempty"`
+	InstanceID  string `json:"instance_id,omitempty"`
+}
+
+// WithHostMetadata 在每条日志中记录主机名、进程号、容器ID与实例ID，用于区分同一服务的不同副本
+func WithHostMetadata() Option {
+	host := HostMetadata()
+	return func(f *LogsV1Formatter) {
+		f.Host = host
+	}
+}
+
+// HostMetadata 获取当前运行实例的信息
+func HostMetadata() *HostData {
+	hostname, _ := os.Hostname()
+	return &HostData{
+		Hostname:    hostname,
+		PID:         os.Getpid(),
+		ContainerID: containerID(),
+		InstanceID:  os.Getenv(EnvInstanceID),
+	}
+}
+
+// containerID 从 cgroup 信息中解析容器ID，非容器环境返回空
+func containerID() string {
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		var id string
+		if path == "/proc/self/cgroup" {
+			id = cgroupContainerID(f)
+		} else {
+			id = mountContainerID(f)
+		}
+		f.Close()
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// cgroupContainerID 读取 /proc/self/cgroup 中 hierarchy-ID:controllers:path 格式的路径
+func cgroupContainerID(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if m := cgroupIDPattern.FindStringSubmatch(parts[2]); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// mountContainerID 读取 /proc/self/mountinfo，只匹配 docker 容器目录下的挂载，
+// 避免将镜像层等其他 64 位十六进制的路径当作容器ID
+func mountContainerID(r io.Reader) string {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if m := mountIDPattern.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHostMetadata(t *testing.T) {
+	os.Setenv(EnvInstanceID, "i-123")
+	defer os.Unsetenv(EnvInstanceID)
+
+	f := NewFormatter("test", "test", WithHostMetadata())
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	hostname, _ := os.Hostname()
+	if v := jsoniter.Get(data, "host", "hostname").ToString(); v != hostname {
+		t.Fatalf("output host.hostname, Expected=%q, Actual=%q", hostname, v)
+	}
+	if v := jsoniter.Get(data, "host", "pid").ToInt(); v != os.Getpid() {
+		t.Fatalf("output host.pid, Expected=%d, Actual=%d", os.Getpid(), v)
+	}
+	if v := jsoniter.Get(data, "host", "instance_id").ToString(); v != "i-123" {
+		t.Fatalf("output host.instance_id, Expected=%q, Actual=%q", "i-123", v)
+	}
+
+	plain, _ := NewFormatter("test", "test").Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{}})
+	if jsoniter.Get(plain, "host").LastError() == nil {
+		t.Fatalf("output host should be omitted by default")
+	}
+}
+
+func TestContainerID(t *testing.T) {
+	id := strings.Repeat("0123456789abcdef", 4)
+	layer := strings.Repeat("fedcba9876543210", 4)
+	cases := []struct {
+		parse    func(r io.Reader) string
+		content  string
+		expected string
+	}{
+		{parse: cgroupContainerID, content: "12:memory:/docker/" + id + "\n", expected: id},
+		{parse: cgroupContainerID, content: "0::/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + id + ".scope\n", expected: id},
+		{parse: cgroupContainerID, content: "1:name=systemd:/system.slice/docker-" + id + ".scope\n", expected: id},
+		{parse: cgroupContainerID, content: "0::/\n", expected: ""},
+		{parse: cgroupContainerID, content: "0::/user.slice/" + id + "/session.scope\n", expected: ""},
+		{parse: mountContainerID, content: "1 0 0:1 / / rw - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/" + layer + "/diff\n" +
+			"2 1 8:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n", expected: id},
+		{parse: mountContainerID, content: "1 0 0:1 / / rw - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/" + layer + "/diff\n", expected: ""},
+	}
+	for _, c := range cases {
+		if v := c.parse(strings.NewReader(c.content)); v != c.expected {
+			t.Fatalf("containerID(%q) error, Expected=%q, Actual=%q", c.content, c.expected, v)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxPrealloc 按长度前缀预先分配的最大元素数，长度来自输入，超出部分随读取增长，
+// 避免错误或恶意的长度前缀一次分配大量内存
+const maxPrealloc = 1024
+
+// Decoder 从输入中依次解码 MessagePack 数据
+//
+// 整数统一解码为 int64，超出范围时为 uint64；浮点数解码为 float64；
+// map 解码为 map[string]interface{}，扩展类型解码为 Ext
+type Decoder struct {
+	r byteReader
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// NewDecoder 创建解码对象，r 未实现 io.ByteReader 时会增加缓冲
+func NewDecoder(r io.Reader) *Decoder {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Decoder{r: br}
+}
+
+// Decode 解码下一个值，输入结束时返回 io.EOF
+func (d *Decoder) Decode() (interface{}, error) {
+	return d.decodeValue()
+}
+
+func (d *Decoder) decodeValue() (interface{}, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.readString(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.readArray(int(c & 0x0f))
+	case c&0xf0 == 0x80:
+		return d.readMap(int(c & 0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		// 整数统一解码为 int64，超出范围时才使用 uint64
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		shift := uint(64 - size*8)
+		return int64(n<<shift) >> shift, nil
+	case 0xca:
+		n, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.readBytes(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.readArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.readMap(int(n))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.readExt(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.readExt(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unknown type 0x%02x", c)
+}
+
+func (d *Decoder) readUint(size int) (uint64, error) {
+	var scratch [8]byte
+	if _, err := io.ReadFull(d.r, scratch[:size]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	var n uint64
+	for _, b := range scratch[:size] {
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
+
+func (d *Decoder) readBytes(n int) ([]byte, error) {
+	if n <= maxPrealloc {
+		p := make([]byte, n)
+		if _, err := io.ReadFull(d.r, p); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return p, nil
+	}
+	// 长度较大时随读取的内容增长，输入提前结束时不会分配长度前缀声明的内存
+	b := &bytes.Buffer{}
+	b.Grow(maxPrealloc)
+	if _, err := io.CopyN(b, d.r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b.Bytes(), nil
+}
+
+func (d *Decoder) readString(n int) (string, error) {
+	p, err := d.readBytes(n)
+	return string(p), err
+}
+
+func (d *Decoder) readExt(n int) (Ext, error) {
+	typ, err := d.r.ReadByte()
+	if err != nil {
+		return Ext{}, unexpectedEOF(err)
+	}
+	p, err := d.readBytes(n)
+	return Ext{Type: int8(typ), Data: p}, err
+}
+
+func (d *Decoder) readArray(n int) ([]interface{}, error) {
+	arr := make([]interface{}, 0, prealloc(n))
+	for i := 0; i < n; i++ {
+		v, err := d.decodeValue()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func (d *Decoder) readMap(n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, prealloc(n))
+	for i := 0; i < n; i++ {
+		k, err := d.decodeValue()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		v, err := d.decodeValue()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		m[fmt.Sprintf("%v", k)] = v
+	}
+	return m, nil
+}
+
+// prealloc 按长度前缀预先分配的元素数
+func prealloc(n int) int {
+	if n > maxPrealloc {
+		return maxPrealloc
+	}
+	return n
+}
+
+// unexpectedEOF 数据读取到一半时结束属于格式错误
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
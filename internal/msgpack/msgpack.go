@@ -0,0 +1,318 @@
+// Package msgpack 实现日志输出使用的 MessagePack 编解码
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Ext MessagePack 扩展类型
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// Codec 将无法直接写入的类型编码为 JSON，logger.Encoder 满足该接口
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// Writer 将常见类型直接编码为 MessagePack，其他类型先通过 Codec 转换为通用结构
+type Writer struct {
+	b   *bytes.Buffer
+	enc Codec
+}
+
+// NewWriter 创建写入 b 的编码对象
+func NewWriter(b *bytes.Buffer, enc Codec) *Writer {
+	return &Writer{b: b, enc: enc}
+}
+
+// WriteNil 写入 nil
+func (w *Writer) WriteNil() {
+	w.b.WriteByte(0xc0)
+}
+
+// WriteBool 写入布尔值
+func (w *Writer) WriteBool(v bool) {
+	if v {
+		w.b.WriteByte(0xc3)
+	} else {
+		w.b.WriteByte(0xc2)
+	}
+}
+
+// WriteInt 使用最短的格式写入有符号整数
+func (w *Writer) WriteInt(v int64) {
+	switch {
+	case v >= 0:
+		w.WriteUint(uint64(v))
+	case v >= -32:
+		w.b.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		w.b.WriteByte(0xd0)
+		w.b.WriteByte(byte(v))
+	case v >= math.MinInt16:
+		w.b.WriteByte(0xd1)
+		w.writeUint16(uint16(v))
+	case v >= math.MinInt32:
+		w.b.WriteByte(0xd2)
+		w.writeUint32(uint32(v))
+	default:
+		w.b.WriteByte(0xd3)
+		w.writeUint64(uint64(v))
+	}
+}
+
+// WriteUint 使用最短的格式写入无符号整数
+func (w *Writer) WriteUint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		w.b.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		w.b.WriteByte(0xcc)
+		w.b.WriteByte(byte(v))
+	case v <= math.MaxUint16:
+		w.b.WriteByte(0xcd)
+		w.writeUint16(uint16(v))
+	case v <= math.MaxUint32:
+		w.b.WriteByte(0xce)
+		w.writeUint32(uint32(v))
+	default:
+		w.b.WriteByte(0xcf)
+		w.writeUint64(v)
+	}
+}
+
+// WriteFloat32 写入单精度浮点数
+func (w *Writer) WriteFloat32(v float32) {
+	w.b.WriteByte(0xca)
+	w.writeUint32(math.Float32bits(v))
+}
+
+// WriteFloat64 写入双精度浮点数
+func (w *Writer) WriteFloat64(v float64) {
+	w.b.WriteByte(0xcb)
+	w.writeUint64(math.Float64bits(v))
+}
+
+// WriteString 写入字符串
+func (w *Writer) WriteString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.b.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		w.b.WriteByte(0xd9)
+		w.b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		w.b.WriteByte(0xda)
+		w.writeUint16(uint16(n))
+	default:
+		w.b.WriteByte(0xdb)
+		w.writeUint32(uint32(n))
+	}
+	w.b.WriteString(s)
+}
+
+// WriteBinary 写入二进制数据
+func (w *Writer) WriteBinary(p []byte) {
+	n := len(p)
+	switch {
+	case n <= math.MaxUint8:
+		w.b.WriteByte(0xc4)
+		w.b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		w.b.WriteByte(0xc5)
+		w.writeUint16(uint16(n))
+	default:
+		w.b.WriteByte(0xc6)
+		w.writeUint32(uint32(n))
+	}
+	w.b.Write(p)
+}
+
+// WriteExt 写入扩展类型
+func (w *Writer) WriteExt(typ int8, p []byte) {
+	n := len(p)
+	switch n {
+	case 1:
+		w.b.WriteByte(0xd4)
+	case 2:
+		w.b.WriteByte(0xd5)
+	case 4:
+		w.b.WriteByte(0xd6)
+	case 8:
+		w.b.WriteByte(0xd7)
+	case 16:
+		w.b.WriteByte(0xd8)
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			w.b.WriteByte(0xc7)
+			w.b.WriteByte(byte(n))
+		case n <= math.MaxUint16:
+			w.b.WriteByte(0xc8)
+			w.writeUint16(uint16(n))
+		default:
+			w.b.WriteByte(0xc9)
+			w.writeUint32(uint32(n))
+		}
+	}
+	w.b.WriteByte(byte(typ))
+	w.b.Write(p)
+}
+
+// WriteArrayHeader 写入长度为 n 的数组头，随后需要写入 n 个元素
+func (w *Writer) WriteArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.b.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		w.b.WriteByte(0xdc)
+		w.writeUint16(uint16(n))
+	default:
+		w.b.WriteByte(0xdd)
+		w.writeUint32(uint32(n))
+	}
+}
+
+// WriteMapHeader 写入长度为 n 的 map 头，随后需要写入 n 组键值
+func (w *Writer) WriteMapHeader(n int) {
+	switch {
+	case n < 16:
+		w.b.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		w.b.WriteByte(0xde)
+		w.writeUint16(uint16(n))
+	default:
+		w.b.WriteByte(0xdf)
+		w.writeUint32(uint32(n))
+	}
+}
+
+func (w *Writer) writeUint16(v uint16) {
+	var scratch [2]byte
+	binary.BigEndian.PutUint16(scratch[:], v)
+	w.b.Write(scratch[:])
+}
+
+func (w *Writer) writeUint32(v uint32) {
+	var scratch [4]byte
+	binary.BigEndian.PutUint32(scratch[:], v)
+	w.b.Write(scratch[:])
+}
+
+func (w *Writer) writeUint64(v uint64) {
+	var scratch [8]byte
+	binary.BigEndian.PutUint64(scratch[:], v)
+	w.b.Write(scratch[:])
+}
+
+// WriteFields 写入字段 map
+func (w *Writer) WriteFields(m map[string]interface{}) error {
+	w.WriteMapHeader(len(m))
+	for k, v := range m {
+		w.WriteString(k)
+		if err := w.WriteValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteValue 写入字段值，与 JSON 输出保持相同的结构
+func (w *Writer) WriteValue(v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		w.WriteNil()
+	case string:
+		w.WriteString(val)
+	case []byte:
+		w.WriteBinary(val)
+	case bool:
+		w.WriteBool(val)
+	case int:
+		w.WriteInt(int64(val))
+	case int8:
+		w.WriteInt(int64(val))
+	case int16:
+		w.WriteInt(int64(val))
+	case int32:
+		w.WriteInt(int64(val))
+	case int64:
+		w.WriteInt(val)
+	case time.Duration:
+		w.WriteInt(int64(val))
+	case uint:
+		w.WriteUint(uint64(val))
+	case uint8:
+		w.WriteUint(uint64(val))
+	case uint16:
+		w.WriteUint(uint64(val))
+	case uint32:
+		w.WriteUint(uint64(val))
+	case uint64:
+		w.WriteUint(val)
+	case float32:
+		w.WriteFloat32(val)
+	case float64:
+		w.WriteFloat64(val)
+	case Ext:
+		w.WriteExt(val.Type, val.Data)
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			w.WriteInt(n)
+		} else if f, err := val.Float64(); err == nil {
+			w.WriteFloat64(f)
+		} else {
+			return err
+		}
+	case logrus.Fields:
+		return w.WriteFields(val)
+	case map[string]interface{}:
+		return w.WriteFields(val)
+	case map[string]string:
+		w.WriteMapHeader(len(val))
+		for k, s := range val {
+			w.WriteString(k)
+			w.WriteString(s)
+		}
+	case []string:
+		w.WriteArrayHeader(len(val))
+		for _, s := range val {
+			w.WriteString(s)
+		}
+	case []interface{}:
+		w.WriteArrayHeader(len(val))
+		for _, item := range val {
+			if err := w.WriteValue(item); err != nil {
+				return err
+			}
+		}
+	default:
+		// 其他类型通过 JSON 转换为通用结构，保证字段名与 JSON 输出一致
+		encoded, err := w.enc.Marshal(v)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(encoded))
+		dec.UseNumber()
+		var generic interface{}
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		return w.WriteValue(generic)
+	}
+	return nil
+}
+
+// WriteRaw 写入已经编码好的 MessagePack 数据
+func (w *Writer) WriteRaw(p []byte) {
+	w.b.Write(p)
+}
@@ -0,0 +1,94 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func TestRoundTrip(t *testing.T) {
+	values := []interface{}{
+		nil, true, false,
+		int64(0), int64(127), int64(-1), int64(-33), int64(math.MinInt16), int64(math.MinInt64),
+		int64(255), int64(65536), uint64(math.MaxUint64),
+		1.5, "", "short", string(bytes.Repeat([]byte("x"), 300)),
+		[]byte{1, 2, 3},
+		Ext{Type: 0, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		[]interface{}{int64(1), "a"},
+		map[string]interface{}{"k": "v"},
+	}
+
+	for idx, v := range values {
+		b := &bytes.Buffer{}
+		w := NewWriter(b, stdCodec{})
+		if err := w.WriteValue(v); err != nil {
+			t.Fatalf("%d: WriteValue() error: %s", idx, err)
+		}
+		d := NewDecoder(b)
+		actual, err := d.Decode()
+		if err != nil {
+			t.Fatalf("%d: Decode() error: %s", idx, err)
+		}
+		if !reflect.DeepEqual(actual, v) {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, v, actual)
+		}
+	}
+}
+
+func TestWriteValueConvert(t *testing.T) {
+	type data struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	cases := []struct {
+		Value  interface{}
+		Expect interface{}
+	}{
+		{Value: json.Number("12"), Expect: int64(12)},
+		{Value: json.Number("1.25"), Expect: 1.25},
+		{Value: data{Name: "a", Count: 3}, Expect: map[string]interface{}{"name": "a", "count": int64(3)}},
+		{Value: map[string]string{"k": "v"}, Expect: map[string]interface{}{"k": "v"}},
+		{Value: []string{"a"}, Expect: []interface{}{"a"}},
+	}
+
+	for idx, each := range cases {
+		b := &bytes.Buffer{}
+		if err := NewWriter(b, stdCodec{}).WriteValue(each.Value); err != nil {
+			t.Fatalf("%d: WriteValue() error: %s", idx, err)
+		}
+		actual, err := NewDecoder(b).Decode()
+		if err != nil {
+			t.Fatalf("%d: Decode() error: %s", idx, err)
+		}
+		if !reflect.DeepEqual(actual, each.Expect) {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, actual)
+		}
+	}
+}
+
+func TestDecodeOversizedHeader(t *testing.T) {
+	cases := [][]byte{
+		{0xc6, 0xff, 0xff, 0xff, 0xff, 1, 2, 3},
+		{0xdb, 0xff, 0xff, 0xff, 0xff, 'a'},
+		{0xc9, 0xff, 0xff, 0xff, 0xff, 1},
+		{0xdd, 0xff, 0xff, 0xff, 0xff, 1},
+		{0xdf, 0xff, 0xff, 0xff, 0xff, 0xa1, 'k'},
+		{0xc6, 0x00, 0x00},
+	}
+
+	for idx, each := range cases {
+		if _, err := NewDecoder(bytes.NewReader(each)).Decode(); err != io.ErrUnexpectedEOF {
+			t.Fatalf("%d: expect: %v, got: %v", idx, io.ErrUnexpectedEOF, err)
+		}
+	}
+}
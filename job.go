@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 后台任务的事件
+const (
+	JobStart  = "start"
+	JobFinish = "finish"
+)
+
+// JobData 后台任务相关的参数
+type JobData struct {
+	Name     string `json:"name"`
+	RunID    string `json:"run_id"`
+	Event    string `json:"event"`
+	Duration string `json:"duration,omitempty"`
+	Outcome  string `json:"outcome,omitempty"`
+}
+
+// JobRun 一次后台任务的执行
+type JobRun struct {
+	// 本次执行的唯一标识
+	ID   string
+	Name string
+	// 绑定了 job、run_id 字段的日志对象，任务内的日志都应通过它输出
+	Logger *logrus.Entry
+}
+
+// Job 为名为 name 的后台任务分配一次执行
+//
+//	err := logger.Job(l, "sync-users").Run(func(log *logrus.Entry) error {
+//		log.Info("syncing")
+//		return sync()
+//	})
+func Job(l *logrus.Logger, name string) *JobRun {
+	id := newUUID()
+	return &JobRun{
+		ID:   id,
+		Name: name,
+		Logger: l.WithFields(logrus.Fields{
+			"job_name": name,
+			"run_id":   id,
+		}),
+	}
+}
+
+// Run 执行任务并记录开始与结束，任务 panic 时记录调用栈后继续向上抛出
+func (j *JobRun) Run(fn func(log *logrus.Entry) error) (err error) {
+	j.Logger.WithField("job", &JobData{
+		Name:  j.Name,
+		RunID: j.ID,
+		Event: JobStart,
+	}).Info("job started")
+
+	start := time.Now()
+	defer func() {
+		data := &JobData{
+			Name:     j.Name,
+			RunID:    j.ID,
+			Event:    JobFinish,
+			Duration: time.Since(start).String(),
+			Outcome:  OutcomeSuccess,
+		}
+
+		if r := recover(); r != nil {
+			data.Outcome = OutcomePanic
+			j.Logger.WithFields(logrus.Fields{
+				"job":   data,
+				"error": fmt.Sprintf("%v", r),
+				"stack": string(debug.Stack()),
+			}).Error("job panicked")
+			panic(r)
+		}
+
+		if err != nil {
+			data.Outcome = OutcomeError
+			j.Logger.WithFields(logrus.Fields{
+				"job":   data,
+				"error": err,
+			}).Error("job failed")
+			return
+		}
+
+		j.Logger.WithField("job", data).Info("job finished")
+	}()
+
+	return fn(j.Logger)
+}
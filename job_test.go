@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestJobRun(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	job := Job(l, "sync-users")
+	err := job.Run(func(log *logrus.Entry) error {
+		log.Info("syncing")
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Fatalf("Run() error, Expected=%q, Actual=nil", "boom")
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if len(lines) != 3 {
+		t.Fatalf("log lines, Expected=3, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"schema"}, expected: string(SchemaJobRunV1)},
+		{line: 0, path: []interface{}{"job", "event"}, expected: JobStart},
+		{line: 1, path: []interface{}{"schema"}, expected: string(SchemaGeneralLogsV1)},
+		{line: 1, path: []interface{}{"ctx", "run_id"}, expected: job.ID},
+		{line: 2, path: []interface{}{"job", "run_id"}, expected: job.ID},
+		{line: 2, path: []interface{}{"job", "event"}, expected: JobFinish},
+		{line: 2, path: []interface{}{"job", "outcome"}, expected: OutcomeError},
+		{line: 2, path: []interface{}{"err"}, expected: "boom"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
+
+func TestJobPanic(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	defer func() {
+		if r := recover(); r != "oops" {
+			t.Fatalf("Run() panic, Expected=%q, Actual=%v", "oops", r)
+		}
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		if v := jsoniter.Get(lines[len(lines)-1], "job", "outcome").ToString(); v != OutcomePanic {
+			t.Fatalf("output job.outcome, Expected=%q, Actual=%q", OutcomePanic, v)
+		}
+	}()
+
+	_ = Job(l, "panics").Run(func(log *logrus.Entry) error {
+		panic("oops")
+	})
+}
@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schemaSections 日志规范与其专属的字段
+var schemaSections = map[Schema]string{
+	SchemaGeneralLogsV1:  "",
+	SchemaHTTPRequestV1:  "request",
+	SchemaSQLQueryV1:     "sql",
+	SchemaHTTPClientV1:   "client",
+	SchemaMQMessageV1:    "mq",
+	SchemaJobRunV1:       "job",
+	SchemaRuntimeStatsV1: "runtime",
+	SchemaGeneralLogsV2:  "",
+}
+
+// JSONSchema 返回日志规范的 JSON Schema (draft-07)，由输出结构生成，与实际输出保持一致，
+// 未知的日志规范返回 nil
+func JSONSchema(schema Schema) []byte {
+	doc := jsonSchemaDoc(schema)
+	if doc == nil {
+		return nil
+	}
+	b, _ := json.MarshalIndent(doc, "", "  ")
+	return b
+}
+
+func jsonSchemaDoc(schema Schema) map[string]interface{} {
+	if schema == SchemaGeneralLogsV2 {
+		return jsonSchemaV2()
+	}
+	section, ok := schemaSections[schema]
+	custom, registered := lookupSchema(schema)
+	if !ok && !registered {
+		return nil
+	}
+
+	doc := typeSchema(reflect.TypeOf(LogsV1{}))
+	doc["$schema"] = "http://json-schema.org/draft-07/schema#"
+	doc["title"] = string(schema)
+
+	props := doc["properties"].(map[string]interface{})
+	props["schema"] = map[string]interface{}{"type": "string", "const": string(schema)}
+	props["l"] = map[string]interface{}{
+		"type": "string",
+		"enum": []string{"trace", "debug", "info", "warning", "error", "fatal", "panic"},
+	}
+	// ctx 始终输出对象
+	props["ctx"] = map[string]interface{}{"type": "object"}
+	if registered {
+		section = custom.Field
+		props[section] = customSchemaDoc(custom)
+	}
+	if section != "" {
+		doc["required"] = append(doc["required"].([]string), section)
+	}
+	return doc
+}
+
+// customSchemaDoc 自定义规范内容的 JSON Schema，保留字段的类型不确定
+func customSchemaDoc(def SchemaDefinition) map[string]interface{} {
+	doc := map[string]interface{}{}
+	if def.Type != nil {
+		doc = typeSchema(reflect.TypeOf(def.Type))
+	}
+	if len(def.Reserved) > 0 {
+		props, _ := doc["properties"].(map[string]interface{})
+		if props == nil {
+			props = map[string]interface{}{}
+			doc["type"] = "object"
+			doc["properties"] = props
+		}
+		for _, k := range def.Reserved {
+			props[k] = map[string]interface{}{}
+		}
+	}
+	return doc
+}
+
+// typeSchema 按 encoding/json 的规则生成类型对应的 JSON Schema
+func typeSchema(t reflect.Type) map[string]interface{} {
+	return typeSchemaOf(t, map[reflect.Type]bool{})
+}
+
+// typeSchemaOf 生成类型的 JSON Schema，seen 记录正在展开的结构体，
+// 递归引用自身的结构体 (如 ErrorV2.Errors) 只标记为对象
+func typeSchemaOf(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchemaOf(t.Elem(), seen)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		doc := map[string]interface{}{"type": []string{"object", "null"}}
+		if t.Elem().Kind() != reflect.Interface {
+			doc["additionalProperties"] = typeSchemaOf(t.Elem(), seen)
+		}
+		return doc
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		props := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, omitempty := jsonFieldName(field)
+			if name == "-" {
+				continue
+			}
+			props[name] = typeSchemaOf(field.Type, seen)
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	return map[string]interface{}{}
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field.Name, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	omitempty := false
+	for _, opt := range parts[1:] {
+		omitempty = omitempty || opt == "omitempty"
+	}
+	return name, omitempty
+}
+
+// ValidationError 日志不符合规范的字段
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid log: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate 按日志中 schema 字段对应的 JSON Schema 校验一条格式化后的日志
+func Validate(p []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return wrapf(err, "decode log")
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return errors.New("invalid log: not a JSON object")
+	}
+	name, _ := m["schema"].(string)
+	doc := jsonSchemaDoc(Schema(name))
+	if doc == nil {
+		return fmt.Errorf("invalid log: unknown schema %q", name)
+	}
+
+	var problems []string
+	validateValue("$", v, doc, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateValue 支持 type、const、enum、properties、required、additionalProperties 与 items
+func validateValue(path string, v interface{}, doc map[string]interface{}, problems *[]string) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := doc["type"]; ok {
+		var types []string
+		switch tt := t.(type) {
+		case string:
+			types = []string{tt}
+		case []string:
+			types = tt
+		}
+		if !matchType(v, types) {
+			report("expected %s, got %s", strings.Join(types, " or "), jsonType(v))
+			return
+		}
+	}
+	if c, ok := doc["const"]; ok && v != c {
+		report("expected %v, got %v", c, v)
+	}
+	if enum, ok := doc["enum"].([]string); ok {
+		s, _ := v.(string)
+		found := false
+		for _, e := range enum {
+			found = found || e == s
+		}
+		if !found {
+			report("unexpected value %v", v)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		props, _ := doc["properties"].(map[string]interface{})
+		required, _ := doc["required"].([]string)
+		for _, k := range required {
+			if _, ok := val[k]; !ok {
+				report("missing field %s", k)
+			}
+		}
+
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if sub, ok := props[k].(map[string]interface{}); ok {
+				validateValue(path+"."+k, val[k], sub, problems)
+				continue
+			}
+			switch additional := doc["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					report("unexpected field %s", k)
+				}
+			case map[string]interface{}:
+				validateValue(path+"."+k, val[k], additional, problems)
+			}
+		}
+	case []interface{}:
+		if items, ok := doc["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), item, items, problems)
+			}
+		}
+	}
+}
+
+func matchType(v interface{}, types []string) bool {
+	actual := jsonType(v)
+	for _, t := range types {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(val.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
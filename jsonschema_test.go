@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schemas := []Schema{}
+	for schema := range schemaSections {
+		schemas = append(schemas, schema)
+	}
+	for _, def := range registeredSchemas() {
+		schemas = append(schemas, def.Name)
+	}
+	for _, schema := range schemas {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(JSONSchema(schema), &doc); err != nil {
+			t.Fatalf("JSONSchema(%s) is not valid JSON: %s", schema, err)
+		}
+		if doc["title"] != string(schema) {
+			t.Fatalf("JSONSchema(%s) title, Actual=%v", schema, doc["title"])
+		}
+	}
+
+	var doc map[string]interface{}
+	_ = json.Unmarshal(JSONSchema(SchemaHTTPRequestV1), &doc)
+	request := doc["properties"].(map[string]interface{})["request"].(map[string]interface{})
+	if request["properties"].(map[string]interface{})["bytes_in"].(map[string]interface{})["type"] != "integer" {
+		t.Fatalf("JSONSchema(%s) request.bytes_in should be integer", SchemaHTTPRequestV1)
+	}
+
+	if JSONSchema("unknown.v1") != nil {
+		t.Fatalf("JSONSchema(unknown) Expected=nil")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f := NewFormatter("test", "test", WithHostMetadata(), WithBuildInfo())
+	entries := []logrus.Fields{
+		{"foo": "bar", "error": "failed"},
+		{
+			"request": &http.Request{
+				Method: http.MethodPost,
+				URL:    &url.URL{Path: "/api", RawQuery: "a=1&a=2"},
+				Header: http.Header{"User-Agent": {"curl/7.0"}},
+			},
+			"status":    200,
+			"duration":  time.Second,
+			"bytes_out": 10,
+		},
+		{"sql": &SQLData{Statement: "select ?", Args: []interface{}{1}}},
+		{"client": &ClientRequestData{Host: "example.com"}},
+		{"mq": &MessageData{System: "kafka"}},
+		{"job": &JobData{Name: "sync", Event: JobStart}},
+		{"grpc": &GRPCCallData{Method: "/pay.Payment/Charge", Code: "OK", Duration: "1ms"}},
+	}
+	for _, fields := range entries {
+		p, err := f.Format(&logrus.Entry{Time: time.Now(), Data: fields})
+		if err != nil {
+			t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+		}
+		if err := Validate(p); err != nil {
+			t.Fatalf("Validate() error, Expected=nil, Actual=%q\n%s", err.Error(), p)
+		}
+	}
+
+	valid := `{"schema":"http.request.v1","t":"2021-01-02T03:04:05Z","l":"info","s":"a","c":"","i":"","e":"","u":"","m":"","ctx":{},"err":"",` +
+		`"request":{"ip":"","method":"GET","path":"/","header":{},"status":"200","duration":"","bytes_in":0,"param":{}}}`
+	if err := Validate([]byte(valid)); err != nil {
+		t.Fatalf("Validate() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	cases := []struct {
+		Log      string
+		Expected string
+	}{
+		{Log: `[]`, Expected: "not a JSON object"},
+		{Log: `{"schema":"custom.v9"}`, Expected: `unknown schema "custom.v9"`},
+		{Log: strings.Replace(valid, `"l":"info"`, `"l":"loud"`, 1), Expected: "$.l: unexpected value loud"},
+		{Log: strings.Replace(valid, `"s":"a",`, ``, 1), Expected: "$: missing field s"},
+		{Log: strings.Replace(valid, `"bytes_in":0`, `"bytes_in":"0"`, 1), Expected: "$.request.bytes_in: expected integer, got string"},
+		{Log: strings.Replace(valid, `"err":""`, `"err":"","extra":1`, 1), Expected: "$: unexpected field extra"},
+		{Log: strings.Replace(valid, `"header":{}`, `"header":{"x":1}`, 1), Expected: "$.request.header.x: expected string, got integer"},
+		{Log: strings.Replace(valid, `,"request":{"ip":"","method":"GET","path":"/","header":{},"status":"200","duration":"","bytes_in":0,"param":{}}`, ``, 1), Expected: "$: missing field request"},
+	}
+	for _, c := range cases {
+		err := Validate([]byte(c.Log))
+		if err == nil || !strings.Contains(err.Error(), c.Expected) {
+			t.Fatalf("Validate(%s) error, Expected=%q, Actual=%v", c.Log, c.Expected, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	// 注册 JWT 签名算法使用的哈希函数
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// JWTKeyFunc 根据 JWT header (alg、kid 等) 返回验证签名的 key，
+// HS* 使用 []byte，RS*、PS* 使用 *rsa.PublicKey，ES* 使用 *ecdsa.PublicKey
+type JWTKeyFunc func(header map[string]interface{}) (interface{}, error)
+
+// JWTOptions 从 Authorization bearer token 提取用户标识与 claim 的配置
+type JWTOptions struct {
+	// 写入 u 的 claim，使用第一个存在的，默认 sub、uid
+	UserClaims []string
+	// 记录在 ctx.jwt 中的 claim
+	Claims []string
+	// 验证签名的 key，为空时不验证签名，验证失败或 token 过期时不提取任何内容
+	KeyFunc JWTKeyFunc
+}
+
+// WithJWTClaims 请求日志没有 user 字段时，从 Authorization bearer token 中提取用户标识写入 u，
+// 并将 opts.Claims 指定的 claim 记录在 ctx.jwt，entry 中的 user 字段优先
+func WithJWTClaims(opts JWTOptions) Option {
+	if len(opts.UserClaims) == 0 {
+		opts.UserClaims = []string{"sub", "uid"}
+	}
+	return func(f *LogsV1Formatter) {
+		f.JWT = &opts
+	}
+}
+
+// extract 提取请求 token 中的用户标识与 claim，返回更新后的用户标识
+func (o *JWTOptions) extract(req *http.Request, uid string) (string, map[string]interface{}) {
+	auth := req.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return uid, nil
+	}
+	claims, err := parseJWT(strings.TrimSpace(auth[7:]), o.KeyFunc)
+	if err != nil {
+		return uid, nil
+	}
+
+	if uid == "" {
+		for _, name := range o.UserClaims {
+			if v, ok := claims[name]; ok && v != nil {
+				uid = toString(v)
+				break
+			}
+		}
+	}
+
+	var selected map[string]interface{}
+	for _, name := range o.Claims {
+		if v, ok := claims[name]; ok {
+			if selected == nil {
+				selected = map[string]interface{}{}
+			}
+			selected[name] = v
+		}
+	}
+	return uid, selected
+}
+
+// parseJWT 解析 JWT 的 claim，keyFunc 不为空时验证签名与有效期
+func parseJWT(token string, keyFunc JWTKeyFunc) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt: malformed token")
+	}
+
+	var header, claims map[string]interface{}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, wrapf(err, "jwt: decode header")
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, wrapf(err, "jwt: decode claims")
+	}
+	if keyFunc == nil {
+		return claims, nil
+	}
+
+	key, err := keyFunc(header)
+	if err != nil {
+		return nil, wrapf(err, "jwt: key")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, wrapf(err, "jwt: decode signature")
+	}
+	alg, _ := header["alg"].(string)
+	if err := verifyJWT(alg, parts[0]+"."+parts[1], sig, key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if exp, ok := claims["exp"].(json.Number); ok {
+		if n, err := exp.Int64(); err == nil && now >= n {
+			return nil, errors.New("jwt: token expired")
+		}
+	}
+	if nbf, ok := claims["nbf"].(json.Number); ok {
+		if n, err := nbf.Int64(); err == nil && now < n {
+			return nil, errors.New("jwt: token not valid yet")
+		}
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// verifyJWT 验证 HS*、RS*、PS* 与 ES* 算法的签名
+func verifyJWT(alg, signed string, sig []byte, key interface{}) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("jwt: unsupported alg %q", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("jwt: unsupported alg %q", alg)
+	}
+
+	switch k := key.(type) {
+	case []byte:
+		if alg[:2] != "HS" {
+			break
+		}
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("jwt: invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" && alg[:2] != "PS" {
+			break
+		}
+		h := hash.New()
+		h.Write([]byte(signed))
+		var err error
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(k, hash, h.Sum(nil), sig)
+		} else {
+			err = rsa.VerifyPSS(k, hash, h.Sum(nil), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return wrapf(err, "jwt: invalid signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			break
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("jwt: invalid signature")
+		}
+		h := hash.New()
+		h.Write([]byte(signed))
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, h.Sum(nil), r, s) {
+			return errors.New("jwt: invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("jwt: key %T does not match alg %s", key, alg)
+}
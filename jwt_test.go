@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func signTestJWT(t *testing.T, alg string, claims string, key interface{}) string {
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString([]byte(`{"alg":"`+alg+`","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("SignPKCS1v15() error, Expected=nil, Actual=%q", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("ecdsa.Sign() error, Expected=nil, Actual=%q", err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	default:
+		sig = []byte("unsigned")
+	}
+	return signed + "." + enc.EncodeToString(sig)
+}
+
+func TestWithJWTClaims(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error, Expected=nil, Actual=%q", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error, Expected=nil, Actual=%q", err)
+	}
+	secret := []byte("secret")
+	keyFunc := func(header map[string]interface{}) (interface{}, error) {
+		switch header["alg"] {
+		case "RS256":
+			return &rsaKey.PublicKey, nil
+		case "ES256":
+			return &ecKey.PublicKey, nil
+		}
+		return secret, nil
+	}
+	claims := `{"sub":"u-42","role":"admin","exp":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `}`
+	expired := `{"sub":"u-42","exp":1}`
+
+	cases := []struct {
+		Name    string
+		Token   string
+		KeyFunc JWTKeyFunc
+		User    string
+		Field   interface{}
+		Expect  string
+	}{
+		{Name: "unverified", Token: signTestJWT(t, "none", claims, nil), Expect: "u-42"},
+		{Name: "hs256", Token: signTestJWT(t, "HS256", claims, secret), KeyFunc: keyFunc, Expect: "u-42"},
+		{Name: "rs256", Token: signTestJWT(t, "RS256", claims, rsaKey), KeyFunc: keyFunc, Expect: "u-42"},
+		{Name: "es256", Token: signTestJWT(t, "ES256", claims, ecKey), KeyFunc: keyFunc, Expect: "u-42"},
+		{Name: "bad signature", Token: signTestJWT(t, "HS256", claims, []byte("other")), KeyFunc: keyFunc, Expect: ""},
+		{Name: "expired", Token: signTestJWT(t, "HS256", expired, secret), KeyFunc: keyFunc, Expect: ""},
+		{Name: "user field", Token: signTestJWT(t, "none", claims, nil), Field: "u-1", Expect: "u-1"},
+	}
+
+	for _, each := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+each.Token)
+		fields := logrus.Fields{"request": req}
+		if each.Field != nil {
+			fields["user"] = each.Field
+		}
+		entry := &logrus.Entry{Time: time.Now(), Message: "request", Data: fields}
+
+		f := NewFormatter("test", "test", WithJWTClaims(JWTOptions{Claims: []string{"role"}, KeyFunc: each.KeyFunc}))
+		data, err := f.Format(entry)
+		if err != nil {
+			t.Fatalf("%s: Format() error, Expected=nil, Actual=%q", each.Name, err)
+		}
+		if v := jsoniter.Get(data, "u").ToString(); v != each.Expect {
+			t.Fatalf("%s: output u, Expected=%q, Actual=%q", each.Name, each.Expect, v)
+		}
+		role := jsoniter.Get(data, "ctx", "jwt", "role").ToString()
+		if (each.Expect != "") != (role == "admin") {
+			t.Fatalf("%s: output ctx.jwt.role, Actual=%q", each.Name, role)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// DownwardAPIDir Downward API 文件挂载的目录，文件名为环境变量名的小写形式，例如 pod_name
+	DownwardAPIDir = "/etc/podinfo"
+
+	serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// KubernetesData Kubernetes 相关的参数
+type KubernetesData struct {
+	Pod       string `json:"pod,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Node      string `json:"node,omitempty"`
+}
+
+// WithKubernetesMetadata 在每条日志的 ctx.k8s 中记录 pod、namespace 与 node，
+// 优先读取 POD_NAME、POD_NAMESPACE、NODE_NAME 环境变量，其次读取 Downward API 文件
+func WithKubernetesMetadata() Option {
+	k8s := KubernetesMetadata()
+	return func(f *LogsV1Formatter) {
+		f.Kubernetes = k8s
+	}
+}
+
+// KubernetesMetadata 获取当前 pod 的信息，非 Kubernetes 环境返回 nil
+func KubernetesMetadata() *KubernetesData {
+	k8s := &KubernetesData{
+		Pod:       downwardValue("POD_NAME"),
+		Namespace: downwardValue("POD_NAMESPACE", serviceAccountNamespace),
+		Node:      downwardValue("NODE_NAME"),
+	}
+	if *k8s == (KubernetesData{}) {
+		return nil
+	}
+	return k8s
+}
+
+func downwardValue(env string, fallbacks ...string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+
+	paths := append([]string{filepath.Join(DownwardAPIDir, strings.ToLower(env))}, fallbacks...)
+	for _, path := range paths {
+		if b, err := ioutil.ReadFile(path); err == nil {
+			if v := strings.TrimSpace(string(b)); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
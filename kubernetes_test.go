@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestKubernetesMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podinfo")
+	if err != nil {
+		t.Fatalf("TempDir() error, Expected=nil, Actual=%q", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(old string) { DownwardAPIDir = old }(DownwardAPIDir)
+	DownwardAPIDir = dir
+	_ = ioutil.WriteFile(filepath.Join(dir, "node_name"), []byte("node-1\n"), 0o644)
+
+	os.Setenv("POD_NAME", "api-7d9f")
+	os.Setenv("POD_NAMESPACE", "prod")
+	defer os.Unsetenv("POD_NAME")
+	defer os.Unsetenv("POD_NAMESPACE")
+
+	f := NewFormatter("test", "test", WithKubernetesMetadata())
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"ctx", "k8s", "pod"}, expected: "api-7d9f"},
+		{path: []interface{}{"ctx", "k8s", "namespace"}, expected: "prod"},
+		{path: []interface{}{"ctx", "k8s", "node"}, expected: "node-1"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package logger
+
+// LazyValue 延迟计算的字段值，只有日志真正输出时才会在格式化阶段计算
+//
+// logrus 会丢弃函数类型的字段，因此将函数包装在结构体中
+type LazyValue struct {
+	fn func() interface{}
+}
+
+// Lazy 包装计算代价较高的字段值，被级别过滤掉的日志不会执行 fn
+//
+//	l.WithField("state", logger.Lazy(func() interface{} {
+//		return dumpState()
+//	})).Debug("state snapshot")
+func Lazy(fn func() interface{}) *LazyValue {
+	return &LazyValue{fn: fn}
+}
+
+// Value 计算字段值
+func (l *LazyValue) Value() interface{} {
+	return l.fn()
+}
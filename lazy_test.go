@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLazy(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetLevel(logrus.InfoLevel)
+
+	calls := 0
+	value := Lazy(func() interface{} {
+		calls++
+		return map[string]int{"size": 3}
+	})
+
+	l.WithField("state", value).Debug("suppressed")
+	if calls != 0 {
+		t.Fatalf("Lazy() calls for suppressed entry, Expected=0, Actual=%d", calls)
+	}
+
+	l.WithField("state", value).Info("emitted")
+	if calls != 1 {
+		t.Fatalf("Lazy() calls for emitted entry, Expected=1, Actual=%d", calls)
+	}
+	if v := jsoniter.Get(out.Bytes(), "ctx", "state", "size").ToInt(); v != 3 {
+		t.Fatalf("output ctx.state.size, Expected=3, Actual=%d", v)
+	}
+}
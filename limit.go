@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxFieldSize 单个字段默认的最大字节数
+	DefaultMaxFieldSize = 16 << 10
+	// DefaultMaxEntrySize 单条日志默认的最大字节数
+	DefaultMaxEntrySize = 64 << 10
+
+	truncatedKey       = "_truncated"
+	truncatedFieldsKey = "_truncated_fields"
+)
+
+// WithSizeLimits 设置单个字段与单条日志的最大字节数，0 表示不限制
+//
+// 超出限制的字段被截断，ctx 中记录 "_truncated": true 以及各字段截断前的字节数
+// "_truncated_fields"，避免过大的日志超出下游采集的限制
+func WithSizeLimits(field, entry int) Option {
+	return func(af *LogsV1Formatter) {
+		af.MaxFieldSize = field
+		af.MaxEntrySize = entry
+	}
+}
+
+// limitFields 截断超过 MaxFieldSize 的消息、错误与 ctx 内的字符串字段
+func (af *LogsV1Formatter) limitFields(data *LogsV1) {
+	max := af.MaxFieldSize
+	if max <= 0 {
+		return
+	}
+
+	var truncated map[string]int
+	record := func(path string, size int) {
+		if truncated == nil {
+			truncated = map[string]int{}
+		}
+		truncated[path] = size
+	}
+
+	if len(data.Message) > max {
+		record("m", len(data.Message))
+		data.Message = truncateString(data.Message, max)
+	}
+	if len(data.Err) > max {
+		record("err", len(data.Err))
+		data.Err = truncateString(data.Err, max)
+	}
+	for k, v := range data.Context {
+		switch val := v.(type) {
+		case string:
+			if len(val) > max {
+				record("ctx."+k, len(val))
+				data.Context[k] = truncateString(val, max)
+			}
+		case []byte:
+			if len(val) > max {
+				record("ctx."+k, len(val))
+				data.Context[k] = val[:max]
+			}
+		}
+	}
+
+	markTruncated(data, truncated)
+}
+
+// limitEntry 日志超过 MaxEntrySize 时，从最大的字段开始截断 ctx、请求参数与消息，再次调用 write 编码
+//
+// size 为当前编码后的字节数，measure 返回单个值编码后的字节数
+func limitEntry(data *LogsV1, max, size int, measure func(v interface{}) int, write func() error) error {
+	type candidate struct {
+		path  string
+		value interface{}
+		size  int
+		set   func(s string)
+	}
+
+	var candidates []candidate
+	for k, v := range data.Context {
+		k := k
+		candidates = append(candidates, candidate{
+			path:  "ctx." + k,
+			value: v,
+			set:   func(s string) { data.Context[k] = s },
+		})
+	}
+	if data.Request != nil {
+		for k, v := range data.Request.Param {
+			k := k
+			candidates = append(candidates, candidate{
+				path:  "request.param." + k,
+				value: v,
+				set:   func(s string) { data.Request.Param[k] = s },
+			})
+		}
+	}
+	candidates = append(candidates, candidate{
+		path:  "m",
+		value: data.Message,
+		set:   func(s string) { data.Message = s },
+	})
+
+	for i := range candidates {
+		candidates[i].size = measure(candidates[i].value)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].size != candidates[j].size {
+			return candidates[i].size > candidates[j].size
+		}
+		return candidates[i].path < candidates[j].path
+	})
+
+	// 预留截断标记占用的空间
+	excess := size - max + 256
+	truncated := map[string]int{}
+	for _, c := range candidates {
+		if excess <= 0 {
+			break
+		}
+
+		text, ok := c.value.(string)
+		if !ok {
+			text = sprintValue(c.value)
+		}
+		// 截断的字段需要在 _truncated_fields 中记录
+		marker := len(c.path) + 16
+		keep := c.size - excess - marker
+		if keep < 0 {
+			keep = 0
+		}
+		if keep >= len(text) {
+			continue
+		}
+
+		c.set(truncateString(text, keep))
+		truncated[c.path] = c.size
+		excess -= c.size - keep - marker
+	}
+
+	markTruncated(data, truncated)
+	return encodeSafely(write)
+}
+
+// markTruncated 在 ctx 中记录被截断的字段与截断前的字节数
+func markTruncated(data *LogsV1, truncated map[string]int) {
+	if len(truncated) == 0 {
+		return
+	}
+
+	if existing, ok := data.Context[truncatedFieldsKey].(map[string]int); ok {
+		for k, v := range existing {
+			if _, ok := truncated[k]; !ok {
+				truncated[k] = v
+			}
+		}
+	}
+	data.Context[truncatedKey] = true
+	data.Context[truncatedFieldsKey] = truncated
+}
+
+// truncateString 截断到 max 字节以内，不会截断在多字节字符的中间
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
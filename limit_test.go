@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type limitOutput struct {
+	Message string                 `json:"m"`
+	Err     string                 `json:"err"`
+	Context map[string]interface{} `json:"ctx"`
+}
+
+func formatLimited(t *testing.T, f logrus.Formatter, entry *logrus.Entry) ([]byte, limitOutput) {
+	p, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	var out limitOutput
+	if err := json.Unmarshal(p, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+	return p, out
+}
+
+func TestLimitFields(t *testing.T) {
+	f := NewFormatter("test", "prod", WithSizeLimits(8, 0))
+	_, out := formatLimited(t, f, &logrus.Entry{
+		Message: "message too long",
+		Data: logrus.Fields{
+			"error": "short",
+			"body":  "中文中文中文",
+			"ok":    "fits",
+			"num":   12345678910,
+		},
+	})
+
+	cases := []struct {
+		Actual interface{}
+		Expect interface{}
+	}{
+		{Actual: out.Message, Expect: "message "},
+		{Actual: out.Err, Expect: "short"},
+		{Actual: out.Context["body"], Expect: "中文"},
+		{Actual: out.Context["ok"], Expect: "fits"},
+		{Actual: out.Context["num"], Expect: 12345678910.0},
+		{Actual: out.Context[truncatedKey], Expect: true},
+	}
+	for idx, each := range cases {
+		if each.Actual != each.Expect {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, each.Actual)
+		}
+	}
+
+	sizes := out.Context[truncatedFieldsKey].(map[string]interface{})
+	if len(sizes) != 2 || sizes["m"] != 16.0 || sizes["ctx.body"] != 18.0 {
+		t.Fatalf("expect original sizes of m and ctx.body, got %v", sizes)
+	}
+}
+
+func TestLimitEntry(t *testing.T) {
+	f := NewFormatter("test", "prod", WithSizeLimits(0, 4096))
+	p, out := formatLimited(t, f, &logrus.Entry{
+		Message: "dump",
+		Data: logrus.Fields{
+			"payload": map[string]interface{}{"rows": strings.Repeat("x", 10000)},
+			"small":   "kept",
+		},
+	})
+
+	if len(p) > 4096 {
+		t.Fatalf("expect entry within 4096 bytes, got %d", len(p))
+	}
+	if out.Message != "dump" || out.Context["small"] != "kept" {
+		t.Fatalf("expect small fields to be kept, got %s", p)
+	}
+	if payload, _ := out.Context["payload"].(string); !strings.HasPrefix(payload, "map[rows:xxx") {
+		t.Fatalf("expect payload truncated to text, got %.80q", payload)
+	}
+	sizes := out.Context[truncatedFieldsKey].(map[string]interface{})
+	if sizes["ctx.payload"].(float64) < 10000 {
+		t.Fatalf("expect original payload size, got %v", sizes)
+	}
+}
+
+func TestLimitDefaults(t *testing.T) {
+	_, out := formatLimited(t, NewFormatter("test", "prod"), &logrus.Entry{
+		Data: logrus.Fields{"body": strings.Repeat("y", DefaultMaxFieldSize+1)},
+	})
+	if body := out.Context["body"].(string); len(body) != DefaultMaxFieldSize {
+		t.Fatalf("expect default field limit, got %d bytes", len(body))
+	}
+
+	unlimited := NewFormatter("test", "prod", WithSizeLimits(0, 0))
+	_, out = formatLimited(t, unlimited, &logrus.Entry{
+		Data: logrus.Fields{"body": strings.Repeat("y", DefaultMaxEntrySize+1)},
+	})
+	if _, ok := out.Context[truncatedKey]; ok {
+		t.Fatal("expect no truncation without limits")
+	}
+}
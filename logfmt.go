@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Formatter = (*LogfmtFormatter)(nil)
+
+// LogfmtFormatter 以 logfmt (key=value) 格式输出日志，字段名与 LogsV1 一致，
+// 嵌套字段使用 . 展开，如 ctx.order_id=1 request.method=GET
+type LogfmtFormatter struct {
+	*LogsV1Formatter
+}
+
+// NewLogfmtFormatter 创建 logfmt 格式化对象，可选配置与 NewFormatter 相同
+func NewLogfmtFormatter(service, env string, opts ...Option) *LogfmtFormatter {
+	return &LogfmtFormatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (lf *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	lf.collect(entry, data)
+	enc := lf.encoder()
+	err := encodeSafely(func() error { return writeLogfmt(b, enc, data) })
+	if err != nil {
+		b.Truncate(start)
+		err = encodeFallback(data, err,
+			func(v interface{}) error { return writeLogfmtValue(&bytes.Buffer{}, enc, "", v) },
+			func() error { return writeLogfmt(b, enc, data) },
+		)
+	}
+	if size := b.Len() - start; err == nil && lf.MaxEntrySize > 0 && size > lf.MaxEntrySize {
+		b.Truncate(start)
+		err = limitEntry(data, lf.MaxEntrySize, size,
+			func(v interface{}) int {
+				scratch := &bytes.Buffer{}
+				_ = writeLogfmtValue(scratch, enc, "", v)
+				return scratch.Len()
+			},
+			func() error { return writeLogfmt(b, enc, data) },
+		)
+	}
+	schema := data.Schema
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "logfmt encode %s log", schema)
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeLogfmt(b *bytes.Buffer, enc Encoder, data *LogsV1) error {
+	pair := func(key, value string) {
+		writeLogfmtPair(b, key, value)
+	}
+
+	pair("schema", data.Schema)
+	pair("t", string(data.timeBuf))
+	pair("l", data.Level)
+	pair("s", data.Service)
+	pair("c", data.Channel)
+	pair("i", data.ID)
+	if data.RequestID != "" {
+		pair("request_id", data.RequestID)
+	}
+	if data.Tenant != "" {
+		pair("tenant", data.Tenant)
+	}
+	if data.SessionID != "" {
+		pair("session_id", data.SessionID)
+	}
+	pair("e", data.Environment)
+	pair("u", data.User)
+	pair("m", data.Message)
+	if data.Code != "" {
+		pair("code", data.Code)
+	}
+
+	if data.Host != nil {
+		if err := writeLogfmtValue(b, enc, "host", data.Host); err != nil {
+			return err
+		}
+	}
+	if data.Retention != "" {
+		pair("retention", data.Retention)
+	}
+	if data.Build != nil {
+		if err := writeLogfmtValue(b, enc, "build", data.Build); err != nil {
+			return err
+		}
+	}
+	if err := writeLogfmtFields(b, enc, "ctx", data.Context); err != nil {
+		return err
+	}
+	pair("err", data.Err)
+	if data.ErrFingerprint != "" {
+		pair("err_fingerprint", data.ErrFingerprint)
+	}
+	if data.Errors != nil {
+		if err := writeLogfmtValue(b, enc, "errors", data.Errors); err != nil {
+			return err
+		}
+	}
+
+	sections := []struct {
+		key   string
+		value interface{}
+		omit  bool
+	}{
+		{key: "request", value: data.Request, omit: data.Request == nil},
+		{key: "request_parse_error", value: data.RequestParseError, omit: data.RequestParseError == ""},
+		{key: "response", value: data.Response, omit: data.Response == nil},
+		{key: "sql", value: data.SQL, omit: data.SQL == nil},
+		{key: "client", value: data.Client, omit: data.Client == nil},
+		{key: "mq", value: data.MQ, omit: data.MQ == nil},
+		{key: "job", value: data.Job, omit: data.Job == nil},
+		{key: "runtime", value: data.Runtime, omit: data.Runtime == nil},
+		{key: data.sectionKey, value: data.section, omit: data.sectionKey == ""},
+	}
+	for _, s := range sections {
+		if s.omit {
+			continue
+		}
+		if err := writeLogfmtValue(b, enc, s.key, s.value); err != nil {
+			return err
+		}
+	}
+
+	// 去掉末尾多余的空格
+	if b.Len() > 0 && b.Bytes()[b.Len()-1] == ' ' {
+		b.Truncate(b.Len() - 1)
+	}
+	b.WriteByte('\n')
+	return nil
+}
+
+// writeLogfmtValue 写入字段，对象按键排序后展开，其他值沿用 JSON 输出的格式
+func writeLogfmtValue(b *bytes.Buffer, enc Encoder, key string, v interface{}) error {
+	switch val := v.(type) {
+	case string:
+		writeLogfmtPair(b, key, val)
+		return nil
+	case logrus.Fields:
+		return writeLogfmtFields(b, enc, key, val)
+	case map[string]interface{}:
+		return writeLogfmtFields(b, enc, key, val)
+	}
+
+	scratch := &bytes.Buffer{}
+	if err := writeValue(scratch, enc, v); err != nil {
+		return err
+	}
+
+	raw := scratch.Bytes()
+	if len(raw) > 0 && (raw[0] == '{' || raw[0] == '"') {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var generic interface{}
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		switch val := generic.(type) {
+		case string:
+			writeLogfmtPair(b, key, val)
+			return nil
+		case map[string]interface{}:
+			return writeLogfmtFields(b, enc, key, val)
+		}
+	}
+	writeLogfmtPair(b, key, string(raw))
+	return nil
+}
+
+func writeLogfmtFields(b *bytes.Buffer, enc Encoder, prefix string, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := writeLogfmtValue(b, enc, prefix+"."+k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeLogfmtPair(b *bytes.Buffer, key, value string) {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' {
+			c = '_'
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('=')
+	if logfmtNeedsQuote(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+	b.WriteByte(' ')
+}
+
+func logfmtNeedsQuote(s string) bool {
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogfmtFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetFormatter(NewLogfmtFormatter("test", "prod"))
+	l.SetOutput(out)
+
+	l.WithFields(logrus.Fields{
+		"channel": "order",
+		"user":    "u1",
+		"attempt": 2,
+		"note":    `say "hi" a=b`,
+		"nested":  map[string]interface{}{"b": true, "a": 1.5},
+		"tags":    []string{"x", "y"},
+		"error":   errors.New("boom"),
+	}).Info("order created")
+
+	line := out.String()
+	cases := []string{
+		"schema=" + string(SchemaGeneralLogsV1) + " t=",
+		" l=info s=test c=order ",
+		" e=prod u=u1 m=\"order created\" ",
+		` ctx.attempt=2 ctx.nested.a=1.5 ctx.nested.b=true ctx.note="say \"hi\" a=b" ctx.tags="[\"x\",\"y\"]" err=boom`,
+	}
+	for idx, expect := range cases {
+		if !strings.Contains(line, expect) {
+			t.Fatalf("%d: expect %q in %q", idx, expect, line)
+		}
+	}
+	if !strings.Contains(line, " err=boom err_fingerprint=") {
+		t.Fatalf("expect err followed by err_fingerprint, got %q", line)
+	}
+}
+
+func TestLogfmtFormatterRequest(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetFormatter(NewLogfmtFormatter("test", "prod"))
+	l.SetOutput(out)
+
+	l.WithFields(logrus.Fields{
+		"request": httptest.NewRequest(http.MethodGet, "/api?q=1", nil),
+		"status":  200,
+	}).Info("http request")
+
+	line := out.String()
+	cases := []string{
+		"schema=" + string(SchemaHTTPRequestV1) + " ",
+		" request.method=GET ",
+		" request.path=/api ",
+		" request.status=200",
+		" err= ",
+	}
+	for idx, expect := range cases {
+		if !strings.Contains(line, expect) {
+			t.Fatalf("%d: expect %q in %q", idx, expect, line)
+		}
+	}
+}
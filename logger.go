@@ -4,9 +4,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// NewLogger 创建新的日志对象
-func NewLogger(service, env string) (*logrus.Logger, error) {
-	f := NewFormatter(service, env)
+// NewLogger 创建新的日志对象，同时登记 logrus 的退出处理函数，
+// Fatal 退出进程前关闭通过 RegisterCloser 登记的输出
+func NewLogger(service, env string, opts ...Option) (*logrus.Logger, error) {
+	registerExitHandler()
+	f := NewFormatter(service, env, opts...)
 
 	l := logrus.New()
 	l.SetFormatter(f)
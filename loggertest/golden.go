@@ -0,0 +1,89 @@
+package loggertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv 设置该环境变量为非空值时，AssertGolden 用当前输出更新 golden 文件
+//
+//	UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// ignoredValue 替换忽略字段的内容
+const ignoredValue = "<ignored>"
+
+// Normalize 将一行 JSON 日志转换为键按字母排序、带缩进的格式，
+// ignore 中以 . 分隔的字段路径替换为 "<ignored>"，用于调用栈等与运行环境相关的字段
+func Normalize(p []byte, ignore ...string) ([]byte, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	for _, path := range ignore {
+		replace(v, strings.Split(path, "."))
+	}
+
+	b := &bytes.Buffer{}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func replace(v interface{}, path []string) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		if _, ok := m[path[0]]; ok {
+			m[path[0]] = ignoredValue
+		}
+		return
+	}
+	replace(m[path[0]], path[1:])
+}
+
+// AssertGolden 比较格式化后的日志与 golden 文件，字段顺序不影响比较结果，
+// 设置 UPDATE_GOLDEN 环境变量时写入 golden 文件
+func AssertGolden(t testing.TB, path string, got []byte, ignore ...string) {
+	t.Helper()
+
+	normalized, err := Normalize(got, ignore...)
+	if err != nil {
+		t.Errorf("golden %s: output is not valid JSON: %s\n%s", path, err, got)
+		return
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Errorf("golden %s: %s", path, err)
+			return
+		}
+		if err := ioutil.WriteFile(path, normalized, 0o644); err != nil {
+			t.Errorf("golden %s: %s", path, err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("golden %s: %s, run with %s=1 to create it", path, err, UpdateGoldenEnv)
+		return
+	}
+	if !bytes.Equal(expected, normalized) {
+		t.Errorf("golden %s mismatch, run with %s=1 to update if the change is intended\nExpected:\n%s\nActual:\n%s",
+			path, UpdateGoldenEnv, expected, normalized)
+	}
+}
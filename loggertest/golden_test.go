@@ -0,0 +1,55 @@
+package loggertest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	p, err := Normalize([]byte(`{"m":"hi","ctx":{"b":1,"a":{"trace":["x"]}},"n":1.50}`), "ctx.a.trace", "missing.path")
+	if err != nil {
+		t.Fatalf("Normalize() error, Expected=nil, Actual=%q", err.Error())
+	}
+	expected := `{
+  "ctx": {
+    "a": {
+      "trace": "<ignored>"
+    },
+    "b": 1
+  },
+  "m": "hi",
+  "n": 1.50
+}
+`
+	if string(p) != expected {
+		t.Fatalf("Normalize(), Expected=%s, Actual=%s", expected, p)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatalf("TempDir() error, Expected=nil, Actual=%q", err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nested", "entry.json")
+
+	ft := &fakeT{}
+	AssertGolden(ft, path, []byte(`{"m":"hi"}`))
+	if len(ft.failures) != 1 {
+		t.Fatalf("missing golden file, Expected=1 failure, Actual=%v", ft.failures)
+	}
+
+	os.Setenv(UpdateGoldenEnv, "1")
+	AssertGolden(t, path, []byte(`{"m":"hi"}`))
+	os.Unsetenv(UpdateGoldenEnv)
+
+	ft = &fakeT{}
+	AssertGolden(ft, path, []byte(`{"m":"hi"}`))
+	AssertGolden(ft, path, []byte(`{"m":"changed"}`))
+	if len(ft.failures) != 1 {
+		t.Fatalf("golden comparison, Expected=1 failure, Actual=%v", ft.failures)
+	}
+}
@@ -0,0 +1,223 @@
+// Package loggertest 在内存中记录日志，用于在单元测试中检查服务输出的日志
+//
+//	l, rec := loggertest.NewRecorder()
+//	svc := NewService(l)
+//	svc.CreateOrder(ctx, order)
+//	rec.AssertLogged(t, logrus.InfoLevel, "order created", map[string]interface{}{"order_id": 42})
+package loggertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Hook = (*Recorder)(nil)
+
+// Entry 按日志规范解析的一条日志
+type Entry struct {
+	Schema      string                 `json:"schema"`
+	Time        string                 `json:"t"`
+	Level       string                 `json:"l"`
+	Service     string                 `json:"s"`
+	Channel     string                 `json:"c"`
+	ID          string                 `json:"i"`
+	RequestID   string                 `json:"request_id"`
+	Tenant      string                 `json:"tenant"`
+	SessionID   string                 `json:"session_id"`
+	Environment string                 `json:"e"`
+	User        string                 `json:"u"`
+	Message     string                 `json:"m"`
+	Code        string                 `json:"code"`
+	Context     map[string]interface{} `json:"ctx"`
+	Err         string                 `json:"err"`
+
+	// 完整的日志内容，包括 request、sql 等规范相关的字段
+	Data map[string]interface{} `json:"-"`
+	// 格式化后的原始输出
+	Raw []byte `json:"-"`
+}
+
+// Field 按字段名获得日志内容，先查找 ctx，不存在时按 . 分隔的路径查找完整的日志内容，
+// 例如 request.path
+func (e *Entry) Field(key string) (interface{}, bool) {
+	if v, ok := e.Context[key]; ok {
+		return v, true
+	}
+
+	var cur interface{} = e.Data
+	for _, part := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Recorder 记录日志的 hook，日志使用日志对象的 Formatter 格式化后解析
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewRecorder 创建记录所有级别日志的日志对象，日志不输出到其他地方
+func NewRecorder(opts ...logger.Option) (*logrus.Logger, *Recorder) {
+	l, _ := logger.NewLogger("test", "test", opts...)
+	l.SetOutput(ioutil.Discard)
+	l.SetLevel(logrus.TraceLevel)
+
+	rec := &Recorder{}
+	l.AddHook(rec)
+	return l, rec
+}
+
+// Levels implements logrus.Hook interface
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook interface
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	dup := entry.Dup()
+	dup.Level = entry.Level
+	dup.Message = entry.Message
+	dup.Caller = entry.Caller
+
+	var f logrus.Formatter = logger.NewFormatter("test", "test")
+	if entry.Logger != nil && entry.Logger.Formatter != nil {
+		f = entry.Logger.Formatter
+	}
+	p, err := f.Format(dup)
+	if err != nil {
+		return err
+	}
+
+	e := Entry{Raw: append([]byte(nil), p...)}
+	if err := json.Unmarshal(p, &e); err != nil {
+		return fmt.Errorf("loggertest: decode log: %w", err)
+	}
+	if err := json.Unmarshal(p, &e.Data); err != nil {
+		return fmt.Errorf("loggertest: decode log: %w", err)
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+	return nil
+}
+
+// Entries 返回记录的全部日志
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// LastEntry 返回最后一条日志，没有日志时返回 nil
+func (r *Recorder) LastEntry() *Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) == 0 {
+		return nil
+	}
+	e := r.entries[len(r.entries)-1]
+	return &e
+}
+
+// Reset 清空记录的日志
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// Find 返回级别为 level、消息包含 msg 且字段与 fields 一致的日志，
+// 字段值按 JSON 编码后比较，字段名的查找方式与 Entry.Field 一致
+func (r *Recorder) Find(level logrus.Level, msg string, fields map[string]interface{}) []Entry {
+	var found []Entry
+	for _, e := range r.Entries() {
+		if e.matches(level, msg, fields) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// AssertLogged 检查是否有符合条件的日志，返回第一条符合条件的日志
+func (r *Recorder) AssertLogged(t testing.TB, level logrus.Level, msg string, fields map[string]interface{}) *Entry {
+	t.Helper()
+	found := r.Find(level, msg, fields)
+	if len(found) == 0 {
+		t.Errorf("expected %s log containing %q with fields %v, recorded:\n%s", level, msg, fields, r.dump())
+		return nil
+	}
+	return &found[0]
+}
+
+// AssertNotLogged 检查没有符合条件的日志
+func (r *Recorder) AssertNotLogged(t testing.TB, level logrus.Level, msg string, fields map[string]interface{}) {
+	t.Helper()
+	if found := r.Find(level, msg, fields); len(found) > 0 {
+		t.Errorf("unexpected %s log containing %q with fields %v:\n%s", level, msg, fields, found[0].Raw)
+	}
+}
+
+// AssertCount 检查记录的日志条数
+func (r *Recorder) AssertCount(t testing.TB, n int) {
+	t.Helper()
+	if entries := r.Entries(); len(entries) != n {
+		t.Errorf("expected %d logs, recorded %d:\n%s", n, len(entries), r.dump())
+	}
+}
+
+func (e *Entry) matches(level logrus.Level, msg string, fields map[string]interface{}) bool {
+	if e.Level != level.String() || !strings.Contains(e.Message, msg) {
+		return false
+	}
+	for k, expected := range fields {
+		actual, ok := e.Field(k)
+		if !ok || !equalJSON(expected, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalJSON 将期望值按 JSON 编码后与解析出的日志字段比较
+func equalJSON(expected, actual interface{}) bool {
+	p, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(p, &normalized); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalized, actual)
+}
+
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return "  (none)"
+	}
+	b := &bytes.Buffer{}
+	for _, e := range entries {
+		b.WriteString("  ")
+		b.Write(bytes.TrimSpace(e.Raw))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package loggertest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeT 记录断言失败而不使测试失败
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	l, rec := NewRecorder()
+
+	l.WithFields(logrus.Fields{"order_id": 42, "tags": []string{"a", "b"}}).Info("order created")
+	l.WithError(errors.New("timeout")).Warn("payment retry")
+	l.Debug("debug message")
+
+	entries := rec.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Entries() len, Expected=3, Actual=%d", len(entries))
+	}
+	if e := rec.LastEntry(); e == nil || e.Level != "debug" || e.Message != "debug message" {
+		t.Fatalf("LastEntry(), Actual=%+v", e)
+	}
+
+	rec.AssertCount(t, 3)
+	e := rec.AssertLogged(t, logrus.InfoLevel, "order", map[string]interface{}{
+		"order_id": 42,
+		"tags":     []string{"a", "b"},
+		"schema":   "general.logs.v1",
+	})
+	if e == nil || e.Service != "test" {
+		t.Fatalf("AssertLogged() entry, Actual=%+v", e)
+	}
+	rec.AssertLogged(t, logrus.WarnLevel, "payment", map[string]interface{}{"err": "timeout"})
+	rec.AssertNotLogged(t, logrus.ErrorLevel, "", nil)
+
+	ft := &fakeT{}
+	rec.AssertLogged(ft, logrus.InfoLevel, "order", map[string]interface{}{"order_id": 43})
+	rec.AssertLogged(ft, logrus.ErrorLevel, "order", nil)
+	rec.AssertNotLogged(ft, logrus.InfoLevel, "order created", nil)
+	rec.AssertCount(ft, 1)
+	if len(ft.failures) != 4 {
+		t.Fatalf("failed assertions, Expected=4, Actual=%d: %v", len(ft.failures), ft.failures)
+	}
+
+	rec.Reset()
+	if rec.LastEntry() != nil {
+		t.Fatalf("LastEntry() after Reset, Expected=nil")
+	}
+}
+
+func TestRecorderRequest(t *testing.T) {
+	l, rec := NewRecorder()
+
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/users/1"}}
+	l.WithFields(logrus.Fields{"request": req, "status": 200}).Info("http request")
+
+	rec.AssertLogged(t, logrus.InfoLevel, "http request", map[string]interface{}{
+		"schema":         "http.request.v1",
+		"request.path":   "/users/1",
+		"request.status": "200",
+	})
+}
@@ -0,0 +1,475 @@
+// Package lokisink 将格式化后的日志批量推送到 Grafana Loki 的 HTTP 接口
+//
+// 日志原文作为 Loki 的日志内容，s / e / l / c 字段映射为 service / env / level / channel 标签
+package lokisink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lancer05/logger"
+)
+
+// PushPath Loki 推送接口的路径
+const PushPath = "/loki/api/v1/push"
+
+// ErrClosed 向已关闭的 Sink 写入日志
+var ErrClosed = errors.New("lokisink: sink closed")
+
+// Option Sink 的可选配置
+type Option func(*config)
+
+type config struct {
+	client       *http.Client
+	batchSize    int
+	batchWait    time.Duration
+	bufferSize   int
+	maxRetries   int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	labels       map[string]string
+	tenantID     string
+	blocking     bool
+	errorHandler func(error)
+	budget       *logger.Budget
+}
+
+// WithHTTPClient 设置推送使用的 HTTP 客户端
+func WithHTTPClient(c *http.Client) Option {
+	return func(cfg *config) {
+		cfg.client = c
+	}
+}
+
+// WithBatch 设置每批最多的日志条数与最长等待时间，默认 1000 条 / 1 秒
+func WithBatch(size int, wait time.Duration) Option {
+	return func(cfg *config) {
+		cfg.batchSize = size
+		cfg.batchWait = wait
+	}
+}
+
+// WithBufferSize 设置等待推送的日志队列长度，默认 10000
+func WithBufferSize(n int) Option {
+	return func(cfg *config) {
+		cfg.bufferSize = n
+	}
+}
+
+// WithRetry 设置推送失败时的最大重试次数与指数退避的范围，默认 5 次 / 500ms ~ 30s
+func WithRetry(max int, minBackoff, maxBackoff time.Duration) Option {
+	return func(cfg *config) {
+		cfg.maxRetries = max
+		cfg.minBackoff = minBackoff
+		cfg.maxBackoff = maxBackoff
+	}
+}
+
+// WithLabels 为所有日志附加固定标签
+func WithLabels(labels map[string]string) Option {
+	return func(cfg *config) {
+		cfg.labels = labels
+	}
+}
+
+// WithTenant 设置多租户 Loki 的租户 ID (X-Scope-OrgID)
+func WithTenant(id string) Option {
+	return func(cfg *config) {
+		cfg.tenantID = id
+	}
+}
+
+// WithBlocking 队列已满时阻塞写入，默认丢弃日志并计数，
+// 内存预算不足时仍然丢弃
+func WithBlocking() Option {
+	return func(cfg *config) {
+		cfg.blocking = true
+	}
+}
+
+// WithErrorHandler 设置推送失败的处理函数，默认输出到 stderr
+func WithErrorHandler(fn func(error)) Option {
+	return func(cfg *config) {
+		cfg.errorHandler = fn
+	}
+}
+
+// Sink 实现 io.Writer，可以直接作为 logrus 的输出，
+// 等待推送的日志占用 logger.MemoryBudget，预算不足时丢弃并计数
+//
+//	sink := lokisink.New("http://loki:3100")
+//	defer sink.Close()
+//	l.SetOutput(sink)
+type Sink struct {
+	url    string
+	config *config
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan line
+	done   chan struct{}
+
+	dropped uint64
+}
+
+type line struct {
+	key    string
+	labels map[string]string
+	ts     time.Time
+	body   string
+}
+
+// New 创建 Loki 推送对象，addr 为 Loki 的地址，未指定路径时使用 PushPath
+func New(addr string, opts ...Option) *Sink {
+	cfg := &config{
+		client:     http.DefaultClient,
+		batchSize:  1000,
+		batchWait:  time.Second,
+		bufferSize: 10000,
+		maxRetries: 5,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		errorHandler: func(err error) {
+			fmt.Fprintf(os.Stderr, "lokisink: %v\n", err)
+		},
+		budget: logger.MemoryBudget(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	addr = strings.TrimSuffix(addr, "/")
+	if !strings.HasSuffix(addr, PushPath) {
+		addr += PushPath
+	}
+
+	s := &Sink{
+		url:    addr,
+		config: cfg,
+		queue:  make(chan line, cfg.bufferSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// ConfigName 日志配置中 sinks 下的配置名
+const ConfigName = "loki"
+
+// Config 日志配置中 sinks.loki 的内容，未设置的项使用默认值，batch_wait 为 time.ParseDuration 的格式
+//
+//	sinks:
+//	  loki:
+//	    url: http://loki:3100
+//	    batch_size: 500
+//	    batch_wait: 2s
+//	    tenant: team-a
+//	    labels: {cluster: c1}
+type Config struct {
+	URL        string            `json:"url"`
+	BatchSize  int               `json:"batch_size"`
+	BatchWait  string            `json:"batch_wait"`
+	BufferSize int               `json:"buffer_size"`
+	MaxRetries int               `json:"max_retries"`
+	Labels     map[string]string `json:"labels"`
+	Tenant     string            `json:"tenant"`
+	Blocking   bool              `json:"blocking"`
+}
+
+// FromConfig 根据日志配置中的 sinks.loki 创建推送对象，未配置时返回 false，opts 优先于配置
+//
+//	c, _ := logger.LoadConfig("log.yaml")
+//	l, _ := logger.NewWithConfig(c)
+//	sink, ok, err := lokisink.FromConfig(c)
+//	if err != nil {
+//		return err
+//	}
+//	if ok {
+//		defer sink.Close()
+//		l.SetOutput(sink)
+//	}
+func FromConfig(c *logger.Config, opts ...Option) (*Sink, bool, error) {
+	sc := &Config{}
+	ok, err := c.SinkOptions(ConfigName, sc)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	if sc.URL == "" {
+		return nil, true, errors.New("lokisink: sinks.loki.url is required")
+	}
+
+	wait, err := parseDuration(sc.BatchWait)
+	if err != nil {
+		return nil, true, fmt.Errorf("lokisink: parse sinks.loki.batch_wait: %w", err)
+	}
+
+	var configOpts []Option
+	if sc.BatchSize > 0 {
+		configOpts = append(configOpts, func(cfg *config) {
+			cfg.batchSize = sc.BatchSize
+		})
+	}
+	if wait > 0 {
+		configOpts = append(configOpts, func(cfg *config) {
+			cfg.batchWait = wait
+		})
+	}
+	if sc.BufferSize > 0 {
+		configOpts = append(configOpts, WithBufferSize(sc.BufferSize))
+	}
+	if sc.MaxRetries > 0 {
+		configOpts = append(configOpts, func(cfg *config) {
+			cfg.maxRetries = sc.MaxRetries
+		})
+	}
+	if len(sc.Labels) > 0 {
+		configOpts = append(configOpts, WithLabels(sc.Labels))
+	}
+	if sc.Tenant != "" {
+		configOpts = append(configOpts, WithTenant(sc.Tenant))
+	}
+	if sc.Blocking {
+		configOpts = append(configOpts, WithBlocking())
+	}
+	return New(sc.URL, append(configOpts, opts...)...), true, nil
+}
+
+// parseDuration 解析配置中的时间，为空时返回 0
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Write implements io.Writer interface，p 为一条格式化后的 JSON 日志
+func (s *Sink) Write(p []byte) (int, error) {
+	key, labels := s.labels(p)
+	l := line{
+		key:    key,
+		labels: labels,
+		ts:     time.Now(),
+		body:   strings.TrimRight(string(p), "\n"),
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	if !s.config.budget.Acquire(int64(len(l.body))) {
+		atomic.AddUint64(&s.dropped, 1)
+		return len(p), nil
+	}
+
+	if s.config.blocking {
+		s.queue <- l
+		return len(p), nil
+	}
+
+	select {
+	case s.queue <- l:
+	default:
+		s.config.budget.Release(int64(len(l.body)))
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped 返回因队列已满、内存预算不足或推送失败而丢弃的日志条数
+func (s *Sink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close 停止接收日志，推送队列中剩余的日志后返回
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// labels 从日志中读取标签，同时返回用于分组的标签选择器
+func (s *Sink) labels(p []byte) (string, map[string]string) {
+	var fields struct {
+		Service     string `json:"s"`
+		Environment string `json:"e"`
+		Level       string `json:"l"`
+		Channel     string `json:"c"`
+	}
+	_ = json.Unmarshal(p, &fields)
+
+	labels := make(map[string]string, len(s.config.labels)+4)
+	for k, v := range s.config.labels {
+		labels[k] = v
+	}
+	set := func(k, v string) {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	set("service", fields.Service)
+	set("env", fields.Environment)
+	set("level", fields.Level)
+	set("channel", fields.Channel)
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := &strings.Builder{}
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[k]))
+	}
+	b.WriteByte('}')
+	return b.String(), labels
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+
+	batch := make([]line, 0, s.config.batchSize)
+	timer := time.NewTimer(s.config.batchWait)
+	defer timer.Stop()
+
+	flush := func() {
+		if len(batch) > 0 {
+			s.push(batch)
+			for _, l := range batch {
+				s.config.budget.Release(int64(len(l.body)))
+			}
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case l, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, l)
+			if len(batch) >= s.config.batchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(s.config.batchWait)
+		}
+	}
+}
+
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push 按标签分组推送一批日志，失败时按指数退避重试
+func (s *Sink) push(batch []line) {
+	body, err := encodeBatch(batch)
+	if err != nil {
+		s.fail(len(batch), err)
+		return
+	}
+
+	backoff := s.config.minBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.send(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= s.config.maxRetries {
+			s.fail(len(batch), err)
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > s.config.maxBackoff {
+			backoff = s.config.maxBackoff
+		}
+	}
+}
+
+func (s *Sink) fail(n int, err error) {
+	atomic.AddUint64(&s.dropped, uint64(n))
+	if s.config.errorHandler != nil {
+		s.config.errorHandler(fmt.Errorf("drop %d lines: %w", n, err))
+	}
+}
+
+// send 发送推送请求，返回值表示失败后是否可以重试
+func (s *Sink) send(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.config.tenantID)
+	}
+
+	resp, err := s.config.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	err = fmt.Errorf("push status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+func encodeBatch(batch []line) ([]byte, error) {
+	streams := map[string]*stream{}
+	order := []string{}
+	for _, l := range batch {
+		st, ok := streams[l.key]
+		if !ok {
+			st = &stream{Stream: l.labels}
+			streams[l.key] = st
+			order = append(order, l.key)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(l.ts.UnixNano(), 10), l.body})
+	}
+
+	req := pushRequest{Streams: make([]stream, 0, len(order))}
+	for _, key := range order {
+		req.Streams = append(req.Streams, *streams[key])
+	}
+	return json.Marshal(req)
+}
@@ -0,0 +1,219 @@
+package lokisink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	requests []pushRequest
+	headers  []http.Header
+	failures int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path != PushPath {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var body pushRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.requests = append(r.requests, body)
+	r.headers = append(r.headers, req.Header.Clone())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestSink(t *testing.T) {
+	rec := &recorder{failures: 1}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	sink := New(srv.URL,
+		WithBatch(10, 10*time.Millisecond),
+		WithRetry(3, time.Millisecond, time.Millisecond),
+		WithLabels(map[string]string{"cluster": "c1"}),
+		WithTenant("team-a"),
+	)
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(sink)
+	l.WithField("channel", "order").Info("first")
+	l.Warn("second")
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if _, err := sink.Write([]byte("{}")); err != ErrClosed {
+		t.Fatalf("Write() error, Expected=%v, Actual=%v", ErrClosed, err)
+	}
+
+	if len(rec.requests) != 1 {
+		t.Fatalf("expect 1 push request, got %d", len(rec.requests))
+	}
+	if tenant := rec.headers[0].Get("X-Scope-OrgID"); tenant != "team-a" {
+		t.Fatalf("expect tenant header team-a, got %q", tenant)
+	}
+
+	streams := rec.requests[0].Streams
+	if len(streams) != 2 {
+		t.Fatalf("expect 2 streams, got %d", len(streams))
+	}
+
+	cases := []struct {
+		Labels  map[string]string
+		Message string
+	}{
+		{
+			Labels:  map[string]string{"cluster": "c1", "service": "test", "env": "prod", "level": "info", "channel": "order"},
+			Message: "first",
+		},
+		{
+			Labels:  map[string]string{"cluster": "c1", "service": "test", "env": "prod", "level": "warning"},
+			Message: "second",
+		},
+	}
+	for idx, each := range cases {
+		st := streams[idx]
+		if len(st.Stream) != len(each.Labels) {
+			t.Fatalf("%d: expect labels %v, got %v", idx, each.Labels, st.Stream)
+		}
+		for k, v := range each.Labels {
+			if st.Stream[k] != v {
+				t.Fatalf("%d: expect labels %v, got %v", idx, each.Labels, st.Stream)
+			}
+		}
+
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(st.Values[0][1]), &body); err != nil {
+			t.Fatalf("%d: log body is not JSON: %q", idx, st.Values[0][1])
+		}
+		if body["m"] != each.Message {
+			t.Fatalf("%d: expect message %q, got %v", idx, each.Message, body["m"])
+		}
+	}
+}
+
+func TestSinkDrop(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var handled error
+	sink := New(srv.URL,
+		WithBatch(1, time.Hour),
+		WithBufferSize(1),
+		WithErrorHandler(func(err error) { handled = err }),
+	)
+
+	l := logrus.New()
+	l.SetFormatter(logger.NewFormatter("test", "prod"))
+	l.SetOutput(sink)
+	for i := 0; i < 10; i++ {
+		l.Info("flood")
+	}
+	close(block)
+	sink.Close()
+
+	if sink.Dropped() < 8 {
+		t.Fatalf("expect at least 8 dropped lines, got %d", sink.Dropped())
+	}
+	if handled == nil {
+		t.Fatal("expect error handler to be called on rejected push")
+	}
+}
+
+func TestSinkBudget(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	sink := New(srv.URL, WithBatch(10, time.Hour))
+	budget := logger.NewBudget(30)
+	sink.config.budget = budget
+
+	for _, body := range []string{`{"m":"first"}`, `{"m":"second"}`, `{"m":"third"}`} {
+		_, _ = sink.Write([]byte(body + "\n"))
+	}
+	if stats := budget.Stats(); stats.InUse != 27 || stats.Dropped != 1 {
+		t.Fatalf("expect 27 bytes in use and 1 dropped, got %+v", stats)
+	}
+	if sink.Dropped() != 1 {
+		t.Fatalf("expect 1 dropped line, got %d", sink.Dropped())
+	}
+
+	sink.Close()
+	if inUse := budget.Stats().InUse; inUse != 0 {
+		t.Fatalf("expect budget released after push, got %d in use", inUse)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	if _, ok, err := FromConfig(&logger.Config{}); ok || err != nil {
+		t.Fatalf("FromConfig() without sinks.loki, Expected=false,nil Actual=%v,%v", ok, err)
+	}
+	invalid := &logger.Config{Sinks: map[string]map[string]interface{}{
+		ConfigName: {"url": "http://loki:3100", "batch_wait": "soon"},
+	}}
+	if _, ok, err := FromConfig(invalid); !ok || err == nil {
+		t.Fatalf("FromConfig() with invalid batch_wait, Expected=true,error Actual=%v,%v", ok, err)
+	}
+
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	c := &logger.Config{Sinks: map[string]map[string]interface{}{
+		ConfigName: {
+			"url":        srv.URL,
+			"batch_size": 10,
+			"batch_wait": "10ms",
+			"tenant":     "team-a",
+			"labels":     map[string]interface{}{"cluster": "c1"},
+		},
+	}}
+	sink, ok, err := FromConfig(c, WithTenant("team-b"))
+	if !ok || err != nil {
+		t.Fatalf("FromConfig() Expected=true,nil Actual=%v,%v", ok, err)
+	}
+	if sink.config.batchSize != 10 || sink.config.batchWait != 10*time.Millisecond {
+		t.Fatalf("expect batch 10 / 10ms, got %d / %s", sink.config.batchSize, sink.config.batchWait)
+	}
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(sink)
+	l.Info("first")
+	sink.Close()
+
+	if len(rec.requests) != 1 {
+		t.Fatalf("expect 1 push request, got %d", len(rec.requests))
+	}
+	if tenant := rec.headers[0].Get("X-Scope-OrgID"); tenant != "team-b" {
+		t.Fatalf("expect option to override tenant, got %q", tenant)
+	}
+	if v := rec.requests[0].Streams[0].Stream["cluster"]; v != "c1" {
+		t.Fatalf("expect cluster label c1, got %q", v)
+	}
+}
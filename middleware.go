@@ -0,0 +1,314 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MiddlewareOption 访问日志中间件的可选配置
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	// 额外输出 combined 或 common 格式访问日志的目标
+	accessLog    io.Writer
+	accessFormat func(r *accessRequest, status int, size int64, start time.Time) string
+	accessLogMu  sync.Mutex
+	exclusions   []*exclusion
+	sampling     []*samplingRule
+	// 设置后使用配置文件中可以重新加载的采样规则
+	reloader    *Reloader
+	statusLevel func(status int) logrus.Level
+	// 超过阈值的请求为慢请求，0 表示不检测
+	slowThreshold time.Duration
+}
+
+// WithSlowRequestThreshold 处理时间超过 d 的请求在 ctx 中记录 "slow": true，
+// 至少以 warning 级别输出，并且不受排除规则与采样的影响
+func WithSlowRequestThreshold(d time.Duration) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.slowThreshold = d
+	}
+}
+
+// DefaultStatusLevel 默认的响应状态码与日志级别的对应关系，
+// 5xx 为 error，4xx 为 warning，其余为 info
+func DefaultStatusLevel(status int) logrus.Level {
+	switch {
+	case status >= 500:
+		return logrus.ErrorLevel
+	case status >= 400:
+		return logrus.WarnLevel
+	}
+	return logrus.InfoLevel
+}
+
+// WithStatusLevel 设置响应状态码对应的日志级别，默认使用 DefaultStatusLevel
+func WithStatusLevel(fn func(status int) logrus.Level) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.statusLevel = fn
+	}
+}
+
+// ExclusionRule 访问日志的排除规则，按路径、前缀或正则匹配请求路径，
+// 命中的请求按 Rate 采样记录，Rate 为 0 时不记录
+type ExclusionRule struct {
+	Paths    []string
+	Prefixes []string
+	Pattern  *regexp.Regexp
+	// 记录的比例，0 到 1 之间，例如 0.01 表示每 100 个请求记录 1 个
+	Rate float64
+}
+
+type exclusion struct {
+	ExclusionRule
+	sampler
+}
+
+// sampler 按比例均匀地采样，命中次数乘以比例的整数部分增加时记录
+type sampler struct {
+	hits uint64
+}
+
+func (s *sampler) sample(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	n := atomic.AddUint64(&s.hits, 1)
+	return uint64(float64(n)*rate) != uint64(float64(n-1)*rate)
+}
+
+// SamplingRule 按响应状态码采样访问日志，例如 2xx 只记录 1%：
+//
+//	logger.WithSampling(logger.SamplingRule{MinStatus: 200, MaxStatus: 299, Rate: 0.01})
+type SamplingRule struct {
+	// 状态码范围，包含两端
+	MinStatus int
+	MaxStatus int
+	// 记录的比例，0 到 1 之间
+	Rate float64
+}
+
+type samplingRule struct {
+	SamplingRule
+	sampler
+}
+
+// WithSampling 按响应状态码采样访问日志，按顺序匹配，未命中规则的请求全部记录，
+// 配合 WithSlowRequestThreshold 时慢请求总是记录。
+// 状态码在处理函数返回后才能确定，采样在请求结束时决定，不影响请求的处理过程
+func WithSampling(rules ...SamplingRule) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		for _, rule := range rules {
+			c.sampling = append(c.sampling, &samplingRule{SamplingRule: rule})
+		}
+	}
+}
+
+// WithReloadableSampling 使用 r 加载的配置文件中 sampling 的采样规则，
+// 重新加载时原子地替换，设置后 WithSampling 不再生效
+func WithReloadableSampling(r *Reloader) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.reloader = r
+	}
+}
+
+// sampled 判断响应状态码为 status 的请求是否记录
+func (c *middlewareConfig) sampled(status int) bool {
+	rules := c.sampling
+	if c.reloader != nil {
+		rules = c.reloader.samplingRules()
+	}
+	for _, rule := range rules {
+		if status >= rule.MinStatus && status <= rule.MaxStatus {
+			return rule.sample(rule.Rate)
+		}
+	}
+	return true
+}
+
+// WithExclusions 设置访问日志的排除规则，例如不记录健康检查、监控指标与静态资源的请求，
+// 按顺序匹配，使用第一条命中的规则
+//
+//	logger.WithExclusions(
+//		logger.ExclusionRule{Paths: []string{"/healthz", "/metrics"}},
+//		logger.ExclusionRule{Prefixes: []string{"/static/"}, Rate: 0.01},
+//	)
+func WithExclusions(rules ...ExclusionRule) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		for _, rule := range rules {
+			c.exclusions = append(c.exclusions, &exclusion{ExclusionRule: rule})
+		}
+	}
+}
+
+// match 判断路径是否命中规则
+func (e *exclusion) match(path string) bool {
+	for _, p := range e.Paths {
+		if path == p {
+			return true
+		}
+	}
+	for _, p := range e.Prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return e.Pattern != nil && e.Pattern.MatchString(path)
+}
+
+// skip 判断请求是否不记录访问日志
+func (c *middlewareConfig) skip(r *http.Request) bool {
+	if len(c.exclusions) == 0 || r.URL == nil {
+		return false
+	}
+	for _, e := range c.exclusions {
+		if e.match(r.URL.Path) {
+			return !e.sample(e.Rate)
+		}
+	}
+	return false
+}
+
+// WithCombinedLog 在输出 http.request.v1 日志的同时，
+// 向 w 额外写入 Apache/NCSA combined 格式的访问日志，供 awstats、goaccess 等工具分析，
+// 客户端 IP 与查询参数同样按日志对象的 IP 匿名化与参数过滤规则处理；
+// 被接管的 WebSocket 升级请求只记录 http.request.v1 日志，不写入访问日志
+func WithCombinedLog(w io.Writer) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.accessLog = w
+		c.accessFormat = combinedLine
+	}
+}
+
+// WithCommonLog 与 WithCombinedLog 相同，但写入不带 Referer 与 User-Agent 的 common 格式，
+// 同样不写入 WebSocket 升级请求，与 WithCombinedLog 同时使用时后设置的生效
+func WithCommonLog(w io.Writer) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.accessLog = w
+		c.accessFormat = commonLine
+	}
+}
+
+// writeAccessLog 向 WithCombinedLog、WithCommonLog 设置的目标写入一行访问日志，
+// 客户端 IP 与查询参数按 l 的格式化对象的配置匿名化与过滤
+func (c *middlewareConfig) writeAccessLog(l *logrus.Logger, r *http.Request, status int, size int64, start time.Time) {
+	if c.accessLog == nil {
+		return
+	}
+	var af *LogsV1Formatter
+	if fb, ok := l.Formatter.(formatterBase); ok {
+		af = fb.base()
+	}
+	line := c.accessFormat(newAccessRequest(r, af), status, size, start)
+	c.accessLogMu.Lock()
+	_, _ = io.WriteString(c.accessLog, line)
+	c.accessLogMu.Unlock()
+}
+
+// Middleware 访问日志中间件，每个请求结束后以 http.request.v1 规范记录一条日志
+func Middleware(l *logrus.Logger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	c := &middlewareConfig{statusLevel: DefaultStatusLevel}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 排除的请求没有设置慢请求阈值时直接处理，否则需要计时以便记录慢请求
+			skip := c.skip(r)
+			if skip && c.slowThreshold <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			r = r.WithContext(ContextWithRoute(r.Context()))
+			rw := newResponseWriter(w)
+			// 未知长度的请求统计处理过程中读取的字节数
+			var body *countingBody
+			if r.ContentLength < 0 && r.Body != nil {
+				body = &countingBody{ReadCloser: r.Body}
+				r.Body = body
+			}
+			// WebSocket 升级在连接被接管时单独记录，连接可能持续很久，不等处理函数返回
+			if isWebSocketUpgrade(r) {
+				rw.onHijack = func() {
+					if skip || !c.sampled(http.StatusSwitchingProtocols) {
+						return
+					}
+					l.WithContext(r.Context()).WithFields(logrus.Fields{
+						"request":  r,
+						"status":   http.StatusSwitchingProtocols,
+						"duration": time.Since(start),
+						"upgrade":  "websocket",
+					}).Log(c.statusLevel(http.StatusSwitchingProtocols), "websocket upgrade")
+				}
+			}
+			// 客户端断开连接或请求超时时记录取消的时刻，处理函数返回后服务端才会取消请求的上下文，
+			// 返回时上下文已取消说明处理过程中发生了取消
+			canceled := watchCancel(r.Context(), start)
+			next.ServeHTTP(rw, r)
+			canceledAfter, disconnected := canceled()
+			if rw.hijacked && rw.onHijack != nil {
+				return
+			}
+			duration := time.Since(start)
+			slow := c.slowThreshold > 0 && duration > c.slowThreshold
+			if !slow && (skip || !c.sampled(rw.status)) {
+				return
+			}
+
+			fields := logrus.Fields{
+				"request":   r,
+				"status":    rw.status,
+				"duration":  duration,
+				"bytes_out": rw.bytes,
+			}
+			if body != nil {
+				fields["bytes_in"] = body.n
+			}
+			// 流式响应的 duration 为整个流的持续时间，另外记录开始输出的耗时
+			if rw.wroteHeader && rw.streaming() {
+				fields["streaming"] = true
+				fields["first_byte"] = rw.wroteAt.Sub(start)
+			}
+			if disconnected {
+				fields["client_disconnected"] = true
+				fields["canceled_after"] = canceledAfter
+			}
+			level := c.statusLevel(rw.status)
+			if slow {
+				fields["slow"] = true
+				if level > logrus.WarnLevel {
+					level = logrus.WarnLevel
+				}
+			}
+			l.WithContext(r.Context()).WithFields(fields).Log(level, "http request")
+
+			c.writeAccessLog(l, r, rw.status, rw.bytes, start)
+		})
+	}
+}
+
+// countingBody 统计读取的请求 body 字节数
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read implements io.Reader interface
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,24 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// watchCancel 记录请求上下文被取消的时刻，返回的函数在处理函数返回后调用，
+// 报告处理过程中是否发生了取消以及取消时距请求开始的耗时
+func watchCancel(ctx context.Context, start time.Time) func() (time.Duration, bool) {
+	canceled := make(chan time.Duration, 1)
+	stop := context.AfterFunc(ctx, func() {
+		canceled <- time.Since(start)
+	})
+	return func() (time.Duration, bool) {
+		if stop() {
+			return 0, false
+		}
+		return <-canceled, true
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !go1.21
+// +build !go1.21
+
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// watchCancel go1.21 之前的版本没有 context.AfterFunc，只在处理函数返回后检查上下文，
+// 取消的耗时记录为处理函数返回时的耗时
+func watchCancel(ctx context.Context, start time.Time) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		if ctx.Err() == nil {
+			return 0, false
+		}
+		return time.Since(start), true
+	}
+}
@@ -0,0 +1,380 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	access := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := Middleware(l, WithCombinedLog(access))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api?x=1", nil)
+	req.RemoteAddr = "1.2.3.4:1234"
+	req.Header.Set("Referer", "http://example.com/")
+	req.Header.Set("User-Agent", `curl/7.0 "quoted"`)
+	req.SetBasicAuth("frank", "secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaHTTPRequestV1)},
+		{path: []interface{}{"request", "path"}, expected: "/api"},
+		{path: []interface{}{"request", "status"}, expected: "201"},
+		{path: []interface{}{"request", "bytes_in"}, expected: "0"},
+		{path: []interface{}{"response", "bytes_out"}, expected: "5"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+
+	expected := regexp.MustCompile(`^1\.2\.3\.4 - frank \[[^\]]+\] "POST /api\?x=1 HTTP/1\.1" 201 5 "http://example\.com/" "curl/7\.0 \\"quoted\\""\n$`)
+	if !expected.Match(access.Bytes()) {
+		t.Fatalf("combined log, Actual=%q", access.String())
+	}
+}
+
+func TestMiddlewareBytesIn(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+	}))
+
+	// 已知长度时使用 Content-Length
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello world"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if v := jsoniter.Get(out.Bytes(), "request", "bytes_in").ToInt(); v != 11 {
+		t.Fatalf("output request.bytes_in, Expected=11, Actual=%d", v)
+	}
+
+	// chunked 请求统计读取的字节数
+	out.Reset()
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello"))
+	req.ContentLength = -1
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if v := jsoniter.Get(out.Bytes(), "request", "bytes_in").ToInt(); v != 5 {
+		t.Fatalf("output request.bytes_in, Expected=5, Actual=%d", v)
+	}
+	if v := jsoniter.Get(out.Bytes(), "response", "bytes_out").ToInt(); v != 0 {
+		t.Fatalf("output response.bytes_out, Expected=0, Actual=%d", v)
+	}
+}
+
+func TestMiddlewareExclusions(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	served := 0
+	h := Middleware(l, WithExclusions(
+		ExclusionRule{Paths: []string{"/healthz", "/metrics"}},
+		ExclusionRule{Prefixes: []string{"/static/"}, Rate: 0.25},
+		ExclusionRule{Pattern: regexp.MustCompile(`\.(ico|png)$`)},
+	))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	paths := []string{"/healthz", "/metrics", "/favicon.ico", "/api/users", "/healthz/deep"}
+	for i := 0; i < 8; i++ {
+		paths = append(paths, "/static/app.js")
+	}
+	for _, p := range paths {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	if served != len(paths) {
+		t.Fatalf("served requests, Expected=%d, Actual=%d", len(paths), served)
+	}
+
+	var logged []string
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		logged = append(logged, jsoniter.Get(line, "request", "path").ToString())
+	}
+	expected := []string{"/api/users", "/healthz/deep", "/static/app.js", "/static/app.js"}
+	if strings.Join(logged, ",") != strings.Join(expected, ",") {
+		t.Fatalf("logged paths, Expected=%v, Actual=%v", expected, logged)
+	}
+}
+
+func TestMiddlewareStatusLevel(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(status)
+	})
+	custom := func(status int) logrus.Level {
+		if status == http.StatusNotFound {
+			return logrus.DebugLevel
+		}
+		return DefaultStatusLevel(status)
+	}
+	l.SetLevel(logrus.DebugLevel)
+
+	cases := []struct {
+		Options  []MiddlewareOption
+		Status   int
+		Expected string
+	}{
+		{Status: http.StatusOK, Expected: "info"},
+		{Status: http.StatusFound, Expected: "info"},
+		{Status: http.StatusNotFound, Expected: "warning"},
+		{Status: http.StatusServiceUnavailable, Expected: "error"},
+		{Options: []MiddlewareOption{WithStatusLevel(custom)}, Status: http.StatusNotFound, Expected: "debug"},
+	}
+	for _, c := range cases {
+		out.Reset()
+		Middleware(l, c.Options...)(handler).ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodGet, "/"+strconv.Itoa(c.Status), nil))
+		if v := jsoniter.Get(out.Bytes(), "l").ToString(); v != c.Expected {
+			t.Fatalf("status %d level, Expected=%q, Actual=%q", c.Status, c.Expected, v)
+		}
+	}
+}
+
+func TestMiddlewareSlowRequest(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := Middleware(l,
+		WithSlowRequestThreshold(20*time.Millisecond),
+		WithExclusions(ExclusionRule{Paths: []string{"/healthz"}}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sleep") != "" {
+			time.Sleep(30 * time.Millisecond)
+		}
+	}))
+
+	cases := []struct {
+		Target string
+		Logged bool
+		Level  string
+		Slow   bool
+	}{
+		{Target: "/api", Logged: true, Level: "info"},
+		{Target: "/api?sleep=1", Logged: true, Level: "warning", Slow: true},
+		{Target: "/healthz", Logged: false},
+		{Target: "/healthz?sleep=1", Logged: true, Level: "warning", Slow: true},
+	}
+	for _, c := range cases {
+		out.Reset()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, c.Target, nil))
+		if logged := out.Len() > 0; logged != c.Logged {
+			t.Fatalf("%s logged, Expected=%v, Actual=%v", c.Target, c.Logged, logged)
+		}
+		if !c.Logged {
+			continue
+		}
+		if v := jsoniter.Get(out.Bytes(), "l").ToString(); v != c.Level {
+			t.Fatalf("%s level, Expected=%q, Actual=%q", c.Target, c.Level, v)
+		}
+		if v := jsoniter.Get(out.Bytes(), "ctx", "slow").ToBool(); v != c.Slow {
+			t.Fatalf("%s ctx.slow, Expected=%v, Actual=%v", c.Target, c.Slow, v)
+		}
+	}
+}
+
+func TestMiddlewareSampling(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := Middleware(l,
+		WithSampling(SamplingRule{MinStatus: 200, MaxStatus: 299, Rate: 0.1}),
+		WithSlowRequestThreshold(20*time.Millisecond),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sleep") != "" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(status)
+	}))
+
+	count := func(target string, n int) int {
+		out.Reset()
+		for i := 0; i < n; i++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		}
+		return bytes.Count(out.Bytes(), []byte("\n"))
+	}
+
+	if v := count("/?status=200", 100); v != 10 {
+		t.Fatalf("logged 2xx, Expected=10, Actual=%d", v)
+	}
+	if v := count("/?status=404", 20); v != 20 {
+		t.Fatalf("logged 4xx, Expected=20, Actual=%d", v)
+	}
+	if v := count("/?status=500", 20); v != 20 {
+		t.Fatalf("logged 5xx, Expected=20, Actual=%d", v)
+	}
+	if v := count("/?status=200&sleep=1", 2); v != 2 {
+		t.Fatalf("logged slow 2xx, Expected=2, Actual=%d", v)
+	}
+}
+
+func TestMiddlewareStreaming(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		if r.URL.Path == "/flush" {
+			w.(http.Flusher).Flush()
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	}))
+
+	cases := []struct {
+		Target    string
+		Streaming bool
+	}{
+		{Target: "/events", Streaming: true},
+		{Target: "/flush", Streaming: true},
+		{Target: "/plain", Streaming: false},
+	}
+	for _, c := range cases {
+		out.Reset()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, c.Target, nil))
+		if v := jsoniter.Get(out.Bytes(), "response", "streaming").ToBool(); v != c.Streaming {
+			t.Fatalf("%s response.streaming, Expected=%v, Actual=%v", c.Target, c.Streaming, v)
+		}
+		if !c.Streaming {
+			if v := jsoniter.Get(out.Bytes(), "response", "first_byte").ToString(); v != "" {
+				t.Fatalf("%s response.first_byte, Expected=%q, Actual=%q", c.Target, "", v)
+			}
+			continue
+		}
+
+		firstByte, err := time.ParseDuration(jsoniter.Get(out.Bytes(), "response", "first_byte").ToString())
+		if err != nil {
+			t.Fatalf("ParseDuration() error, Expected=nil, Actual=%q", err)
+		}
+		duration, err := time.ParseDuration(jsoniter.Get(out.Bytes(), "request", "duration").ToString())
+		if err != nil {
+			t.Fatalf("ParseDuration() error, Expected=nil, Actual=%q", err)
+		}
+		if duration-firstByte < 20*time.Millisecond {
+			t.Fatalf("%s stream duration, Expected>=20ms, Actual=%s", c.Target, duration-firstByte)
+		}
+	}
+}
+
+func TestMiddlewareClientDisconnect(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cancel" {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			<-r.Context().Done()
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil).WithContext(ctx))
+	if v := jsoniter.Get(out.Bytes(), "ctx", "client_disconnected").ToBool(); v {
+		t.Fatalf("ctx.client_disconnected, Expected=false, Actual=%v", v)
+	}
+
+	out.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cancel", nil).WithContext(ctx))
+	if v := jsoniter.Get(out.Bytes(), "ctx", "client_disconnected").ToBool(); !v {
+		t.Fatalf("ctx.client_disconnected, Expected=true, Actual=%v", v)
+	}
+	if v := jsoniter.Get(out.Bytes(), "ctx", "canceled_after").ToFloat64(); v < 10 {
+		t.Fatalf("ctx.canceled_after, Expected>=10, Actual=%v", v)
+	}
+}
+
+func TestMiddlewareCommonLog(t *testing.T) {
+	access := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(ioutil.Discard)
+
+	h := Middleware(l, WithCommonLog(access))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api?x=1", nil)
+	req.RemoteAddr = "1.2.3.4:1234"
+	req.Header.Set("Referer", "http://example.com/")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := regexp.MustCompile(`^1\.2\.3\.4 - - \[[^\]]+\] "GET /api\?x=1 HTTP/1\.1" 200 5\n$`)
+	if !expected.Match(access.Bytes()) {
+		t.Fatalf("common log, Actual=%q", access.String())
+	}
+}
+
+func TestMiddlewareAccessLogPrivacy(t *testing.T) {
+	cases := []struct {
+		opts     []Option
+		query    string
+		expected string
+	}{
+		{opts: nil, query: "q=1&token=t0k3n", expected: `^1\.2\.3\.4 - - \[[^\]]+\] "GET /api\?q=1&token=t0k3n HTTP/1\.1" 200 -\n$`},
+		{
+			opts:     []Option{WithIPTruncation(), WithParamRules(ParamRule{Names: DefaultSensitiveParams})},
+			query:    "q=1&token=t0k3n&api_key=k3y",
+			expected: `^1\.2\.3\.0 - - \[[^\]]+\] "GET /api\?q=1&token=\[REDACTED\]&api_key=\[REDACTED\] HTTP/1\.1" 200 -\n$`,
+		},
+		{
+			opts:     []Option{WithParamRules(ParamRule{Names: []string{"token"}, Action: ParamDrop})},
+			query:    "token=t0k3n",
+			expected: `^1\.2\.3\.4 - - \[[^\]]+\] "GET /api HTTP/1\.1" 200 -\n$`,
+		},
+	}
+
+	for _, c := range cases {
+		access := &bytes.Buffer{}
+		l, _ := NewLogger("test", "test", c.opts...)
+		l.SetOutput(ioutil.Discard)
+
+		h := Middleware(l, WithCommonLog(access))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest(http.MethodGet, "/api?"+c.query, nil)
+		req.RemoteAddr = "1.2.3.4:1234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !regexp.MustCompile(c.expected).Match(access.Bytes()) {
+			t.Fatalf("common log %q, Expected=%q, Actual=%q", c.query, c.expected, access.String())
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package logger
+
+// MongoCommandData MongoDB 命令相关的参数
+type MongoCommandData struct {
+	Command    string `json:"command"`
+	Database   string `json:"database"`
+	Collection string `json:"collection,omitempty"`
+	// 命令的内容，默认只保留结构，值替换为 ?
+	Document interface{} `json:"document,omitempty"`
+	Duration string      `json:"duration"`
+	Outcome  string      `json:"outcome"`
+}
+
+func init() {
+	registerBuiltinSchema(SchemaDefinition{
+		Name:      SchemaMongoCommandV1,
+		Field:     "mongo",
+		Type:      MongoCommandData{},
+		Sensitive: []string{"document"},
+	})
+}
@@ -0,0 +1,193 @@
+// Package mongologger 提供 mongo-driver 的命令监听，以 mongo.command.v1 规范记录每条 MongoDB 命令
+//
+//	opts := options.Client().ApplyURI(uri).SetMonitor(mongologger.Monitor(l))
+//	client, err := mongo.Connect(ctx, opts)
+package mongologger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Option 命令监听的可选配置
+type Option func(*config)
+
+type config struct {
+	level         logrus.Level
+	slowThreshold time.Duration
+	withoutDoc    bool
+	rawValues     bool
+}
+
+// WithLevel 设置命令日志的级别，默认 debug
+func WithLevel(level logrus.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithSlowThreshold 执行时间超过 d 的命令提升为 warn 级别
+func WithSlowThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowThreshold = d
+	}
+}
+
+// WithoutDocument 不记录命令的内容
+func WithoutDocument() Option {
+	return func(c *config) {
+		c.withoutDoc = true
+	}
+}
+
+// WithRawValues 记录命令内容中的原始值，用于在测试环境排查问题，
+// 敏感字段可以通过格式化对象的脱敏规则处理，路径为 mongo.document.<field>
+func WithRawValues() Option {
+	return func(c *config) {
+		c.rawValues = true
+	}
+}
+
+// Monitor 创建记录 MongoDB 命令的监听，默认只记录命令内容的结构，值替换为 ?，
+// 认证等敏感命令的内容由驱动清空，不会记录
+func Monitor(l *logrus.Logger, opts ...Option) *event.CommandMonitor {
+	c := &config{
+		level: logrus.DebugLevel,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	m := &monitor{logger: l, config: c}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+	}
+}
+
+type monitor struct {
+	logger *logrus.Logger
+	config *config
+	// 执行中的命令，按 RequestID 索引，命令结束时取出
+	pending sync.Map
+}
+
+// internalFields 驱动附加在命令中的字段，不记录
+var internalFields = map[string]bool{
+	"lsid": true, "$clusterTime": true, "$db": true, "txnNumber": true,
+	"$readPreference": true, "autocommit": true, "startTransaction": true,
+}
+
+func (m *monitor) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	if !m.enabled() {
+		return
+	}
+	data := &logger.MongoCommandData{
+		Command:  evt.CommandName,
+		Database: evt.DatabaseName,
+	}
+	if v, err := evt.Command.LookupErr(evt.CommandName); err == nil {
+		data.Collection, _ = v.StringValueOK()
+	}
+	if !m.config.withoutDoc && len(evt.Command) > 0 {
+		data.Document = m.document(evt.CommandName, evt.Command)
+	}
+	m.pending.Store(evt.RequestID, data)
+}
+
+// enabled 判断命令日志可能使用的级别是否开启，都未开启时不生成命令内容
+func (m *monitor) enabled() bool {
+	if m.logger.IsLevelEnabled(m.config.level) || m.logger.IsLevelEnabled(logrus.ErrorLevel) {
+		return true
+	}
+	return m.config.slowThreshold > 0 && m.logger.IsLevelEnabled(logrus.WarnLevel)
+}
+
+func (m *monitor) succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	m.finish(ctx, &evt.CommandFinishedEvent, nil)
+}
+
+func (m *monitor) failed(ctx context.Context, evt *event.CommandFailedEvent) {
+	m.finish(ctx, &evt.CommandFinishedEvent, errors.New(evt.Failure))
+}
+
+func (m *monitor) finish(ctx context.Context, evt *event.CommandFinishedEvent, err error) {
+	v, ok := m.pending.LoadAndDelete(evt.RequestID)
+	if !ok {
+		return
+	}
+	data := v.(*logger.MongoCommandData)
+
+	duration := time.Duration(evt.DurationNanos)
+	level := m.config.level
+	if m.config.slowThreshold > 0 && duration >= m.config.slowThreshold && level > logrus.WarnLevel {
+		level = logrus.WarnLevel
+	}
+	if err != nil && level > logrus.ErrorLevel {
+		level = logrus.ErrorLevel
+	}
+	if !m.logger.IsLevelEnabled(level) {
+		return
+	}
+
+	data.Duration = duration.String()
+	data.Outcome = logger.OutcomeSuccess
+	entry := m.logger.WithContext(ctx).WithField("mongo", data)
+	if err != nil {
+		data.Outcome = logger.OutcomeError
+		entry = entry.WithField("error", err)
+	}
+	entry.Log(level, "mongo command")
+}
+
+// document 将命令转换为 relaxed extended JSON 格式的对象，去掉驱动附加的字段，
+// 命令名对应的集合名不替换
+func (m *monitor) document(name string, raw bson.Raw) interface{} {
+	b, err := bson.MarshalExtJSON(raw, false, false)
+	if err != nil {
+		return nil
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil
+	}
+	for k := range doc {
+		if internalFields[k] {
+			delete(doc, k)
+		}
+	}
+	if m.config.rawValues {
+		return doc
+	}
+	collection, ok := doc[name]
+	shape(doc)
+	if ok {
+		doc[name] = collection
+	}
+	return doc
+}
+
+// shape 保留文档的结构，值替换为 ?，数组只保留第一个元素的结构
+func shape(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = shape(item)
+		}
+		return val
+	case []interface{}:
+		if len(val) == 0 {
+			return val
+		}
+		return []interface{}{shape(val[0])}
+	}
+	return "?"
+}
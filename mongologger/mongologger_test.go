@@ -0,0 +1,109 @@
+package mongologger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func command(t *testing.T, doc bson.D) bson.Raw {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("bson.Marshal() error, Expected=nil, Actual=%q", err)
+	}
+	return raw
+}
+
+func TestMonitor(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetLevel(logrus.DebugLevel)
+
+	ctx := context.Background()
+	find := command(t, bson.D{
+		{Key: "find", Value: "users"},
+		{Key: "filter", Value: bson.D{{Key: "email", Value: "frank@example.com"}, {Key: "age", Value: bson.D{{Key: "$gt", Value: 18}}}}},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: "session"}}},
+		{Key: "$db", Value: "app"},
+	})
+
+	m := Monitor(l, WithSlowThreshold(time.Second))
+	m.Started(ctx, &event.CommandStartedEvent{Command: find, DatabaseName: "app", CommandName: "find", RequestID: 1})
+	m.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+		DurationNanos: int64(2 * time.Second), CommandName: "find", RequestID: 1,
+	}})
+	m.Started(ctx, &event.CommandStartedEvent{Command: find, DatabaseName: "app", CommandName: "find", RequestID: 2})
+	m.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+		DurationNanos: int64(time.Millisecond), CommandName: "find", RequestID: 2,
+	}, Failure: "(Unauthorized) not authorized"})
+
+	raw := Monitor(l, WithRawValues())
+	raw.Started(ctx, &event.CommandStartedEvent{Command: find, DatabaseName: "app", CommandName: "find", RequestID: 3})
+	raw.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 3}})
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if len(lines) != 3 {
+		t.Fatalf("log lines, Expected=3, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"schema"}, expected: string(logger.SchemaMongoCommandV1)},
+		{line: 0, path: []interface{}{"l"}, expected: "warning"},
+		{line: 0, path: []interface{}{"mongo", "command"}, expected: "find"},
+		{line: 0, path: []interface{}{"mongo", "database"}, expected: "app"},
+		{line: 0, path: []interface{}{"mongo", "collection"}, expected: "users"},
+		{line: 0, path: []interface{}{"mongo", "outcome"}, expected: logger.OutcomeSuccess},
+		{line: 0, path: []interface{}{"mongo", "duration"}, expected: "2s"},
+		{line: 0, path: []interface{}{"mongo", "document", "find"}, expected: "users"},
+		{line: 0, path: []interface{}{"mongo", "document", "filter", "email"}, expected: "?"},
+		{line: 0, path: []interface{}{"mongo", "document", "filter", "age", "$gt"}, expected: "?"},
+		{line: 0, path: []interface{}{"mongo", "document", "lsid"}, expected: ""},
+		{line: 1, path: []interface{}{"l"}, expected: "error"},
+		{line: 1, path: []interface{}{"mongo", "outcome"}, expected: logger.OutcomeError},
+		{line: 1, path: []interface{}{"err"}, expected: "(Unauthorized) not authorized"},
+		{line: 2, path: []interface{}{"mongo", "document", "filter", "email"}, expected: "frank@example.com"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
+
+func TestMonitorDisabled(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetLevel(logrus.FatalLevel)
+
+	ctx := context.Background()
+	find := command(t, bson.D{{Key: "find", Value: "users"}, {Key: "$db", Value: "app"}})
+	m := &monitor{logger: l, config: &config{level: logrus.DebugLevel, slowThreshold: time.Second}}
+	m.started(ctx, &event.CommandStartedEvent{Command: find, DatabaseName: "app", CommandName: "find", RequestID: 1})
+	if _, ok := m.pending.Load(int64(1)); ok {
+		t.Fatalf("pending error, Expected=empty, Actual=%d", 1)
+	}
+	m.failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+		DurationNanos: int64(2 * time.Second), CommandName: "find", RequestID: 1,
+	}, Failure: "boom"})
+	if out.Len() != 0 {
+		t.Fatalf("output error, Expected=empty, Actual=%q", out.String())
+	}
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 消息的处理方向
+const (
+	DirectionConsume = "consume"
+	DirectionPublish = "publish"
+)
+
+// 消息的处理结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	OutcomePanic   = "panic"
+)
+
+// MessageData 消息队列相关的参数，适用于 Kafka、NATS、RabbitMQ 等
+type MessageData struct {
+	// 消息系统，例如 kafka、nats、rabbitmq
+	System    string `json:"system"`
+	Direction string `json:"direction"`
+	// topic、subject 或 queue 名称
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	MessageID string `json:"message_id"`
+	Size      int    `json:"size"`
+	Duration  string `json:"duration"`
+	Outcome   string `json:"outcome"`
+}
+
+// HandleMessage 执行消息处理函数，并以 mq.message.v1 规范记录耗时与处理结果
+//
+//	err := logger.HandleMessage(l, &logger.MessageData{
+//		System:    "kafka",
+//		Topic:     msg.Topic,
+//		Partition: msg.Partition,
+//		Offset:    msg.Offset,
+//		Size:      len(msg.Value),
+//	}, func() error {
+//		return process(msg)
+//	})
+func HandleMessage(l *logrus.Logger, msg *MessageData, handler func() error) error {
+	if msg.Direction == "" {
+		msg.Direction = DirectionConsume
+	}
+
+	start := time.Now()
+	err := handler()
+	LogMessage(l, msg, start, err)
+	return err
+}
+
+// LogMessage 记录从 start 开始的一次消息处理，发送消息后也可以直接调用
+func LogMessage(l logrus.FieldLogger, msg *MessageData, start time.Time, err error) {
+	if msg.Direction == "" {
+		msg.Direction = DirectionPublish
+	}
+	msg.Duration = time.Since(start).String()
+
+	if err != nil {
+		msg.Outcome = OutcomeError
+		l.WithField("mq", msg).WithField("error", err).Error("mq message")
+		return
+	}
+
+	msg.Outcome = OutcomeSuccess
+	l.WithField("mq", msg).Info("mq message")
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestHandleMessage(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	msg := &MessageData{System: "kafka", Topic: "orders", Partition: 3, Offset: 42, Size: 128}
+	err := HandleMessage(l, msg, func() error {
+		return errors.New("boom")
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("HandleMessage() error, Expected=%q, Actual=%v", "boom", err)
+	}
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaMQMessageV1)},
+		{path: []interface{}{"l"}, expected: "error"},
+		{path: []interface{}{"err"}, expected: "boom"},
+		{path: []interface{}{"mq", "direction"}, expected: DirectionConsume},
+		{path: []interface{}{"mq", "topic"}, expected: "orders"},
+		{path: []interface{}{"mq", "partition"}, expected: "3"},
+		{path: []interface{}{"mq", "offset"}, expected: "42"},
+		{path: []interface{}{"mq", "outcome"}, expected: OutcomeError},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/lancer05/logger/internal/msgpack"
+)
+
+// MsgpackExt MessagePack 扩展类型
+type MsgpackExt = msgpack.Ext
+
+// MsgpackDecoder 从输入中依次解码 MsgpackFormatter 输出的日志
+//
+// 整数解码为 int64，浮点数解码为 float64，嵌套对象解码为 map[string]interface{}
+type MsgpackDecoder struct {
+	d *msgpack.Decoder
+}
+
+// NewMsgpackDecoder 创建 MessagePack 日志解码器
+func NewMsgpackDecoder(r io.Reader) *MsgpackDecoder {
+	return &MsgpackDecoder{d: msgpack.NewDecoder(r)}
+}
+
+// Decode 解码下一条日志，输入结束时返回 io.EOF
+func (d *MsgpackDecoder) Decode() (map[string]interface{}, error) {
+	v, err := d.d.Decode()
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("msgpack: expected map, got %T", v)
+	}
+	return m, nil
+}
+
+// DecodeMsgpack 解码单条 MessagePack 日志
+func DecodeMsgpack(p []byte) (map[string]interface{}, error) {
+	return NewMsgpackDecoder(bytes.NewReader(p)).Decode()
+}
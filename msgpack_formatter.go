@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"bytes"
+
+	"github.com/lancer05/logger/internal/msgpack"
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Formatter = (*MsgpackFormatter)(nil)
+
+// MsgpackFormatter 以 MessagePack 格式输出日志，字段名与 LogsV1 的 JSON 输出一致，
+// 用于 JSON 编解码成为瓶颈的内部日志管道，可以使用 MsgpackDecoder 解码
+type MsgpackFormatter struct {
+	*LogsV1Formatter
+}
+
+// NewMsgpackFormatter 创建 MessagePack 格式化对象，可选配置与 NewFormatter 相同
+func NewMsgpackFormatter(service, env string, opts ...Option) *MsgpackFormatter {
+	return &MsgpackFormatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (mf *MsgpackFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	mf.collect(entry, data)
+	enc := mf.encoder()
+	err := encodeSafely(func() error { return writeMsgpackLogsV1(msgpack.NewWriter(b, enc), data) })
+	if err != nil {
+		b.Truncate(start)
+		err = encodeFallback(data, err,
+			func(v interface{}) error { return msgpack.NewWriter(&bytes.Buffer{}, enc).WriteValue(v) },
+			func() error { return writeMsgpackLogsV1(msgpack.NewWriter(b, enc), data) },
+		)
+	}
+	if size := b.Len() - start; err == nil && mf.MaxEntrySize > 0 && size > mf.MaxEntrySize {
+		b.Truncate(start)
+		err = limitEntry(data, mf.MaxEntrySize, size,
+			func(v interface{}) int {
+				scratch := &bytes.Buffer{}
+				_ = msgpack.NewWriter(scratch, enc).WriteValue(v)
+				return scratch.Len()
+			},
+			func() error { return writeMsgpackLogsV1(msgpack.NewWriter(b, enc), data) },
+		)
+	}
+	schema := data.Schema
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "msgpack encode %s log", schema)
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeMsgpackLogsV1(w *msgpack.Writer, data *LogsV1) error {
+	fields := []struct {
+		key   string
+		value interface{}
+		omit  bool
+	}{
+		{key: "schema", value: data.Schema},
+		{key: "t", value: string(data.timeBuf)},
+		{key: "l", value: data.Level},
+		{key: "s", value: data.Service},
+		{key: "c", value: data.Channel},
+		{key: "i", value: data.ID},
+		{key: "request_id", value: data.RequestID, omit: data.RequestID == ""},
+		{key: "tenant", value: data.Tenant, omit: data.Tenant == ""},
+		{key: "session_id", value: data.SessionID, omit: data.SessionID == ""},
+		{key: "e", value: data.Environment},
+		{key: "u", value: data.User},
+		{key: "m", value: data.Message},
+		{key: "code", value: data.Code, omit: data.Code == ""},
+		{key: "host", value: data.Host, omit: data.Host == nil},
+		{key: "retention", value: data.Retention, omit: data.Retention == ""},
+		{key: "build", value: data.Build, omit: data.Build == nil},
+		{key: "ctx", value: data.Context},
+		{key: "err", value: data.Err},
+		{key: "err_fingerprint", value: data.ErrFingerprint, omit: data.ErrFingerprint == ""},
+		{key: "errors", value: data.Errors, omit: data.Errors == nil},
+		{key: "request", value: data.Request, omit: data.Request == nil},
+		{key: "request_parse_error", value: data.RequestParseError, omit: data.RequestParseError == ""},
+		{key: "response", value: data.Response, omit: data.Response == nil},
+		{key: "sql", value: data.SQL, omit: data.SQL == nil},
+		{key: "client", value: data.Client, omit: data.Client == nil},
+		{key: "mq", value: data.MQ, omit: data.MQ == nil},
+		{key: "job", value: data.Job, omit: data.Job == nil},
+		{key: "runtime", value: data.Runtime, omit: data.Runtime == nil},
+		{key: data.sectionKey, value: data.section, omit: data.sectionKey == ""},
+	}
+
+	n := 0
+	for _, f := range fields {
+		if !f.omit {
+			n++
+		}
+	}
+
+	w.WriteMapHeader(n)
+	for _, f := range fields {
+		if f.omit {
+			continue
+		}
+		w.WriteString(f.key)
+		if err := w.WriteValue(f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
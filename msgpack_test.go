@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMsgpackFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetFormatter(NewMsgpackFormatter("test", "prod"))
+	l.SetOutput(out)
+
+	l.WithFields(logrus.Fields{"channel": "mq", "attempt": 2}).Info("first")
+	l.WithFields(logrus.Fields{
+		"request": httptest.NewRequest(http.MethodGet, "/api", nil),
+		"status":  200,
+		"elapsed": time.Second,
+	}).Warn("second")
+
+	d := NewMsgpackDecoder(out)
+	first, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode() error, Expected=nil, Actual=%q", err.Error())
+	}
+	second, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Fatalf("Decode() error, Expected=EOF, Actual=%v", err)
+	}
+
+	cases := []struct {
+		Actual interface{}
+		Expect interface{}
+	}{
+		{Actual: first["schema"], Expect: string(SchemaGeneralLogsV1)},
+		{Actual: first["s"], Expect: "test"},
+		{Actual: first["e"], Expect: "prod"},
+		{Actual: first["c"], Expect: "mq"},
+		{Actual: first["m"], Expect: "first"},
+		{Actual: first["ctx"].(map[string]interface{})["attempt"], Expect: int64(2)},
+		{Actual: second["schema"], Expect: string(SchemaHTTPRequestV1)},
+		{Actual: second["l"], Expect: "warning"},
+		{Actual: second["request"].(map[string]interface{})["path"], Expect: "/api"},
+		{Actual: second["request"].(map[string]interface{})["status"], Expect: "200"},
+		{Actual: second["ctx"].(map[string]interface{})["elapsed"], Expect: 1000.0},
+	}
+
+	for idx, each := range cases {
+		if !reflect.DeepEqual(each.Actual, each.Expect) {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, each.Actual)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+)
+
+// DefaultMaxMultipartSize 解析 multipart 请求时默认最多读取的 body 字节数
+const DefaultMaxMultipartSize = 1 << 20
+
+// WithMaxMultipartSize 设置解析 multipart 请求时最多读取的 body 字节数，
+// 0 表示不读取 body，只记录处理请求时已经解析的表单
+func WithMaxMultipartSize(n int64) Option {
+	return func(af *LogsV1Formatter) {
+		af.MaxMultipartSize = n
+	}
+}
+
+// FileData 上传文件的信息，不记录文件内容
+type FileData struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// replayBody 读取过的内容与原 body 剩余部分组成的 body，关闭时关闭原 body
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// multipartParams 将 multipart 表单的字段记录为请求参数，文件只记录文件名、类型与大小
+//
+// 请求已经调用过 ParseMultipartForm 时直接使用解析结果，表单字段已经包含在 req.Form 中；
+// 否则最多读取 limit 字节的 body，读取的内容会还原到 req.Body，不影响之后的处理
+func multipartParams(req *http.Request, request *RequestData, limit int64) error {
+	if form := req.MultipartForm; form != nil {
+		for k, files := range form.File {
+			for _, fh := range files {
+				request.Files = append(request.Files, FileData{
+					Field:       k,
+					Filename:    fh.Filename,
+					ContentType: fh.Header.Get("Content-Type"),
+					Size:        fh.Size,
+				})
+			}
+		}
+		// 表单是 map，按字段名排序使输出稳定
+		sort.SliceStable(request.Files, func(i, j int) bool {
+			return request.Files[i].Field < request.Files[j].Field
+		})
+		return nil
+	}
+	if req.Body == nil || limit <= 0 {
+		return nil
+	}
+
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return errors.New("missing boundary")
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+	req.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+	if err != nil {
+		return err
+	}
+	exceeded := int64(len(buf)) > limit
+	if exceeded {
+		buf = buf[:limit]
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(buf), boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if exceeded {
+				break
+			}
+			return err
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+		if part.FileName() == "" {
+			value, err := ioutil.ReadAll(part)
+			if err != nil {
+				if exceeded {
+					break
+				}
+				return err
+			}
+			setParam(request, name, []string{string(value)})
+			continue
+		}
+
+		size, err := io.Copy(ioutil.Discard, part)
+		if err != nil && exceeded {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		request.Files = append(request.Files, FileData{
+			Field:       name,
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        size,
+		})
+	}
+
+	if exceeded {
+		return fmt.Errorf("body exceeds %d bytes", limit)
+	}
+	return nil
+}
+
+// setParam 记录请求参数，同名参数有多个值时记录为数组
+func setParam(request *RequestData, k string, v []string) {
+	if prev, ok := request.Param[k]; ok {
+		switch p := prev.(type) {
+		case string:
+			v = append([]string{p}, v...)
+		case []string:
+			v = append(append([]string(nil), p...), v...)
+		}
+	}
+
+	switch len(v) {
+	case 0:
+	case 1:
+		request.Param[k] = v[0]
+	default:
+		request.Param[k] = v
+	}
+}
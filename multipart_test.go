@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func newMultipartRequest(t *testing.T) (*http.Request, []byte) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	_ = mw.WriteField("name", "gopher")
+	_ = mw.WriteField("tag", "a")
+	_ = mw.WriteField("tag", "b")
+	fw, err := mw.CreateFormFile("avatar", "me.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write(bytes.Repeat([]byte{0x89}, 300))
+	_ = mw.Close()
+
+	req := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: "/upload"},
+		Header: http.Header{"Content-Type": {mw.FormDataContentType()}},
+		Body:   ioutil.NopCloser(bytes.NewReader(body.Bytes())),
+	}
+	return req, body.Bytes()
+}
+
+func TestFormatMultipart(t *testing.T) {
+	f := NewFormatter("test", "test")
+
+	req, raw := newMultipartRequest(t)
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if v := jsoniter.Get(data, "request", "param", "name").ToString(); v != "gopher" {
+		t.Fatalf("output request.param.name, Expected=%q, Actual=%q", "gopher", v)
+	}
+	if v := jsoniter.Get(data, "request", "param", "tag").ToString(); v != `["a","b"]` {
+		t.Fatalf("output request.param.tag, Expected=%q, Actual=%q", `["a","b"]`, v)
+	}
+	file := jsoniter.Get(data, "request", "files", 0)
+	if file.Get("field").ToString() != "avatar" || file.Get("filename").ToString() != "me.png" ||
+		file.Get("content_type").ToString() != "application/octet-stream" || file.Get("size").ToInt() != 300 {
+		t.Fatalf("output request.files, Actual=%s", file.ToString())
+	}
+	if strings.Contains(string(data), "\x89") {
+		t.Fatalf("output must not contain file content")
+	}
+
+	// body 还原后仍然可以被处理请求的代码读取
+	if b, _ := ioutil.ReadAll(req.Body); !bytes.Equal(b, raw) {
+		t.Fatalf("request body is not restored")
+	}
+}
+
+func TestFormatMultipartParsed(t *testing.T) {
+	f := NewFormatter("test", "test")
+
+	req, _ := newMultipartRequest(t)
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if v := jsoniter.Get(data, "request", "param", "name").ToString(); v != "gopher" {
+		t.Fatalf("output request.param.name, Expected=%q, Actual=%q", "gopher", v)
+	}
+	if v := jsoniter.Get(data, "request", "files", 0, "size").ToInt(); v != 300 {
+		t.Fatalf("output request.files[0].size, Expected=300, Actual=%d", v)
+	}
+}
+
+func TestFormatMultipartLimit(t *testing.T) {
+	f := NewFormatter("test", "test", WithMaxMultipartSize(200))
+
+	req, raw := newMultipartRequest(t)
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if v := jsoniter.Get(data, "request", "param", "name").ToString(); v != "gopher" {
+		t.Fatalf("output request.param.name, Expected=%q, Actual=%q", "gopher", v)
+	}
+	if v := jsoniter.Get(data, "request", "files").Size(); v != 0 {
+		t.Fatalf("output request.files, Expected none, Actual=%d", v)
+	}
+	if v := jsoniter.Get(data, "request_parse_error").ToString(); v != "parse multipart: body exceeds 200 bytes" {
+		t.Fatalf("output request_parse_error, Actual=%q", v)
+	}
+	if b, _ := ioutil.ReadAll(req.Body); !bytes.Equal(b, raw) {
+		t.Fatalf("request body is not restored")
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"encoding/base64"
+	"time"
+)
+
+// BytesEncoding []byte 字段的输出格式
+type BytesEncoding string
+
+const (
+	// BytesBase64 标准 base64 编码
+	BytesBase64 BytesEncoding = "base64"
+	// BytesHex 小写十六进制
+	BytesHex BytesEncoding = "hex"
+)
+
+// WithBytesEncoding 设置 ctx 中 []byte 字段的输出格式
+func WithBytesEncoding(e BytesEncoding) Option {
+	return func(af *LogsV1Formatter) {
+		af.BytesEncoding = e
+	}
+}
+
+// normalizer 统一 ctx 字段的输出格式：
+// time.Duration 输出为毫秒数，time.Time 使用格式化对象的 TimeLayout，[]byte 按 BytesEncoding 编码
+type normalizer struct {
+	timeLayout string
+	bytes      BytesEncoding
+}
+
+func (n normalizer) value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case time.Duration:
+		return float64(val) / float64(time.Millisecond)
+	case time.Time:
+		if n.timeLayout == "" {
+			return val.Format(time.RFC3339Nano)
+		}
+		return val.Format(n.timeLayout)
+	case []byte:
+		if n.bytes == BytesHex {
+			encoded := make([]byte, len(val)*2)
+			for i, c := range val {
+				encoded[i*2] = hex[c>>4]
+				encoded[i*2+1] = hex[c&0xf]
+			}
+			return string(encoded)
+		}
+		return base64.StdEncoding.EncodeToString(val)
+	}
+	return v
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNormalize(t *testing.T) {
+	at := time.Date(2021, 5, 19, 8, 30, 0, 0, time.UTC)
+
+	type event struct {
+		Name    string        `json:"name"`
+		Elapsed time.Duration `json:"elapsed"`
+	}
+
+	entry := &logrus.Entry{
+		Data: logrus.Fields{
+			"elapsed": 1500 * time.Microsecond,
+			"at":      at,
+			"raw":     []byte{0xde, 0xad, 0xbe, 0xef},
+			"nested":  map[string]interface{}{"timeout": 2 * time.Second},
+			"event":   event{Name: "sync", Elapsed: time.Minute},
+		},
+	}
+
+	var out struct {
+		Context map[string]interface{} `json:"ctx"`
+	}
+
+	cases := []struct {
+		Formatter logrus.Formatter
+		Time      string
+		Raw       string
+	}{
+		{Formatter: NewFormatter("test", "prod"), Time: "2021-05-19T08:30:00Z", Raw: "3q2+7w=="},
+		{
+			Formatter: NewFormatter("test", "prod", WithBytesEncoding(BytesHex), func(af *LogsV1Formatter) {
+				af.TimeLayout = "2006-01-02 15:04:05"
+			}),
+			Time: "2021-05-19 08:30:00",
+			Raw:  "deadbeef",
+		},
+	}
+
+	for idx, each := range cases {
+		p, err := each.Formatter.Format(entry)
+		if err != nil {
+			t.Fatalf("%d: Format() error, Expected=nil, Actual=%q", idx, err.Error())
+		}
+		if err := json.Unmarshal(p, &out); err != nil {
+			t.Fatalf("%d: output is not valid JSON: %s", idx, err)
+		}
+
+		ctx := out.Context
+		if ctx["elapsed"] != 1.5 {
+			t.Fatalf("%d: expect elapsed 1.5ms, got %v", idx, ctx["elapsed"])
+		}
+		if ctx["at"] != each.Time {
+			t.Fatalf("%d: expect at %q, got %v", idx, each.Time, ctx["at"])
+		}
+		if ctx["raw"] != each.Raw {
+			t.Fatalf("%d: expect raw %q, got %v", idx, each.Raw, ctx["raw"])
+		}
+		if timeout := ctx["nested"].(map[string]interface{})["timeout"]; timeout != 2000.0 {
+			t.Fatalf("%d: expect nested timeout 2000ms, got %v", idx, timeout)
+		}
+		if ev := ctx["event"].(map[string]interface{}); ev["name"] != "sync" || ev["elapsed"] != 60000.0 {
+			t.Fatalf("%d: expect struct duration in ms, got %v", idx, ev)
+		}
+	}
+}
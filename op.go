@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OpData 一次操作的执行情况
+type OpData struct {
+	Name     string `json:"name"`
+	Duration string `json:"duration"`
+	Outcome  string `json:"outcome"`
+}
+
+// Op 一次操作，结束时记录一条包含耗时与结果的日志
+type Op struct {
+	name  string
+	start time.Time
+	ctx   context.Context
+	entry *logrus.Entry
+}
+
+// StartOp 开始名为 name 的操作，操作继承 ctx 中日志对象的字段，
+// 返回的 Op.Context() 会额外带有 op_name 字段，嵌套的操作可以据此追溯
+//
+//	op := logger.StartOp(ctx, "rebuild-index")
+//	err := rebuild(op.Context())
+//	op.End(err)
+func StartOp(ctx context.Context, name string) *Op {
+	entry := FromContext(ctx).WithField("op_name", name)
+	return &Op{
+		name:  name,
+		start: time.Now(),
+		ctx:   NewContext(ctx, entry),
+		entry: entry,
+	}
+}
+
+// Context 获得携带本次操作日志对象的 ctx
+func (o *Op) Context() context.Context {
+	return o.ctx
+}
+
+// End 结束操作并记录日志，err 不为空时以 error 级别记录
+func (o *Op) End(err error) {
+	data := &OpData{
+		Name:     o.name,
+		Duration: time.Since(o.start).String(),
+		Outcome:  OutcomeSuccess,
+	}
+
+	if err != nil {
+		data.Outcome = OutcomeError
+		o.entry.WithFields(logrus.Fields{
+			"op":    data,
+			"error": err,
+		}).Error("op failed")
+		return
+	}
+
+	o.entry.WithField("op", data).Info("op finished")
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestStartOp(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	ctx := NewContext(context.Background(), l.WithField("tenant", "acme"))
+	op := StartOp(ctx, "rebuild-index")
+	op.End(errors.New("boom"))
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"l"}, expected: "error"},
+		{path: []interface{}{"err"}, expected: "boom"},
+		{path: []interface{}{"tenant"}, expected: "acme"},
+		{path: []interface{}{"ctx", "op", "name"}, expected: "rebuild-index"},
+		{path: []interface{}{"ctx", "op", "outcome"}, expected: OutcomeError},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+	if v := jsoniter.Get(data, "ctx", "op", "duration").ToString(); v == "" {
+		t.Fatalf("output ctx.op.duration should not be empty")
+	}
+}
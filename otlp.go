@@ -0,0 +1,372 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Formatter = (*OTLPFormatter)(nil)
+
+// otlpScope 输出的 instrumentation scope 名称
+const otlpScope = "github.com/lancer05/logger"
+
+// OTLPMapping LogsV1 字段到 OpenTelemetry 属性名的映射，键为字段名，值为属性名，值为空时不输出该字段
+//
+// 可以映射的字段为 service、env、channel、id、user、request_id、tenant、session_id、code、kind、retention，
+// 以及 host.hostname、host.pid、host.container_id、host.instance_id、build.version、build.revision、build.go；
+// ctx 中的字段与 request、sql 等规范内容直接使用原来的字段名作为属性名
+type OTLPMapping struct {
+	// 输出到 resource.attributes 的字段
+	Resource map[string]string
+	// 输出到 logRecord.attributes 的字段
+	Attributes map[string]string
+}
+
+// DefaultOTLPMapping 默认的字段映射，尽量使用 OpenTelemetry 语义约定中的属性名
+var DefaultOTLPMapping = OTLPMapping{
+	Resource: map[string]string{
+		"service":           "service.name",
+		"env":               "deployment.environment",
+		"host.hostname":     "host.name",
+		"host.pid":          "process.pid",
+		"host.container_id": "container.id",
+		"host.instance_id":  "service.instance.id",
+		"build.version":     "service.version",
+		"build.revision":    "vcs.revision",
+		"build.go":          "process.runtime.version",
+	},
+	Attributes: map[string]string{
+		"channel":    "log.channel",
+		"id":         "log.record.uid",
+		"user":       "enduser.id",
+		"request_id": "request_id",
+		"tenant":     "tenant",
+		"session_id": "session.id",
+		"code":       "log.code",
+		"kind":       "log.kind",
+		"retention":  "log.retention",
+	},
+}
+
+// merge 在 m 的基础上覆盖 override 中的映射，字段只会出现在 Resource 与 Attributes 其中之一
+func (m OTLPMapping) merge(override *OTLPMapping) OTLPMapping {
+	merged := OTLPMapping{Resource: map[string]string{}, Attributes: map[string]string{}}
+	for k, v := range m.Resource {
+		merged.Resource[k] = v
+	}
+	for k, v := range m.Attributes {
+		merged.Attributes[k] = v
+	}
+	if override == nil {
+		return merged
+	}
+	for k, v := range override.Resource {
+		delete(merged.Attributes, k)
+		merged.Resource[k] = v
+	}
+	for k, v := range override.Attributes {
+		delete(merged.Resource, k)
+		merged.Attributes[k] = v
+	}
+	return merged
+}
+
+// OTLPFormatter 以 OTLP/JSON 格式输出日志，每行为一个 ExportLogsServiceRequest，
+// 可以由 OpenTelemetry Collector 的 otlpjsonfile receiver 读取后导出
+//
+// ctx 中的 trace_id 与 span_id 为合法的十六进制 ID 时写入 traceId 与 spanId，
+// 错误信息记录为 exception.message、exception.type 与 exception.stacktrace
+type OTLPFormatter struct {
+	*LogsV1Formatter
+	// 合并默认映射后的字段映射
+	Mapping OTLPMapping
+}
+
+// NewOTLPFormatter 创建 OTLP/JSON 格式化对象，mapping 中的映射覆盖 DefaultOTLPMapping 中的同名字段，
+// 为 nil 时使用默认映射，可选配置与 NewFormatter 相同
+//
+//	logger.NewOTLPFormatter("order", "prod", &logger.OTLPMapping{
+//		Resource:   map[string]string{"channel": "app.channel"},
+//		Attributes: map[string]string{"user": ""},
+//	})
+func NewOTLPFormatter(service, env string, mapping *OTLPMapping, opts ...Option) *OTLPFormatter {
+	return &OTLPFormatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+		Mapping:         DefaultOTLPMapping.merge(mapping),
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (of *OTLPFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	of.collect(entry, data)
+	enc := of.encoder()
+	err := encodeSafely(func() error { return of.write(b, enc, entry, of.logsV2(data)) })
+	schema := data.Schema
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "otlp encode %s log", schema)
+	}
+	return b.Bytes(), nil
+}
+
+// otlpAttr 一个 OTLP 属性
+type otlpAttr struct {
+	key   string
+	value interface{}
+}
+
+func (of *OTLPFormatter) write(b *bytes.Buffer, enc Encoder, entry *logrus.Entry, v *LogsV2) error {
+	var resource, attrs []otlpAttr
+	for field, value := range otlpFields(v) {
+		if name := of.Mapping.Resource[field]; name != "" {
+			resource = append(resource, otlpAttr{key: name, value: value})
+		} else if name := of.Mapping.Attributes[field]; name != "" {
+			attrs = append(attrs, otlpAttr{key: name, value: value})
+		}
+	}
+
+	traceID, spanID := v.TraceID, v.SpanID
+	if !isHexID(traceID, 32) {
+		if traceID != "" {
+			attrs = append(attrs, otlpAttr{key: "trace_id", value: traceID})
+		}
+		traceID = ""
+	}
+	if !isHexID(spanID, 16) {
+		if spanID != "" {
+			attrs = append(attrs, otlpAttr{key: "span_id", value: spanID})
+		}
+		spanID = ""
+	}
+
+	for k, value := range v.Context {
+		attrs = append(attrs, otlpAttr{key: k, value: value})
+	}
+	for k, section := range v.Sections {
+		attrs = append(attrs, otlpAttr{key: k, value: section})
+	}
+	if v.RequestParseError != "" {
+		attrs = append(attrs, otlpAttr{key: "request_parse_error", value: v.RequestParseError})
+	}
+	if v.Err != nil {
+		attrs = append(attrs, otlpAttr{key: "exception.message", value: v.Err.Message})
+		if v.Err.Type != "" {
+			attrs = append(attrs, otlpAttr{key: "exception.type", value: v.Err.Type})
+		}
+		if len(v.Err.Trace) > 0 {
+			attrs = append(attrs, otlpAttr{key: "exception.stacktrace", value: strings.Join(v.Err.Trace, "\n")})
+		}
+	}
+
+	b.WriteString(`{"resourceLogs":[{"resource":{"attributes":`)
+	if err := writeOTLPAttrs(b, enc, resource); err != nil {
+		return err
+	}
+	b.WriteString(`},"scopeLogs":[{"scope":{"name":`)
+	writeString(b, otlpScope)
+	b.WriteString(`},"logRecords":[{"timeUnixNano":"`)
+	b.WriteString(strconv.FormatInt(entry.Time.UnixNano(), 10))
+	b.WriteString(`","severityNumber":`)
+	number, text := otlpSeverity(entry.Level)
+	b.WriteString(strconv.Itoa(number))
+	b.WriteString(`,"severityText":`)
+	writeString(b, text)
+	b.WriteString(`,"body":{"stringValue":`)
+	writeString(b, v.Message)
+	b.WriteString(`},"attributes":`)
+	if err := writeOTLPAttrs(b, enc, attrs); err != nil {
+		return err
+	}
+	if traceID != "" {
+		b.WriteString(`,"traceId":`)
+		writeString(b, strings.ToLower(traceID))
+	}
+	if spanID != "" {
+		b.WriteString(`,"spanId":`)
+		writeString(b, strings.ToLower(spanID))
+	}
+	b.WriteString("}]}]}]}\n")
+	return nil
+}
+
+// otlpFields 可以映射的字段，值为空的字段不返回
+func otlpFields(v *LogsV2) map[string]interface{} {
+	fields := map[string]interface{}{}
+	add := func(k, s string) {
+		if s != "" {
+			fields[k] = s
+		}
+	}
+	add("service", v.Service)
+	add("env", v.Environment)
+	add("channel", v.Channel)
+	add("id", v.ID)
+	add("user", v.User)
+	add("request_id", v.RequestID)
+	add("tenant", v.Tenant)
+	add("session_id", v.SessionID)
+	add("code", v.Code)
+	add("kind", v.Kind)
+	add("retention", v.Retention)
+	if host, ok := v.Host.(*HostData); ok && host != nil {
+		add("host.hostname", host.Hostname)
+		fields["host.pid"] = host.PID
+		add("host.container_id", host.ContainerID)
+		add("host.instance_id", host.InstanceID)
+	}
+	if build, ok := v.Build.(*BuildData); ok && build != nil {
+		add("build.version", build.Version)
+		add("build.revision", build.Revision)
+		add("build.go", build.GoVersion)
+	}
+	return fields
+}
+
+// otlpSeverity 日志级别对应的 OpenTelemetry SeverityNumber 与 SeverityText
+func otlpSeverity(level logrus.Level) (int, string) {
+	switch level {
+	case logrus.TraceLevel:
+		return 1, "TRACE"
+	case logrus.DebugLevel:
+		return 5, "DEBUG"
+	case logrus.InfoLevel:
+		return 9, "INFO"
+	case logrus.WarnLevel:
+		return 13, "WARN"
+	case logrus.ErrorLevel:
+		return 17, "ERROR"
+	case logrus.FatalLevel:
+		return 21, "FATAL"
+	default:
+		return 24, "PANIC"
+	}
+}
+
+// isHexID 判断 s 是否为长度为 n 且不全为 0 的十六进制字符串
+func isHexID(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	zero := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			zero = false
+		default:
+			return false
+		}
+	}
+	return !zero
+}
+
+// writeOTLPAttrs 按属性名排序后写入 KeyValue 数组
+func writeOTLPAttrs(b *bytes.Buffer, enc Encoder, attrs []otlpAttr) error {
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].key < attrs[j].key })
+
+	b.WriteByte('[')
+	for i, attr := range attrs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"key":`)
+		writeString(b, attr.key)
+		b.WriteString(`,"value":`)
+		if err := writeOTLPValue(b, enc, attr.value); err != nil {
+			return err
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(']')
+	return nil
+}
+
+// writeOTLPValue 写入 AnyValue，对象写为 kvlistValue，数组写为 arrayValue，
+// 其他类型先按 JSON 输出的格式编码再转换
+func writeOTLPValue(b *bytes.Buffer, enc Encoder, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		b.WriteString("{}")
+		return nil
+	case string:
+		b.WriteString(`{"stringValue":`)
+		writeString(b, val)
+		b.WriteByte('}')
+		return nil
+	case bool:
+		b.WriteString(`{"boolValue":`)
+		b.WriteString(strconv.FormatBool(val))
+		b.WriteByte('}')
+		return nil
+	case json.Number:
+		s := val.String()
+		if strings.ContainsAny(s, ".eE") {
+			b.WriteString(`{"doubleValue":`)
+			b.WriteString(s)
+			b.WriteByte('}')
+		} else {
+			b.WriteString(`{"intValue":"`)
+			b.WriteString(s)
+			b.WriteString(`"}`)
+		}
+		return nil
+	case logrus.Fields:
+		return writeOTLPKvlist(b, enc, val)
+	case map[string]interface{}:
+		return writeOTLPKvlist(b, enc, val)
+	case []interface{}:
+		b.WriteString(`{"arrayValue":{"values":[`)
+		for i, item := range val {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeOTLPValue(b, enc, item); err != nil {
+				return err
+			}
+		}
+		b.WriteString(`]}}`)
+		return nil
+	}
+
+	scratch := &bytes.Buffer{}
+	if err := writeValue(scratch, enc, v); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(scratch)
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	return writeOTLPValue(b, enc, generic)
+}
+
+func writeOTLPKvlist(b *bytes.Buffer, enc Encoder, m map[string]interface{}) error {
+	attrs := make([]otlpAttr, 0, len(m))
+	for k, v := range m {
+		attrs = append(attrs, otlpAttr{key: k, value: v})
+	}
+	b.WriteString(`{"kvlistValue":{"values":`)
+	if err := writeOTLPAttrs(b, enc, attrs); err != nil {
+		return err
+	}
+	b.WriteString(`}}`)
+	return nil
+}
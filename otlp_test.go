@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// otlpLine 测试中解码的 OTLP/JSON 日志
+type otlpLine struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []otlpKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano   string                 `json:"timeUnixNano"`
+				SeverityNumber int                    `json:"severityNumber"`
+				SeverityText   string                 `json:"severityText"`
+				Body           map[string]interface{} `json:"body"`
+				Attributes     []otlpKeyValue         `json:"attributes"`
+				TraceID        string                 `json:"traceId"`
+				SpanID         string                 `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func otlpValue(attrs []otlpKeyValue, key string) map[string]interface{} {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return nil
+}
+
+func TestOTLPFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(out)
+	l.SetFormatter(NewOTLPFormatter("order", "prod", nil, WithBuildInfo()))
+
+	l.WithFields(logrus.Fields{
+		"channel":  "billing",
+		"user":     "u1",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"order_id": 42,
+		"paid":     true,
+		"items":    []string{"a", "b"},
+		"error":    errors.New("payment declined"),
+	}).Warn("charge failed")
+
+	var line otlpLine
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Unmarshal() error, Expected=nil, Actual=%q: %s", err, out.String())
+	}
+	resource := line.ResourceLogs[0].Resource.Attributes
+	scope := line.ResourceLogs[0].ScopeLogs[0]
+	record := scope.LogRecords[0]
+
+	if scope.Scope.Name != otlpScope {
+		t.Fatalf("scope, Expected=%q, Actual=%q", otlpScope, scope.Scope.Name)
+	}
+	if record.SeverityNumber != 13 || record.SeverityText != "WARN" {
+		t.Fatalf("severity, Expected=13 WARN, Actual=%d %s", record.SeverityNumber, record.SeverityText)
+	}
+	if record.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || record.SpanID != "00f067aa0ba902b7" {
+		t.Fatalf("trace, Actual=%q %q", record.TraceID, record.SpanID)
+	}
+	if record.TimeUnixNano == "" || record.Body["stringValue"] != "charge failed" {
+		t.Fatalf("record, Actual=%q %v", record.TimeUnixNano, record.Body)
+	}
+
+	cases := []struct {
+		attrs    []otlpKeyValue
+		key      string
+		kind     string
+		expected interface{}
+	}{
+		{attrs: resource, key: "service.name", kind: "stringValue", expected: "order"},
+		{attrs: resource, key: "deployment.environment", kind: "stringValue", expected: "prod"},
+		{attrs: resource, key: "process.runtime.version", kind: "stringValue", expected: BuildInfo().GoVersion},
+		{attrs: record.Attributes, key: "log.channel", kind: "stringValue", expected: "billing"},
+		{attrs: record.Attributes, key: "enduser.id", kind: "stringValue", expected: "u1"},
+		{attrs: record.Attributes, key: "order_id", kind: "intValue", expected: "42"},
+		{attrs: record.Attributes, key: "paid", kind: "boolValue", expected: true},
+		{attrs: record.Attributes, key: "exception.message", kind: "stringValue", expected: "payment declined"},
+		{attrs: record.Attributes, key: "exception.type", kind: "stringValue", expected: "*errors.errorString"},
+	}
+	for _, c := range cases {
+		if v := otlpValue(c.attrs, c.key); v[c.kind] != c.expected {
+			t.Fatalf("attribute %s, Expected=%v, Actual=%v", c.key, c.expected, v)
+		}
+	}
+	if v := otlpValue(record.Attributes, "items"); v["arrayValue"] == nil {
+		t.Fatalf("attribute items, Expected=arrayValue, Actual=%v", v)
+	}
+	if v := otlpValue(record.Attributes, "trace_id"); v != nil {
+		t.Fatalf("attribute trace_id, Expected=nil, Actual=%v", v)
+	}
+}
+
+func TestOTLPFormatterMapping(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(out)
+	l.SetFormatter(NewOTLPFormatter("order", "prod", &OTLPMapping{
+		Resource:   map[string]string{"channel": "app.channel", "env": "env"},
+		Attributes: map[string]string{"user": "", "service": "svc"},
+	}))
+
+	l.WithFields(logrus.Fields{
+		"channel":  "billing",
+		"user":     "u1",
+		"trace_id": "not-a-trace",
+	}).Info("hello")
+
+	var line otlpLine
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Unmarshal() error, Expected=nil, Actual=%q: %s", err, out.String())
+	}
+	resource := line.ResourceLogs[0].Resource.Attributes
+	record := line.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+
+	cases := []struct {
+		attrs    []otlpKeyValue
+		key      string
+		expected interface{}
+	}{
+		{attrs: resource, key: "app.channel", expected: "billing"},
+		{attrs: resource, key: "env", expected: "prod"},
+		{attrs: resource, key: "service.name", expected: nil},
+		{attrs: record.Attributes, key: "svc", expected: "order"},
+		{attrs: record.Attributes, key: "log.channel", expected: nil},
+		{attrs: record.Attributes, key: "enduser.id", expected: nil},
+		{attrs: record.Attributes, key: "trace_id", expected: "not-a-trace"},
+	}
+	for _, c := range cases {
+		if v := otlpValue(c.attrs, c.key)["stringValue"]; v != c.expected {
+			t.Fatalf("attribute %s, Expected=%v, Actual=%v", c.key, c.expected, v)
+		}
+	}
+	if record.TraceID != "" {
+		t.Fatalf("traceId, Expected=empty, Actual=%q", record.TraceID)
+	}
+}
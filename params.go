@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ParamAction 请求参数命中规则时的处理方式
+type ParamAction int
+
+const (
+	// ParamMask 替换参数值
+	ParamMask ParamAction = iota
+	// ParamDrop 不记录参数
+	ParamDrop
+)
+
+// DefaultSensitiveParams 常见的携带凭证的请求参数名，可用于 ParamRule.Names
+var DefaultSensitiveParams = []string{"token", "access_token", "refresh_token", "api_key", "apikey", "code", "password", "secret"}
+
+// ParamRule 请求参数的处理规则，按参数名或参数名正则匹配
+type ParamRule struct {
+	// 参数名，不区分大小写
+	Names   []string
+	Pattern *regexp.Regexp
+	Action  ParamAction
+	// 替换内容，默认 DefaultRedactReplacement
+	Replacement string
+}
+
+// ParamFilter 请求参数的过滤规则，在参数写入 request.param 之前执行，
+// 因此不会出现在任何输出中，包括脱敏审计报告
+type ParamFilter struct {
+	// 只记录允许的参数，均为空时记录全部参数
+	Allow        []string
+	AllowPattern *regexp.Regexp
+	Rules        []ParamRule
+}
+
+// WithParamRules 添加请求参数的替换或丢弃规则
+func WithParamRules(rules ...ParamRule) Option {
+	return func(f *LogsV1Formatter) {
+		if f.ParamFilter == nil {
+			f.ParamFilter = &ParamFilter{}
+		}
+		f.ParamFilter.Rules = append(f.ParamFilter.Rules, rules...)
+	}
+}
+
+// WithParamAllowlist 只记录名称在 names 中或匹配 pattern 的请求参数
+func WithParamAllowlist(names []string, pattern *regexp.Regexp) Option {
+	return func(f *LogsV1Formatter) {
+		if f.ParamFilter == nil {
+			f.ParamFilter = &ParamFilter{}
+		}
+		f.ParamFilter.Allow = append(f.ParamFilter.Allow, names...)
+		f.ParamFilter.AllowPattern = pattern
+	}
+}
+
+func (r *ParamRule) replacement() string {
+	if r.Replacement != "" {
+		return r.Replacement
+	}
+	return DefaultRedactReplacement
+}
+
+// filter 按规则删除或替换参数
+func (pf *ParamFilter) filter(param logrus.Fields) {
+	if pf == nil {
+		return
+	}
+
+	for k := range param {
+		rule, ok := pf.match(k)
+		switch {
+		case !ok || (rule != nil && rule.Action == ParamDrop):
+			delete(param, k)
+		case rule != nil:
+			param[k] = rule.replacement()
+		}
+	}
+}
+
+// filterQuery 按规则删除或替换查询字符串中的参数，保持参数顺序，替换内容不做 URL 编码
+func (pf *ParamFilter) filterQuery(rawQuery string) string {
+	if pf == nil || rawQuery == "" {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		key := pair
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			key = pair[:i]
+		}
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		rule, ok := pf.match(name)
+		switch {
+		case !ok || (rule != nil && rule.Action == ParamDrop):
+			continue
+		case rule != nil:
+			pair = key + "=" + rule.replacement()
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+// match 返回参数是否允许记录以及命中的第一条规则
+func (pf *ParamFilter) match(name string) (*ParamRule, bool) {
+	if (len(pf.Allow) > 0 || pf.AllowPattern != nil) && !matchName(pf.Allow, pf.AllowPattern, name) {
+		return nil, false
+	}
+	for i := range pf.Rules {
+		if matchName(pf.Rules[i].Names, pf.Rules[i].Pattern, name) {
+			return &pf.Rules[i], true
+		}
+	}
+	return nil, true
+}
+
+func matchName(names []string, pattern *regexp.Regexp, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return pattern != nil && pattern.MatchString(name)
+}
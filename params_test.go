@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParamFilter(t *testing.T) {
+	query := url.Values{}
+	query.Set("q", "shoes")
+	query.Set("page", "2")
+	query.Set("token", "t0k3n")
+	query.Set("API_KEY", "k3y")
+	query.Set("x_signature", "sig")
+	query.Set("code", "c0de")
+
+	cases := []struct {
+		Name     string
+		Options  []Option
+		Expected map[string]string
+	}{
+		{
+			Name:     "none",
+			Expected: map[string]string{"q": "shoes", "page": "2", "token": "t0k3n", "API_KEY": "k3y", "x_signature": "sig", "code": "c0de"},
+		},
+		{
+			Name: "mask and drop",
+			Options: []Option{WithParamRules(
+				ParamRule{Names: []string{"token", "api_key"}},
+				ParamRule{Pattern: regexp.MustCompile(`^x_`), Action: ParamDrop},
+				ParamRule{Names: []string{"code"}, Replacement: "***"},
+			)},
+			Expected: map[string]string{"q": "shoes", "page": "2", "token": DefaultRedactReplacement, "API_KEY": DefaultRedactReplacement, "code": "***"},
+		},
+		{
+			Name: "allowlist",
+			Options: []Option{
+				WithParamAllowlist([]string{"q"}, regexp.MustCompile(`^(page|token)$`)),
+				WithParamRules(ParamRule{Names: DefaultSensitiveParams}),
+			},
+			Expected: map[string]string{"q": "shoes", "page": "2", "token": DefaultRedactReplacement},
+		},
+	}
+
+	for _, c := range cases {
+		req := &http.Request{
+			Method: http.MethodGet,
+			URL:    &url.URL{Path: "/search", RawQuery: query.Encode()},
+		}
+		f := NewFormatter("test", "test", c.Options...)
+		data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+		if err != nil {
+			t.Fatalf("%s: Format() error, Expected=nil, Actual=%q", c.Name, err.Error())
+		}
+
+		param := map[string]string{}
+		jsoniter.Get(data, "request", "param").ToVal(&param)
+		if len(param) != len(c.Expected) {
+			t.Fatalf("%s: output request.param, Expected=%v, Actual=%v", c.Name, c.Expected, param)
+		}
+		for k, v := range c.Expected {
+			if param[k] != v {
+				t.Fatalf("%s: output request.param.%s, Expected=%q, Actual=%q", c.Name, k, v, param[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	// IPAnonymizeTruncate 截断 IP，IPv4 保留 /24，IPv6 保留 /48
+	IPAnonymizeTruncate = "truncate"
+	// IPAnonymizeHash 使用加盐的 HMAC-SHA256 替换 IP
+	IPAnonymizeHash = "hash"
+)
+
+// ipHeaders 记录客户端 IP 的请求头，匿名化时一并处理
+var ipHeaders = []string{"x-forwarded-for", "x-real-ip", "true-client-ip", "cf-connecting-ip"}
+
+// WithIPTruncation 记录请求 IP 时截断，IPv4 保留 /24，IPv6 保留 /48
+func WithIPTruncation() Option {
+	return func(af *LogsV1Formatter) {
+		af.IPAnonymizer = TruncateIP
+	}
+}
+
+// WithIPHashing 记录请求 IP 时使用 salt 计算的 HMAC-SHA256 替换，
+// 同一服务内相同的 IP 结果相同，可以用于统计但无法还原
+func WithIPHashing(salt string) Option {
+	return func(af *LogsV1Formatter) {
+		af.IPAnonymizer = HashIP(salt)
+	}
+}
+
+// TruncateIP 截断 IP，IPv4 保留 /24，IPv6 保留 /48，不是 IP 时返回空字符串
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// HashIP 返回使用 salt 计算 HMAC-SHA256 的匿名化函数，结果为 32 位十六进制字符
+func HashIP(salt string) func(ip string) string {
+	return func(ip string) string {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			return ""
+		}
+		return hmacHex([]byte(salt), ip)
+	}
+}
+
+// WithUserHashing 使用 key 计算用户标识的 HMAC-SHA256 作为 u 输出，
+// 同一用户的日志仍然可以关联，但不会暴露原始的用户标识
+func WithUserHashing(key []byte) Option {
+	return func(af *LogsV1Formatter) {
+		af.UserKey = key
+	}
+}
+
+// hmacHex 返回 HMAC-SHA256 的前 16 字节，32 位十六进制字符
+func hmacHex(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return fmt.Sprintf("%x", mac.Sum(nil)[:16])
+}
+
+// anonymizeIP 匿名化请求 IP 与代理请求头中的客户端 IP
+func (af *LogsV1Formatter) anonymizeIP(request *RequestData) {
+	anonymize := af.IPAnonymizer
+	if anonymize == nil {
+		return
+	}
+
+	request.IP = anonymize(request.IP)
+	for _, h := range ipHeaders {
+		v, ok := request.Headers[h]
+		if !ok {
+			continue
+		}
+		ips := strings.Split(v, ",")
+		for i, ip := range ips {
+			ips[i] = anonymize(ip)
+		}
+		request.Headers[h] = strings.Join(ips, ", ")
+	}
+}
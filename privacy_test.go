@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTruncateIP(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.45":                  "203.0.113.0",
+		" 10.1.2.3":                     "10.1.2.0",
+		"2001:db8:85a3:8d3:1319:8a2e::": "2001:db8:85a3::",
+		"::ffff:192.0.2.128":            "192.0.2.0",
+		"unix-socket":                   "",
+	}
+	for ip, expected := range cases {
+		if v := TruncateIP(ip); v != expected {
+			t.Fatalf("TruncateIP(%q), Expected=%q, Actual=%q", ip, expected, v)
+		}
+	}
+}
+
+func TestHashIP(t *testing.T) {
+	a, b := HashIP("service-a"), HashIP("service-b")
+	if a("203.0.113.45") != a("203.0.113.45") {
+		t.Fatalf("HashIP() must be stable for the same salt")
+	}
+	if a("203.0.113.45") == b("203.0.113.45") {
+		t.Fatalf("HashIP() must differ between salts")
+	}
+	if v := a("203.0.113.45"); len(v) != 32 {
+		t.Fatalf("HashIP() length, Expected=32, Actual=%d", len(v))
+	}
+}
+
+func TestFormatAnonymizedIP(t *testing.T) {
+	req := &http.Request{
+		RemoteAddr: "203.0.113.45:5678",
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: "/"},
+		Header:     http.Header{"X-Forwarded-For": {"198.51.100.7, 10.0.0.1"}},
+	}
+
+	f := NewFormatter("test", "test", WithIPTruncation())
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if v := jsoniter.Get(data, "request", "ip").ToString(); v != "203.0.113.0" {
+		t.Fatalf("output request.ip, Expected=%q, Actual=%q", "203.0.113.0", v)
+	}
+	if v := jsoniter.Get(data, "request", "header", "x-forwarded-for").ToString(); v != "198.51.100.0, 10.0.0.0" {
+		t.Fatalf("output request.header.x-forwarded-for, Expected=%q, Actual=%q", "198.51.100.0, 10.0.0.0", v)
+	}
+}
+
+func TestFormatUserHashing(t *testing.T) {
+	entry := &logrus.Entry{Time: time.Now(), Data: logrus.Fields{"user": 65535}}
+
+	f := NewFormatter("test", "test", WithUserHashing([]byte("secret")))
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	expected := hmacHex([]byte("secret"), "65535")
+	if v := jsoniter.Get(data, "u").ToString(); v != expected || v == "65535" {
+		t.Fatalf("output u, Expected=%q, Actual=%q", expected, v)
+	}
+
+	data, _ = f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{}})
+	if v := jsoniter.Get(data, "u").ToString(); v != "" {
+		t.Fatalf("output u without user, Expected=%q, Actual=%q", "", v)
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecoverOption panic 恢复的可选配置
+type RecoverOption func(*recoverConfig)
+
+type recoverConfig struct {
+	rePanic bool
+}
+
+// WithRePanic 记录日志后继续向上抛出 panic，交给外层处理
+func WithRePanic() RecoverOption {
+	return func(c *recoverConfig) {
+		c.rePanic = true
+	}
+}
+
+// Recover 恢复当前 goroutine 的 panic 并记录日志，必须直接 defer 调用
+//
+//	go func() {
+//		defer logger.Recover(l)
+//		work()
+//	}()
+func Recover(l *logrus.Logger, opts ...RecoverOption) {
+	c := &recoverConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if r := recover(); r != nil {
+		LogPanic(l, r, debug.Stack())
+		if c.rePanic {
+			panic(r)
+		}
+	}
+}
+
+// RecoverMiddleware 恢复 handler 中的 panic，记录 panic 值与调用栈后返回 500
+func RecoverMiddleware(l *logrus.Logger, opts ...RecoverOption) func(http.Handler) http.Handler {
+	c := &recoverConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := newResponseWriter(w)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// http.ErrAbortHandler 用于主动中断请求，不属于异常
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				LogPanic(l.WithContext(r.Context()).WithFields(logrus.Fields{
+					"request": r,
+					"status":  http.StatusInternalServerError,
+				}), rec, debug.Stack())
+
+				if !rw.wroteHeader {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+				if c.rePanic {
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// LogPanic 将 recover() 得到的 panic 值与调用栈记录在 panic 上下文中，stack 为空时记录当前调用栈，
+// 用于 Recover 不适用的场景，例如已经自行 recover 的 worker goroutine
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			logger.LogPanic(l, r, debug.Stack())
+//		}
+//	}()
+//
+// panic 值为 error 时保留错误本身，输出错误码与指纹；其他类型的值记录在 panic.value 中
+func LogPanic(l FieldBinder, recovered interface{}, stack []byte) {
+	if len(stack) == 0 {
+		stack = debug.Stack()
+	}
+	msg := fmt.Sprintf("%v", recovered)
+	data := logrus.Fields{
+		"msg":   msg,
+		"type":  fmt.Sprintf("%T", recovered),
+		"trace": strings.Split(strings.TrimSpace(string(stack)), "\n"),
+	}
+
+	var errValue interface{} = msg
+	switch val := recovered.(type) {
+	case error:
+		errValue = val
+	case string:
+	default:
+		data["value"] = val
+	}
+	l.WithField("panic", data).WithField("error", errValue).Error("panic recovered")
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := RecoverMiddleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status, Expected=%d, Actual=%d", http.StatusInternalServerError, w.Code)
+	}
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaHTTPRequestV1)},
+		{path: []interface{}{"l"}, expected: "error"},
+		{path: []interface{}{"err"}, expected: "oops"},
+		{path: []interface{}{"ctx", "panic", "msg"}, expected: "oops"},
+		{path: []interface{}{"request", "status"}, expected: "500"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+	if n := jsoniter.Get(data, "ctx", "panic", "trace").Size(); n == 0 {
+		t.Fatalf("output ctx.panic.trace should not be empty")
+	}
+}
+
+func TestRecoverRePanic(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	defer func() {
+		if r := recover(); r != "oops" {
+			t.Fatalf("panic, Expected=%q, Actual=%v", "oops", r)
+		}
+		if v := jsoniter.Get(out.Bytes(), "ctx", "panic", "msg").ToString(); v != "oops" {
+			t.Fatalf("output ctx.panic.msg, Expected=%q, Actual=%q", "oops", v)
+		}
+	}()
+
+	func() {
+		defer Recover(l, WithRePanic())
+		panic("oops")
+	}()
+}
+
+func TestLogPanic(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	type payload struct {
+		ID int `json:"id"`
+	}
+	cases := []struct {
+		recovered interface{}
+		path      []interface{}
+		expected  string
+	}{
+		{recovered: errors.New("boom"), path: []interface{}{"ctx", "panic", "type"}, expected: "*errors.errorString"},
+		{recovered: errors.New("boom"), path: []interface{}{"err"}, expected: "boom"},
+		{recovered: "oops", path: []interface{}{"ctx", "panic", "msg"}, expected: "oops"},
+		{recovered: payload{ID: 7}, path: []interface{}{"ctx", "panic", "value", "id"}, expected: "7"},
+	}
+
+	for _, c := range cases {
+		out.Reset()
+		LogPanic(l, c.recovered, nil)
+		data := out.Bytes()
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`LogPanic(%v) %q, Expected=%q, Actual=%q`, c.recovered, c.path, c.expected, v)
+		}
+		if n := jsoniter.Get(data, "ctx", "panic", "trace").Size(); n == 0 {
+			t.Fatalf("LogPanic(%v) ctx.panic.trace should not be empty", c.recovered)
+		}
+	}
+}
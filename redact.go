@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRedactReplacement 脱敏后的默认替换内容
+const DefaultRedactReplacement = "[REDACTED]"
+
+// RedactRule 脱敏规则，按字段名匹配时替换整个值，按内容匹配时只替换匹配的部分
+type RedactRule struct {
+	// 规则标识，用于审计报告
+	ID string
+	// 需要脱敏的字段名，不区分大小写
+	Keys []string
+	// 需要脱敏的字段名正则
+	KeyPattern *regexp.Regexp
+	// 需要脱敏的字符串内容正则
+	ValuePattern *regexp.Regexp
+	// 对 ValuePattern 匹配的内容再次校验，例如信用卡号的 Luhn 校验，为空时不校验
+	Verify func(match string) bool
+	// 替换内容，默认 DefaultRedactReplacement
+	Replacement string
+}
+
+// RedactFinding 一次命中脱敏规则的记录
+type RedactFinding struct {
+	// 字段在输出中的路径，例如 ctx.password、request.header.authorization
+	Path string `json:"path"`
+	Rule string `json:"rule"`
+}
+
+// Redactor 对 ctx、request.header、request.param、sql.args、已登记规范的敏感字段与日志内容执行脱敏
+type Redactor struct {
+	Rules []RedactRule
+	// 审计模式下不修改输出，只报告将被脱敏的字段
+	Audit bool
+	// 审计报告的接收函数，为空时报告写入 ctx.redact_audit
+	Report func(entry *logrus.Entry, findings []RedactFinding)
+}
+
+// WithRedaction 启用脱敏规则
+func WithRedaction(rules ...RedactRule) Option {
+	return func(f *LogsV1Formatter) {
+		if f.Redactor == nil {
+			f.Redactor = &Redactor{}
+		}
+		f.Redactor.Rules = append(f.Redactor.Rules, rules...)
+	}
+}
+
+// WithRedactionAudit 以审计模式运行脱敏规则，输出保持不变，
+// 命中的字段路径与规则通过 report 报告，便于新规则上线前在生产流量中验证
+func WithRedactionAudit(report func(entry *logrus.Entry, findings []RedactFinding)) Option {
+	return func(f *LogsV1Formatter) {
+		if f.Redactor == nil {
+			f.Redactor = &Redactor{}
+		}
+		f.Redactor.Audit = true
+		f.Redactor.Report = report
+	}
+}
+
+func (r *RedactRule) replacement() string {
+	if r.Replacement != "" {
+		return r.Replacement
+	}
+	return DefaultRedactReplacement
+}
+
+func (r *RedactRule) matchKey(key string) bool {
+	return matchName(r.Keys, r.KeyPattern, key)
+}
+
+// redact 对格式化结果执行脱敏，enc 用于将规范内容转换为 map
+func (rd *Redactor) redact(entry *logrus.Entry, data *LogsV1, enc Encoder) {
+	if rd == nil || len(rd.Rules) == 0 {
+		return
+	}
+
+	var findings []RedactFinding
+	report := func(path, rule string) {
+		findings = append(findings, RedactFinding{Path: path, Rule: rule})
+	}
+
+	data.Message = rd.redactString("m", data.Message, report)
+	data.Err = rd.redactString("err", data.Err, report)
+	for k, v := range data.Context {
+		if def, ok := sensitiveSection(entry, k); ok {
+			data.Context[k] = rd.redactSection(def, enc, v, report)
+			continue
+		}
+		data.Context[k] = rd.redactValue("ctx."+k, k, v, report)
+	}
+	for i, v := range data.Errors {
+		data.Errors[i] = rd.redactValue("errors."+strconv.Itoa(i), "errors", v, report)
+	}
+	if data.Request != nil {
+		for k, v := range data.Request.Headers {
+			data.Request.Headers[k] = rd.redactValue("request.header."+k, k, v, report).(string)
+		}
+		for k, v := range data.Request.Cookies {
+			data.Request.Cookies[k] = rd.redactValue("request.cookie."+k, k, v, report).(string)
+		}
+		for k, v := range data.Request.RouteParams {
+			data.Request.RouteParams[k] = rd.redactValue("request.route_params."+k, k, v, report).(string)
+		}
+		for k, v := range data.Request.Param {
+			data.Request.Param[k] = rd.redactValue("request.param."+k, k, v, report)
+		}
+	}
+	if data.SQL != nil && len(data.SQL.Args) > 0 {
+		// sql 字段由调用方传入，脱敏在副本上进行
+		q := *data.SQL
+		q.Args = rd.redactPayload("sql.args", q.Args, report).([]interface{})
+		data.SQL = &q
+	}
+
+	if rd.Audit && len(findings) > 0 {
+		if rd.Report != nil {
+			rd.Report(entry, findings)
+		} else {
+			data.Context["redact_audit"] = findings
+		}
+	}
+}
+
+// redactSection 对规范内容中的敏感字段脱敏，路径为输出中规范内容的路径，例如 grpc.request，
+// 没有敏感字段时返回原值
+func (rd *Redactor) redactSection(def SchemaDefinition, enc Encoder, v interface{}, report func(path, rule string)) interface{} {
+	fields := sectionFields(enc, v)
+	found := false
+	for _, k := range def.Sensitive {
+		if fv, ok := fields[k]; ok {
+			fields[k] = rd.redactPayload(def.Field+"."+k, fv, report)
+			found = true
+		}
+	}
+	if !found {
+		return v
+	}
+	return fields
+}
+
+func (rd *Redactor) redactValue(path, key string, v interface{}, report func(path, rule string)) interface{} {
+	for i := range rd.Rules {
+		rule := &rd.Rules[i]
+		if rule.matchKey(key) {
+			report(path, rule.ID)
+			if rd.Audit {
+				return v
+			}
+			return rule.replacement()
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		return rd.redactString(path, val, report)
+	case logrus.Fields:
+		return logrus.Fields(rd.redactMap(path, val, report))
+	case map[string]interface{}:
+		return rd.redactMap(path, val, report)
+	case []interface{}:
+		// 组合错误的 errors 等数组，数组元素沿用数组的字段名
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = rd.redactValue(path+"."+strconv.Itoa(i), key, item, report)
+		}
+		return items
+	case map[string]string:
+		m := make(map[string]string, len(val))
+		for k, s := range val {
+			m[k] = rd.redactValue(path+"."+k, k, s, report).(string)
+		}
+		return m
+	}
+	return v
+}
+
+// redactPayload 对消息内容脱敏，对象按字段名匹配规则，截断的文本只按内容匹配
+func (rd *Redactor) redactPayload(path string, v interface{}, report func(path, rule string)) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return rd.redactMap(path, val, report)
+	case string:
+		return rd.redactString(path, val, report)
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = rd.redactPayload(path+"."+strconv.Itoa(i), item, report)
+		}
+		return items
+	}
+	return v
+}
+
+// redactMap 返回脱敏后的副本，避免修改调用方传入的数据
+func (rd *Redactor) redactMap(path string, val map[string]interface{}, report func(path, rule string)) map[string]interface{} {
+	m := make(map[string]interface{}, len(val))
+	for k, item := range val {
+		m[k] = rd.redactValue(path+"."+k, k, item, report)
+	}
+	return m
+}
+
+func (rd *Redactor) redactString(path, s string, report func(path, rule string)) string {
+	for i := range rd.Rules {
+		rule := &rd.Rules[i]
+		if rule.ValuePattern == nil || !rule.ValuePattern.MatchString(s) {
+			continue
+		}
+		if rule.Verify != nil {
+			s = rd.redactVerified(path, s, rule, report)
+			continue
+		}
+		report(path, rule.ID)
+		if !rd.Audit {
+			s = rule.ValuePattern.ReplaceAllString(s, rule.replacement())
+		}
+	}
+	return s
+}
+
+// redactVerified 只替换通过 Verify 校验的匹配内容
+func (rd *Redactor) redactVerified(path, s string, rule *RedactRule, report func(path, rule string)) string {
+	found := false
+	redacted := rule.ValuePattern.ReplaceAllStringFunc(s, func(match string) string {
+		if !rule.Verify(match) {
+			return match
+		}
+		found = true
+		return rule.replacement()
+	})
+	if !found {
+		return s
+	}
+	report(path, rule.ID)
+	if rd.Audit {
+		return s
+	}
+	return redacted
+}
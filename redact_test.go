@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+var testRedactRules = []RedactRule{
+	{ID: "password", Keys: []string{"password"}},
+	{ID: "card", ValuePattern: regexp.MustCompile(`\b\d{16}\b`), Replacement: "[CARD]"},
+}
+
+func TestRedaction(t *testing.T) {
+	nested := map[string]interface{}{"password": "secret", "name": "foo"}
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Message: "paid with 4111111111111111",
+		Data: logrus.Fields{
+			"Password": "hunter2",
+			"account":  nested,
+		},
+	}
+
+	f := NewFormatter("test", "test", WithRedaction(testRedactRules...))
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"m"}, expected: "paid with [CARD]"},
+		{path: []interface{}{"ctx", "Password"}, expected: DefaultRedactReplacement},
+		{path: []interface{}{"ctx", "account", "password"}, expected: DefaultRedactReplacement},
+		{path: []interface{}{"ctx", "account", "name"}, expected: "foo"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+
+	if nested["password"] != "secret" {
+		t.Fatalf("Format() should not modify caller data")
+	}
+}
+
+func TestRedactionAudit(t *testing.T) {
+	entry := &logrus.Entry{
+		Time:    time.Now(),
+		Message: "paid with 4111111111111111",
+		Data:    logrus.Fields{"password": "hunter2"},
+	}
+
+	var findings []RedactFinding
+	f := NewFormatter("test", "test",
+		WithRedaction(testRedactRules...),
+		WithRedactionAudit(func(entry *logrus.Entry, f []RedactFinding) {
+			findings = f
+		}),
+	)
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if v := jsoniter.Get(data, "m").ToString(); v != entry.Message {
+		t.Fatalf("output m, Expected=%q, Actual=%q", entry.Message, v)
+	}
+	if v := jsoniter.Get(data, "ctx", "password").ToString(); v != "hunter2" {
+		t.Fatalf("output ctx.password, Expected=%q, Actual=%q", "hunter2", v)
+	}
+
+	expected := map[RedactFinding]bool{
+		{Path: "m", Rule: "card"}:                true,
+		{Path: "ctx.password", Rule: "password"}: true,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("findings, Expected=%v, Actual=%v", expected, findings)
+	}
+	for _, finding := range findings {
+		if !expected[finding] {
+			t.Fatalf("unexpected finding %+v", finding)
+		}
+	}
+}
+
+func TestRedactionSQLArgs(t *testing.T) {
+	q := &SQLData{Statement: "insert into cards values (?, ?)", Args: []interface{}{"4111111111111111", 7}}
+	entry := &logrus.Entry{
+		Time: time.Now(),
+		Data: logrus.Fields{"sql": q},
+	}
+
+	f := NewFormatter("test", "test", WithRedaction(testRedactRules...))
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if v := jsoniter.Get(data, "sql", "args", 0).ToString(); v != "[CARD]" {
+		t.Fatalf("output sql.args.0, Expected=%q, Actual=%q", "[CARD]", v)
+	}
+	if v := jsoniter.Get(data, "sql", "args", 1).ToString(); v != "7" {
+		t.Fatalf("output sql.args.1, Expected=%q, Actual=%q", "7", v)
+	}
+	if q.Args[0] != "4111111111111111" {
+		t.Fatalf("Format() should not modify caller data")
+	}
+}
+
+func TestRedactionSections(t *testing.T) {
+	g := &GRPCCallData{
+		Method:   "/pay.Payment/Charge",
+		Code:     "OK",
+		Metadata: map[string]string{"password": "hunter2", "x-region": "cn"},
+		Request:  map[string]interface{}{"card": "4111111111111111", "amount": 100},
+		Response: "charged 4111111111111111",
+	}
+	entry := &logrus.Entry{
+		Time: time.Now(),
+		Data: logrus.Fields{"grpc": g},
+	}
+
+	var findings []RedactFinding
+	f := NewFormatter("test", "test",
+		WithRedaction(testRedactRules...),
+		WithRedactionAudit(func(entry *logrus.Entry, f []RedactFinding) {
+			findings = f
+		}),
+	)
+	if _, err := f.Format(entry); err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	expected := map[RedactFinding]bool{
+		{Path: "grpc.metadata.password", Rule: "password"}: true,
+		{Path: "grpc.request.card", Rule: "card"}:          true,
+		{Path: "grpc.response", Rule: "card"}:              true,
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("findings, Expected=%v, Actual=%v", expected, findings)
+	}
+	for _, finding := range findings {
+		if !expected[finding] {
+			t.Fatalf("unexpected finding %+v", finding)
+		}
+	}
+
+	f = NewFormatter("test", "test", WithRedaction(testRedactRules...))
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaGRPCClientV1)},
+		{path: []interface{}{"grpc", "method"}, expected: "/pay.Payment/Charge"},
+		{path: []interface{}{"grpc", "metadata", "password"}, expected: DefaultRedactReplacement},
+		{path: []interface{}{"grpc", "metadata", "x-region"}, expected: "cn"},
+		{path: []interface{}{"grpc", "request", "card"}, expected: "[CARD]"},
+		{path: []interface{}{"grpc", "request", "amount"}, expected: "100"},
+		{path: []interface{}{"grpc", "response"}, expected: "charged [CARD]"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+	if g.Metadata["password"] != "hunter2" || g.Request.(map[string]interface{})["card"] != "4111111111111111" {
+		t.Fatalf("Format() should not modify caller data")
+	}
+}
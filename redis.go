@@ -0,0 +1,21 @@
+package logger
+
+// RedisCommandData Redis 命令相关的参数
+type RedisCommandData struct {
+	Command string `json:"command"`
+	// 键中的 ID 等变化的部分替换为 * 后的模式，用于聚合统计
+	KeyPattern string        `json:"key_pattern,omitempty"`
+	Args       []interface{} `json:"args,omitempty"`
+	Duration   string        `json:"duration"`
+	// 通过 pipeline 执行时为 pipeline 中的命令数，耗时为整个 pipeline 的耗时
+	Pipeline int `json:"pipeline,omitempty"`
+}
+
+func init() {
+	registerBuiltinSchema(SchemaDefinition{
+		Name:      SchemaRedisCommandV1,
+		Field:     "redis",
+		Type:      RedisCommandData{},
+		Sensitive: []string{"args"},
+	})
+}
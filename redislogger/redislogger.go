@@ -0,0 +1,269 @@
+// Package redislogger 提供 go-redis 的 hook，以 redis.command.v1 规范记录每条 Redis 命令
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: addr})
+//	rdb.AddHook(redislogger.Hook(l, redislogger.WithSlowThreshold(50*time.Millisecond)))
+package redislogger
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Redactor 在记录前处理命令参数，返回值替换原参数写入日志，i 为参数在键之后的序号
+type Redactor func(command string, i int, value interface{}) interface{}
+
+// KeepArgs 原样记录参数的 Redactor，凭证所在的参数仍然脱敏
+//
+//	redislogger.Hook(l, redislogger.WithRedactor(redislogger.KeepArgs))
+func KeepArgs(command string, i int, value interface{}) interface{} {
+	return value
+}
+
+// Option hook 的可选配置
+type Option func(*config)
+
+type config struct {
+	level         logrus.Level
+	slowThreshold time.Duration
+	redactor      Redactor
+	withoutArgs   bool
+}
+
+// WithLevel 设置命令日志的级别，默认 debug
+func WithLevel(level logrus.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithSlowThreshold 执行时间超过 d 的命令提升为 warn 级别
+func WithSlowThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowThreshold = d
+	}
+}
+
+// WithRedactor 设置参数脱敏函数，设置后才记录键之后的参数，未设置时只记录命令名与键的模式；
+// AUTH、HELLO、CONFIG SET requirepass、ACL SETUSER 与 MIGRATE AUTH 中的凭证总是脱敏，
+// 记录的参数还会按格式化对象的脱敏规则与密钥检测按内容匹配，路径为 redis.args.<index>
+func WithRedactor(r Redactor) Option {
+	return func(c *config) {
+		c.redactor = r
+	}
+}
+
+// WithoutArgs 设置 WithRedactor 时仍然不记录命令参数，只记录命令名与键的模式
+func WithoutArgs() Option {
+	return func(c *config) {
+		c.withoutArgs = true
+	}
+}
+
+// Hook 创建记录 Redis 命令的 hook，redis.Nil 表示键不存在，不作为错误记录
+func Hook(l *logrus.Logger, opts ...Option) redis.Hook {
+	c := &config{
+		level: logrus.DebugLevel,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return &hook{logger: l, config: c}
+}
+
+type startKey struct{}
+
+type hook struct {
+	logger *logrus.Logger
+	config *config
+}
+
+// BeforeProcess implements redis.Hook interface
+func (h *hook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+// AfterProcess implements redis.Hook interface
+func (h *hook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.log(ctx, cmd, 0)
+	return nil
+}
+
+// BeforeProcessPipeline implements redis.Hook interface
+func (h *hook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+// AfterProcessPipeline implements redis.Hook interface
+func (h *hook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.log(ctx, cmd, len(cmds))
+	}
+	return nil
+}
+
+func (h *hook) log(ctx context.Context, cmd redis.Cmder, pipeline int) {
+	var duration time.Duration
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		duration = time.Since(start)
+	}
+	err := cmd.Err()
+	if err == redis.Nil {
+		err = nil
+	}
+
+	level := h.config.level
+	if h.config.slowThreshold > 0 && duration >= h.config.slowThreshold && level > logrus.WarnLevel {
+		level = logrus.WarnLevel
+	}
+	if err != nil && level > logrus.ErrorLevel {
+		level = logrus.ErrorLevel
+	}
+	if !h.logger.IsLevelEnabled(level) {
+		return
+	}
+
+	name := cmd.Name()
+	data := &logger.RedisCommandData{
+		Command:  name,
+		Duration: duration.String(),
+		Pipeline: pipeline,
+	}
+	args := cmd.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	if key, ok := commandKey(name, args); ok {
+		data.KeyPattern = KeyPattern(key)
+		args = args[1:]
+	}
+	if !h.config.withoutArgs && h.config.redactor != nil && len(args) > 0 {
+		credentials := credentialArgs(name, args)
+		data.Args = make([]interface{}, 0, len(args))
+		for i, v := range args {
+			if credentials[i] {
+				v = logger.DefaultRedactReplacement
+			} else {
+				v = h.config.redactor(name, i, v)
+			}
+			data.Args = append(data.Args, v)
+		}
+	}
+
+	entry := h.logger.WithContext(ctx).WithField("redis", data)
+	if err != nil {
+		entry = entry.WithField("error", err)
+	}
+	entry.Log(level, "redis command")
+}
+
+// keylessCommands 第一个参数不是键的命令
+var keylessCommands = map[string]bool{
+	"auth": true, "hello": true, "ping": true, "echo": true, "select": true, "info": true,
+	"config": true, "client": true, "dbsize": true, "flushdb": true, "flushall": true,
+	"time": true, "quit": true, "script": true, "eval": true, "evalsha": true,
+	"publish": true, "subscribe": true, "psubscribe": true, "command": true, "cluster": true,
+	"acl": true, "migrate": true,
+}
+
+// sensitiveConfigs 值为凭证的 CONFIG SET 参数
+var sensitiveConfigs = map[string]bool{
+	"requirepass": true, "masterauth": true,
+	"tls-key-file-pass": true, "tls-client-key-file-pass": true,
+}
+
+// credentialArgs 返回凭证所在的参数，这些参数总是脱敏
+//
+//	AUTH [username] password / HELLO ... AUTH username password
+//	CONFIG SET requirepass <password>
+//	ACL SETUSER username >password <password #hash !hash
+//	MIGRATE host port key db timeout AUTH password / AUTH2 username password
+func credentialArgs(name string, args []interface{}) []bool {
+	credentials := make([]bool, len(args))
+	switch name {
+	case "auth", "hello":
+		for i := range credentials {
+			credentials[i] = true
+		}
+	case "config":
+		if len(args) == 0 || !argIs(args[0], "set") {
+			break
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			if param, ok := args[i].(string); ok && sensitiveConfigs[strings.ToLower(param)] {
+				credentials[i+1] = true
+			}
+		}
+	case "acl":
+		if len(args) == 0 || !argIs(args[0], "setuser") {
+			break
+		}
+		for i := 2; i < len(args); i++ {
+			if rule, ok := args[i].(string); ok && rule != "" && strings.IndexByte("><#!", rule[0]) >= 0 {
+				credentials[i] = true
+			}
+		}
+	case "migrate":
+		for i := 0; i < len(args); i++ {
+			switch {
+			case argIs(args[i], "keys"):
+				return credentials
+			case argIs(args[i], "auth"):
+				markArgs(credentials, i+1, 1)
+			case argIs(args[i], "auth2"):
+				markArgs(credentials, i+1, 2)
+			}
+		}
+	}
+	return credentials
+}
+
+func argIs(arg interface{}, s string) bool {
+	v, ok := arg.(string)
+	return ok && strings.EqualFold(v, s)
+}
+
+func markArgs(credentials []bool, from, n int) {
+	for i := from; i < from+n && i < len(credentials); i++ {
+		credentials[i] = true
+	}
+}
+
+func commandKey(name string, args []interface{}) (string, bool) {
+	if keylessCommands[name] || len(args) == 0 {
+		return "", false
+	}
+	key, ok := args[0].(string)
+	return key, ok
+}
+
+var (
+	keySeparator = regexp.MustCompile(`[:./|#{}]`)
+	keyVariable  = regexp.MustCompile(`\d|^[0-9a-fA-F]{16,}$`)
+)
+
+// KeyPattern 将键中包含数字或较长十六进制的部分替换为 *，如 user:42:profile 替换为 user:*:profile
+func KeyPattern(key string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range keySeparator.FindAllStringIndex(key, -1) {
+		writeKeySegment(&b, key[last:loc[0]])
+		b.WriteString(key[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	writeKeySegment(&b, key[last:])
+	return b.String()
+}
+
+func writeKeySegment(b *strings.Builder, segment string) {
+	if keyVariable.MatchString(segment) {
+		b.WriteByte('*')
+		return
+	}
+	b.WriteString(segment)
+}
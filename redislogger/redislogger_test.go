@@ -0,0 +1,152 @@
+package redislogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func TestKeyPattern(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Expect string
+	}{
+		{Input: "user:42:profile", Expect: "user:*:profile"},
+		{Input: "session:550e8400-e29b-41d4-a716-446655440000", Expect: "session:*"},
+		{Input: "cache{v2}.items", Expect: "cache{*}.items"},
+		{Input: "config", Expect: "config"},
+	}
+
+	for idx, each := range cases {
+		if actual := KeyPattern(each.Input); actual != each.Expect {
+			t.Fatalf("%d: expect: %s, got: %s", idx, each.Expect, actual)
+		}
+	}
+}
+
+func TestHook(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetLevel(logrus.DebugLevel)
+
+	h := Hook(l,
+		WithSlowThreshold(5*time.Millisecond),
+		WithRedactor(func(command string, i int, value interface{}) interface{} {
+			if command == "set" && i == 0 {
+				return "***"
+			}
+			return value
+		}),
+	)
+	process := func(cmd redis.Cmder, sleep time.Duration, err error) {
+		ctx, _ := h.BeforeProcess(context.Background(), cmd)
+		time.Sleep(sleep)
+		cmd.SetErr(err)
+		_ = h.AfterProcess(ctx, cmd)
+	}
+
+	ctx := context.Background()
+	process(redis.NewStatusCmd(ctx, "set", "user:42:token", "secret", "ex", 60), 0, nil)
+	process(redis.NewStringCmd(ctx, "get", "user:7:token"), 10*time.Millisecond, redis.Nil)
+	process(redis.NewStatusCmd(ctx, "auth", "password"), 0, errors.New("WRONGPASS"))
+
+	cmds := []redis.Cmder{redis.NewIntCmd(ctx, "incr", "counter:1"), redis.NewIntCmd(ctx, "incr", "counter:2")}
+	pctx, _ := h.BeforeProcessPipeline(ctx, cmds)
+	_ = h.AfterProcessPipeline(pctx, cmds)
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if len(lines) != 5 {
+		t.Fatalf("log lines, Expected=5, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"schema"}, expected: string(logger.SchemaRedisCommandV1)},
+		{line: 0, path: []interface{}{"l"}, expected: "debug"},
+		{line: 0, path: []interface{}{"redis", "command"}, expected: "set"},
+		{line: 0, path: []interface{}{"redis", "key_pattern"}, expected: "user:*:token"},
+		{line: 0, path: []interface{}{"redis", "args", 0}, expected: "***"},
+		{line: 0, path: []interface{}{"redis", "args", 1}, expected: "ex"},
+		{line: 1, path: []interface{}{"l"}, expected: "warning"},
+		{line: 1, path: []interface{}{"err"}, expected: ""},
+		{line: 2, path: []interface{}{"l"}, expected: "error"},
+		{line: 2, path: []interface{}{"redis", "key_pattern"}, expected: ""},
+		{line: 2, path: []interface{}{"redis", "args", 0}, expected: logger.DefaultRedactReplacement},
+		{line: 2, path: []interface{}{"err"}, expected: "WRONGPASS"},
+		{line: 3, path: []interface{}{"redis", "pipeline"}, expected: "2"},
+		{line: 4, path: []interface{}{"redis", "key_pattern"}, expected: "counter:*"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
+
+func TestHookCredentials(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		opts       []Option
+		args       []interface{}
+		keyPattern string
+		expected   []string
+	}{
+		{args: []interface{}{"set", "user:42:token", "secret"}, keyPattern: "user:*:token"},
+		{opts: []Option{WithRedactor(KeepArgs)}, args: []interface{}{"set", "user:42:token", "secret"}, keyPattern: "user:*:token", expected: []string{"secret"}},
+		{
+			opts:     []Option{WithRedactor(KeepArgs)},
+			args:     []interface{}{"config", "set", "requirepass", "pw", "maxmemory", "1gb"},
+			expected: []string{"set", "requirepass", logger.DefaultRedactReplacement, "maxmemory", "1gb"},
+		},
+		{
+			opts:     []Option{WithRedactor(KeepArgs)},
+			args:     []interface{}{"acl", "SETUSER", "alice", "on", ">pw", "~cache:*"},
+			expected: []string{"SETUSER", "alice", "on", logger.DefaultRedactReplacement, "~cache:*"},
+		},
+		{
+			opts:     []Option{WithRedactor(KeepArgs)},
+			args:     []interface{}{"migrate", "10.0.0.2", 6379, "", 0, 5000, "AUTH2", "bob", "pw", "KEYS", "auth"},
+			expected: []string{"10.0.0.2", "6379", "", "0", "5000", "AUTH2", logger.DefaultRedactReplacement, logger.DefaultRedactReplacement, "KEYS", "auth"},
+		},
+	}
+
+	for idx, c := range cases {
+		out := &bytes.Buffer{}
+		l, _ := logger.NewLogger("test", "test")
+		l.SetOutput(out)
+		l.SetLevel(logrus.DebugLevel)
+
+		h := Hook(l, c.opts...)
+		cmd := redis.NewStatusCmd(ctx, c.args...)
+		_ = h.AfterProcess(ctx, cmd)
+
+		data := out.Bytes()
+		if v := jsoniter.Get(data, "redis", "key_pattern").ToString(); v != c.keyPattern {
+			t.Fatalf("%d: redis.key_pattern, Expected=%q, Actual=%q", idx, c.keyPattern, v)
+		}
+		if n := jsoniter.Get(data, "redis", "args").Size(); n != len(c.expected) {
+			t.Fatalf("%d: redis.args, Expected=%d args, Actual=%d", idx, len(c.expected), n)
+		}
+		for i, expected := range c.expected {
+			if v := jsoniter.Get(data, "redis", "args", i).ToString(); v != expected {
+				t.Fatalf("%d: redis.args.%d, Expected=%q, Actual=%q", idx, i, expected, v)
+			}
+		}
+	}
+}
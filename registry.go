@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SchemaDefinition 自定义的日志规范，沿用 t、l、s、c、e 等公共字段，
+// 规范相关的内容记录在 Field 字段中
+//
+//	logger.RegisterSchema(logger.SchemaDefinition{
+//		Name:     "payment.event.v1",
+//		Field:    "payment",
+//		Type:     PaymentEvent{},
+//		Reserved: []string{"merchant_id"},
+//	})
+//	l.WithFields(logrus.Fields{"payment": &PaymentEvent{...}, "merchant_id": "m1"}).Info("captured")
+type SchemaDefinition struct {
+	Name Schema
+	// 输出中记录规范内容的字段名，也是 entry.Data 中对应的字段名
+	Field string
+	// 规范内容的类型，entry.Data[Field] 为该类型或其指针时使用该规范，为空时任意值都匹配，
+	// 同时用于生成 JSON Schema
+	Type interface{}
+	// 保留的字段名，使用该规范时从 ctx 移到规范内容中
+	Reserved []string
+	// 规范内容中可能包含敏感信息的字段，按脱敏规则处理，对象按字段名匹配，文本按内容匹配
+	Sensitive []string
+
+	// 内置的规范不能移除
+	builtin bool
+}
+
+// builtinFields 内置规范输出的字段名与 entry 中有特殊含义的字段名，不能作为自定义规范的字段名
+var builtinFields = map[string]bool{
+	"schema": true, "t": true, "l": true, "s": true, "c": true, "i": true, "request_id": true, "tenant": true, "session_id": true,
+	"e": true, "u": true, "m": true, "code": true, "host": true, "retention": true, "build": true,
+	"ctx": true, "err": true, "err_fingerprint": true, "errors": true, "request": true, "request_parse_error": true, "response": true,
+	"sql": true, "client": true, "mq": true, "job": true, "runtime": true,
+	// entry 中有特殊含义的字段
+	"channel": true, "user": true, "status": true, "id": true, "duration": true,
+	"error": true, "bytes_in": true, "bytes_out": true, "first_byte": true, "streaming": true,
+}
+
+var (
+	schemaRegistryMu sync.Mutex
+	// schemaRegistry 保存 []SchemaDefinition，写入时复制，格式化时无需加锁
+	schemaRegistry atomic.Value
+)
+
+// RegisterSchema 登记自定义的日志规范，所有格式化对象都会识别已登记的规范，
+// JSONSchema 与 Validate 同样支持已登记的规范
+func RegisterSchema(def SchemaDefinition) error {
+	if _, ok := schemaSections[def.Name]; ok {
+		return fmt.Errorf("register schema: %s is a builtin schema", def.Name)
+	}
+	if builtinFields[def.Field] {
+		return fmt.Errorf("register schema %s: field %s is reserved", def.Name, def.Field)
+	}
+	def.builtin = false
+	return registerSchema(def)
+}
+
+// registerBuiltinSchema 登记内置的日志规范，在 init 中调用
+func registerBuiltinSchema(def SchemaDefinition) {
+	def.builtin = true
+	if err := registerSchema(def); err != nil {
+		panic(err)
+	}
+}
+
+func registerSchema(def SchemaDefinition) error {
+	if def.Name == "" || def.Field == "" {
+		return errors.New("register schema: name and field are required")
+	}
+
+	schemaRegistryMu.Lock()
+	defer schemaRegistryMu.Unlock()
+
+	defs := registeredSchemas()
+	for _, d := range defs {
+		if d.Name == def.Name && d.builtin {
+			return fmt.Errorf("register schema: %s is a builtin schema", def.Name)
+		}
+		if d.Name == def.Name {
+			return fmt.Errorf("register schema: %s already registered", def.Name)
+		}
+		if d.Field == def.Field {
+			return fmt.Errorf("register schema %s: field %s is used by %s", def.Name, def.Field, d.Name)
+		}
+	}
+	schemaRegistry.Store(append(append([]SchemaDefinition(nil), defs...), def))
+	return nil
+}
+
+// UnregisterSchema 移除已登记的日志规范，内置的规范不会被移除
+func UnregisterSchema(name Schema) {
+	schemaRegistryMu.Lock()
+	defer schemaRegistryMu.Unlock()
+
+	var defs []SchemaDefinition
+	for _, d := range registeredSchemas() {
+		if d.Name != name || d.builtin {
+			defs = append(defs, d)
+		}
+	}
+	schemaRegistry.Store(defs)
+}
+
+func registeredSchemas() []SchemaDefinition {
+	defs, _ := schemaRegistry.Load().([]SchemaDefinition)
+	return defs
+}
+
+func lookupSchema(name Schema) (SchemaDefinition, bool) {
+	for _, d := range registeredSchemas() {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return SchemaDefinition{}, false
+}
+
+// sensitiveSection 查找以 key 为字段名、包含敏感字段且与 entry 中的值匹配的规范
+func sensitiveSection(entry *logrus.Entry, key string) (SchemaDefinition, bool) {
+	for _, d := range registeredSchemas() {
+		if d.Field == key && len(d.Sensitive) > 0 && d.match(entry.Data[key]) {
+			return d, true
+		}
+	}
+	return SchemaDefinition{}, false
+}
+
+// match 判断 entry 中的值是否符合规范内容的类型
+func (d *SchemaDefinition) match(v interface{}) bool {
+	if d.Type == nil {
+		return true
+	}
+	want := reflect.TypeOf(d.Type)
+	got := reflect.TypeOf(v)
+	return got == want || got != nil && got.Kind() == reflect.Ptr && got.Elem() == want
+}
+
+// customSchema 查找 entry 匹配的自定义规范，将规范内容与保留字段从 ctx 移到 data
+func (af *LogsV1Formatter) customSchema(entry *logrus.Entry, data *LogsV1) (Schema, bool) {
+	for _, d := range registeredSchemas() {
+		v, ok := entry.Data[d.Field]
+		if !ok || !d.match(v) {
+			continue
+		}
+
+		section := data.Context[d.Field]
+		delete(data.Context, d.Field)
+		if len(d.Reserved) > 0 {
+			fields := sectionFields(af.encoder(), section)
+			for _, k := range d.Reserved {
+				if rv, ok := data.Context[k]; ok {
+					fields[k] = rv
+					delete(data.Context, k)
+				}
+			}
+			section = fields
+		}
+		data.sectionKey = d.Field
+		data.section = section
+		return d.Name, true
+	}
+	return "", false
+}
+
+// sectionFields 将规范内容转换为 map，以便合并保留字段与脱敏
+func sectionFields(enc Encoder, v interface{}) map[string]interface{} {
+	switch val := v.(type) {
+	case nil:
+		return map[string]interface{}{}
+	case logrus.Fields:
+		return copyFields(val)
+	case map[string]interface{}:
+		return copyFields(val)
+	}
+
+	fields := map[string]interface{}{}
+	if p, err := enc.Marshal(v); err == nil {
+		_ = enc.Unmarshal(p, &fields)
+	}
+	return fields
+}
+
+func copyFields(m map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
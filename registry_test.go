@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type paymentEvent struct {
+	OrderID string `json:"order_id"`
+	Amount  int    `json:"amount"`
+}
+
+func TestRegisterSchema(t *testing.T) {
+	const schema Schema = "payment.event.v1"
+	if err := RegisterSchema(SchemaDefinition{
+		Name:     schema,
+		Field:    "payment",
+		Type:     paymentEvent{},
+		Reserved: []string{"merchant_id"},
+	}); err != nil {
+		t.Fatalf("RegisterSchema() error, Expected=nil, Actual=%q", err)
+	}
+	defer UnregisterSchema(schema)
+
+	f := NewFormatter("test", "test")
+	entry := logrus.WithFields(logrus.Fields{
+		"payment":     &paymentEvent{OrderID: "o1", Amount: 100},
+		"merchant_id": "m1",
+		"foo":         "bar",
+	})
+	entry.Level = logrus.InfoLevel
+	entry.Message = "captured"
+	p, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(p, &m); err != nil {
+		t.Fatalf("Format() output is not valid JSON: %s", p)
+	}
+	if m["schema"] != string(schema) {
+		t.Fatalf("Format() schema, Expected=%s, Actual=%v", schema, m["schema"])
+	}
+	payment, _ := m["payment"].(map[string]interface{})
+	if payment["order_id"] != "o1" || payment["amount"] != float64(100) || payment["merchant_id"] != "m1" {
+		t.Fatalf("Format() payment, Actual=%v", m["payment"])
+	}
+	ctx := m["ctx"].(map[string]interface{})
+	if _, ok := ctx["merchant_id"]; ok {
+		t.Fatalf("Format() reserved field should be moved out of ctx, Actual=%v", ctx)
+	}
+	if ctx["foo"] != "bar" {
+		t.Fatalf("Format() ctx.foo, Expected=bar, Actual=%v", ctx["foo"])
+	}
+	if err := Validate(p); err != nil {
+		t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+	}
+	if JSONSchema(schema) == nil {
+		t.Fatalf("JSONSchema(%s) Expected=not nil", schema)
+	}
+
+	// 类型不匹配时使用通用规范
+	entry = logrus.WithField("payment", "o1")
+	entry.Level = logrus.InfoLevel
+	p, _ = f.Format(entry)
+	_ = json.Unmarshal(p, &m)
+	if m["schema"] != string(SchemaGeneralLogsV1) {
+		t.Fatalf("Format() schema, Expected=%s, Actual=%v", SchemaGeneralLogsV1, m["schema"])
+	}
+}
+
+func TestRegisterSchemaErrors(t *testing.T) {
+	if err := RegisterSchema(SchemaDefinition{Name: "a.v1", Field: "a"}); err != nil {
+		t.Fatalf("RegisterSchema() error, Expected=nil, Actual=%q", err)
+	}
+	defer UnregisterSchema("a.v1")
+
+	for _, def := range []SchemaDefinition{
+		{Name: "a.v1", Field: "b"},
+		{Name: "b.v1", Field: "a"},
+		{Name: "b.v1", Field: "request"},
+		{Name: SchemaSQLQueryV1, Field: "b"},
+		{Name: SchemaGRPCClientV1, Field: "b"},
+		{Name: "b.v1", Field: "grpc"},
+		{Field: "b"},
+	} {
+		if err := RegisterSchema(def); err == nil {
+			t.Fatalf("RegisterSchema(%v) error, Expected=error, Actual=nil", def)
+		}
+	}
+
+	UnregisterSchema("a.v1")
+	if _, ok := lookupSchema("a.v1"); ok {
+		t.Fatalf("UnregisterSchema() schema should be removed")
+	}
+	UnregisterSchema(SchemaGRPCClientV1)
+	if _, ok := lookupSchema(SchemaGRPCClientV1); !ok {
+		t.Fatalf("UnregisterSchema() builtin schema should be kept")
+	}
+}
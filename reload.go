@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader 在运行期间重新加载配置文件，原子地替换日志级别、脱敏规则与访问日志的采样规则，
+// 正在格式化的日志继续使用旧的配置，不会丢失。
+// 通过 WithRedaction、WithSecretScanning、WithRedactionAudit 设置的脱敏配置保持不变，
+// 重新加载只替换配置文件中的规则
+//
+// 服务名、环境、输出目标等在创建日志对象时确定，不支持重新加载
+type Reloader struct {
+	path      string
+	logger    *logrus.Logger
+	formatter *LogsV1Formatter
+	// 可选配置中的脱敏配置
+	base *Redactor
+	// []*samplingRule
+	sampling atomic.Value
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewReloadable 根据配置文件创建支持重新加载的日志对象
+func NewReloadable(path string, opts ...Option) (*logrus.Logger, *Reloader, error) {
+	c, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 配置文件中的脱敏规则由 apply 设置，格式化对象的 Redactor 只保留可选配置中的部分
+	withoutRedaction := *c
+	withoutRedaction.Redact = nil
+	withoutRedaction.RedactAudit = false
+	withoutRedaction.SecretScanning = false
+	l, err := NewWithConfig(&withoutRedaction, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := l.Formatter.(*LogsV1Formatter)
+	r := &Reloader{
+		path:      path,
+		logger:    l,
+		formatter: f,
+		base:      f.Redactor,
+	}
+	if err := r.apply(c); err != nil {
+		return nil, nil, err
+	}
+	if fi, err := os.Stat(path); err == nil {
+		r.modTime = fi.ModTime()
+	}
+	return l, r, nil
+}
+
+// Reload 重新加载配置文件，配置有误时保持原有配置不变
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if fi, err := os.Stat(r.path); err == nil {
+		r.modTime = fi.ModTime()
+	}
+
+	c, err := LoadConfig(r.path)
+	if err != nil {
+		return err
+	}
+
+	return r.apply(c)
+}
+
+// apply 应用配置中可以重新加载的部分，配置有误时不做任何修改
+func (r *Reloader) apply(c *Config) error {
+	level := logrus.InfoLevel
+	if c.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(c.Level); err != nil {
+			return wrapf(err, "parse log level")
+		}
+	}
+
+	rules, err := c.RedactRules()
+	if err != nil {
+		return err
+	}
+
+	sampling, err := c.samplingRules()
+	if err != nil {
+		return err
+	}
+
+	r.formatter.SetRedactor(r.redactor(rules, c.RedactAudit))
+	r.sampling.Store(sampling)
+	r.logger.SetLevel(level)
+	return nil
+}
+
+// redactor 合并配置文件中的脱敏规则与可选配置中的脱敏配置
+func (r *Reloader) redactor(rules []RedactRule, audit bool) *Redactor {
+	if r.base == nil {
+		if len(rules) == 0 {
+			return nil
+		}
+		return &Redactor{Rules: rules, Audit: audit}
+	}
+	return &Redactor{
+		Rules:  append(rules, r.base.Rules...),
+		Audit:  audit || r.base.Audit,
+		Report: r.base.Report,
+	}
+}
+
+// samplingRules 当前配置文件中的访问日志采样规则
+func (r *Reloader) samplingRules() []*samplingRule {
+	rules, _ := r.sampling.Load().([]*samplingRule)
+	return rules
+}
+
+// Watch 每隔 interval 检查配置文件的修改时间，发生变化时重新加载，直到 ctx 结束
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fi, err := os.Stat(r.path)
+			if err != nil {
+				continue
+			}
+
+			r.mu.Lock()
+			changed := !fi.ModTime().Equal(r.modTime)
+			r.mu.Unlock()
+
+			if changed {
+				if err := r.Reload(); err != nil {
+					r.logger.WithField("error", err).Error("reload log config")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+)
+
+// HeaderRequestID 传递请求ID的 header
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength 外部传入的请求ID的最大长度，超出时重新生成
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// ContextWithRequestID 将请求ID保存到 ctx，通过 WithContext(ctx) 输出的日志都会带有 request_id 字段
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext 获得 ctx 中保存的请求ID
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware 从 X-Request-ID 读取请求ID，不存在或不合法时生成新的ID，
+// 请求ID会写入响应的 header 并保存到请求的 ctx 中
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newUUID()
+		}
+
+		w.Header().Set(HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID 只接受可打印的 ASCII 字符，避免通过 header 注入日志内容
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.WithContext(r.Context()).Info("handled")
+	}))
+
+	cases := []struct {
+		Input    string
+		Generate bool
+	}{
+		{Input: "abc-123", Generate: false},
+		{Input: "", Generate: true},
+		{Input: "bad\nid", Generate: true},
+	}
+
+	for idx, each := range cases {
+		out.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if each.Input != "" {
+			req.Header.Set(HeaderRequestID, each.Input)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		id := w.Header().Get(HeaderRequestID)
+		if each.Generate && (id == "" || id == each.Input) {
+			t.Fatalf("%d: expect generated id, got: %q", idx, id)
+		}
+		if !each.Generate && id != each.Input {
+			t.Fatalf("%d: expect: %s, got: %s", idx, each.Input, id)
+		}
+		if v := jsoniter.Get(out.Bytes(), "request_id").ToString(); v != id {
+			t.Fatalf("%d: output request_id expect: %s, got: %s", idx, id, v)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// responseWriter 记录响应的状态码与字节数
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	// 开始输出响应的时间与是否主动刷新过，用于识别流式响应
+	wroteAt  time.Time
+	flushed  bool
+	hijacked bool
+	// 连接被接管时调用，用于记录 WebSocket 升级
+	onHijack func()
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader implements http.ResponseWriter interface
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.status = status
+	w.wroteHeader = true
+	w.wroteAt = time.Now()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter interface
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher interface
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		w.flushed = true
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		conn, rw, err := h.Hijack()
+		if err == nil && !w.hijacked {
+			w.hijacked = true
+			if w.onHijack != nil {
+				w.onHijack()
+			}
+		}
+		return conn, rw, err
+	}
+	return nil, nil, errors.New("http.Hijacker is not supported")
+}
+
+// streaming 判断是否为流式响应：处理过程中主动刷新过、SSE 或分块传输
+func (w *responseWriter) streaming() bool {
+	if w.flushed {
+		return true
+	}
+	h := w.Header()
+	return strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") ||
+		headerHasToken(h, "Transfer-Encoding", "chunked")
+}
+
+// Unwrap 供 http.ResponseController 获取原始的 ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
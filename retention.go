@@ -0,0 +1,29 @@
+package logger
+
+// WithRetention 设置日志默认的保留期限标记，例如 30d，下游存储据此分层
+func WithRetention(class string) Option {
+	return func(f *LogsV1Formatter) {
+		f.Retention = class
+	}
+}
+
+// WithChannelRetention 设置指定 channel 的保留期限标记，例如审计日志 7y
+func WithChannelRetention(channel, class string) Option {
+	return func(f *LogsV1Formatter) {
+		if f.ChannelRetention == nil {
+			f.ChannelRetention = map[string]string{}
+		}
+		f.ChannelRetention[channel] = class
+	}
+}
+
+// retention 获得日志的保留期限标记，优先级为 entry 字段、channel 配置、默认配置
+func (af *LogsV1Formatter) retention(channel, retention string) string {
+	if retention != "" {
+		return retention
+	}
+	if class, ok := af.ChannelRetention[channel]; ok {
+		return class
+	}
+	return af.Retention
+}
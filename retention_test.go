@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRetention(t *testing.T) {
+	f := NewFormatter("test", "test", WithRetention("30d"), WithChannelRetention("audit", "7y"))
+
+	cases := []struct {
+		Data   logrus.Fields
+		Expect string
+	}{
+		{Data: logrus.Fields{}, Expect: "30d"},
+		{Data: logrus.Fields{"channel": "audit"}, Expect: "7y"},
+		{Data: logrus.Fields{"channel": "audit", "retention": "90d"}, Expect: "90d"},
+	}
+
+	for idx, each := range cases {
+		data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: each.Data})
+		if err != nil {
+			t.Fatalf("%d: Format() error, Expected=nil, Actual=%q", idx, err.Error())
+		}
+		if v := jsoniter.Get(data, "retention").ToString(); v != each.Expect {
+			t.Fatalf("%d: expect: %s, got: %s", idx, each.Expect, v)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type routeKey struct{}
+
+// routeInfo 路由匹配结果，路由在中间件之后匹配，因此保存可修改的指针
+type routeInfo struct {
+	mu      sync.Mutex
+	pattern string
+	handler string
+	params  map[string]string
+}
+
+// ContextWithRoute 在 ctx 中预留路由信息，之后通过 SetRoute 记录的路由模板与处理函数名
+// 会输出到 request.route 与 request.handler，Middleware 会自动调用
+func ContextWithRoute(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(routeKey{}).(*routeInfo); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, routeKey{}, &routeInfo{})
+}
+
+// SetRoute 记录请求匹配的路由模板 (例如 /users/:id) 与处理函数名，
+// 由 Gin、chi、echo 等路由框架的适配代码在匹配路由后调用，便于按接口而不是按具体路径统计
+func SetRoute(r *http.Request, pattern, handler string) {
+	info, ok := r.Context().Value(routeKey{}).(*routeInfo)
+	if !ok {
+		return
+	}
+	info.mu.Lock()
+	info.pattern = pattern
+	info.handler = handler
+	info.mu.Unlock()
+}
+
+// SetRouteParams 记录路由模板中参数的值，例如 /users/{id} 中的 id，输出到 request.route_params
+func SetRouteParams(r *http.Request, params map[string]string) {
+	info, ok := r.Context().Value(routeKey{}).(*routeInfo)
+	if !ok {
+		return
+	}
+	info.mu.Lock()
+	info.params = params
+	info.mu.Unlock()
+}
+
+// routeFromContext 获得 SetRoute 与 SetRouteParams 记录的路由信息
+func routeFromContext(ctx context.Context) (pattern, handler string, params map[string]string) {
+	if ctx == nil {
+		return "", "", nil
+	}
+	info, ok := ctx.Value(routeKey{}).(*routeInfo)
+	if !ok {
+		return "", "", nil
+	}
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	return info.pattern, info.handler, info.params
+}
+
+// HandlerName 返回处理函数的名称，例如 main.(*UserHandler).Get，
+// 用于没有提供处理函数名的路由框架
+func HandlerName(h interface{}) string {
+	if h == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(h)
+	if rv.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(rv.Pointer()); fn != nil {
+			return strings.TrimSuffix(fn.Name(), "-fm")
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
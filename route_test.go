@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type userHandler struct{}
+
+func (userHandler) Get(w http.ResponseWriter, r *http.Request) {}
+
+func TestMiddlewareRoute(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	get := userHandler{}.Get
+	// 模拟路由框架：匹配路由后替换请求的 context，再记录路由信息
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(r.Context())
+		SetRoute(r, "/users/:id", HandlerName(get))
+		SetRouteParams(r, map[string]string{"id": "42"})
+		get(w, r)
+	})
+	Middleware(l)(router).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	data := out.Bytes()
+	if v := jsoniter.Get(data, "request", "path").ToString(); v != "/users/42" {
+		t.Fatalf("output request.path, Expected=%q, Actual=%q", "/users/42", v)
+	}
+	if v := jsoniter.Get(data, "request", "route").ToString(); v != "/users/:id" {
+		t.Fatalf("output request.route, Expected=%q, Actual=%q", "/users/:id", v)
+	}
+	if v := jsoniter.Get(data, "request", "route_params", "id").ToString(); v != "42" {
+		t.Fatalf("output request.route_params.id, Expected=%q, Actual=%q", "42", v)
+	}
+	if v := jsoniter.Get(data, "request", "handler").ToString(); !strings.HasSuffix(v, "logger.userHandler.Get") {
+		t.Fatalf("output request.handler, Expected=*logger.userHandler.Get, Actual=%q", v)
+	}
+}
+
+func TestSetRouteWithoutContext(t *testing.T) {
+	// 没有经过 Middleware 的请求忽略路由信息
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	SetRoute(req, "/", "index")
+	if pattern, _, _ := routeFromContext(req.Context()); pattern != "" {
+		t.Fatalf("route without context, Expected=%q, Actual=%q", "", pattern)
+	}
+}
@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelRouter 按日志规范、channel 与级别将格式化后的日志分发到不同的输出，
+// 优先级依次降低。分发依赖 LogsV1、LogsV2 JSON 的字段，应使用 Install 设置为 logger 的输出
+//
+//	err := logger.NewLevelRouter(os.Stdout).
+//		Route(os.Stderr, logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel).
+//		Route(debugFile, logrus.DebugLevel, logrus.TraceLevel).
+//		Install(l)
+type LevelRouter struct {
+	// 没有匹配规则时的输出
+	Default  io.Writer
+	levels   map[logrus.Level]io.Writer
+	channels map[string]io.Writer
+	schemas  map[Schema]io.Writer
+	tenants  map[string]io.Writer
+	sampling map[string]*tenantSampling
+}
+
+// ErrRouterFormatter logger 的格式化对象输出的不是 LevelRouter 可以分发的 JSON
+var ErrRouterFormatter = errors.New("logger: level router requires LogsV1 or LogsV2 json formatter without DualEmit")
+
+type tenantSampling struct {
+	rate float64
+	sampler
+}
+
+// NewLevelRouter 创建按级别分发的输出，未匹配的日志写入 def
+func NewLevelRouter(def io.Writer) *LevelRouter {
+	return &LevelRouter{
+		Default:  def,
+		levels:   map[logrus.Level]io.Writer{},
+		channels: map[string]io.Writer{},
+		schemas:  map[Schema]io.Writer{},
+		tenants:  map[string]io.Writer{},
+		sampling: map[string]*tenantSampling{},
+	}
+}
+
+// NewStdLevelRouter 容器平台常用的分发方式，error 及以上级别写入 stderr，其余写入 stdout
+func NewStdLevelRouter() *LevelRouter {
+	return NewLevelRouter(os.Stdout).
+		Route(os.Stderr, logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel)
+}
+
+// Route 将指定级别的日志写入 w
+func (r *LevelRouter) Route(w io.Writer, levels ...logrus.Level) *LevelRouter {
+	for _, level := range levels {
+		r.levels[level] = w
+	}
+	return r
+}
+
+// RouteChannel 将指定 channel 的日志写入 w，优先于按级别分发
+func (r *LevelRouter) RouteChannel(channel string, w io.Writer) *LevelRouter {
+	r.channels[channel] = w
+	return r
+}
+
+// RouteSchema 将指定规范的日志写入 w，例如将 http.request.v1 访问日志写入单独的文件，
+// 优先于按 channel 与级别分发
+func (r *LevelRouter) RouteSchema(schema Schema, w io.Writer) *LevelRouter {
+	r.schemas[schema] = w
+	return r
+}
+
+// RouteTenant 将指定租户的日志写入 w，用于隔离租户的日志，优先于其他分发规则
+func (r *LevelRouter) RouteTenant(tenant string, w io.Writer) *LevelRouter {
+	r.tenants[tenant] = w
+	return r
+}
+
+// SampleTenant 按比例采样指定租户的日志，rate 在 0 到 1 之间，
+// error 及以上级别的日志总是记录
+func (r *LevelRouter) SampleTenant(tenant string, rate float64) *LevelRouter {
+	r.sampling[tenant] = &tenantSampling{rate: rate}
+	return r
+}
+
+// Install 检查 l 的格式化对象后将 l 的输出设置为 r。LevelRouter 从格式化后的 JSON 中读取级别、channel、
+// 租户与规范，logfmt、msgpack 等其他格式或开启 DualEmit 时所有日志都会写入 Default，此时返回 ErrRouterFormatter，
+// 不修改 l 的输出
+func (r *LevelRouter) Install(l *logrus.Logger) error {
+	var af *LogsV1Formatter
+	switch f := l.Formatter.(type) {
+	case *LogsV1Formatter:
+		af = f
+	case *LogsV2Formatter:
+		af = f.LogsV1Formatter
+	default:
+		return ErrRouterFormatter
+	}
+	if af.DualEmit {
+		return ErrRouterFormatter
+	}
+	l.SetOutput(r)
+	return nil
+}
+
+// Write implements io.Writer interface
+func (r *LevelRouter) Write(p []byte) (int, error) {
+	if len(r.sampling) > 0 && !r.sampled(p) {
+		return len(p), nil
+	}
+	return r.writer(p).Write(p)
+}
+
+// sampled 判断租户的日志是否记录
+func (r *LevelRouter) sampled(p []byte) bool {
+	s, ok := r.sampling[envelopeField(p, "tenant")]
+	if !ok {
+		return true
+	}
+	if level, err := logrus.ParseLevel(lineField(p, "l")); err == nil && level <= logrus.ErrorLevel {
+		return true
+	}
+	return s.sample(s.rate)
+}
+
+func (r *LevelRouter) writer(p []byte) io.Writer {
+	if len(r.tenants) > 0 {
+		if w, ok := r.tenants[envelopeField(p, "tenant")]; ok {
+			return w
+		}
+	}
+	if len(r.schemas) > 0 {
+		if w, ok := r.schemas[Schema(lineField(p, "schema"))]; ok {
+			return w
+		}
+	}
+	if len(r.channels) > 0 {
+		if w, ok := r.channels[lineField(p, "c")]; ok {
+			return w
+		}
+	}
+	if len(r.levels) > 0 {
+		if level, err := logrus.ParseLevel(lineField(p, "l")); err == nil {
+			if w, ok := r.levels[level]; ok {
+				return w
+			}
+		}
+	}
+	return r.Default
+}
+
+// lineField 从格式化后的日志中读取顶层字符串字段的值，
+// 依赖 LogsV1 的字段顺序：顶层字段位于 ctx 等嵌套内容之前，首次匹配即为顶层字段
+func lineField(p []byte, key string) string {
+	prefix := []byte(`"` + key + `":"`)
+	idx := bytes.Index(p, prefix)
+	if idx < 0 {
+		return ""
+	}
+
+	p = p[idx+len(prefix):]
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case '"':
+			return string(p[:i])
+		}
+	}
+	return ""
+}
+
+// envelopeField 读取 ctx 之前的顶层字符串字段，用于可能省略的字段，
+// 避免字段省略时匹配到 ctx 等嵌套内容中的同名字段
+func envelopeField(p []byte, key string) string {
+	if idx := bytes.Index(p, []byte(`,"ctx":`)); idx >= 0 {
+		p = p[:idx]
+	}
+	return lineField(p, key)
+}
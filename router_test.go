@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelRouter(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	debug := &bytes.Buffer{}
+	audit := &bytes.Buffer{}
+
+	l, _ := NewLogger("test", "test")
+	l.SetLevel(logrus.DebugLevel)
+	if err := NewLevelRouter(stdout).
+		Route(stderr, logrus.ErrorLevel).
+		Route(debug, logrus.DebugLevel).
+		RouteChannel("audit", audit).
+		Install(l); err != nil {
+		t.Fatalf("Install() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	l.Info("info")
+	l.Error("error")
+	l.Debug("debug")
+	l.WithField("channel", "audit").Error("audit")
+
+	cases := []struct {
+		Name   string
+		Buffer *bytes.Buffer
+		Expect string
+	}{
+		{Name: "stdout", Buffer: stdout, Expect: "info"},
+		{Name: "stderr", Buffer: stderr, Expect: "error"},
+		{Name: "debug", Buffer: debug, Expect: "debug"},
+		{Name: "audit", Buffer: audit, Expect: "audit"},
+	}
+
+	for _, each := range cases {
+		lines := bytes.Split(bytes.TrimSpace(each.Buffer.Bytes()), []byte("\n"))
+		if len(lines) != 1 || lineField(lines[0], "m") != each.Expect {
+			t.Fatalf("%s: expect: %s, got: %q", each.Name, each.Expect, each.Buffer.String())
+		}
+	}
+}
+
+func TestLevelRouterInstall(t *testing.T) {
+	dual := NewFormatter("test", "test").(*LogsV1Formatter)
+	dual.DualEmit = true
+	cases := []struct {
+		formatter logrus.Formatter
+		expected  error
+	}{
+		{formatter: NewFormatter("test", "test"), expected: nil},
+		{formatter: NewV2Formatter("test", "test"), expected: nil},
+		{formatter: NewLogfmtFormatter("test", "test"), expected: ErrRouterFormatter},
+		{formatter: NewMsgpackFormatter("test", "test"), expected: ErrRouterFormatter},
+		{formatter: dual, expected: ErrRouterFormatter},
+		{formatter: &logrus.JSONFormatter{}, expected: ErrRouterFormatter},
+	}
+	for _, c := range cases {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		l := logrus.New()
+		l.SetOutput(stdout)
+		l.SetFormatter(c.formatter)
+		if err := NewLevelRouter(stdout).Route(stderr, logrus.ErrorLevel).Install(l); err != c.expected {
+			t.Fatalf("Install(%T) error, Expected=%v, Actual=%v", c.formatter, c.expected, err)
+		}
+
+		l.Error("error")
+		if c.expected == nil && (stderr.Len() == 0 || stdout.Len() != 0) {
+			t.Fatalf("Install(%T) route error, Expected=stderr, Actual=%q", c.formatter, stdout.String())
+		}
+		if c.expected != nil && (stderr.Len() != 0 || stdout.Len() == 0) {
+			t.Fatalf("Install(%T) output error, Expected=unchanged, Actual=%q", c.formatter, stderr.String())
+		}
+	}
+}
+
+func TestLevelRouterTenant(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	acme := &bytes.Buffer{}
+
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(NewLevelRouter(stdout).
+		RouteTenant("acme", acme).
+		SampleTenant("globex", 0))
+
+	l.WithField("tenant", "acme").Info("acme")
+	l.WithField("tenant", "globex").Info("sampled")
+	l.WithField("tenant", "globex").Error("globex")
+	l.WithField("nested", map[string]string{"tenant": "acme"}).Info("other")
+
+	cases := []struct {
+		Name   string
+		Buffer *bytes.Buffer
+		Expect []string
+	}{
+		{Name: "acme", Buffer: acme, Expect: []string{"acme"}},
+		{Name: "stdout", Buffer: stdout, Expect: []string{"globex", "other"}},
+	}
+
+	for _, each := range cases {
+		lines := bytes.Split(bytes.TrimSpace(each.Buffer.Bytes()), []byte("\n"))
+		if len(lines) != len(each.Expect) {
+			t.Fatalf("%s: expect: %v, got: %q", each.Name, each.Expect, each.Buffer.String())
+		}
+		for i, line := range lines {
+			if lineField(line, "m") != each.Expect[i] {
+				t.Fatalf("%s: expect: %v, got: %q", each.Name, each.Expect, each.Buffer.String())
+			}
+		}
+	}
+}
+
+func TestLineField(t *testing.T) {
+	line := []byte(`{"schema":"general.logs.v1","l":"info","s":"a\"c\":\"x","c":"audit","ctx":{"c":"nested"}}`)
+
+	cases := []struct {
+		Key    string
+		Expect string
+	}{
+		{Key: "l", Expect: "info"},
+		{Key: "s", Expect: `a\"c\":\"x`},
+		{Key: "c", Expect: "audit"},
+		{Key: "missing", Expect: ""},
+	}
+
+	for idx, each := range cases {
+		if actual := lineField(line, each.Key); actual != each.Expect {
+			t.Fatalf("%d: expect: %s, got: %s", idx, each.Expect, actual)
+		}
+	}
+}
+
+func TestSchemaRouter(t *testing.T) {
+	app := &bytes.Buffer{}
+	access := &bytes.Buffer{}
+
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(NewLevelRouter(app).RouteSchema(SchemaHTTPRequestV1, access))
+
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.Error("handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if v := lineField(app.Bytes(), "schema"); v != string(SchemaGeneralLogsV1) || bytes.Count(app.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("app output, Actual=%q", app.String())
+	}
+	if v := lineField(access.Bytes(), "schema"); v != string(SchemaHTTPRequestV1) || bytes.Count(access.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("access output, Actual=%q", access.String())
+	}
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RuntimeStatsData 运行时统计，GC 与 CPU 的数据为距上一次统计的增量
+type RuntimeStatsData struct {
+	// 堆上存活对象占用的字节数与对象数
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapObjects uint64 `json:"heap_objects"`
+	// 向操作系统申请的总字节数
+	Sys        uint64 `json:"sys"`
+	Goroutines int    `json:"goroutines"`
+	// 统计间隔内的 GC 次数、暂停总时长与最长暂停
+	GCCount      uint32 `json:"gc_count"`
+	GCPauseTotal string `json:"gc_pause_total"`
+	GCPauseMax   string `json:"gc_pause_max"`
+	// 统计间隔内进程的 CPU 使用率，100 表示占满一个核，无法获取时为 -1
+	CPUPercent float64 `json:"cpu_percent"`
+}
+
+// runtimeStats 计算两次统计之间的增量
+type runtimeStats struct {
+	mem     runtime.MemStats
+	cpu     time.Duration
+	cpuOK   bool
+	sampled time.Time
+}
+
+// StartRuntimeStatsLogger 每隔 interval 以 runtime.stats.v1 规范输出一条运行时统计日志，
+// 关闭返回的对象后停止，可以通过 RegisterCloser 登记
+//
+// 每次统计调用 runtime.ReadMemStats，会短暂地暂停所有 goroutine，interval 不宜过短
+func StartRuntimeStatsLogger(l *logrus.Logger, interval time.Duration) io.Closer {
+	s := &runtimeStatsLogger{
+		logger: l,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	prev := &runtimeStats{}
+	prev.read()
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				cur := &runtimeStats{}
+				cur.read()
+				l.WithField("runtime", cur.diff(prev)).Info("runtime stats")
+				prev = cur
+			}
+		}
+	}()
+	return s
+}
+
+type runtimeStatsLogger struct {
+	logger *logrus.Logger
+	once   sync.Once
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// Close 停止统计并等待后台 goroutine 退出
+func (s *runtimeStatsLogger) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+func (s *runtimeStats) read() {
+	runtime.ReadMemStats(&s.mem)
+	s.cpu, s.cpuOK = processCPUTime()
+	s.sampled = time.Now()
+}
+
+// diff 生成本次统计相对 prev 的数据
+func (s *runtimeStats) diff(prev *runtimeStats) *RuntimeStatsData {
+	data := &RuntimeStatsData{
+		HeapAlloc:   s.mem.HeapAlloc,
+		HeapObjects: s.mem.HeapObjects,
+		Sys:         s.mem.Sys,
+		Goroutines:  runtime.NumGoroutine(),
+		GCCount:     s.mem.NumGC - prev.mem.NumGC,
+		CPUPercent:  -1,
+	}
+	data.GCPauseTotal = time.Duration(s.mem.PauseTotalNs - prev.mem.PauseTotalNs).String()
+
+	// PauseNs 是最近 256 次 GC 的循环缓冲区，第 NumGC 次 GC 位于 (NumGC+255)%256
+	var max uint64
+	n := data.GCCount
+	if n > uint32(len(s.mem.PauseNs)) {
+		n = uint32(len(s.mem.PauseNs))
+	}
+	for i := uint32(0); i < n; i++ {
+		pause := s.mem.PauseNs[(s.mem.NumGC-i+255)%256]
+		if pause > max {
+			max = pause
+		}
+	}
+	data.GCPauseMax = time.Duration(max).String()
+
+	if wall := s.sampled.Sub(prev.sampled); s.cpuOK && prev.cpuOK && wall > 0 {
+		data.CPUPercent = float64(s.cpu-prev.cpu) / float64(wall) * 100
+	}
+	return data
+}
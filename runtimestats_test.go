@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRuntimeStatsDiff(t *testing.T) {
+	prev := &runtimeStats{}
+	prev.read()
+	runtime.GC()
+	time.Sleep(time.Millisecond)
+	cur := &runtimeStats{}
+	cur.read()
+
+	data := cur.diff(prev)
+	if data.GCCount < 1 {
+		t.Fatalf("diff() GCCount, Expected>=1, Actual=%d", data.GCCount)
+	}
+	if data.HeapAlloc == 0 || data.Goroutines < 1 {
+		t.Fatalf("diff() Actual=%+v", data)
+	}
+	if _, err := time.ParseDuration(data.GCPauseMax); err != nil {
+		t.Fatalf("diff() GCPauseMax error, Expected=nil, Actual=%q", err)
+	}
+	if _, ok := processCPUTime(); ok && data.CPUPercent < 0 {
+		t.Fatalf("diff() CPUPercent, Expected>=0, Actual=%f", data.CPUPercent)
+	}
+}
+
+func TestStartRuntimeStatsLogger(t *testing.T) {
+	out := &lockedBuffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	c := StartRuntimeStatsLogger(l, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	_ = c.Close()
+
+	out.mu.Lock()
+	logged := append([]byte(nil), out.Bytes()...)
+	out.mu.Unlock()
+	lines := bytes.Split(bytes.TrimSpace(logged), []byte("\n"))
+	if len(logged) == 0 {
+		t.Fatalf("StartRuntimeStatsLogger() Expected at least 1 log")
+	}
+	for _, line := range lines {
+		if err := Validate(line); err != nil {
+			t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+		}
+		if !bytes.Contains(line, []byte(`"schema":"runtime.stats.v1"`)) {
+			t.Fatalf("output schema, Expected=runtime.stats.v1, Actual=%s", line)
+		}
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if out.Len() != len(logged) {
+		t.Fatalf("Close() should stop logging")
+	}
+}
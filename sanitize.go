@@ -0,0 +1,382 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMaxDepth ctx 字段默认的最大嵌套深度
+const DefaultMaxDepth = 10
+
+// WithMaxDepth 设置 ctx 字段的最大嵌套深度，超出部分替换为描述文本
+func WithMaxDepth(n int) Option {
+	return func(af *LogsV1Formatter) {
+		af.MaxDepth = n
+	}
+}
+
+// sanitizer 检查字段值中无法编码的类型 (chan、func、complex 等)、循环引用与过深的嵌套，
+// 替换为描述文本，escape 时同时转义字符串中的控制字符；值本身没有问题时原样返回，不会复制
+type sanitizer struct {
+	maxDepth int
+	escape   bool
+	// 是否统一时间、时长与二进制数据的输出格式
+	normalize bool
+	normalizer
+	// 当前路径上的指针、map 与 slice，用于发现循环引用
+	path []uintptr
+}
+
+// sanitizer 获得按格式化对象配置的 sanitizer
+func (af *LogsV1Formatter) sanitizer() sanitizer {
+	return sanitizer{
+		maxDepth:  af.MaxDepth,
+		escape:    !af.RawStrings,
+		normalize: true,
+		normalizer: normalizer{
+			timeLayout: af.TimeLayout,
+			bytes:      af.BytesEncoding,
+		},
+	}
+}
+
+// sanitize 返回可以安全编码的值
+func (s sanitizer) sanitize(v interface{}) interface{} {
+	if s.maxDepth <= 0 {
+		s.maxDepth = DefaultMaxDepth
+	}
+	v, _ = s.value(v, 0)
+	return v
+}
+
+// value 返回处理后的值，以及值是否被替换
+func (s *sanitizer) value(v interface{}, depth int) (interface{}, bool) {
+	switch val := v.(type) {
+	case string:
+		if s.escape {
+			if escaped := escapeString(val); escaped != val {
+				return escaped, true
+			}
+		}
+		return v, false
+	case []string:
+		return s.strings(val)
+	case time.Duration, time.Time, []byte:
+		if s.normalize {
+			return s.normalizer.value(val), true
+		}
+		return v, false
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Marshaler, encoding.TextMarshaler:
+		return v, false
+	case logrus.Fields:
+		return s.fields(val, depth)
+	case map[string]interface{}:
+		return s.fields(val, depth)
+	case []interface{}:
+		return s.slice(val, depth)
+	}
+
+	return s.reflect(reflect.ValueOf(v), depth)
+}
+
+func (s *sanitizer) fields(m map[string]interface{}, depth int) (interface{}, bool) {
+	if m == nil {
+		return m, false
+	}
+	if depth >= s.maxDepth {
+		return depthExceeded(m), true
+	}
+	ptr := reflect.ValueOf(m).Pointer()
+	if s.visiting(ptr) {
+		return cyclic(m), true
+	}
+	s.path = append(s.path, ptr)
+	defer s.leave()
+
+	var changes map[string]interface{}
+	keyChanged := false
+	for k, item := range m {
+		if sanitized, changed := s.value(item, depth+1); changed {
+			if changes == nil {
+				changes = map[string]interface{}{}
+			}
+			changes[k] = sanitized
+		}
+		keyChanged = keyChanged || s.key(k) != k
+	}
+	if changes == nil && !keyChanged {
+		return m, false
+	}
+
+	copied := make(map[string]interface{}, len(m))
+	for k, item := range m {
+		if sanitized, ok := changes[k]; ok {
+			item = sanitized
+		}
+		copied[s.key(k)] = item
+	}
+	return copied, true
+}
+
+func (s *sanitizer) slice(items []interface{}, depth int) (interface{}, bool) {
+	if len(items) == 0 {
+		return items, false
+	}
+	if depth >= s.maxDepth {
+		return depthExceeded(items), true
+	}
+	ptr := reflect.ValueOf(items).Pointer()
+	if s.visiting(ptr) {
+		return cyclic(items), true
+	}
+	s.path = append(s.path, ptr)
+	defer s.leave()
+
+	var copied []interface{}
+	for i, item := range items {
+		sanitized, changed := s.value(item, depth+1)
+		if changed && copied == nil {
+			copied = make([]interface{}, len(items))
+			copy(copied, items[:i])
+		}
+		if copied != nil {
+			copied[i] = sanitized
+		}
+	}
+	if copied == nil {
+		return items, false
+	}
+	return copied, true
+}
+
+func (s *sanitizer) reflect(rv reflect.Value, depth int) (interface{}, bool) {
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Sprintf("<%s>", rv.Type()), true
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprintf("%v", rv.Complex()), true
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return rv.Interface(), false
+		}
+		if depth >= s.maxDepth {
+			return depthExceeded(rv.Interface()), true
+		}
+		if rv.Kind() == reflect.Ptr {
+			ptr := rv.Pointer()
+			if s.visiting(ptr) {
+				return cyclic(rv.Interface()), true
+			}
+			s.path = append(s.path, ptr)
+			defer s.leave()
+		}
+		if sanitized, changed := s.value(rv.Elem().Interface(), depth+1); changed {
+			return sanitized, true
+		}
+		return rv.Interface(), false
+	case reflect.Map:
+		return s.reflectMap(rv, depth)
+	case reflect.Slice, reflect.Array:
+		return s.reflectSlice(rv, depth)
+	case reflect.Struct:
+		return s.reflectStruct(rv, depth)
+	}
+	return rv.Interface(), false
+}
+
+func (s *sanitizer) reflectMap(rv reflect.Value, depth int) (interface{}, bool) {
+	if rv.IsNil() || rv.Len() == 0 {
+		return rv.Interface(), false
+	}
+	if depth >= s.maxDepth {
+		return depthExceeded(rv.Interface()), true
+	}
+	ptr := rv.Pointer()
+	if s.visiting(ptr) {
+		return cyclic(rv.Interface()), true
+	}
+	s.path = append(s.path, ptr)
+	defer s.leave()
+
+	// 先检查是否需要替换，只有需要时才复制
+	changed := false
+	iter := rv.MapRange()
+	for iter.Next() && !changed {
+		_, changed = s.value(iter.Value().Interface(), depth+1)
+		if key := iter.Key(); key.Kind() == reflect.String {
+			changed = changed || s.key(key.String()) != key.String()
+		}
+	}
+	if !changed {
+		return rv.Interface(), false
+	}
+
+	copied := make(map[string]interface{}, rv.Len())
+	iter = rv.MapRange()
+	for iter.Next() {
+		copied[s.key(fmt.Sprint(iter.Key().Interface()))], _ = s.value(iter.Value().Interface(), depth+1)
+	}
+	return copied, true
+}
+
+func (s *sanitizer) reflectSlice(rv reflect.Value, depth int) (interface{}, bool) {
+	if rv.Len() == 0 {
+		return rv.Interface(), false
+	}
+	if depth >= s.maxDepth {
+		return depthExceeded(rv.Interface()), true
+	}
+	if rv.Kind() == reflect.Slice {
+		ptr := rv.Pointer()
+		if s.visiting(ptr) {
+			return cyclic(rv.Interface()), true
+		}
+		s.path = append(s.path, ptr)
+		defer s.leave()
+	}
+
+	changed := false
+	for i := 0; i < rv.Len() && !changed; i++ {
+		_, changed = s.value(rv.Index(i).Interface(), depth+1)
+	}
+	if !changed {
+		return rv.Interface(), false
+	}
+
+	copied := make([]interface{}, rv.Len())
+	for i := range copied {
+		copied[i], _ = s.value(rv.Index(i).Interface(), depth+1)
+	}
+	return copied, true
+}
+
+// reflectStruct 检查导出的字段，有字段被替换时按 json tag 转换为 map
+func (s *sanitizer) reflectStruct(rv reflect.Value, depth int) (interface{}, bool) {
+	if depth >= s.maxDepth {
+		return depthExceeded(rv.Interface()), true
+	}
+
+	if !s.structFields(rv, depth, nil) {
+		return rv.Interface(), false
+	}
+	copied := map[string]interface{}{}
+	s.structFields(rv, depth, copied)
+	return copied, true
+}
+
+// structFields 处理结构体的字段，out 为 nil 时只检查是否需要替换
+func (s *sanitizer) structFields(rv reflect.Value, depth int, out map[string]interface{}) bool {
+	changed := false
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := field.Name
+		omitempty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitempty = omitempty || opt == "omitempty"
+			}
+		}
+
+		fv := rv.Field(i)
+		// 未指定名称的嵌入结构体与 encoding/json 一致，展开到上一层
+		if field.Anonymous && name == field.Name {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				changed = s.structFields(fv, depth, out) || changed
+				if out == nil && changed {
+					return true
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if omitempty && fv.IsZero() {
+			continue
+		}
+
+		sanitized, c := s.value(fv.Interface(), depth+1)
+		changed = changed || c
+		if out != nil {
+			out[name] = sanitized
+		} else if changed {
+			return true
+		}
+	}
+	return changed
+}
+
+func (s *sanitizer) strings(items []string) (interface{}, bool) {
+	if !s.escape {
+		return items, false
+	}
+
+	var copied []string
+	for i, item := range items {
+		escaped := escapeString(item)
+		if escaped != item && copied == nil {
+			copied = make([]string, len(items))
+			copy(copied, items[:i])
+		}
+		if copied != nil {
+			copied[i] = escaped
+		}
+	}
+	if copied == nil {
+		return items, false
+	}
+	return copied, true
+}
+
+func (s *sanitizer) key(k string) string {
+	if s.escape {
+		return escapeString(k)
+	}
+	return k
+}
+
+func (s *sanitizer) visiting(ptr uintptr) bool {
+	for _, p := range s.path {
+		if p == ptr {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sanitizer) leave() {
+	s.path = s.path[:len(s.path)-1]
+}
+
+func cyclic(v interface{}) string {
+	return fmt.Sprintf("<cycle %T>", v)
+}
+
+func depthExceeded(v interface{}) string {
+	return fmt.Sprintf("<max depth %T>", v)
+}
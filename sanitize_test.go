@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next,omitempty"`
+}
+
+type withFunc struct {
+	Name     string   `json:"name"`
+	Callback func()   `json:"callback"`
+	Skipped  chan int `json:"-"`
+	hidden   chan int
+}
+
+func TestSanitize(t *testing.T) {
+	cyclicMap := map[string]interface{}{"k": "v"}
+	cyclicMap["self"] = cyclicMap
+
+	loop := &node{Name: "a"}
+	loop.Next = &node{Name: "b", Next: loop}
+
+	shared := map[string]interface{}{"x": 1}
+	fields := logrus.Fields{"ok": "v", "ch": make(chan int)}
+
+	cases := []struct {
+		Value  interface{}
+		Expect interface{}
+	}{
+		{Value: "plain", Expect: "plain"},
+		{Value: 1.5, Expect: 1.5},
+		{Value: time.Second, Expect: time.Second},
+		{Value: make(chan int), Expect: "<chan int>"},
+		{Value: func() {}, Expect: "<func()>"},
+		{Value: complex(1, 2), Expect: "(1+2i)"},
+		{Value: fields, Expect: map[string]interface{}{"ok": "v", "ch": "<chan int>"}},
+		{Value: []interface{}{1, make(chan bool)}, Expect: []interface{}{1, "<chan bool>"}},
+		{Value: map[int]interface{}{1: func() {}}, Expect: map[string]interface{}{"1": "<func()>"}},
+		{Value: []func(){nil}, Expect: []interface{}{"<func()>"}},
+		{Value: cyclicMap, Expect: map[string]interface{}{"k": "v", "self": "<cycle map[string]interface {}>"}},
+		{
+			Value:  loop,
+			Expect: map[string]interface{}{"name": "a", "next": map[string]interface{}{"name": "b", "next": "<cycle *logger.node>"}},
+		},
+		{
+			Value:  withFunc{Name: "w"},
+			Expect: map[string]interface{}{"name": "w", "callback": "<func()>"},
+		},
+		{Value: []interface{}{shared, shared}, Expect: []interface{}{shared, shared}},
+		{Value: &node{Name: "ok"}, Expect: &node{Name: "ok"}},
+	}
+
+	for idx, each := range cases {
+		actual := (sanitizer{}).sanitize(each.Value)
+		if !reflect.DeepEqual(actual, each.Expect) {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, actual)
+		}
+	}
+
+	if fields["ch"] == "<chan int>" {
+		t.Fatal("sanitize must not modify the original fields")
+	}
+}
+
+func TestSanitizeMaxDepth(t *testing.T) {
+	deep := map[string]interface{}{"l3": map[string]interface{}{"l4": "v"}}
+	value := map[string]interface{}{"l1": map[string]interface{}{"l2": deep}}
+
+	actual := (sanitizer{maxDepth: 2}).sanitize(value)
+	expect := map[string]interface{}{"l1": map[string]interface{}{"l2": "<max depth map[string]interface {}>"}}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, actual)
+	}
+	if (sanitizer{}).sanitize(value).(map[string]interface{})["l1"] == nil {
+		t.Fatal("expect default depth to keep the value")
+	}
+}
+
+func TestFormatSanitize(t *testing.T) {
+	loop := &node{Name: "a"}
+	loop.Next = loop
+
+	entry := &logrus.Entry{
+		Message: "sanitize",
+		Data: logrus.Fields{
+			"node": loop,
+			"ch":   make(chan int),
+		},
+	}
+
+	p, err := NewFormatter("test", "prod", WithMaxDepth(5)).Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	var out struct {
+		Context map[string]interface{} `json:"ctx"`
+	}
+	if err := json.Unmarshal(p, &out); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, p)
+	}
+	if out.Context["ch"] != "<chan int>" {
+		t.Fatalf("expect channel description, got %s", p)
+	}
+	if node, _ := out.Context["node"].(map[string]interface{}); node["next"] != "<cycle *logger.node>" {
+		t.Fatalf("expect cycle marker, got %s", p)
+	}
+	if _, ok := out.Context[encodeErrorKey]; ok {
+		t.Fatalf("expect sanitized fields to encode without fallback, got %s", p)
+	}
+}
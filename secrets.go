@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"regexp"
+)
+
+// SecretRules 常见密钥与敏感信息的检测规则，命中的内容替换为带类型的占位符，
+// 例如 [REDACTED:jwt]
+//
+//	aws_access_key  AWS access key ID
+//	aws_secret_key  紧跟 aws_secret_access_key 等字段名的 AWS secret access key
+//	jwt             JSON Web Token
+//	credit_card     通过 Luhn 校验的 13 到 19 位卡号，可以包含空格与 -
+//	private_key     PEM 格式的私钥
+func SecretRules() []RedactRule {
+	return []RedactRule{
+		secretRule("private_key", `-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`),
+		secretRule("aws_access_key", `\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+		secretRule("aws_secret_key", `(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+]{40}\b`),
+		secretRule("jwt", `\beyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{8,}`),
+		{
+			ID:           "credit_card",
+			ValuePattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			Verify:       luhnValid,
+			Replacement:  secretPlaceholder("credit_card"),
+		},
+	}
+}
+
+// WithSecretScanning 检测消息、错误与 ctx 字符串中的密钥与敏感信息，
+// 规则见 SecretRules，与 WithRedaction 的规则一起执行，同样支持审计模式
+func WithSecretScanning() Option {
+	return WithRedaction(SecretRules()...)
+}
+
+func secretRule(id, pattern string) RedactRule {
+	return RedactRule{
+		ID:           id,
+		ValuePattern: regexp.MustCompile(pattern),
+		Replacement:  secretPlaceholder(id),
+	}
+}
+
+func secretPlaceholder(id string) string {
+	return "[REDACTED:" + id + "]"
+}
+
+// luhnValid 校验卡号的 Luhn 校验位，忽略空格与 -
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,455 @@
+// Package sentryhook 将 error 及以上级别的日志转发到 Sentry
+//
+// 日志内的错误转换为 Sentry exception，调用栈转换为 frames；
+// http.request.v1 规范的日志附带请求信息
+package sentryhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidDSN DSN 格式错误
+var ErrInvalidDSN = errors.New("sentryhook: invalid dsn")
+
+// Option Hook 的可选配置
+type Option func(*Hook)
+
+// WithLevels 设置转发的日志级别，默认 error / fatal / panic
+func WithLevels(levels ...logrus.Level) Option {
+	return func(h *Hook) {
+		h.levels = levels
+	}
+}
+
+// WithHTTPClient 设置发送使用的 HTTP 客户端
+func WithHTTPClient(c *http.Client) Option {
+	return func(h *Hook) {
+		h.client = c
+	}
+}
+
+// WithFormatter 设置生成 LogsV1 日志的格式化对象，默认使用日志对象的 Formatter，
+// 事件内容与错误信息按其脱敏规则处理
+func WithFormatter(f logrus.Formatter) Option {
+	return func(h *Hook) {
+		h.formatter = f
+	}
+}
+
+// WithBufferSize 设置等待发送的事件队列长度，默认 100，队列已满或内存预算不足时丢弃事件
+func WithBufferSize(n int) Option {
+	return func(h *Hook) {
+		h.bufferSize = n
+	}
+}
+
+// WithErrorHandler 设置发送失败的处理函数，默认输出到 stderr
+func WithErrorHandler(fn func(error)) Option {
+	return func(h *Hook) {
+		h.errorHandler = fn
+	}
+}
+
+// Hook 实现 logrus.Hook
+//
+// error 级别的事件异步发送，等待发送的事件占用 logger.MemoryBudget，
+// fatal 与 panic 级别在进程退出前同步发送
+//
+//	hook, err := sentryhook.New(os.Getenv("SENTRY_DSN"))
+//	l.AddHook(hook)
+//	defer hook.Close()
+type Hook struct {
+	endpoint     string
+	auth         string
+	levels       []logrus.Level
+	client       *http.Client
+	formatter    logrus.Formatter
+	bufferSize   int
+	errorHandler func(error)
+	budget       *logger.Budget
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+}
+
+// New 创建 Sentry hook，dsn 格式为 https://<key>@<host>/<project>
+func New(dsn string, opts ...Option) (*Hook, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, ErrInvalidDSN
+	}
+	idx := strings.LastIndex(u.Path, "/")
+	project := u.Path[idx+1:]
+	if idx < 0 || project == "" {
+		return nil, ErrInvalidDSN
+	}
+
+	h := &Hook{
+		endpoint:   fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, u.Path[:idx], project),
+		auth:       "Sentry sentry_version=7, sentry_client=lancer05-logger/1, sentry_key=" + u.User.Username(),
+		levels:     []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel},
+		client:     &http.Client{Timeout: 5 * time.Second},
+		bufferSize: 100,
+		errorHandler: func(err error) {
+			fmt.Fprintf(os.Stderr, "sentryhook: %v\n", err)
+		},
+		budget: logger.MemoryBudget(),
+	}
+	if secret, ok := u.User.Password(); ok {
+		h.auth += ", sentry_secret=" + secret
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.queue = make(chan []byte, h.bufferSize)
+	h.done = make(chan struct{})
+	go h.run()
+	return h, nil
+}
+
+// Levels implements logrus.Hook interface
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook interface
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	body, err := json.Marshal(h.event(entry))
+	if err != nil {
+		return err
+	}
+
+	if entry.Level <= logrus.FatalLevel {
+		return h.send(body)
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return nil
+	}
+	if !h.budget.Acquire(int64(len(body))) {
+		h.report(errors.New("memory budget exceeded, drop event"))
+		return nil
+	}
+	select {
+	case h.queue <- body:
+	default:
+		h.budget.Release(int64(len(body)))
+		h.report(errors.New("queue full, drop event"))
+	}
+	return nil
+}
+
+// Close 发送队列中剩余的事件后返回
+func (h *Hook) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.queue)
+	h.mu.Unlock()
+
+	<-h.done
+	return nil
+}
+
+func (h *Hook) run() {
+	defer close(h.done)
+	for body := range h.queue {
+		if err := h.send(body); err != nil {
+			h.report(err)
+		}
+		h.budget.Release(int64(len(body)))
+	}
+}
+
+func (h *Hook) report(err error) {
+	if h.errorHandler != nil {
+		h.errorHandler(err)
+	}
+}
+
+func (h *Hook) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", h.auth)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("store status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// record LogsV1 日志中用于生成事件的字段
+type record struct {
+	Schema    string                 `json:"schema"`
+	Service   string                 `json:"s"`
+	Channel   string                 `json:"c"`
+	RequestID string                 `json:"request_id"`
+	Env       string                 `json:"e"`
+	User      string                 `json:"u"`
+	Message   string                 `json:"m"`
+	Code      string                 `json:"code"`
+	Err       string                 `json:"err"`
+	Context   map[string]interface{} `json:"ctx"`
+	Host      *struct {
+		Hostname string `json:"hostname"`
+	} `json:"host"`
+	Build *struct {
+		Version string `json:"version"`
+	} `json:"build"`
+	Request *logger.RequestData `json:"request"`
+}
+
+// format 使用 LogsV1 格式化日志，经过格式化对象的脱敏规则处理；
+// 格式化对象不是 logger 包创建的时无法脱敏，只记录日志内容，不记录 ctx 与错误
+func (h *Hook) format(entry *logrus.Entry) (*record, *logger.Forwarder) {
+	formatters := []logrus.Formatter{h.formatter}
+	if entry.Logger != nil {
+		formatters = append(formatters, entry.Logger.Formatter)
+	}
+	fw := logger.NewForwarder(formatters...)
+	if fw == nil {
+		return &record{Message: entry.Message}, nil
+	}
+	rec := &record{}
+	if err := fw.Decode(entry, rec); err != nil {
+		return &record{Message: entry.Message}, nil
+	}
+	return rec, fw
+}
+
+// Event Sentry 事件
+type Event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Platform    string                 `json:"platform"`
+	Message     string                 `json:"message,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	User        *User                  `json:"user,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Exception   *ExceptionList         `json:"exception,omitempty"`
+	Request     *Request               `json:"request,omitempty"`
+}
+
+// User Sentry 事件的用户信息
+type User struct {
+	ID string `json:"id"`
+}
+
+// ExceptionList Sentry 事件的异常列表
+type ExceptionList struct {
+	Values []Exception `json:"values"`
+}
+
+// Exception Sentry 异常
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Module     string      `json:"module,omitempty"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace Sentry 调用栈，frames 按调用顺序排列，最内层在最后
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame Sentry 调用栈帧
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Request Sentry 事件的请求信息
+type Request struct {
+	URL     string            `json:"url,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Data    interface{}       `json:"data,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// event 将日志转换为 Sentry 事件
+func (h *Hook) event(entry *logrus.Entry) *Event {
+	rec, fw := h.format(entry)
+
+	ev := &Event{
+		EventID:     newEventID(),
+		Timestamp:   entry.Time.UTC().Format(time.RFC3339Nano),
+		Level:       level(entry.Level),
+		Logger:      rec.Service,
+		Platform:    "go",
+		Message:     rec.Message,
+		Environment: rec.Env,
+		Tags:        map[string]string{},
+		Extra:       rec.Context,
+	}
+	if rec.Host != nil {
+		ev.ServerName = rec.Host.Hostname
+	}
+	if rec.Build != nil {
+		ev.Release = rec.Build.Version
+	}
+	if rec.User != "" {
+		ev.User = &User{ID: rec.User}
+	}
+	for k, v := range map[string]string{
+		"service":    rec.Service,
+		"channel":    rec.Channel,
+		"code":       rec.Code,
+		"request_id": rec.RequestID,
+		"schema":     rec.Schema,
+	} {
+		if v != "" {
+			ev.Tags[k] = v
+		}
+	}
+
+	// 错误按字段名排序，ctx 内已转换为异常的错误不再重复记录
+	// With 绑定的字段中的错误同样记录
+	fields := logger.Fields(entry)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var exceptions []Exception
+	for _, k := range keys {
+		err, ok := fields[k].(error)
+		if !ok {
+			continue
+		}
+		exceptions = append(exceptions, exception(err, fw))
+		delete(ev.Extra, k)
+	}
+	if len(exceptions) == 0 && rec.Err != "" {
+		exceptions = append(exceptions, Exception{Type: "error", Value: rec.Err})
+	}
+	if len(exceptions) > 0 {
+		ev.Exception = &ExceptionList{Values: exceptions}
+	}
+
+	if req := rec.Request; req != nil && rec.Schema == string(logger.SchemaHTTPRequestV1) {
+		ev.Request = &Request{
+			URL:     req.Path,
+			Method:  req.Method,
+			Headers: req.Headers,
+			Env:     map[string]string{"REMOTE_ADDR": req.IP},
+		}
+		if host := req.Headers["host"]; host != "" {
+			ev.Request.URL = "http://" + host + req.Path
+		}
+		if len(req.Param) > 0 {
+			ev.Request.Data = req.Param
+		}
+	}
+	return ev
+}
+
+// exception 错误信息经过 fw 的脱敏规则处理，fw 为 nil 时不记录错误信息
+func exception(err error, fw *logger.Forwarder) Exception {
+	ex := Exception{Type: fmt.Sprintf("%T", err)}
+	if fw != nil {
+		ex.Value = fw.RedactString(err.Error())
+	}
+
+	trace := logger.StackTrace(err)
+	if len(trace) == 0 {
+		return ex
+	}
+
+	// trace 最内层在前，Sentry 要求最内层在最后
+	frames := make([]Frame, 0, len(trace))
+	for i := len(trace) - 1; i >= 0; i-- {
+		frames = append(frames, parseFrame(trace[i]))
+	}
+	ex.Stacktrace = &Stacktrace{Frames: frames}
+	return ex
+}
+
+// parseFrame 解析 "pkg.Func /path/to/file.go:12" 格式的调用栈
+func parseFrame(s string) Frame {
+	f := Frame{Function: s, InApp: true}
+
+	idx := strings.LastIndexByte(s, ' ')
+	if idx < 0 {
+		return f
+	}
+	f.Function = s[:idx]
+
+	location := s[idx+1:]
+	if colon := strings.LastIndexByte(location, ':'); colon >= 0 {
+		f.Lineno, _ = strconv.Atoi(location[colon+1:])
+		location = location[:colon]
+	}
+	f.AbsPath = location
+	f.Filename = location
+	if slash := strings.LastIndexByte(location, '/'); slash >= 0 {
+		f.Filename = location[slash+1:]
+	}
+	f.InApp = !strings.HasPrefix(f.Function, "runtime.")
+	return f
+}
+
+func level(l logrus.Level) string {
+	switch l {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return "fatal"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.WarnLevel:
+		return "warning"
+	case logrus.InfoLevel:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+func newEventID() string {
+	p := make([]byte, 16)
+	_, _ = rand.Read(p)
+	return hex.EncodeToString(p)
+}
@@ -0,0 +1,278 @@
+package sentryhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lancer05/logger"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+	auth   []string
+	paths  []string
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ev Event
+	if err := json.NewDecoder(req.Body).Decode(&ev); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, ev)
+	r.auth = append(r.auth, req.Header.Get("X-Sentry-Auth"))
+	r.paths = append(r.paths, req.URL.Path)
+}
+
+func TestNewInvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "http://host/1", "http://key@host", "http://key@host/", "://"} {
+		if _, err := New(dsn); err != ErrInvalidDSN {
+			t.Fatalf("New(%q) error, Expected=%v, Actual=%v", dsn, ErrInvalidDSN, err)
+		}
+	}
+}
+
+func TestHook(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, err := New(strings.Replace(srv.URL, "://", "://public@", 1) + "/42")
+	if err != nil {
+		t.Fatalf("New() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(io.Discard)
+	l.AddHook(hook)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?id=1", nil)
+	l.WithFields(logrus.Fields{
+		"request": req,
+		"status":  500,
+		"user":    "u1",
+		"channel": "order",
+		"cause":   errors.New("db timeout"),
+		"attempt": 3,
+	}).Error("create order failed")
+	l.Info("ignored")
+
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if len(rec.events) != 1 {
+		t.Fatalf("expect 1 event, got %d", len(rec.events))
+	}
+	if rec.paths[0] != "/api/42/store/" {
+		t.Fatalf("expect store path, got %q", rec.paths[0])
+	}
+	if !strings.Contains(rec.auth[0], "sentry_key=public") {
+		t.Fatalf("expect sentry_key in auth header, got %q", rec.auth[0])
+	}
+
+	ev := rec.events[0]
+	cases := []struct {
+		Actual interface{}
+		Expect interface{}
+	}{
+		{Actual: ev.Level, Expect: "error"},
+		{Actual: ev.Message, Expect: "create order failed"},
+		{Actual: ev.Environment, Expect: "prod"},
+		{Actual: ev.Tags["service"], Expect: "test"},
+		{Actual: ev.Tags["channel"], Expect: "order"},
+		{Actual: ev.Tags["schema"], Expect: string(logger.SchemaHTTPRequestV1)},
+		{Actual: ev.User.ID, Expect: "u1"},
+		{Actual: ev.Extra["attempt"], Expect: 3.0},
+		{Actual: ev.Extra["cause"], Expect: nil},
+		{Actual: len(ev.Exception.Values), Expect: 1},
+		{Actual: ev.Exception.Values[0].Value, Expect: "db timeout"},
+		{Actual: ev.Request.Method, Expect: http.MethodPost},
+		{Actual: ev.Request.URL, Expect: "/orders"},
+	}
+	for idx, each := range cases {
+		if each.Actual != each.Expect {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, each.Actual)
+		}
+	}
+
+	frames := ev.Exception.Values[0].Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if !strings.HasSuffix(last.Function, "TestHook") || last.Filename != "sentryhook_test.go" || last.Lineno == 0 {
+		t.Fatalf("expect innermost frame to be TestHook, got %#v", last)
+	}
+}
+
+func TestHookBoundError(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, err := New(strings.Replace(srv.URL, "://", "://public@", 1) + "/42")
+	if err != nil {
+		t.Fatalf("New() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(io.Discard)
+	l.AddHook(hook)
+
+	logger.With(l, logrus.Fields{"cause": errors.New("db timeout")}).Error("create order failed")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if len(rec.events) != 1 || rec.events[0].Exception == nil {
+		t.Fatalf("expect 1 event with exception, got %#v", rec.events)
+	}
+	if v := rec.events[0].Exception.Values[0].Value; v != "db timeout" {
+		t.Fatalf("expect bound error as exception, got %q", v)
+	}
+}
+
+func TestHookRedaction(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, err := New(strings.Replace(srv.URL, "://", "://public@", 1) + "/42")
+	if err != nil {
+		t.Fatalf("New() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(logger.NewLogfmtFormatter("test", "prod", logger.WithRedaction(
+		logger.RedactRule{ID: "password", Keys: []string{"password"}},
+		logger.RedactRule{ID: "token", ValuePattern: regexp.MustCompile(`tok_\w+`)},
+	)))
+	l.AddHook(hook)
+
+	l.WithFields(logrus.Fields{
+		"password": "hunter2",
+		"cause":    errors.New("login with tok_abc123 failed"),
+	}).Error("login failed")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if len(rec.events) != 1 || rec.events[0].Exception == nil {
+		t.Fatalf("expect 1 event with exception, got %#v", rec.events)
+	}
+	ev := rec.events[0]
+	if v := ev.Extra["password"]; v != logger.DefaultRedactReplacement {
+		t.Fatalf("expect password redacted, got %#v", v)
+	}
+	if v := ev.Exception.Values[0].Value; v != "login with "+logger.DefaultRedactReplacement+" failed" {
+		t.Fatalf("expect exception value redacted, got %q", v)
+	}
+}
+
+func TestHookForeignFormatter(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	hook, err := New(strings.Replace(srv.URL, "://", "://public@", 1) + "/42")
+	if err != nil {
+		t.Fatalf("New() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.AddHook(hook)
+
+	l.WithFields(logrus.Fields{
+		"password": "hunter2",
+		"cause":    errors.New("password hunter2 rejected"),
+	}).Error("login failed")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if len(rec.events) != 1 || rec.events[0].Exception == nil {
+		t.Fatalf("expect 1 event with exception, got %#v", rec.events)
+	}
+	ev := rec.events[0]
+	if ev.Message != "login failed" || len(ev.Extra) != 0 {
+		t.Fatalf("expect message without extra, got %#v", ev)
+	}
+	if v := ev.Exception.Values[0].Value; v != "" {
+		t.Fatalf("expect exception value dropped, got %q", v)
+	}
+}
+
+func TestHookBudget(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	var handled []error
+	hook, err := New(strings.Replace(srv.URL, "://", "://public@", 1)+"/42",
+		WithErrorHandler(func(err error) { handled = append(handled, err) }),
+	)
+	if err != nil {
+		t.Fatalf("New() error, Expected=nil, Actual=%q", err.Error())
+	}
+	budget := logger.NewBudget(1)
+	hook.budget = budget
+
+	l, _ := logger.NewLogger("test", "prod")
+	l.SetOutput(io.Discard)
+	l.AddHook(hook)
+
+	l.Error("dropped")
+	budget.SetLimit(0)
+	l.Error("sent")
+	if err := hook.Close(); err != nil {
+		t.Fatalf("Close() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	if len(rec.events) != 1 || rec.events[0].Message != "sent" {
+		t.Fatalf("expect only the event within budget, got %#v", rec.events)
+	}
+	if len(handled) != 1 {
+		t.Fatalf("expect error handler called for dropped event, got %v", handled)
+	}
+	if stats := budget.Stats(); stats.InUse != 0 || stats.Dropped != 1 {
+		t.Fatalf("expect budget released with 1 dropped, got %+v", stats)
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	cases := []struct {
+		Trace  string
+		Expect Frame
+	}{
+		{
+			Trace:  "main.run /src/app/main.go:42",
+			Expect: Frame{Function: "main.run", Filename: "main.go", AbsPath: "/src/app/main.go", Lineno: 42, InApp: true},
+		},
+		{
+			Trace:  "runtime.goexit /usr/local/go/src/runtime/asm_amd64.s:1571",
+			Expect: Frame{Function: "runtime.goexit", Filename: "asm_amd64.s", AbsPath: "/usr/local/go/src/runtime/asm_amd64.s", Lineno: 1571},
+		},
+		{
+			Trace:  "unknown",
+			Expect: Frame{Function: "unknown", InApp: true},
+		},
+	}
+	for idx, each := range cases {
+		if actual := parseFrame(each.Trace); actual != each.Expect {
+			t.Fatalf("%d: expect: %#v, got: %#v", idx, each.Expect, actual)
+		}
+	}
+}
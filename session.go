@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+)
+
+// SessionOptions 从请求中读取会话ID的配置，按 Header、Cookie 的顺序使用第一个存在的值
+type SessionOptions struct {
+	// 传递会话ID的 header，例如 X-Session-ID，原样记录
+	Header string
+	// 会话 cookie 的名称，cookie 通常就是登录凭证，只记录 SHA-256 的前 16 个十六进制字符，
+	// 同一会话的值不变，可以用于串联多个请求
+	Cookie string
+}
+
+// WithSessionID 请求日志没有 session_id 字段时，从请求的 header 或 cookie 读取会话ID，
+// 与每个请求不同的 request_id 不同，会话ID 用于串联同一用户的多个请求
+func WithSessionID(opts SessionOptions) Option {
+	return func(f *LogsV1Formatter) {
+		f.Session = &opts
+	}
+}
+
+func (o *SessionOptions) extract(req *http.Request) string {
+	if o.Header != "" {
+		if v := req.Header.Get(o.Header); v != "" {
+			return v
+		}
+	}
+	if o.Cookie != "" {
+		if c, err := req.Cookie(o.Cookie); err == nil && c.Value != "" {
+			return fmt.Sprintf("%x", sha256.Sum256([]byte(c.Value)))[:16]
+		}
+	}
+	return ""
+}
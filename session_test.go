@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithSessionID(t *testing.T) {
+	f := NewFormatter("test", "test", WithSessionID(SessionOptions{Header: "X-Session-ID", Cookie: "sid"}))
+
+	cases := []struct {
+		Name   string
+		Header string
+		Cookie string
+		Field  string
+		Expect string
+	}{
+		{Name: "header", Header: "s-1", Cookie: "secret", Expect: "s-1"},
+		{Name: "cookie", Cookie: "secret", Expect: "2bb80d537b1da3e3"},
+		{Name: "field", Header: "s-1", Field: "s-2", Expect: "s-2"},
+		{Name: "none", Expect: ""},
+	}
+
+	for _, each := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if each.Header != "" {
+			req.Header.Set("X-Session-ID", each.Header)
+		}
+		if each.Cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "sid", Value: each.Cookie})
+		}
+		fields := logrus.Fields{"request": req}
+		if each.Field != "" {
+			fields["session_id"] = each.Field
+		}
+
+		data, err := f.Format(&logrus.Entry{Time: time.Now(), Message: "request", Data: fields})
+		if err != nil {
+			t.Fatalf("%s: Format() error, Expected=nil, Actual=%q", each.Name, err)
+		}
+		if v := jsoniter.Get(data, "session_id").ToString(); v != each.Expect {
+			t.Fatalf("%s: output session_id, Expected=%q, Actual=%q", each.Name, each.Expect, v)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Package slogbridge 连接 log/slog 与 logrus，便于新旧代码混用时逐步迁移
+//
+// NewHandler 让 *slog.Logger 通过 logrus 日志对象输出，沿用本包的格式化对象与 hook：
+//
+//	l, _ := logger.NewLogger("api", "prod")
+//	slog.SetDefault(slog.New(slogbridge.NewHandler(l)))
+//
+// NewLogger 与 Hook 将 logrus 的日志转交给 slog.Handler 处理：
+//
+//	l := slogbridge.NewLogger(slog.Default().Handler())
+//
+// 需要 Go 1.21 及以上版本
+package slogbridge
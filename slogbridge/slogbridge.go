@@ -0,0 +1,229 @@
+//go:build go1.21
+// +build go1.21
+
+package slogbridge
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	_ slog.Handler = (*Handler)(nil)
+	_ logrus.Hook  = (*Hook)(nil)
+)
+
+// Handler 通过 logrus 日志对象输出 slog 日志的 slog.Handler，
+// 属性转换为 entry 字段，分组转换为嵌套的 logrus.Fields
+type Handler struct {
+	logger *logrus.Logger
+	fields logrus.Fields
+	// 当前分组的路径，之后的属性记录在该路径下
+	groups []string
+}
+
+// NewHandler 创建通过 l 输出日志的 slog.Handler
+func NewHandler(l *logrus.Logger) *Handler {
+	return &Handler{logger: l, fields: logrus.Fields{}}
+}
+
+// Enabled implements slog.Handler interface
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.IsLevelEnabled(LogrusLevel(level))
+}
+
+// Handle implements slog.Handler interface
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	fields := copyFields(h.fields)
+	target := groupFields(fields, h.groups)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(target, a, len(h.groups) > 0)
+		return true
+	})
+
+	entry := logrus.NewEntry(h.logger).WithContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+	entry.Log(LogrusLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler interface
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.clone()
+	target := groupFields(h2.fields, h2.groups)
+	for _, a := range attrs {
+		addAttr(target, a, len(h2.groups) > 0)
+	}
+	return h2
+}
+
+// WithGroup implements slog.Handler interface
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups[:len(h2.groups):len(h2.groups)], name)
+	return h2
+}
+
+// clone 深复制字段，分组内的字段在之后的 WithAttrs 中会被修改
+func (h *Handler) clone() *Handler {
+	return &Handler{
+		logger: h.logger,
+		fields: copyFields(h.fields),
+		groups: h.groups,
+	}
+}
+
+// LogrusLevel slog 级别转换为 logrus 级别，低于 debug 的为 trace，高于 error 的仍为 error，
+// 避免 slog 日志触发 logrus 的 exit 与 panic
+func LogrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
+
+// SlogLevel logrus 级别转换为 slog 级别，fatal 与 panic 转换为高于 error 的级别
+func SlogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel:
+		return slog.LevelDebug - 4
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.FatalLevel:
+		return slog.LevelError + 4
+	}
+	return slog.LevelError + 8
+}
+
+// addAttr 将属性写入 fields，分组内的错误转换为文本，只有顶层的错误按错误记录
+func addAttr(fields logrus.Fields, a slog.Attr, nested bool) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		attrs := v.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		// 没有名称的分组直接展开
+		target := fields
+		if a.Key != "" {
+			target = groupFields(fields, []string{a.Key})
+		}
+		for _, ga := range attrs {
+			addAttr(target, ga, nested || a.Key != "")
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	if err, ok := v.Any().(error); ok && nested {
+		fields[a.Key] = err.Error()
+		return
+	}
+	fields[a.Key] = v.Any()
+}
+
+// groupFields 返回 fields 中 path 对应的嵌套字段，不存在时创建
+func groupFields(fields logrus.Fields, path []string) logrus.Fields {
+	for _, g := range path {
+		sub, ok := fields[g].(logrus.Fields)
+		if !ok {
+			sub = logrus.Fields{}
+			fields[g] = sub
+		}
+		fields = sub
+	}
+	return fields
+}
+
+func copyFields(fields logrus.Fields) logrus.Fields {
+	copied := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		if sub, ok := v.(logrus.Fields); ok {
+			v = copyFields(sub)
+		}
+		copied[k] = v
+	}
+	return copied
+}
+
+// Hook 将 logrus 的日志转交给 slog.Handler 的 hook，字段按字段名排序转换为属性，
+// 包括 logger.With 绑定的字段
+type Hook struct {
+	handler slog.Handler
+}
+
+// NewHook 创建转交日志到 h 的 hook
+func NewHook(h slog.Handler) *Hook {
+	return &Hook{handler: h}
+}
+
+// NewLogger 创建所有日志都由 h 处理的 logrus 日志对象，日志对象本身不输出，
+// 级别设为 trace，由 h 决定是否记录
+func NewLogger(h slog.Handler) *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetLevel(logrus.TraceLevel)
+	l.AddHook(NewHook(h))
+	return l
+}
+
+// Levels implements logrus.Hook interface
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook interface
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := SlogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.Caller != nil {
+		pc = entry.Caller.PC
+	}
+	r := slog.NewRecord(entry.Time, level, entry.Message, pc)
+
+	fields := logger.Fields(entry)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.AddAttrs(slog.Any(k, fields[k]))
+	}
+	return h.handler.Handle(ctx, r)
+}
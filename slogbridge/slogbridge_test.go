@@ -0,0 +1,84 @@
+//go:build go1.21
+// +build go1.21
+
+package slogbridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lancer05/logger"
+	"github.com/lancer05/logger/loggertest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHandler(t *testing.T) {
+	l, rec := loggertest.NewRecorder()
+	l.SetLevel(logrus.InfoLevel)
+	s := slog.New(NewHandler(l))
+
+	s.Debug("hidden")
+	s.With("tenant", "acme").WithGroup("order").With("id", 42).
+		Error("create order", "amount", 10, slog.Group("payment", "method", "card"), "error", errors.New("declined"))
+
+	rec.AssertCount(t, 1)
+	e := rec.AssertLogged(t, logrus.ErrorLevel, "create order", map[string]interface{}{
+		"tenant":                   "acme",
+		"ctx.order.id":             42,
+		"ctx.order.amount":         10,
+		"ctx.order.payment.method": "card",
+		"ctx.order.error":          "declined",
+	})
+	if e == nil {
+		return
+	}
+	if e.Err != "" {
+		t.Fatalf("grouped error should stay in ctx, Actual err=%q", e.Err)
+	}
+
+	rec.Reset()
+	s.Warn("top-level error", "error", errors.New("declined"))
+	if e := rec.LastEntry(); e == nil || e.Err != "declined" || e.Level != "warning" {
+		t.Fatalf("Handle() Actual=%+v", e)
+	}
+}
+
+func TestHook(t *testing.T) {
+	out := &bytes.Buffer{}
+	h := slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := NewLogger(h)
+
+	l.Debug("hidden")
+	logger.With(l, logrus.Fields{"tenant": "acme"}).WithField("order_id", 42).Warn("slow")
+
+	expected := "level=WARN msg=slow order_id=42 tenant=acme\n"
+	if out.String() != expected {
+		t.Fatalf("Hook output, Expected=%q, Actual=%q", expected, out.String())
+	}
+}
+
+func TestLevels(t *testing.T) {
+	for _, level := range logrus.AllLevels {
+		back := LogrusLevel(SlogLevel(level))
+		if level >= logrus.ErrorLevel && back != level {
+			t.Fatalf("LogrusLevel(SlogLevel(%s)) Expected=%s, Actual=%s", level, level, back)
+		}
+		if level < logrus.ErrorLevel && back != logrus.ErrorLevel {
+			t.Fatalf("LogrusLevel(SlogLevel(%s)) Expected=error, Actual=%s", level, back)
+		}
+	}
+	if !NewHandler(logrus.New()).Enabled(context.Background(), slog.LevelInfo) {
+		t.Fatalf("Enabled(info) Expected=true")
+	}
+}
@@ -0,0 +1,33 @@
+package sqllogger
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var (
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	placeholder   = regexp.MustCompile(`\$\d+|:\w+|@\w+`)
+	valueList     = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// Normalize 将SQL语句中的字面量与占位符统一替换为 ?，
+// 使参数不同但结构相同的语句得到一致的结果
+func Normalize(query string) string {
+	q := stringLiteral.ReplaceAllString(query, "?")
+	q = placeholder.ReplaceAllString(q, "?")
+	q = numberLiteral.ReplaceAllString(q, "?")
+	q = valueList.ReplaceAllString(q, "(?)")
+	q = whitespace.ReplaceAllString(q, " ")
+	return strings.ToLower(strings.TrimSpace(q))
+}
+
+// Fingerprint 获得SQL语句的指纹，用于聚合统计同一类查询
+func Fingerprint(query string) string {
+	sum := sha1.Sum([]byte(Normalize(query)))
+	return hex.EncodeToString(sum[:8])
+}
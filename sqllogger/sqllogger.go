@@ -0,0 +1,431 @@
+// Package sqllogger 包装 database/sql 驱动，以 sql.query.v1 规范记录每次查询
+package sqllogger
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Redactor 在记录前按参数位置与名称处理查询参数，返回值替换原参数写入日志，
+// 之后 sql.args 仍会经过格式化对象的脱敏规则
+type Redactor func(ordinal int, name string, value interface{}) interface{}
+
+// Option 驱动包装的可选配置
+type Option func(*config)
+
+type config struct {
+	level         logrus.Level
+	slowThreshold time.Duration
+	redactor      Redactor
+	withoutArgs   bool
+}
+
+// WithLevel 设置查询日志的级别，默认 debug
+func WithLevel(level logrus.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithSlowThreshold 执行时间超过 d 的查询提升为 warn 级别
+func WithSlowThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowThreshold = d
+	}
+}
+
+// WithRedactor 设置参数脱敏函数
+func WithRedactor(r Redactor) Option {
+	return func(c *config) {
+		c.redactor = r
+	}
+}
+
+// WithoutArgs 不记录查询参数
+func WithoutArgs() Option {
+	return func(c *config) {
+		c.withoutArgs = true
+	}
+}
+
+// Wrap 包装数据库驱动，通过返回的驱动执行的语句都会记录日志
+//
+//	sql.Register("mysql-logged", sqllogger.Wrap(&mysql.MySQLDriver{}, l))
+//	db, err := sql.Open("mysql-logged", dsn)
+func Wrap(d driver.Driver, l *logrus.Logger, opts ...Option) driver.Driver {
+	c := &config{
+		level: logrus.DebugLevel,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return &wrappedDriver{parent: d, logger: l, config: c}
+}
+
+type wrappedDriver struct {
+	parent driver.Driver
+	logger *logrus.Logger
+	config *config
+}
+
+// Open implements driver.Driver interface
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedConn{parent: conn, driver: d}, nil
+}
+
+func (d *wrappedDriver) log(ctx context.Context, query string, args []driver.NamedValue, rows int64, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+
+	duration := time.Since(start)
+	level := d.config.level
+	if d.config.slowThreshold > 0 && duration >= d.config.slowThreshold && level > logrus.WarnLevel {
+		level = logrus.WarnLevel
+	}
+	if err != nil && err != io.EOF && level > logrus.ErrorLevel {
+		level = logrus.ErrorLevel
+	}
+	if !d.logger.IsLevelEnabled(level) {
+		return
+	}
+
+	q := &logger.SQLData{
+		Statement:   query,
+		Fingerprint: Fingerprint(query),
+		Rows:        rows,
+		Duration:    duration.String(),
+	}
+	if !d.config.withoutArgs {
+		q.Args = make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			v := arg.Value
+			if d.config.redactor != nil {
+				v = d.config.redactor(arg.Ordinal, arg.Name, v)
+			}
+			q.Args = append(q.Args, v)
+		}
+	}
+
+	entry := d.logger.WithContext(ctx).WithField("sql", q)
+	if err != nil && err != io.EOF {
+		entry = entry.WithField("error", err)
+	}
+	entry.Log(level, "sql query")
+}
+
+type wrappedConn struct {
+	parent driver.Conn
+	driver *wrappedDriver
+}
+
+// Prepare implements driver.Conn interface
+func (c *wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext interface
+func (c *wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.parent.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.parent.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedStmt{parent: stmt, query: query, driver: c.driver}, nil
+}
+
+// Close implements driver.Conn interface
+func (c *wrappedConn) Close() error {
+	return c.parent.Close()
+}
+
+// Begin implements driver.Conn interface
+func (c *wrappedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx interface
+func (c *wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.parent.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	// 与 database/sql 一致，驱动不支持时拒绝非默认的事务选项
+	if opts.Isolation != 0 {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	return c.parent.Begin()
+}
+
+// ExecContext implements driver.ExecerContext interface
+func (c *wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.parent.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+	c.driver.log(ctx, query, args, rowsAffected(result), start, err)
+	return result, err
+}
+
+// QueryContext implements driver.QueryerContext interface
+func (c *wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.parent.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil {
+		c.driver.log(ctx, query, args, 0, start, err)
+		return nil, err
+	}
+	return &wrappedRows{parent: rows, ctx: ctx, query: query, args: args, start: start, driver: c.driver}, nil
+}
+
+// Ping implements driver.Pinger interface
+func (c *wrappedConn) Ping(ctx context.Context) error {
+	if p, ok := c.parent.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter interface
+func (c *wrappedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.parent.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker interface
+func (c *wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type wrappedStmt struct {
+	parent driver.Stmt
+	query  string
+	driver *wrappedDriver
+}
+
+// Close implements driver.Stmt interface
+func (s *wrappedStmt) Close() error {
+	return s.parent.Close()
+}
+
+// NumInput implements driver.Stmt interface
+func (s *wrappedStmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+// Exec implements driver.Stmt interface
+func (s *wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query implements driver.Stmt interface
+func (s *wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements driver.StmtExecContext interface
+func (s *wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var (
+		result driver.Result
+		err    error
+	)
+
+	start := time.Now()
+	if e, ok := s.parent.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		result, err = s.parent.Exec(values(args))
+	}
+	s.driver.log(ctx, s.query, args, rowsAffected(result), start, err)
+	return result, err
+}
+
+// QueryContext implements driver.StmtQueryContext interface
+func (s *wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		rows driver.Rows
+		err  error
+	)
+
+	start := time.Now()
+	if q, ok := s.parent.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.parent.Query(values(args))
+	}
+	if err != nil {
+		s.driver.log(ctx, s.query, args, 0, start, err)
+		return nil, err
+	}
+	return &wrappedRows{parent: rows, ctx: ctx, query: s.query, args: args, start: start, driver: s.driver}, nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker interface
+func (s *wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ColumnConverter implements driver.ColumnConverter interface，
+// 原语句不支持时与 database/sql 一样使用 driver.DefaultParameterConverter
+func (s *wrappedStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.parent.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// wrappedRows 统计读取的行数，在关闭时记录日志，耗时包含读取结果的时间
+type wrappedRows struct {
+	parent driver.Rows
+	ctx    context.Context
+	query  string
+	args   []driver.NamedValue
+	start  time.Time
+	driver *wrappedDriver
+	count  int64
+	err    error
+}
+
+// Columns implements driver.Rows interface
+func (r *wrappedRows) Columns() []string {
+	return r.parent.Columns()
+}
+
+// Next implements driver.Rows interface
+func (r *wrappedRows) Next(dest []driver.Value) error {
+	err := r.parent.Next(dest)
+	if err == nil {
+		r.count++
+	} else if err != io.EOF {
+		r.err = err
+	}
+	return err
+}
+
+// HasNextResultSet implements driver.RowsNextResultSet interface
+func (r *wrappedRows) HasNextResultSet() bool {
+	if n, ok := r.parent.(driver.RowsNextResultSet); ok {
+		return n.HasNextResultSet()
+	}
+	return false
+}
+
+// NextResultSet implements driver.RowsNextResultSet interface
+func (r *wrappedRows) NextResultSet() error {
+	if n, ok := r.parent.(driver.RowsNextResultSet); ok {
+		return n.NextResultSet()
+	}
+	return io.EOF
+}
+
+// 以下 ColumnType 方法在原结果不支持时返回 database/sql 的默认值
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType interface
+func (r *wrappedRows) ColumnTypeScanType(index int) reflect.Type {
+	if c, ok := r.parent.(driver.RowsColumnTypeScanType); ok {
+		return c.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName interface
+func (r *wrappedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if c, ok := r.parent.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return c.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeLength implements driver.RowsColumnTypeLength interface
+func (r *wrappedRows) ColumnTypeLength(index int) (int64, bool) {
+	if c, ok := r.parent.(driver.RowsColumnTypeLength); ok {
+		return c.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable interface
+func (r *wrappedRows) ColumnTypeNullable(index int) (bool, bool) {
+	if c, ok := r.parent.(driver.RowsColumnTypeNullable); ok {
+		return c.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+// ColumnTypePrecisionScale implements driver.RowsColumnTypePrecisionScale interface
+func (r *wrappedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if c, ok := r.parent.(driver.RowsColumnTypePrecisionScale); ok {
+		return c.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+// Close implements driver.Rows interface
+func (r *wrappedRows) Close() error {
+	err := r.parent.Close()
+	r.driver.log(r.ctx, r.query, r.args, r.count, r.start, r.err)
+	return err
+}
+
+func rowsAffected(result driver.Result) int64 {
+	if result == nil {
+		return 0
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vs := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vs[i] = arg.Value
+	}
+	return vs
+}
@@ -0,0 +1,167 @@
+package sqllogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	query string
+	rows  int
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	time.Sleep(5 * time.Millisecond)
+	return driver.RowsAffected(3), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{left: 2}, nil
+}
+
+type fakeRows struct {
+	left int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(int) string { return "BIGINT" }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.left == 0 {
+		return io.EOF
+	}
+	r.left--
+	dest[0] = int64(r.left)
+	return nil
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Expect string
+	}{
+		{
+			Input:  "SELECT * FROM users WHERE id = 42",
+			Expect: "select * from users where id = ?",
+		},
+		{
+			Input:  "select *\n  from users where name = 'it''s' and id in (1, 2, 3)",
+			Expect: "select * from users where name = ? and id in (?)",
+		},
+		{
+			Input:  "UPDATE t SET a = $1 WHERE b = :name",
+			Expect: "update t set a = ? where b = ?",
+		},
+	}
+
+	for idx, each := range cases {
+		if actual := Normalize(each.Input); actual != each.Expect {
+			t.Fatalf("%d: expect: %s, got: %s", idx, each.Expect, actual)
+		}
+	}
+
+	if Fingerprint("select 1") != Fingerprint("SELECT   2") {
+		t.Fatalf("Fingerprint() should ignore literals")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := logger.NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetLevel(logrus.DebugLevel)
+
+	sql.Register("fake-logged", Wrap(fakeDriver{}, l,
+		WithSlowThreshold(time.Millisecond),
+		WithRedactor(func(ordinal int, name string, value interface{}) interface{} {
+			if ordinal == 2 {
+				return "***"
+			}
+			return value
+		}),
+	))
+
+	db, err := sql.Open("fake-logged", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error, Expected=nil, Actual=%q", err.Error())
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("UPDATE users SET name = ? WHERE token = ?", "foo", "secret"); err != nil {
+		t.Fatalf("Exec() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	rows, err := db.Query("SELECT id FROM users")
+	if err != nil {
+		t.Fatalf("Query() error, Expected=nil, Actual=%q", err.Error())
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil || types[0].DatabaseTypeName() != "BIGINT" {
+		t.Fatalf("ColumnTypes() DatabaseTypeName, Expected=BIGINT, Actual=%v %v", types, err)
+	}
+	if _, ok := types[0].Nullable(); ok {
+		t.Fatalf("ColumnTypes() Nullable, Expected=unsupported")
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	if _, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true}); err == nil {
+		t.Fatalf("BeginTx(ReadOnly) error, Expected=error, Actual=nil")
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	if len(lines) != 2 {
+		t.Fatalf("log lines, Expected=2, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"schema"}, expected: string(logger.SchemaSQLQueryV1)},
+		{line: 0, path: []interface{}{"l"}, expected: "warning"},
+		{line: 0, path: []interface{}{"sql", "rows"}, expected: "3"},
+		{line: 0, path: []interface{}{"sql", "args", 0}, expected: "foo"},
+		{line: 0, path: []interface{}{"sql", "args", 1}, expected: "***"},
+		{line: 1, path: []interface{}{"l"}, expected: "debug"},
+		{line: 1, path: []interface{}{"sql", "rows"}, expected: "2"},
+		{line: 1, path: []interface{}{"sql", "fingerprint"}, expected: Fingerprint("SELECT id FROM users")},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
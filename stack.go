@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"strings"
+)
+
+// StackFrameFilter 判断是否从调用栈中去掉一帧，frame 的格式为 "函数名 文件:行号"
+type StackFrameFilter func(frame string) bool
+
+// loggerPackages 日志相关的包，DropLoggerFrames 去掉这些包内的帧
+var loggerPackages = []string{
+	"github.com/lancer05/logger.",
+	"github.com/lancer05/logger/",
+	"github.com/sirupsen/logrus.",
+}
+
+// WithMaxStackTrace 设置错误调用栈的最大深度，覆盖全局的 MaxStackTrace
+func WithMaxStackTrace(n int) Option {
+	return func(af *LogsV1Formatter) {
+		af.MaxStackTrace = n
+	}
+}
+
+// WithStackFrameFilters 过滤错误调用栈中的帧，任一过滤函数返回 true 的帧不记录
+//
+//	logger.WithStackFrameFilters(logger.DropVendorFrames, logger.DropRuntimeFrames, logger.DropLoggerFrames)
+func WithStackFrameFilters(filters ...StackFrameFilter) Option {
+	return func(af *LogsV1Formatter) {
+		af.StackFrameFilters = append(af.StackFrameFilters, filters...)
+	}
+}
+
+// DropVendorFrames 去掉 vendor 目录内的帧
+func DropVendorFrames(frame string) bool {
+	return strings.Contains(frame, "/vendor/")
+}
+
+// DropRuntimeFrames 去掉 Go 运行时的帧，例如 runtime.goexit、runtime.main
+func DropRuntimeFrames(frame string) bool {
+	return strings.HasPrefix(frame, "runtime.")
+}
+
+// DropLoggerFrames 去掉本包、子包与 logrus 内的帧
+func DropLoggerFrames(frame string) bool {
+	return hasAnyPrefix(frame, loggerPackages)
+}
+
+// filterStack 过滤调用栈后截断到 max 帧，会修改 st，没有剩余的帧时返回 nil
+func filterStack(st []string, max int, filters []StackFrameFilter) []string {
+	if len(filters) > 0 {
+		kept := st[:0]
+		for _, frame := range st {
+			if !dropFrame(frame, filters) {
+				kept = append(kept, frame)
+			}
+		}
+		st = kept
+	}
+	if len(st) == 0 {
+		return nil
+	}
+	if len(st) > max {
+		st = st[:max]
+	}
+	return st
+}
+
+func dropFrame(frame string, filters []StackFrameFilter) bool {
+	for _, f := range filters {
+		if f(frame) {
+			return true
+		}
+	}
+	return false
+}
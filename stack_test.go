@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+func TestFilterStack(t *testing.T) {
+	st := []string{
+		"github.com/lancer05/logger.TestFilterStack /src/logger/stack_test.go:12",
+		"github.com/acme/app/vendor/github.com/x/y.Do /src/app/vendor/github.com/x/y/y.go:3",
+		"github.com/acme/app/orders.Create /src/app/orders/create.go:40",
+		"github.com/acme/app/orders.Handle /src/app/orders/handle.go:12",
+		"runtime.goexit /usr/local/go/src/runtime/asm_amd64.s:1571",
+	}
+	filters := []StackFrameFilter{DropVendorFrames, DropRuntimeFrames, DropLoggerFrames}
+
+	actual := filterStack(append([]string(nil), st...), 1, filters)
+	if !reflect.DeepEqual(actual, st[2:3]) {
+		t.Fatalf("filterStack() Expected=%v, Actual=%v", st[2:3], actual)
+	}
+	if actual := filterStack(append([]string(nil), st[:2]...), 10, filters); actual != nil {
+		t.Fatalf("filterStack() Expected=nil, Actual=%v", actual)
+	}
+}
+
+func TestWithMaxStackTrace(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test", WithMaxStackTrace(1), WithStackFrameFilters(DropRuntimeFrames))
+	l.SetOutput(out)
+
+	l.WithField("cause", errors.New("failed")).Error("x")
+
+	trace := jsoniter.Get(out.Bytes(), "ctx", "cause", "trace")
+	if trace.Size() != 1 {
+		t.Fatalf("output ctx.cause.trace, Expected=1 frame, Actual=%s", out.Bytes())
+	}
+	if MaxStackTrace != 10 {
+		t.Fatalf("WithMaxStackTrace() should not change MaxStackTrace, Actual=%d", MaxStackTrace)
+	}
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// formatterBase 由 LogsV1Formatter 及嵌入它的格式化对象实现
+type formatterBase interface {
+	base() *LogsV1Formatter
+}
+
+func (af *LogsV1Formatter) base() *LogsV1Formatter {
+	return af
+}
+
+// LogStartup 输出一条启动日志，ctx 中记录版本、生效的日志配置、配置摘要、启用的功能与实例信息，
+// 作为部署分界的标记；相同配置的 config_hash 相同，便于比较两次部署的日志配置
+//
+// l 的格式化对象不是本包创建的时只记录版本、日志级别与实例信息
+func LogStartup(l *logrus.Logger) {
+	build := BuildInfo()
+	config := map[string]interface{}{
+		"level": l.GetLevel().String(),
+	}
+	var features []string
+	if fb, ok := l.Formatter.(formatterBase); ok {
+		af := fb.base()
+		features = af.features()
+		config["time_layout"] = af.TimeLayout
+		config["max_field_size"] = af.MaxFieldSize
+		config["max_entry_size"] = af.MaxEntrySize
+		config["max_depth"] = af.MaxDepth
+		config["max_stack_trace"] = af.MaxStackTrace
+		config["retention"] = af.Retention
+		config["channel_retention"] = af.ChannelRetention
+		config["features"] = features
+	}
+
+	// encoding/json 按键排序输出 map，摘要与字段顺序无关
+	p, _ := json.Marshal(config)
+	l.WithFields(logrus.Fields{
+		"event":       "startup",
+		"version":     build.Version,
+		"revision":    build.Revision,
+		"go":          build.GoVersion,
+		"config":      config,
+		"config_hash": fmt.Sprintf("%x", sha256.Sum256(p))[:16],
+		"features":    features,
+		"instance":    HostMetadata(),
+	}).Info("service started")
+}
+
+// features 启用的可选功能，按名称排序
+func (af *LogsV1Formatter) features() []string {
+	enabled := map[string]bool{
+		"host_metadata":    af.Host != nil,
+		"build_info":       af.Build != nil,
+		"kubernetes":       af.Kubernetes != nil,
+		"catalog":          af.Catalog != nil,
+		"enrichers":        len(af.Enrichers) > 0,
+		"dynamic_fields":   len(af.DynamicFields) > 0,
+		"redaction":        af.currentRedactor() != nil,
+		"custom_encoder":   af.Encoder != nil,
+		"sorted_keys":      af.SortKeys,
+		"raw_strings":      af.RawStrings,
+		"param_filter":     af.ParamFilter != nil,
+		"cookie_allowlist": len(af.CookieAllowlist) > 0,
+		"jwt_claims":       af.JWT != nil,
+		"session_id":       af.Session != nil,
+		"ip_anonymization": af.IPAnonymizer != nil,
+		"user_hashing":     len(af.UserKey) > 0,
+		"user_agent":       af.UserAgentParser != nil,
+		"body_parsers":     len(af.BodyParsers) > 0,
+		"dual_emit":        af.DualEmit,
+		"goroutine_id":     af.GoroutineID,
+		"caller_options": af.CallerSkip > 0 || len(af.CallerSkipPackages) > 0 ||
+			len(af.CallerTrimPrefixes) > 0 || af.ShortCallerFunc,
+		"stack_filters": len(af.StackFrameFilters) > 0,
+	}
+	features := []string{}
+	for name, on := range enabled {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestLogStartup(t *testing.T) {
+	hash := func(opts ...Option) string {
+		out := &bytes.Buffer{}
+		l, _ := NewLogger("test", "test", opts...)
+		l.SetOutput(out)
+		LogStartup(l)
+
+		data := out.Bytes()
+		if v := jsoniter.Get(data, "ctx", "event").ToString(); v != "startup" {
+			t.Fatalf("output ctx.event, Expected=startup, Actual=%q", v)
+		}
+		if v := jsoniter.Get(data, "ctx", "instance", "pid").ToInt(); v == 0 {
+			t.Fatalf("output ctx.instance.pid, Actual=%s", data)
+		}
+		return jsoniter.Get(data, "ctx", "config_hash").ToString()
+	}
+
+	plain := hash()
+	if len(plain) != 16 || plain != hash() {
+		t.Fatalf("config_hash should be stable, Actual=%q", plain)
+	}
+	if hash(WithSortedKeys()) == plain {
+		t.Fatalf("config_hash should change with features")
+	}
+
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test", WithSortedKeys(), WithGoroutineID(false))
+	l.SetOutput(out)
+	LogStartup(l)
+	features := jsoniter.Get(out.Bytes(), "ctx", "features")
+	if features.Size() != 2 || features.Get(0).ToString() != "goroutine_id" || features.Get(1).ToString() != "sorted_keys" {
+		t.Fatalf("output ctx.features, Actual=%s", features.ToString())
+	}
+}
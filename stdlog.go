@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StdLogger 返回以 level 级别输出到 l 的标准库日志对象，用于 http.Server.ErrorLog 等只接受 *log.Logger 的地方
+//
+//	srv := &http.Server{ErrorLog: logger.StdLogger(l, logrus.WarnLevel)}
+func StdLogger(l *logrus.Logger, level logrus.Level) *log.Logger {
+	return log.New(&levelWriter{logger: l, level: level}, "", 0)
+}
+
+// levelWriter 每次写入作为一条日志，log.Logger 每条日志只调用一次 Write
+type levelWriter struct {
+	logger *logrus.Logger
+	level  logrus.Level
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	w.logger.Log(w.level, strings.TrimRight(string(p), "\r\n"))
+	return len(p), nil
+}
+
+// GRPCLogger 实现 grpclog.LoggerV2，gRPC 内部的日志以 grpc channel 输出，
+// 无需依赖 grpc，按方法签名满足接口
+//
+//	grpclog.SetLoggerV2(logger.NewGRPCLogger(l, 0))
+type GRPCLogger struct {
+	entry *logrus.Entry
+	// 详细级别，V(n) 在 n 不大于该值时返回 true，与 GRPC_GO_LOG_VERBOSITY_LEVEL 含义一致
+	verbosity int
+}
+
+// NewGRPCLogger 创建输出到 l 的 gRPC 日志对象
+func NewGRPCLogger(l *logrus.Logger, verbosity int) *GRPCLogger {
+	return &GRPCLogger{entry: l.WithField("channel", "grpc"), verbosity: verbosity}
+}
+
+// Info implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Info(args ...interface{}) {
+	g.entry.Info(args...)
+}
+
+// Infoln implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Infoln(args ...interface{}) {
+	g.entry.Info(sprintln(args...))
+}
+
+// Infof implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Infof(format string, args ...interface{}) {
+	g.entry.Infof(format, args...)
+}
+
+// Warning implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Warning(args ...interface{}) {
+	g.entry.Warn(args...)
+}
+
+// Warningln implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Warningln(args ...interface{}) {
+	g.entry.Warn(sprintln(args...))
+}
+
+// Warningf implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Warningf(format string, args ...interface{}) {
+	g.entry.Warnf(format, args...)
+}
+
+// Error implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Error(args ...interface{}) {
+	g.entry.Error(args...)
+}
+
+// Errorln implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Errorln(args ...interface{}) {
+	g.entry.Error(sprintln(args...))
+}
+
+// Errorf implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) Errorf(format string, args ...interface{}) {
+	g.entry.Errorf(format, args...)
+}
+
+// Fatal implements grpclog.LoggerV2 interface，输出后退出进程
+func (g *GRPCLogger) Fatal(args ...interface{}) {
+	g.entry.Fatal(args...)
+}
+
+// Fatalln implements grpclog.LoggerV2 interface，输出后退出进程
+func (g *GRPCLogger) Fatalln(args ...interface{}) {
+	g.entry.Fatal(sprintln(args...))
+}
+
+// Fatalf implements grpclog.LoggerV2 interface，输出后退出进程
+func (g *GRPCLogger) Fatalf(format string, args ...interface{}) {
+	g.entry.Fatalf(format, args...)
+}
+
+// V implements grpclog.LoggerV2 interface
+func (g *GRPCLogger) V(l int) bool {
+	return l <= g.verbosity
+}
+
+// sprintln 与 fmt.Sprintln 一致但不带结尾的换行
+func sprintln(args ...interface{}) string {
+	msg := fmt.Sprintln(args...)
+	return msg[:len(msg)-1]
+}
+
+// levelMarkers 按顺序匹配的级别名称，同一位置先匹配到的优先
+var levelMarkers = []struct {
+	names []string
+	level logrus.Level
+}{
+	{names: []string{"PANIC", "FATAL", "CRITICAL", "CRIT"}, level: logrus.FatalLevel},
+	{names: []string{"ERROR", "ERR"}, level: logrus.ErrorLevel},
+	{names: []string{"WARNING", "WARN"}, level: logrus.WarnLevel},
+	{names: []string{"INFO"}, level: logrus.InfoLevel},
+	{names: []string{"DEBUG"}, level: logrus.DebugLevel},
+	{names: []string{"TRACE"}, level: logrus.TraceLevel},
+}
+
+// maxLevelPrefix 只在每行开头的这些字节内查找级别，避免消息内容中的单词影响级别
+const maxLevelPrefix = 64
+
+// WriterLevelDetect 返回按行输出到 l 的 io.Writer，每行按开头的 [ERROR]、WARN:、level=debug、
+// 大写的 INFO 等标记确定级别，没有标记时使用 info，用于只接受 io.Writer 的第三方组件
+//
+// fatal、panic、critical 以 fatal 级别输出，但不会退出进程；
+// 不以换行结尾的内容在下次写入或 Close 时输出
+func WriterLevelDetect(l *logrus.Logger) io.WriteCloser {
+	return &levelDetectWriter{logger: l}
+}
+
+type levelDetectWriter struct {
+	logger *logrus.Logger
+	mu     sync.Mutex
+	// 尚未遇到换行的内容
+	pending []byte
+}
+
+func (w *levelDetectWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+	}
+	if len(w.pending) == 0 {
+		w.pending = nil
+	}
+	return len(p), nil
+}
+
+// Close 输出剩余不以换行结尾的内容
+func (w *levelDetectWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 {
+		w.writeLine(string(w.pending))
+		w.pending = nil
+	}
+	return nil
+}
+
+func (w *levelDetectWriter) writeLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	level, ok := DetectLevel(line)
+	if !ok {
+		level = logrus.InfoLevel
+	}
+	w.logger.Log(level, line)
+}
+
+// DetectLevel 查找行首的级别标记，[error]、error:、level=error 不区分大小写，
+// 不带符号的级别名称只匹配大写的单词
+func DetectLevel(line string) (logrus.Level, bool) {
+	head := line
+	if len(head) > maxLevelPrefix {
+		head = head[:maxLevelPrefix]
+	}
+	upper := strings.ToUpper(head)
+
+	best := -1
+	var level logrus.Level
+	match := func(i int, l logrus.Level) {
+		if i >= 0 && (best < 0 || i < best) {
+			best = i
+			level = l
+		}
+	}
+	for _, m := range levelMarkers {
+		for _, name := range m.names {
+			match(strings.Index(upper, "["+name+"]"), m.level)
+			match(strings.Index(upper, "LEVEL="+name), m.level)
+			match(indexWord(upper, name+":"), m.level)
+			match(indexWord(head, name), m.level)
+		}
+	}
+	return level, best >= 0
+}
+
+// indexWord 查找前后不是字母的 word
+func indexWord(s, word string) int {
+	for start := 0; start < len(s); {
+		i := strings.Index(s[start:], word)
+		if i < 0 {
+			return -1
+		}
+		i += start
+		end := i + len(word)
+		if (i == 0 || !isLetter(s[i-1])) && (end == len(s) || !isLetter(s[end]) || word[len(word)-1] == ':') {
+			return i
+		}
+		start = i + 1
+	}
+	return -1
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
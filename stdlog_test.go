@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+// grpcLoggerV2 与 grpclog.LoggerV2 的方法一致
+type grpcLoggerV2 interface {
+	Info(args ...interface{})
+	Infoln(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningln(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorln(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalln(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	V(l int) bool
+}
+
+var _ grpcLoggerV2 = (*GRPCLogger)(nil)
+
+func TestStdLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	StdLogger(l, logrus.WarnLevel).Printf("http: TLS handshake error from %s", "10.0.0.1:1234")
+
+	data := out.Bytes()
+	if v := jsoniter.Get(data, "l").ToString(); v != "warning" {
+		t.Fatalf("output l, Expected=warning, Actual=%q", v)
+	}
+	if v := jsoniter.Get(data, "m").ToString(); v != "http: TLS handshake error from 10.0.0.1:1234" {
+		t.Fatalf("output m, Actual=%q", v)
+	}
+}
+
+func TestGRPCLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	g := NewGRPCLogger(l, 1)
+	g.Warningln("transport:", "closing")
+
+	data := out.Bytes()
+	cases := map[string]string{"c": "grpc", "l": "warning", "m": "transport: closing"}
+	for k, expected := range cases {
+		if v := jsoniter.Get(data, k).ToString(); v != expected {
+			t.Fatalf("output %s, Expected=%q, Actual=%q", k, expected, v)
+		}
+	}
+	if !g.V(1) || g.V(2) {
+		t.Fatalf("V() Expected verbosity 1")
+	}
+}
+
+func TestDetectLevel(t *testing.T) {
+	cases := []struct {
+		line     string
+		expected logrus.Level
+		ok       bool
+	}{
+		{line: "[ERROR] connection refused", expected: logrus.ErrorLevel, ok: true},
+		{line: "2021/06/01 10:00:00 WARN: disk almost full", expected: logrus.WarnLevel, ok: true},
+		{line: "warn: lowercase with colon", expected: logrus.WarnLevel, ok: true},
+		{line: `time="..." level=debug msg="x"`, expected: logrus.DebugLevel, ok: true},
+		{line: "2021-06-01 10:00:00 INFO retry after ERROR", expected: logrus.InfoLevel, ok: true},
+		{line: "FATAL out of memory", expected: logrus.FatalLevel, ok: true},
+		{line: "an error occurred", ok: false},
+		{line: "WARNINGS are ignored", ok: false},
+	}
+	for _, c := range cases {
+		level, ok := DetectLevel(c.line)
+		if ok != c.ok || ok && level != c.expected {
+			t.Fatalf("DetectLevel(%q) Expected=%s %v, Actual=%s %v", c.line, c.expected, c.ok, level, ok)
+		}
+	}
+}
+
+func TestWriterLevelDetect(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	w := WriterLevelDetect(l)
+	_, _ = w.Write([]byte("[ERROR] first\nplain"))
+	_, _ = w.Write([]byte(" second\n\n[WARN] third"))
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Write() Expected=2 lines, Actual=%s", out.Bytes())
+	}
+	_ = w.Close()
+
+	lines = bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	expected := []struct{ level, msg string }{
+		{level: "error", msg: "[ERROR] first"},
+		{level: "info", msg: "plain second"},
+		{level: "warning", msg: "[WARN] third"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Close() Expected=%d lines, Actual=%s", len(expected), out.Bytes())
+	}
+	for i, e := range expected {
+		if l, m := jsoniter.Get(lines[i], "l").ToString(), jsoniter.Get(lines[i], "m").ToString(); l != e.level || m != e.msg {
+			t.Fatalf("line %d Expected=%s %q, Actual=%s %q", i, e.level, e.msg, l, m)
+		}
+	}
+}
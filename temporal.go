@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TemporalLogger 实现 Temporal SDK 的 log.Logger，工作流与活动的日志以 temporal channel 输出，
+// 无需依赖 Temporal SDK，按方法签名满足接口
+//
+// SDK 以 WorkflowID、RunID 等驼峰形式的键传入上下文，输出时转换为 workflow_id、run_id，
+// Error 键的值作为日志的错误记录；SDK 通过 log.With 包装时会在每次调用时传入绑定的键值
+//
+//	c, err := client.Dial(client.Options{Logger: logger.NewTemporalLogger(l)})
+type TemporalLogger struct {
+	entry *logrus.Entry
+}
+
+// NewTemporalLogger 创建输出到 l 的 Temporal 日志对象
+func NewTemporalLogger(l FieldBinder) *TemporalLogger {
+	return &TemporalLogger{entry: l.WithField("channel", "temporal")}
+}
+
+// Debug implements log.Logger interface
+func (t *TemporalLogger) Debug(msg string, keyvals ...interface{}) {
+	t.log(logrus.DebugLevel, msg, keyvals)
+}
+
+// Info implements log.Logger interface
+func (t *TemporalLogger) Info(msg string, keyvals ...interface{}) {
+	t.log(logrus.InfoLevel, msg, keyvals)
+}
+
+// Warn implements log.Logger interface
+func (t *TemporalLogger) Warn(msg string, keyvals ...interface{}) {
+	t.log(logrus.WarnLevel, msg, keyvals)
+}
+
+// Error implements log.Logger interface
+func (t *TemporalLogger) Error(msg string, keyvals ...interface{}) {
+	t.log(logrus.ErrorLevel, msg, keyvals)
+}
+
+func (t *TemporalLogger) log(level logrus.Level, msg string, keyvals []interface{}) {
+	if !t.entry.Logger.IsLevelEnabled(level) {
+		return
+	}
+	t.entry.WithFields(keyvalFields(keyvals)).Log(level, msg)
+}
+
+// WorkflowLogger 返回绑定了 workflow_id、run_id 的子日志对象，
+// 用于 Temporal 以外的工作流或 worker 框架，字段名与 TemporalLogger 的输出一致
+//
+//	log := logger.WorkflowLogger(l, task.WorkflowID, task.RunID)
+//	log.Info("activity started")
+func WorkflowLogger(l FieldBinder, workflowID, runID string) *logrus.Entry {
+	return With(l, logrus.Fields{
+		"workflow_id": workflowID,
+		"run_id":      runID,
+	})
+}
+
+// keyvalFields 将交替出现的键值转换为字段，键转换为下划线形式，缺少值的键记录为 nil
+func keyvalFields(keyvals []interface{}) logrus.Fields {
+	fields := make(logrus.Fields, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fields[snakeCase(key)] = value
+	}
+	return fields
+}
+
+// snakeCase 将驼峰形式的键转换为下划线形式，连续的大写字母视为一个词，如 WorkflowID 转换为 workflow_id
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestTemporalLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	tl := NewTemporalLogger(l)
+	tl.Debug("ignored")
+	if out.Len() != 0 {
+		t.Fatalf("debug output, Expected=\"\", Actual=%q", out.String())
+	}
+	tl.Error("Activity error.", "Namespace", "default", "WorkflowID", "order-1", "RunID", "run-1",
+		"ActivityType", "Charge", "Attempt", 2, "Error", errors.New("card declined"), "Orphan")
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"c"}, expected: "temporal"},
+		{path: []interface{}{"l"}, expected: "error"},
+		{path: []interface{}{"m"}, expected: "Activity error."},
+		{path: []interface{}{"ctx", "namespace"}, expected: "default"},
+		{path: []interface{}{"ctx", "workflow_id"}, expected: "order-1"},
+		{path: []interface{}{"ctx", "run_id"}, expected: "run-1"},
+		{path: []interface{}{"ctx", "activity_type"}, expected: "Charge"},
+		{path: []interface{}{"ctx", "attempt"}, expected: "2"},
+		{path: []interface{}{"err"}, expected: "card declined"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+}
+
+func TestWorkflowLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	WorkflowLogger(l, "order-1", "run-1").Info("activity started")
+
+	data := out.Bytes()
+	cases := map[string]string{"workflow_id": "order-1", "run_id": "run-1"}
+	for k, expected := range cases {
+		if v := jsoniter.Get(data, "ctx", k).ToString(); v != expected {
+			t.Fatalf("output ctx.%s, Expected=%q, Actual=%q", k, expected, v)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"WorkflowID":    "workflow_id",
+		"RunID":         "run_id",
+		"TaskQueue":     "task_queue",
+		"HTTPStatus":    "http_status",
+		"attempt":       "attempt",
+		"Version2Field": "version2_field",
+	}
+	for input, expected := range cases {
+		if v := snakeCase(input); v != expected {
+			t.Fatalf("snakeCase(%q), Expected=%q, Actual=%q", input, expected, v)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package logger
+
+import "context"
+
+type tenantKey struct{}
+
+// ContextWithTenant 将租户保存到 ctx，通过 WithContext(ctx) 输出的日志都会带有 tenant 字段，
+// entry 中的 tenant 字段优先
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 获得 ctx 中保存的租户
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestTenant(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	ctx := ContextWithTenant(context.Background(), "acme")
+	cases := []struct {
+		Name   string
+		Log    func()
+		Expect string
+	}{
+		{Name: "context", Log: func() { l.WithContext(ctx).Info("hello") }, Expect: "acme"},
+		{Name: "field", Log: func() { l.WithContext(ctx).WithField("tenant", "globex").Info("hello") }, Expect: "globex"},
+		{Name: "none", Log: func() { l.Info("hello") }, Expect: ""},
+	}
+
+	for _, each := range cases {
+		out.Reset()
+		each.Log()
+		if v := jsoniter.Get(out.Bytes(), "tenant").ToString(); v != each.Expect {
+			t.Fatalf("%s: output tenant, Expected=%q, Actual=%q", each.Name, each.Expect, v)
+		}
+		if jsoniter.Get(out.Bytes(), "ctx", "tenant").LastError() == nil {
+			t.Fatalf("%s: output ctx.tenant should be omitted", each.Name)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLSData 加密连接的信息
+type TLSData struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name,omitempty"`
+	// 客户端证书的 subject，双向认证时记录
+	ClientSubject string `json:"client_subject,omitempty"`
+}
+
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+// richTLS 提取连接的 TLS 信息，非加密连接返回 nil
+func richTLS(state *tls.ConnectionState) *TLSData {
+	if state == nil {
+		return nil
+	}
+
+	data := &TLSData{
+		Version:     tlsVersions[state.Version],
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	if data.Version == "" {
+		data.Version = fmt.Sprintf("0x%04x", state.Version)
+	}
+	if len(state.PeerCertificates) > 0 {
+		data.ClientSubject = state.PeerCertificates[0].Subject.String()
+	}
+	return data
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFormatTLS(t *testing.T) {
+	req := &http.Request{
+		Method: http.MethodGet,
+		Proto:  "HTTP/2.0",
+		URL:    &url.URL{Path: "/secure"},
+		TLS: &tls.ConnectionState{
+			Version:     tls.VersionTLS13,
+			CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+			ServerName:  "api.example.com",
+			PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "client-1", Organization: []string{"Example"}}},
+			},
+		},
+	}
+
+	f := NewFormatter("test", "test")
+	data, err := f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"request", "proto"}, expected: "HTTP/2.0"},
+		{path: []interface{}{"request", "tls", "version"}, expected: "TLS 1.3"},
+		{path: []interface{}{"request", "tls", "cipher_suite"}, expected: "TLS_AES_128_GCM_SHA256"},
+		{path: []interface{}{"request", "tls", "server_name"}, expected: "api.example.com"},
+		{path: []interface{}{"request", "tls", "client_subject"}, expected: "CN=client-1,O=Example"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf("output %q, Expected=%q, Actual=%q", c.path, c.expected, v)
+		}
+	}
+
+	req.TLS = nil
+	data, _ = f.Format(&logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}})
+	if jsoniter.Get(data, "request", "tls").LastError() == nil {
+		t.Fatalf("output request.tls for plain connection, Expected=none")
+	}
+}
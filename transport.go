@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type retryKey struct{}
+
+// ClientRequestData 对外请求相关的参数，字段与 RequestData 保持一致便于关联
+type ClientRequestData struct {
+	Host     string `json:"host"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Retry    int    `json:"retry"`
+}
+
+// ContextWithRetry 标记请求是第几次重试，由重试逻辑在重新发起请求前设置
+func ContextWithRetry(ctx context.Context, retry int) context.Context {
+	return context.WithValue(ctx, retryKey{}, retry)
+}
+
+// Transport 包装 http.RoundTripper，以 http.client.v1 规范记录对外请求
+func Transport(base http.RoundTripper, l *logrus.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingTransport{base: base, logger: l}
+}
+
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger *logrus.Logger
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	data := &ClientRequestData{
+		Host:     req.URL.Host,
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		Duration: time.Since(start).String(),
+	}
+	if retry, ok := req.Context().Value(retryKey{}).(int); ok {
+		data.Retry = retry
+	}
+
+	level := logrus.InfoLevel
+	entry := t.logger.WithContext(req.Context()).WithField("client", data)
+	if err != nil {
+		level = logrus.ErrorLevel
+		entry = entry.WithField("error", err)
+	} else {
+		data.Status = strconv.Itoa(resp.StatusCode)
+	}
+	entry.Log(level, "http client request")
+
+	return resp, err
+}
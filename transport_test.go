@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	client := &http.Client{Transport: Transport(nil, l)}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api", nil)
+	req = req.WithContext(ContextWithRetry(req.Context(), 2))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error, Expected=nil, Actual=%q", err.Error())
+	}
+	resp.Body.Close()
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaHTTPClientV1)},
+		{path: []interface{}{"client", "host"}, expected: req.URL.Host},
+		{path: []interface{}{"client", "method"}, expected: http.MethodGet},
+		{path: []interface{}{"client", "path"}, expected: "/api"},
+		{path: []interface{}{"client", "status"}, expected: "418"},
+		{path: []interface{}{"client", "retry"}, expected: "2"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+}
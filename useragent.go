@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"strings"
+)
+
+// UserAgent 解析后的 User-Agent 信息
+type UserAgent struct {
+	// 浏览器或客户端名称与主版本号，例如 Chrome 91
+	Browser string
+	// 操作系统与版本，例如 Windows 10、iOS 14.6
+	OS string
+	// 设备类型：desktop、mobile、tablet、bot 或 other
+	Device string
+}
+
+// UserAgentParser 解析 User-Agent
+type UserAgentParser func(ua string) UserAgent
+
+// WithUserAgentParsing 解析请求的 User-Agent，记录到 request.ua、ua_browser、ua_os 与 ua_device，
+// p 为 nil 时使用 ParseUserAgent
+func WithUserAgentParsing(p UserAgentParser) Option {
+	return func(af *LogsV1Formatter) {
+		if p == nil {
+			p = ParseUserAgent
+		}
+		af.UserAgentParser = p
+	}
+}
+
+// uaBrowsers 按顺序匹配的客户端标识，Chrome 内核的浏览器同时带有 Chrome 与 Safari 标识，需要排在前面
+var uaBrowsers = []struct {
+	token string
+	name  string
+}{
+	{token: "Edg/", name: "Edge"},
+	{token: "Edge/", name: "Edge"},
+	{token: "EdgiOS/", name: "Edge"},
+	{token: "OPR/", name: "Opera"},
+	{token: "SamsungBrowser/", name: "Samsung Internet"},
+	{token: "YaBrowser/", name: "Yandex"},
+	{token: "UCBrowser/", name: "UC Browser"},
+	{token: "MicroMessenger/", name: "WeChat"},
+	{token: "Firefox/", name: "Firefox"},
+	{token: "FxiOS/", name: "Firefox"},
+	{token: "CriOS/", name: "Chrome"},
+	{token: "Chrome/", name: "Chrome"},
+	{token: "MSIE ", name: "IE"},
+	{token: "Trident/", name: "IE"},
+	{token: "curl/", name: "curl"},
+	{token: "Wget/", name: "Wget"},
+	{token: "Go-http-client/", name: "Go"},
+	{token: "okhttp/", name: "okhttp"},
+	{token: "python-requests/", name: "python-requests"},
+	{token: "Version/", name: "Safari"},
+}
+
+// uaBots 爬虫与监控程序的标识，不区分大小写
+var uaBots = []string{"bot", "crawler", "spider", "slurp", "monitor", "headless"}
+
+// ParseUserAgent 按常见的标识解析 User-Agent，不能识别的部分为空
+func ParseUserAgent(ua string) UserAgent {
+	if ua == "" {
+		return UserAgent{}
+	}
+	agent := UserAgent{
+		Browser: uaBrowser(ua),
+		OS:      uaOS(ua),
+	}
+
+	lower := strings.ToLower(ua)
+	switch {
+	case containsAny(lower, uaBots):
+		agent.Device = "bot"
+	case strings.Contains(ua, "iPad") || strings.Contains(lower, "tablet") ||
+		strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		agent.Device = "tablet"
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "Android"):
+		agent.Device = "mobile"
+	case strings.HasPrefix(agent.OS, "Windows") || strings.HasPrefix(agent.OS, "macOS") ||
+		agent.OS == "Linux" || agent.OS == "Chrome OS":
+		agent.Device = "desktop"
+	default:
+		agent.Device = "other"
+	}
+	return agent
+}
+
+func uaBrowser(ua string) string {
+	for _, b := range uaBrowsers {
+		i := strings.Index(ua, b.token)
+		if i < 0 {
+			continue
+		}
+		if b.token == "Version/" && !strings.Contains(ua, "Safari/") {
+			continue
+		}
+		if b.token == "Trident/" {
+			// IE 11 不再使用 MSIE 标识，版本在 rv: 之后
+			if j := strings.Index(ua, "rv:"); j >= 0 {
+				return b.name + " " + uaVersion(ua[j+3:], 1)
+			}
+			return b.name
+		}
+		if v := uaVersion(ua[i+len(b.token):], 1); v != "" {
+			return b.name + " " + v
+		}
+		return b.name
+	}
+	return ""
+}
+
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+func uaOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "Windows NT "):
+		v := uaVersion(ua[strings.Index(ua, "Windows NT ")+len("Windows NT "):], 2)
+		if name, ok := windowsVersions[v]; ok {
+			return "Windows " + name
+		}
+		return "Windows"
+	case strings.Contains(ua, "iPhone OS "), strings.Contains(ua, "CPU OS "):
+		token := "iPhone OS "
+		if !strings.Contains(ua, token) {
+			token = "CPU OS "
+		}
+		return "iOS " + uaVersion(ua[strings.Index(ua, token)+len(token):], 3)
+	case strings.Contains(ua, "Mac OS X"):
+		rest := ua[strings.Index(ua, "Mac OS X")+len("Mac OS X"):]
+		if v := uaVersion(strings.TrimLeft(rest, " "), 3); v != "" {
+			return "macOS " + v
+		}
+		return "macOS"
+	case strings.Contains(ua, "Android"):
+		rest := strings.TrimLeft(ua[strings.Index(ua, "Android")+len("Android"):], " ")
+		if v := uaVersion(rest, 2); v != "" {
+			return "Android " + v
+		}
+		return "Android"
+	case strings.Contains(ua, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(ua, "Linux"):
+		return "Linux"
+	}
+	return ""
+}
+
+// uaVersion 读取开头的版本号，最多保留 parts 段，. 与 _ 均作为分隔符
+func uaVersion(s string, parts int) string {
+	var b strings.Builder
+	n := 1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		if (c == '.' || c == '_') && n < parts && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9' && b.Len() > 0 {
+			b.WriteByte('.')
+			n++
+			continue
+		}
+		break
+	}
+	return b.String()
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	cases := []struct {
+		UA       string
+		Expected UserAgent
+	}{
+		{
+			UA:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
+			Expected: UserAgent{Browser: "Chrome 91", OS: "Windows 10", Device: "desktop"},
+		},
+		{
+			UA:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36 Edg/91.0.864.59",
+			Expected: UserAgent{Browser: "Edge 91", OS: "Windows 10", Device: "desktop"},
+		},
+		{
+			UA:       "Mozilla/5.0 (iPhone; CPU iPhone OS 14_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.1 Mobile/15E148 Safari/604.1",
+			Expected: UserAgent{Browser: "Safari 14", OS: "iOS 14.6", Device: "mobile"},
+		},
+		{
+			UA:       "Mozilla/5.0 (iPad; CPU OS 13_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/87.0.4280.77 Mobile/15E148 Safari/604.1",
+			Expected: UserAgent{Browser: "Chrome 87", OS: "iOS 13.3", Device: "tablet"},
+		},
+		{
+			UA:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1 Safari/605.1.15",
+			Expected: UserAgent{Browser: "Safari 14", OS: "macOS 10.15.7", Device: "desktop"},
+		},
+		{
+			UA:       "Mozilla/5.0 (Linux; Android 11; Pixel 5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.91 Mobile Safari/537.36",
+			Expected: UserAgent{Browser: "Chrome 90", OS: "Android 11", Device: "mobile"},
+		},
+		{
+			UA:       "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:89.0) Gecko/20100101 Firefox/89.0",
+			Expected: UserAgent{Browser: "Firefox 89", OS: "Linux", Device: "desktop"},
+		},
+		{
+			UA:       "Mozilla/5.0 (Windows NT 6.1; Trident/7.0; rv:11.0) like Gecko",
+			Expected: UserAgent{Browser: "IE 11", OS: "Windows 7", Device: "desktop"},
+		},
+		{
+			UA:       "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Expected: UserAgent{Device: "bot"},
+		},
+		{
+			UA:       "curl/7.64.1",
+			Expected: UserAgent{Browser: "curl 7", Device: "other"},
+		},
+	}
+
+	for _, c := range cases {
+		if agent := ParseUserAgent(c.UA); agent != c.Expected {
+			t.Fatalf("ParseUserAgent(%q), Expected=%+v, Actual=%+v", c.UA, c.Expected, agent)
+		}
+	}
+}
+
+func TestFormatUserAgent(t *testing.T) {
+	ua := "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:89.0) Gecko/20100101 Firefox/89.0"
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: "/"},
+		Header: http.Header{"User-Agent": {ua}},
+	}
+	entry := &logrus.Entry{Time: time.Now(), Data: logrus.Fields{"request": req}}
+
+	data, err := NewFormatter("test", "test").Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	if jsoniter.Get(data, "request", "ua").LastError() == nil {
+		t.Fatalf("output request.ua without parsing, Expected=none")
+	}
+
+	data, err = NewFormatter("test", "test", WithUserAgentParsing(nil)).Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err.Error())
+	}
+	expected := map[string]string{"ua": ua, "ua_browser": "Firefox 89", "ua_os": "Linux", "ua_device": "desktop"}
+	for k, v := range expected {
+		if actual := jsoniter.Get(data, "request", k).ToString(); actual != v {
+			t.Fatalf("output request.%s, Expected=%q, Actual=%q", k, v, actual)
+		}
+	}
+}
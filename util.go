@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"crypto/rand"
+	"fmt"
 	"net"
 	"strings"
 )
@@ -13,3 +15,12 @@ func parseIP(remoteAddr string) string {
 
 	return remoteAddr
 }
+
+// newUUID 生成随机的 UUID v4
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
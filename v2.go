@@ -0,0 +1,515 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SchemaGeneralLogsV2 logs.v2 日志规范，修正 v1 中不便于查询的字段：
+//   - 各类日志使用同一个规范，原来的规范记录在 kind，规范相关的内容仍使用 request、sql 等字段
+//   - 耗时记录为毫秒数 duration_ms，状态码记录为整数 status
+//   - err 为包含 msg、type、trace 的对象，没有错误时不输出
+//   - ctx 中的 trace_id、span_id 提取到顶层
+const SchemaGeneralLogsV2 Schema = "general.logs.v2"
+
+// traceFields 从 ctx 提取到 v2 顶层的链路追踪字段
+var traceFields = []string{"trace_id", "span_id"}
+
+// v2Sections v1 中各规范的内容字段与类型，用于生成 v2 的 JSON Schema
+var v2Sections = map[string]reflect.Type{
+	"request":  reflect.TypeOf(RequestData{}),
+	"response": reflect.TypeOf(ResponseData{}),
+	"sql":      reflect.TypeOf(SQLData{}),
+	"client":   reflect.TypeOf(ClientRequestData{}),
+	"mq":       reflect.TypeOf(MessageData{}),
+	"job":      reflect.TypeOf(JobData{}),
+	"runtime":  reflect.TypeOf(RuntimeStatsData{}),
+}
+
+// LogsV2 logs.v2 日志输出内容
+type LogsV2 struct {
+	Schema      string                 `json:"schema"`
+	Kind        string                 `json:"kind,omitempty"`
+	Time        string                 `json:"t"`
+	Level       string                 `json:"l"`
+	Service     string                 `json:"s"`
+	Channel     string                 `json:"c"`
+	ID          string                 `json:"i"`
+	RequestID   string                 `json:"request_id,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	SessionID   string                 `json:"session_id,omitempty"`
+	TraceID     string                 `json:"trace_id,omitempty"`
+	SpanID      string                 `json:"span_id,omitempty"`
+	Environment string                 `json:"e"`
+	User        string                 `json:"u"`
+	Message     string                 `json:"m"`
+	Code        string                 `json:"code,omitempty"`
+	Host        interface{}            `json:"host,omitempty"`
+	Retention   string                 `json:"retention,omitempty"`
+	Build       interface{}            `json:"build,omitempty"`
+	Context     map[string]interface{} `json:"ctx"`
+	Err         *ErrorV2               `json:"err,omitempty"`
+	// 解析请求信息时遇到的问题
+	RequestParseError string `json:"request_parse_error,omitempty"`
+	// 规范相关的内容，键为 request、sql 或自定义规范的字段名
+	Sections map[string]map[string]interface{} `json:"-"`
+}
+
+// ErrorV2 v2 中的错误信息
+type ErrorV2 struct {
+	Message string `json:"msg"`
+	// 错误的 Go 类型，例如 *errors.errorString，从 v1 转换时为空
+	Type        string   `json:"type,omitempty"`
+	Trace       []string `json:"trace,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	// 错误链上 ErrorCoder 与 ErrorCategorizer 提供的错误码与分类
+	Code     string `json:"code,omitempty"`
+	Category string `json:"category,omitempty"`
+	// 组合错误中的各个错误
+	Errors []ErrorV2 `json:"errors,omitempty"`
+}
+
+// errorsV2 将 v1 errors 中的错误对象转换为 v2 的错误信息，
+// 对象可能是格式化时生成的 logrus.Fields，也可能是解码 JSON 得到的 map
+func errorsV2(list []interface{}) []ErrorV2 {
+	if len(list) == 0 {
+		return nil
+	}
+	errs := make([]ErrorV2, 0, len(list))
+	for _, item := range list {
+		var m map[string]interface{}
+		switch val := item.(type) {
+		case logrus.Fields:
+			m = val
+		case map[string]interface{}:
+			m = val
+		default:
+			continue
+		}
+		str := func(k string) string {
+			s, _ := m[k].(string)
+			return s
+		}
+		e := ErrorV2{
+			Message:     str("msg"),
+			Fingerprint: str("fingerprint"),
+			Code:        str("code"),
+			Category:    str("category"),
+		}
+		switch trace := m["trace"].(type) {
+		case []string:
+			e.Trace = trace
+		case []interface{}:
+			for _, frame := range trace {
+				if s, ok := frame.(string); ok {
+					e.Trace = append(e.Trace, s)
+				}
+			}
+		}
+		nested, _ := m["errors"].([]interface{})
+		e.Errors = errorsV2(nested)
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// WithDualEmit 迁移期间每条日志先输出 v1，再输出一行相同内容的 v2，
+// v2 编码失败时只输出 v1
+func WithDualEmit() Option {
+	return func(af *LogsV1Formatter) {
+		af.DualEmit = true
+	}
+}
+
+var _ logrus.Formatter = (*LogsV2Formatter)(nil)
+
+// LogsV2Formatter 以 general.logs.v2 规范输出日志
+type LogsV2Formatter struct {
+	*LogsV1Formatter
+}
+
+// NewV2Formatter 创建 v2 格式化对象，可选配置与 NewFormatter 相同
+func NewV2Formatter(service, env string, opts ...Option) *LogsV2Formatter {
+	return &LogsV2Formatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (vf *LogsV2Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+	start := b.Len()
+
+	data := acquireLogsV1()
+	vf.collect(entry, data)
+	enc := vf.encoder()
+	err := encodeSafely(func() error { return writeLogsV2(b, enc, vf.logsV2(data)) })
+	schema := data.Schema
+	releaseLogsV1(data)
+
+	if err != nil {
+		b.Truncate(start)
+		return nil, wrapf(err, "json encode %s log", schema)
+	}
+	return b.Bytes(), nil
+}
+
+// logsV2 将收集到的 v1 日志内容转换为 v2，data 在写入完成前不能放回对象池
+func (af *LogsV1Formatter) logsV2(data *LogsV1) *LogsV2 {
+	v := &LogsV2{
+		Schema:            string(SchemaGeneralLogsV2),
+		Kind:              v2Kind(data.Schema),
+		Time:              string(data.timeBuf),
+		Level:             data.Level,
+		Service:           data.Service,
+		Channel:           data.Channel,
+		ID:                data.ID,
+		RequestID:         data.RequestID,
+		Tenant:            data.Tenant,
+		SessionID:         data.SessionID,
+		Environment:       data.Environment,
+		User:              data.User,
+		Message:           data.Message,
+		Code:              data.Code,
+		Retention:         data.Retention,
+		Context:           data.Context,
+		RequestParseError: data.RequestParseError,
+		Sections:          map[string]map[string]interface{}{},
+	}
+	if data.Host != nil {
+		v.Host = data.Host
+	}
+	if data.Build != nil {
+		v.Build = data.Build
+	}
+	if data.Err != "" {
+		v.Err = &ErrorV2{Message: data.Err, Fingerprint: data.ErrFingerprint}
+		if data.errValue != nil {
+			v.Err.Type = fmt.Sprintf("%T", data.errValue)
+			_, v.Err.Trace = af.extractError(data.errValue)
+			v.Err.Code, v.Err.Category = errorClass(data.errValue)
+		}
+		v.Err.Errors = errorsV2(data.Errors)
+	}
+	v.extractTrace()
+
+	sections := []struct {
+		key   string
+		value interface{}
+		omit  bool
+	}{
+		{key: "request", value: data.Request, omit: data.Request == nil},
+		{key: "response", value: data.Response, omit: data.Response == nil},
+		{key: "sql", value: data.SQL, omit: data.SQL == nil},
+		{key: "client", value: data.Client, omit: data.Client == nil},
+		{key: "mq", value: data.MQ, omit: data.MQ == nil},
+		{key: "job", value: data.Job, omit: data.Job == nil},
+		{key: "runtime", value: data.Runtime, omit: data.Runtime == nil},
+		{key: data.sectionKey, value: data.section, omit: data.sectionKey == ""},
+	}
+	for _, s := range sections {
+		if !s.omit {
+			v.Sections[s.key] = migrateSection(sectionFields(af.encoder(), s.value))
+		}
+	}
+	return v
+}
+
+// ConvertV1ToV2 将一条 v1 JSON 日志转换为 v2，用于迁移历史日志与下游解析，
+// 转换结果的对象按键排序输出
+func ConvertV1ToV2(p []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, wrapf(err, "decode v1 log")
+	}
+	name, _ := m["schema"].(string)
+	if name == "" {
+		return nil, errors.New("convert v1 log: missing schema")
+	}
+	if name == string(SchemaGeneralLogsV2) {
+		return nil, errors.New("convert v1 log: already general.logs.v2")
+	}
+
+	str := func(k string) string {
+		s, _ := m[k].(string)
+		return s
+	}
+	v := &LogsV2{
+		Schema:            string(SchemaGeneralLogsV2),
+		Kind:              v2Kind(name),
+		Time:              str("t"),
+		Level:             str("l"),
+		Service:           str("s"),
+		Channel:           str("c"),
+		ID:                str("i"),
+		RequestID:         str("request_id"),
+		Tenant:            str("tenant"),
+		SessionID:         str("session_id"),
+		Environment:       str("e"),
+		User:              str("u"),
+		Message:           str("m"),
+		Code:              str("code"),
+		Host:              m["host"],
+		Retention:         str("retention"),
+		Build:             m["build"],
+		RequestParseError: str("request_parse_error"),
+		Sections:          map[string]map[string]interface{}{},
+	}
+	v.Context, _ = m["ctx"].(map[string]interface{})
+	if v.Context == nil {
+		v.Context = map[string]interface{}{}
+	}
+	if msg := str("err"); msg != "" {
+		v.Err = &ErrorV2{Message: msg, Fingerprint: str("err_fingerprint")}
+		list, _ := m["errors"].([]interface{})
+		v.Err.Errors = errorsV2(list)
+	}
+	v.extractTrace()
+
+	envelope := map[string]bool{
+		"schema": true, "t": true, "l": true, "s": true, "c": true, "i": true, "request_id": true, "tenant": true, "session_id": true,
+		"e": true, "u": true, "m": true, "code": true, "host": true, "retention": true, "build": true,
+		"ctx": true, "err": true, "err_fingerprint": true, "errors": true, "request_parse_error": true,
+	}
+	for k, val := range m {
+		if envelope[k] {
+			continue
+		}
+		section, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("convert v1 log: unexpected field %s", k)
+		}
+		v.Sections[k] = migrateSection(section)
+	}
+
+	b := &bytes.Buffer{}
+	if err := writeLogsV2(b, sortedEncoder{sortedDefaultEncoder}, v); err != nil {
+		return nil, wrapf(err, "json encode %s log", SchemaGeneralLogsV2)
+	}
+	return b.Bytes(), nil
+}
+
+// v2Kind v1 的通用日志在 v2 中不记录 kind
+func v2Kind(schema string) string {
+	if schema == string(SchemaGeneralLogsV1) {
+		return ""
+	}
+	return schema
+}
+
+// extractTrace 将 ctx 中的链路追踪字段移到顶层，ctx 可能属于对象池，修改前先复制
+func (v *LogsV2) extractTrace() {
+	copied := false
+	for _, k := range traceFields {
+		tv, ok := v.Context[k]
+		if !ok {
+			continue
+		}
+		if !copied {
+			v.Context = copyFields(v.Context)
+			copied = true
+		}
+		delete(v.Context, k)
+		switch k {
+		case "trace_id":
+			v.TraceID = toString(tv)
+		case "span_id":
+			v.SpanID = toString(tv)
+		}
+	}
+}
+
+// migrateSection 将 duration 转换为毫秒数 duration_ms，将 status 转换为整数，
+// 无法转换的值保持原样
+func migrateSection(m map[string]interface{}) map[string]interface{} {
+	if d, ok := m["duration"].(string); ok {
+		if ms, ok := durationMillis(d); ok {
+			delete(m, "duration")
+			m["duration_ms"] = ms
+		} else if d == "" {
+			delete(m, "duration")
+		}
+	}
+	switch s := m["status"].(type) {
+	case string:
+		if n, err := strconv.Atoi(s); err == nil {
+			m["status"] = n
+		} else if s == "" {
+			delete(m, "status")
+		}
+	case json.Number:
+		if n, err := s.Int64(); err == nil {
+			m["status"] = n
+		}
+	}
+	return m
+}
+
+// durationMillis 解析 time.Duration 格式或表示毫秒数的数字
+func durationMillis(s string) (float64, bool) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return float64(d) / float64(time.Millisecond), true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return 0, false
+}
+
+// writeLogsV2 字段顺序与 v1 一致，规范相关的内容按字段名排序写在最后
+func writeLogsV2(b *bytes.Buffer, enc Encoder, v *LogsV2) error {
+	b.WriteString(`{"schema":`)
+	writeString(b, v.Schema)
+	if v.Kind != "" {
+		b.WriteString(`,"kind":`)
+		writeString(b, v.Kind)
+	}
+	pairs := []struct {
+		key, value string
+		omit       bool
+	}{
+		{key: "t", value: v.Time},
+		{key: "l", value: v.Level},
+		{key: "s", value: v.Service},
+		{key: "c", value: v.Channel},
+		{key: "i", value: v.ID},
+		{key: "request_id", value: v.RequestID, omit: v.RequestID == ""},
+		{key: "tenant", value: v.Tenant, omit: v.Tenant == ""},
+		{key: "session_id", value: v.SessionID, omit: v.SessionID == ""},
+		{key: "trace_id", value: v.TraceID, omit: v.TraceID == ""},
+		{key: "span_id", value: v.SpanID, omit: v.SpanID == ""},
+		{key: "e", value: v.Environment},
+		{key: "u", value: v.User},
+		{key: "m", value: v.Message},
+		{key: "code", value: v.Code, omit: v.Code == ""},
+	}
+	for _, p := range pairs {
+		if p.omit {
+			continue
+		}
+		b.WriteByte(',')
+		writeString(b, p.key)
+		b.WriteByte(':')
+		writeString(b, p.value)
+	}
+
+	if v.Host != nil {
+		if err := writeKeyValue(b, enc, "host", v.Host); err != nil {
+			return err
+		}
+	}
+	if v.Retention != "" {
+		b.WriteString(`,"retention":`)
+		writeString(b, v.Retention)
+	}
+	if v.Build != nil {
+		if err := writeKeyValue(b, enc, "build", v.Build); err != nil {
+			return err
+		}
+	}
+	b.WriteString(`,"ctx":`)
+	if err := writeFields(b, enc, v.Context); err != nil {
+		return err
+	}
+	if v.Err != nil {
+		if err := writeKeyValue(b, enc, "err", v.Err); err != nil {
+			return err
+		}
+	}
+	if v.RequestParseError != "" {
+		b.WriteString(`,"request_parse_error":`)
+		writeString(b, v.RequestParseError)
+	}
+
+	if err := writeSections(b, enc, v.Sections); err != nil {
+		return err
+	}
+
+	b.WriteString("}\n")
+	return nil
+}
+
+// writeSections 按字段名排序写入规范相关的内容
+func writeSections(b *bytes.Buffer, enc Encoder, sections map[string]map[string]interface{}) error {
+	keys := make([]string, 0, len(sections))
+	for k := range sections {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(',')
+		writeString(b, k)
+		b.WriteByte(':')
+		if err := writeFields(b, enc, sections[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonSchemaV2 v2 的 JSON Schema，各规范的内容按 migrateSection 的规则由 v1 的结构生成，
+// 已登记的规范指定了结构体类型时同样生成，其余自定义规范的内容只要求为对象
+func jsonSchemaV2() map[string]interface{} {
+	doc := typeSchema(reflect.TypeOf(LogsV2{}))
+	doc["$schema"] = "http://json-schema.org/draft-07/schema#"
+	doc["title"] = string(SchemaGeneralLogsV2)
+	doc["additionalProperties"] = map[string]interface{}{"type": "object"}
+
+	props := doc["properties"].(map[string]interface{})
+	props["schema"] = map[string]interface{}{"type": "string", "const": string(SchemaGeneralLogsV2)}
+	props["l"] = map[string]interface{}{
+		"type": "string",
+		"enum": []string{"trace", "debug", "info", "warning", "error", "fatal", "panic"},
+	}
+	props["ctx"] = map[string]interface{}{"type": "object"}
+	props["host"] = map[string]interface{}{"type": "object"}
+	props["build"] = map[string]interface{}{"type": "object"}
+	sections := map[string]map[string]interface{}{}
+	for k, t := range v2Sections {
+		sections[k] = typeSchema(t)
+	}
+	for _, d := range registeredSchemas() {
+		if d.Type != nil && reflect.TypeOf(d.Type).Kind() == reflect.Struct {
+			sections[d.Field] = customSchemaDoc(d)
+		}
+	}
+	for k, section := range sections {
+		sp := section["properties"].(map[string]interface{})
+		if _, ok := sp["duration"]; ok {
+			sp["duration"] = map[string]interface{}{"type": "string"}
+			sp["duration_ms"] = map[string]interface{}{"type": "number"}
+			section["required"] = withoutString(section["required"].([]string), "duration")
+		}
+		if _, ok := sp["status"]; ok {
+			sp["status"] = map[string]interface{}{"type": []string{"integer", "string"}}
+			section["required"] = withoutString(section["required"].([]string), "status")
+		}
+		props[k] = section
+	}
+	return doc
+}
+
+func withoutString(s []string, drop string) []string {
+	kept := make([]string, 0, len(s))
+	for _, v := range s {
+		if v != drop {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestV2Formatter(t *testing.T) {
+	f := NewV2Formatter("test", "test")
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: "/orders"},
+		RemoteAddr: "10.0.0.1:1234",
+		Header:     http.Header{},
+	}
+	entry := logrus.WithFields(logrus.Fields{
+		"request":  req,
+		"status":   http.StatusCreated,
+		"duration": 1500 * time.Microsecond,
+		"error":    errors.New("failed"),
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"foo":      "bar",
+	})
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "request"
+	p, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(p, &m); err != nil {
+		t.Fatalf("Format() output is not valid JSON: %s", p)
+	}
+	if m["schema"] != string(SchemaGeneralLogsV2) || m["kind"] != string(SchemaHTTPRequestV1) {
+		t.Fatalf("Format() schema, Actual=%v %v", m["schema"], m["kind"])
+	}
+	if m["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("Format() trace_id, Actual=%v", m["trace_id"])
+	}
+	if _, ok := m["ctx"].(map[string]interface{})["trace_id"]; ok {
+		t.Fatalf("Format() trace_id should be moved out of ctx")
+	}
+	request := m["request"].(map[string]interface{})
+	if request["status"] != float64(201) || request["duration_ms"] != 1.5 {
+		t.Fatalf("Format() request, Actual=%v", request)
+	}
+	e := m["err"].(map[string]interface{})
+	if e["msg"] != "failed" || e["type"] != "*errors.errorString" {
+		t.Fatalf("Format() err, Actual=%v", e)
+	}
+	if err := Validate(p); err != nil {
+		t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+	}
+}
+
+func TestDualEmit(t *testing.T) {
+	f := NewFormatter("test", "test", WithDualEmit())
+	entry := logrus.WithField("foo", "bar")
+	entry.Level = logrus.InfoLevel
+	p, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(p), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Format() Expected=2 lines, Actual=%s", p)
+	}
+	for i, schema := range []Schema{SchemaGeneralLogsV1, SchemaGeneralLogsV2} {
+		if !bytes.Contains(lines[i], []byte(`"schema":"`+string(schema)+`"`)) {
+			t.Fatalf("Format() line %d Expected=%s, Actual=%s", i, schema, lines[i])
+		}
+		if err := Validate(lines[i]); err != nil {
+			t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+		}
+	}
+	if bytes.Contains(lines[1], []byte(`"err"`)) {
+		t.Fatalf("Format() v2 should omit empty err, Actual=%s", lines[1])
+	}
+}
+
+func TestConvertV1ToV2(t *testing.T) {
+	v1 := `{"schema":"http.client.v1","t":"2021-06-01T00:00:00Z","l":"info","s":"api","c":"","i":"",` +
+		`"e":"prod","u":"","m":"call","ctx":{"span_id":"00f067aa0ba902b7","n":1},"err":"timeout",` +
+		`"client":{"host":"example.com","method":"GET","path":"/","status":"502","duration":"2s","retry":0}}`
+	p, err := ConvertV1ToV2([]byte(v1))
+	if err != nil {
+		t.Fatalf("ConvertV1ToV2() error, Expected=nil, Actual=%q", err)
+	}
+	expected := `{"schema":"general.logs.v2","kind":"http.client.v1","t":"2021-06-01T00:00:00Z","l":"info",` +
+		`"s":"api","c":"","i":"","span_id":"00f067aa0ba902b7","e":"prod","u":"","m":"call","ctx":{"n":1},` +
+		`"err":{"msg":"timeout"},` +
+		`"client":{"duration_ms":2000,"host":"example.com","method":"GET","path":"/","retry":0,"status":502}}`
+	if strings.TrimSpace(string(p)) != expected {
+		t.Fatalf("ConvertV1ToV2() Expected=%s, Actual=%s", expected, p)
+	}
+	if err := Validate(p); err != nil {
+		t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+	}
+
+	for _, invalid := range []string{`not json`, `{"m":"x"}`, `{"schema":"general.logs.v2"}`, `{"schema":"general.logs.v1","x":1}`} {
+		if _, err := ConvertV1ToV2([]byte(invalid)); err == nil {
+			t.Fatalf("ConvertV1ToV2(%s) error, Expected=error, Actual=nil", invalid)
+		}
+	}
+}
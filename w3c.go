@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var _ logrus.Formatter = (*W3CFormatter)(nil)
+
+// DefaultW3CFields W3C 扩展日志格式默认输出的字段
+var DefaultW3CFields = []string{
+	"date", "time", "c-ip", "cs-username", "cs-method", "cs-uri-stem", "cs-uri-query",
+	"sc-status", "sc-bytes", "cs-bytes", "time-taken", "cs(User-Agent)", "cs(Referer)",
+}
+
+// W3CFormatter 以 W3C 扩展日志格式 (Extended Log File Format) 输出请求日志，
+// 第一行日志之前输出 #Version 与 #Fields 指令，其他日志不输出
+//
+// 字段的值经过 IP 匿名化、参数过滤与脱敏，取不到的值输出 -，值中的空格替换为 +；
+// 支持的字段为 date、time、c-ip、cs-username、cs-method、cs-uri-stem、cs-uri-query、cs-version、
+// cs-host、sc-status、sc-bytes、cs-bytes、time-taken (秒) 以及 cs(<请求头>)
+type W3CFormatter struct {
+	*LogsV1Formatter
+	Fields []string
+
+	header sync.Once
+}
+
+// NewW3CFormatter 创建 W3C 扩展日志格式的格式化对象，fields 为空时使用 DefaultW3CFields，
+// 可选配置与 NewFormatter 相同
+func NewW3CFormatter(service, env string, fields []string, opts ...Option) *W3CFormatter {
+	if len(fields) == 0 {
+		fields = DefaultW3CFields
+	}
+	return &W3CFormatter{
+		LogsV1Formatter: NewFormatter(service, env, opts...).(*LogsV1Formatter),
+		Fields:          fields,
+	}
+}
+
+// Format implements logrus.Formatter interface
+func (wf *W3CFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if _, ok := entry.Data["request"].(*http.Request); !ok {
+		return nil, nil
+	}
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	data := acquireLogsV1()
+	wf.collect(entry, data)
+	if data.Request != nil {
+		wf.header.Do(func() {
+			b.WriteString("#Version: 1.0\n#Fields: ")
+			b.WriteString(strings.Join(wf.Fields, " "))
+			b.WriteByte('\n')
+		})
+		wf.writeLine(b, entry, data)
+	}
+	releaseLogsV1(data)
+
+	return b.Bytes(), nil
+}
+
+func (wf *W3CFormatter) writeLine(b *bytes.Buffer, entry *logrus.Entry, data *LogsV1) {
+	t := entry.Time.UTC()
+	for i, field := range wf.Fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		var v string
+		switch field {
+		case "date":
+			v = t.Format("2006-01-02")
+		case "time":
+			v = t.Format("15:04:05")
+		case "c-ip":
+			v = data.Request.IP
+		case "cs-username":
+			v = data.User
+		case "cs-method":
+			v = data.Request.Method
+		case "cs-uri-stem":
+			v = data.Request.Path
+		case "cs-uri-query":
+			v = w3cQuery(entry.Data["request"].(*http.Request), data.Request.Param)
+		case "cs-version":
+			v = data.Request.Proto
+		case "cs-host":
+			v = data.Request.Headers["host"]
+		case "sc-status":
+			v = data.Request.Status
+		case "sc-bytes":
+			if data.Response != nil {
+				v = strconv.FormatInt(data.Response.BytesOut, 10)
+			}
+		case "cs-bytes":
+			v = strconv.FormatInt(data.Request.BytesIn, 10)
+		case "time-taken":
+			if d, err := time.ParseDuration(data.Request.Duration); err == nil {
+				v = strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+			}
+		default:
+			if strings.HasPrefix(field, "cs(") && strings.HasSuffix(field, ")") {
+				v = data.Request.Headers[strings.ToLower(field[3:len(field)-1])]
+			}
+		}
+		writeW3CValue(b, v)
+	}
+	b.WriteByte('\n')
+}
+
+// w3cQuery 按原请求的查询参数名输出过滤与脱敏后的参数，被过滤的参数不输出
+func w3cQuery(req *http.Request, params logrus.Fields) string {
+	if req.URL == nil || req.URL.RawQuery == "" {
+		return ""
+	}
+	keys := make([]string, 0, len(req.URL.Query()))
+	for k := range req.URL.Query() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var q strings.Builder
+	for _, k := range keys {
+		var values []string
+		switch v := params[k].(type) {
+		case nil:
+			continue
+		case []string:
+			values = v
+		default:
+			values = []string{toString(v)}
+		}
+		for _, v := range values {
+			if q.Len() > 0 {
+				q.WriteByte('&')
+			}
+			q.WriteString(url.QueryEscape(k))
+			q.WriteByte('=')
+			q.WriteString(url.QueryEscape(v))
+		}
+	}
+	return q.String()
+}
+
+// writeW3CValue 写入字段值，空值写入 -，空白与控制字符替换为 +
+func writeW3CValue(b *bytes.Buffer, v string) {
+	if v == "" {
+		b.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c <= ' ' || c == 0x7f {
+			c = '+'
+		}
+		b.WriteByte(c)
+	}
+}
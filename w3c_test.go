@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestW3CFormatter(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+	l.SetFormatter(NewW3CFormatter("test", "test", nil, WithParamRules(ParamRule{Names: []string{"token"}})))
+
+	h := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/search?q=a+b&tag=x&tag=y&token=secret", nil)
+	req.RemoteAddr = "1.2.3.4:1234"
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11)")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	l.Info("not a request")
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("log lines, Expected=4, Actual=%d: %q", len(lines), out.String())
+	}
+	if lines[0] != "#Version: 1.0" {
+		t.Fatalf("version directive, Actual=%q", lines[0])
+	}
+	if lines[1] != "#Fields: "+strings.Join(DefaultW3CFields, " ") {
+		t.Fatalf("fields directive, Actual=%q", lines[1])
+	}
+
+	fields := strings.Split(lines[2], " ")
+	if len(fields) != len(DefaultW3CFields) {
+		t.Fatalf("fields, Expected=%d, Actual=%d: %q", len(DefaultW3CFields), len(fields), lines[2])
+	}
+	if _, err := time.Parse("2006-01-02 15:04:05", fields[0]+" "+fields[1]); err != nil {
+		t.Fatalf("date time, Actual=%q", fields[0]+" "+fields[1])
+	}
+	expected := []string{"1.2.3.4", "-", "GET", "/search", "q=a+b&tag=x&tag=y&token=%5BREDACTED%5D", "200", "5", "0"}
+	for i, v := range expected {
+		if fields[i+2] != v {
+			t.Fatalf("field %s, Expected=%q, Actual=%q", DefaultW3CFields[i+2], v, fields[i+2])
+		}
+	}
+	if v := fields[len(fields)-2]; v != "Mozilla/5.0+(X11)" {
+		t.Fatalf("cs(User-Agent), Expected=%q, Actual=%q", "Mozilla/5.0+(X11)", v)
+	}
+	if v := fields[len(fields)-1]; v != "-" {
+		t.Fatalf("cs(Referer), Expected=%q, Actual=%q", "-", v)
+	}
+	if strings.Count(lines[3], " ") != len(DefaultW3CFields)-1 {
+		t.Fatalf("second line, Actual=%q", lines[3])
+	}
+}
+
+func TestW3CFormatterFields(t *testing.T) {
+	f := NewW3CFormatter("test", "test", []string{"cs-method", "cs(X-Request-ID)", "x-unknown"})
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	entry := &logrus.Entry{Logger: logrus.New(), Data: logrus.Fields{"request": req}, Time: time.Now()}
+
+	data, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error, Expected=nil, Actual=%q", err)
+	}
+	expected := "#Version: 1.0\n#Fields: cs-method cs(X-Request-ID) x-unknown\nDELETE abc -\n"
+	if string(data) != expected {
+		t.Fatalf("Format(), Expected=%q, Actual=%q", expected, data)
+	}
+}
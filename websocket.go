@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WebSocket 连接的事件
+const (
+	WSOpen  = "open"
+	WSClose = "close"
+)
+
+// WebSocket 正常关闭的状态码，其他状态码按异常关闭记录为 warning
+const (
+	WSCloseNormal       = 1000
+	WSCloseGoingAway    = 1001
+	WSCloseNoStatusRcvd = 1005
+)
+
+// WSConnectionData WebSocket 连接相关的参数
+type WSConnectionData struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	Event       string `json:"event"`
+	Duration    string `json:"duration,omitempty"`
+	MessagesIn  int64  `json:"messages_in"`
+	MessagesOut int64  `json:"messages_out"`
+	CloseCode   int    `json:"close_code,omitempty"`
+	CloseReason string `json:"close_reason,omitempty"`
+}
+
+func init() {
+	registerBuiltinSchema(SchemaDefinition{
+		Name:  SchemaWSConnectionV1,
+		Field: "ws",
+		Type:  WSConnectionData{},
+	})
+}
+
+// WSConnection 一个 WebSocket 连接，收发消息的计数可以并发调用
+type WSConnection struct {
+	// 放在开头保证 32 位平台上原子操作的对齐
+	in  int64
+	out int64
+
+	// 连接的唯一标识
+	ID   string
+	Path string
+	// 绑定了 ws_id 字段的日志对象，连接内的日志都应通过它输出
+	Logger *logrus.Entry
+
+	start time.Time
+}
+
+// WebSocket 为升级后的请求 r 创建连接的日志对象，请求上下文中的 request_id 等字段会带到连接的日志中
+//
+//	conn := logger.WebSocket(l, r)
+//	conn.Open()
+//	defer func() { conn.Close(code, reason, err) }()
+func WebSocket(l *logrus.Logger, r *http.Request) *WSConnection {
+	id := newUUID()
+	return &WSConnection{
+		ID:     id,
+		Path:   r.URL.Path,
+		Logger: l.WithContext(r.Context()).WithField("ws_id", id),
+		start:  time.Now(),
+	}
+}
+
+// MessageIn 记录收到一条消息
+func (c *WSConnection) MessageIn() {
+	atomic.AddInt64(&c.in, 1)
+}
+
+// MessageOut 记录发送一条消息
+func (c *WSConnection) MessageOut() {
+	atomic.AddInt64(&c.out, 1)
+}
+
+// Open 记录连接建立
+func (c *WSConnection) Open() {
+	c.Logger.WithField("ws", &WSConnectionData{
+		ID:    c.ID,
+		Path:  c.Path,
+		Event: WSOpen,
+	}).Info("websocket opened")
+}
+
+// Close 记录连接关闭，包括连接时长、收发的消息数与关闭的状态码，
+// err 不为空时记录为 error，状态码不是正常关闭时记录为 warning
+func (c *WSConnection) Close(code int, reason string, err error) {
+	fields := logrus.Fields{
+		"ws": &WSConnectionData{
+			ID:          c.ID,
+			Path:        c.Path,
+			Event:       WSClose,
+			Duration:    time.Since(c.start).String(),
+			MessagesIn:  atomic.LoadInt64(&c.in),
+			MessagesOut: atomic.LoadInt64(&c.out),
+			CloseCode:   code,
+			CloseReason: reason,
+		},
+	}
+
+	level := logrus.InfoLevel
+	switch {
+	case err != nil:
+		fields["error"] = err
+		level = logrus.ErrorLevel
+	case code != WSCloseNormal && code != WSCloseGoingAway && code != WSCloseNoStatusRcvd:
+		level = logrus.WarnLevel
+	}
+	c.Logger.WithFields(fields).Log(level, "websocket closed")
+}
+
+// isWebSocketUpgrade 判断请求是否要求升级为 WebSocket
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") &&
+		headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// headerHasToken 判断以逗号分隔的请求头中是否包含 token，不区分大小写
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(key)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// hijackRecorder 支持 Hijack 的 ResponseRecorder
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	server, client := net.Pipe()
+	_ = client.Close()
+	return server, bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)), nil
+}
+
+func TestMiddlewareWebSocketUpgrade(t *testing.T) {
+	out := &bytes.Buffer{}
+	access := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	h := Middleware(l, WithCombinedLog(access))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("Hijack() error, Expected=nil, Actual=%q", err)
+		}
+		_ = conn.Close()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	h.ServeHTTP(hijackRecorder{httptest.NewRecorder()}, req)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("log lines, Expected=1, Actual=%d", len(lines))
+	}
+	// 升级的连接不写入 combined / common 访问日志
+	if access.Len() != 0 {
+		t.Fatalf("combined log, Expected=empty, Actual=%q", access.String())
+	}
+
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: string(SchemaHTTPRequestV1)},
+		{path: []interface{}{"m"}, expected: "websocket upgrade"},
+		{path: []interface{}{"request", "status"}, expected: "101"},
+		{path: []interface{}{"ctx", "upgrade"}, expected: "websocket"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[0], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+}
+
+func TestWSConnection(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	conn := WebSocket(l, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	conn.Open()
+	conn.MessageIn()
+	conn.MessageIn()
+	conn.MessageOut()
+	conn.Close(1006, "abnormal closure", nil)
+	conn.Close(WSCloseNormal, "", errors.New("read failed"))
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("log lines, Expected=3, Actual=%d", len(lines))
+	}
+
+	cases := []struct {
+		line     int
+		path     []interface{}
+		expected string
+	}{
+		{line: 0, path: []interface{}{"schema"}, expected: string(SchemaWSConnectionV1)},
+		{line: 0, path: []interface{}{"ws", "event"}, expected: WSOpen},
+		{line: 0, path: []interface{}{"ws", "path"}, expected: "/ws"},
+		{line: 0, path: []interface{}{"ctx", "ws_id"}, expected: conn.ID},
+		{line: 1, path: []interface{}{"l"}, expected: "warning"},
+		{line: 1, path: []interface{}{"ws", "event"}, expected: WSClose},
+		{line: 1, path: []interface{}{"ws", "messages_in"}, expected: "2"},
+		{line: 1, path: []interface{}{"ws", "messages_out"}, expected: "1"},
+		{line: 1, path: []interface{}{"ws", "close_code"}, expected: "1006"},
+		{line: 1, path: []interface{}{"ws", "close_reason"}, expected: "abnormal closure"},
+		{line: 2, path: []interface{}{"l"}, expected: "error"},
+		{line: 2, path: []interface{}{"err"}, expected: "read failed"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(lines[c.line], c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %d %q, Expected=%q, Actual=%q`, c.line, c.path, c.expected, v)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// boundFieldsKey 绑定字段在 entry.Data 中的键
+const boundFieldsKey = "_bound"
+
+// boundFields 绑定到子日志对象的字段，创建后只读
+//
+// 绑定的字段整体保存在 entry.Data 的一个键中，logrus 每次输出复制 entry.Data 时
+// 只需要复制这一个键，由格式化对象展开
+type boundFields struct {
+	fields logrus.Fields
+}
+
+// FieldBinder 可以派生子日志对象的类型，*logrus.Logger 与 *logrus.Entry 都满足
+type FieldBinder interface {
+	WithField(key string, value interface{}) *logrus.Entry
+}
+
+// With 返回绑定了 fields 的子日志对象，子日志对象输出的日志都带有这些字段，
+// 在子日志对象上再次调用 With 会合并父级绑定的字段
+//
+//	log := logger.With(l, logrus.Fields{"channel": "billing", "tenant": "acme"})
+//	log.Info("invoice created")
+func With(l FieldBinder, fields logrus.Fields) *logrus.Entry {
+	merged := logrus.Fields{}
+	if e, ok := l.(*logrus.Entry); ok {
+		if parent, ok := e.Data[boundFieldsKey].(*boundFields); ok {
+			for k, v := range parent.fields {
+				merged[k] = v
+			}
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return l.WithField(boundFieldsKey, &boundFields{fields: merged})
+}
+
+// Fields 返回 entry 的全部字段，展开 With 绑定的字段，entry.Data 内的同名字段优先
+func Fields(entry *logrus.Entry) logrus.Fields {
+	bound, ok := entry.Data[boundFieldsKey].(*boundFields)
+	if !ok {
+		return entry.Data
+	}
+	fields := make(logrus.Fields, len(bound.fields)+len(entry.Data))
+	eachField(entry, func(k string, v interface{}) {
+		fields[k] = v
+	})
+	return fields
+}
+
+// eachField 依次处理 entry 的全部字段，先处理绑定的字段，entry.Data 内的同名字段优先且只处理一次
+func eachField(entry *logrus.Entry, fn func(k string, v interface{})) {
+	if bound, ok := entry.Data[boundFieldsKey].(*boundFields); ok {
+		for k, v := range bound.fields {
+			if _, ok := entry.Data[k]; !ok {
+				fn(k, v)
+			}
+		}
+	}
+	for k, v := range entry.Data {
+		if k != boundFieldsKey {
+			fn(k, v)
+		}
+	}
+}
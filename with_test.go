@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWith(t *testing.T) {
+	out := &bytes.Buffer{}
+	l, _ := NewLogger("test", "test")
+	l.SetOutput(out)
+
+	billing := With(l, logrus.Fields{"channel": "billing", "tenant": "acme", "component": "api"})
+	child := With(billing, logrus.Fields{"component": "worker"})
+	child.WithField("tenant", "override").Info("done")
+
+	data := out.Bytes()
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"c"}, expected: "billing"},
+		{path: []interface{}{"ctx", "component"}, expected: "worker"},
+		{path: []interface{}{"tenant"}, expected: "override"},
+	}
+
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf(`output %q, Expected=%q, Actual=%q`, c.path, c.expected, v)
+		}
+	}
+	if jsoniter.Get(data, "ctx", boundFieldsKey).LastError() == nil {
+		t.Fatalf("output should not contain %s", boundFieldsKey)
+	}
+
+	out.Reset()
+	billing.Info("parent")
+	if v := jsoniter.Get(out.Bytes(), "ctx", "component").ToString(); v != "api" {
+		t.Fatalf("parent ctx.component, Expected=%q, Actual=%q", "api", v)
+	}
+}
+
+func TestFields(t *testing.T) {
+	l, _ := NewLogger("test", "test")
+	entry := With(l, logrus.Fields{"tenant": "acme", "component": "api"}).WithField("component", "worker")
+
+	fields := Fields(entry)
+	if len(fields) != 2 || fields["tenant"] != "acme" || fields["component"] != "worker" {
+		t.Fatalf("Fields() Actual=%v", fields)
+	}
+}
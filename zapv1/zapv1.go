@@ -0,0 +1,172 @@
+// Package zapv1 实现 zapcore.Core，以 LogsV1 规范输出 zap 的日志，
+// 使用 zap 的服务无需更换日志库即可输出统一规范的日志
+//
+//	core := zapv1.NewCore(logger.NewFormatter("api", "prod"), zapcore.Lock(os.Stderr), zapcore.InfoLevel)
+//	l := zap.New(core, zap.AddCaller())
+//	l.Named("billing").Info("invoice created", zap.Int("invoice_id", 42))
+//
+// zap 日志对象的名称记录为 channel，zap.Error 记录为 err，堆栈记录在 ctx.stacktrace
+package zapv1
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	_ zapcore.Core = (*Core)(nil)
+
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			return &bytes.Buffer{}
+		},
+	}
+)
+
+// Core 以 logrus 格式化对象输出日志的 zapcore.Core
+type Core struct {
+	zapcore.LevelEnabler
+	formatter logrus.Formatter
+	out       zapcore.WriteSyncer
+	// 通过 With 绑定的字段，创建后只读
+	fields logrus.Fields
+	// 格式化对象据此判断是否记录调用位置
+	logger *logrus.Logger
+}
+
+// NewCore 创建使用 f 格式化、写入 out 的 zapcore.Core，f 通常为 logger.NewFormatter 的返回值
+func NewCore(f logrus.Formatter, out zapcore.WriteSyncer, enab zapcore.LevelEnabler) *Core {
+	return &Core{
+		LevelEnabler: enab,
+		formatter:    f,
+		out:          out,
+		fields:       logrus.Fields{},
+		logger:       &logrus.Logger{Formatter: f, ReportCaller: true},
+	}
+}
+
+// With implements zapcore.Core interface
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	merged := make(logrus.Fields, len(c.fields)+len(fields))
+	for k, v := range c.fields {
+		merged[k] = v
+	}
+	addFields(merged, fields)
+
+	clone := *c
+	clone.fields = merged
+	return &clone
+}
+
+// Check implements zapcore.Core interface
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core interface
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	data := make(logrus.Fields, len(c.fields)+len(fields)+2)
+	if ent.LoggerName != "" {
+		data["channel"] = ent.LoggerName
+	}
+	if ent.Stack != "" {
+		data["stacktrace"] = ent.Stack
+	}
+	for k, v := range c.fields {
+		data[k] = v
+	}
+	addFields(data, fields)
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	entry := &logrus.Entry{
+		Logger:  c.logger,
+		Data:    data,
+		Time:    ent.Time,
+		Level:   Level(ent.Level),
+		Message: ent.Message,
+		Buffer:  buf,
+	}
+	if ent.Caller.Defined {
+		entry.Caller = &runtime.Frame{
+			PC:       ent.Caller.PC,
+			File:     ent.Caller.File,
+			Line:     ent.Caller.Line,
+			Function: ent.Caller.Function,
+		}
+	}
+
+	p, err := c.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := c.out.Write(p); err != nil {
+		return err
+	}
+	// 与 zap 的 ioCore 一致，error 以上级别的日志立即同步，避免进程退出时丢失
+	if ent.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync implements zapcore.Core interface
+func (c *Core) Sync() error {
+	return c.out.Sync()
+}
+
+// Level zap 级别转换为 logrus 级别，DPanic 与 Panic 均转换为 panic
+func Level(level zapcore.Level) logrus.Level {
+	switch level {
+	case zapcore.DebugLevel:
+		return logrus.DebugLevel
+	case zapcore.InfoLevel:
+		return logrus.InfoLevel
+	case zapcore.WarnLevel:
+		return logrus.WarnLevel
+	case zapcore.ErrorLevel:
+		return logrus.ErrorLevel
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return logrus.PanicLevel
+	case zapcore.FatalLevel:
+		return logrus.FatalLevel
+	}
+	if level < zapcore.DebugLevel {
+		return logrus.TraceLevel
+	}
+	return logrus.ErrorLevel
+}
+
+// addFields 将 zap 的字段转换为 entry 字段，错误保留原值以便格式化对象记录错误信息与调用栈
+func addFields(data logrus.Fields, fields []zapcore.Field) {
+	var enc *zapcore.MapObjectEncoder
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok {
+				data[f.Key] = err
+				continue
+			}
+		case zapcore.SkipType:
+			continue
+		}
+		if enc == nil {
+			enc = zapcore.NewMapObjectEncoder()
+		}
+		f.AddTo(enc)
+	}
+	if enc != nil {
+		for k, v := range enc.Fields {
+			data[k] = v
+		}
+	}
+}
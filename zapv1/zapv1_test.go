@@ -0,0 +1,70 @@
+package zapv1
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/lancer05/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCore(t *testing.T) {
+	out := &bytes.Buffer{}
+	core := NewCore(logger.NewFormatter("test", "test"), zapcore.AddSync(out), zapcore.InfoLevel)
+	l := zap.New(core, zap.AddCaller()).Named("billing").With(zap.String("tenant", "acme"))
+
+	l.Debug("hidden")
+	if out.Len() != 0 {
+		t.Fatalf("Write() debug should be disabled, Actual=%s", out.Bytes())
+	}
+
+	l.Error("charge failed",
+		zap.Int("invoice_id", 42),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Error(errors.New("declined")),
+	)
+
+	data := out.Bytes()
+	if err := logger.Validate(data); err != nil {
+		t.Fatalf("Validate() error, Expected=nil, Actual=%q", err)
+	}
+	cases := []struct {
+		path     []interface{}
+		expected string
+	}{
+		{path: []interface{}{"schema"}, expected: "general.logs.v1"},
+		{path: []interface{}{"l"}, expected: "error"},
+		{path: []interface{}{"c"}, expected: "billing"},
+		{path: []interface{}{"m"}, expected: "charge failed"},
+		{path: []interface{}{"err"}, expected: "declined"},
+		{path: []interface{}{"tenant"}, expected: "acme"},
+		{path: []interface{}{"ctx", "invoice_id"}, expected: "42"},
+		{path: []interface{}{"ctx", "elapsed"}, expected: "1500"},
+	}
+	for _, c := range cases {
+		if v := jsoniter.Get(data, c.path...).ToString(); v != c.expected {
+			t.Fatalf("output %q, Expected=%q, Actual=%q", c.path, c.expected, v)
+		}
+	}
+	if file := jsoniter.Get(data, "ctx", "file").ToString(); file == "" {
+		t.Fatalf("output ctx.file should record caller, Actual=%s", data)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cases := map[zapcore.Level]string{
+		zapcore.DebugLevel:  "debug",
+		zapcore.WarnLevel:   "warning",
+		zapcore.DPanicLevel: "panic",
+		zapcore.FatalLevel:  "fatal",
+	}
+	for level, expected := range cases {
+		if actual := Level(level).String(); actual != expected {
+			t.Fatalf("Level(%s) Expected=%s, Actual=%s", level, expected, actual)
+		}
+	}
+}